@@ -15,7 +15,6 @@
 package main
 
 import (
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
@@ -24,14 +23,12 @@ import (
 	"time"
 
 	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
-	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	"google.golang.org/protobuf/encoding/protojson"
 
-	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/cmd/sigstore-go-cli/cli"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/sign"
 	"github.com/sigstore/sigstore-go/pkg/tuf"
-	"github.com/sigstore/sigstore-go/pkg/verify"
 )
 
 var Version string
@@ -224,153 +221,43 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-	case "verify":
-		// Load certificate
-		cert, err := os.ReadFile(*certPath)
-		if err != nil {
-			log.Fatalf("unable to open certificate file %s", *certPath)
-		}
-
-		pemCert, _ := pem.Decode(cert)
-		if pemCert == nil {
-			log.Fatalf("unable to load cerficate from %s", *certPath)
-		}
-
-		// Load signature
-		sig, err := os.ReadFile(*signaturePath)
-		if err != nil {
-			log.Fatalf("unable to open signature file %s", *signaturePath)
-		}
-		sigBytes, err := base64.StdEncoding.DecodeString(string(sig))
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fileBytes, err := os.ReadFile(os.Args[len(os.Args)-1])
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		fileDigest := sha256.Sum256(fileBytes)
-
-		// Construct bundle
-		signingCert := protocommon.X509Certificate{
-			RawBytes: pemCert.Bytes,
-		}
-
-		pb := protobundle.Bundle{
-			MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.1",
-			VerificationMaterial: &protobundle.VerificationMaterial{
-				Content: &protobundle.VerificationMaterial_X509CertificateChain{
-					X509CertificateChain: &protocommon.X509CertificateChain{
-						Certificates: []*protocommon.X509Certificate{&signingCert},
-					},
-				},
-			},
-			Content: &protobundle.Bundle_MessageSignature{
-				MessageSignature: &protocommon.MessageSignature{
-					MessageDigest: &protocommon.HashOutput{
-						Algorithm: protocommon.HashAlgorithm_SHA2_256,
-						Digest:    fileDigest[:],
-					},
-					Signature: sigBytes,
-				},
-			},
-		}
-
-		identityPolicies := []verify.PolicyOption{}
-		if *certOIDC != "" || *certSAN != "" {
-			certID, err := verify.NewShortCertificateIdentity(*certOIDC, *certSAN, "", "")
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-
-			identityPolicies = append(identityPolicies, verify.WithCertificateIdentity(certID))
-		}
-
-		// Load trust root
-		tr := getTrustedRoot(staging)
-
-		verifierConfig := []verify.VerifierOption{}
-		verifierConfig = append(verifierConfig, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithSignedCertificateTimestamps(1))
-		if len(tr.RekorLogs()) > 0 {
-			verifierConfig = append(verifierConfig, verify.WithOnlineVerification())
-		}
-
-		// Verify bundle
-		sev, err := verify.NewSignedEntityVerifier(tr, verifierConfig...)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		bun, err := bundle.NewProtobufBundle(&pb)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		_, err = sev.Verify(bun, verify.NewPolicy(verify.WithArtifactDigest("sha256", fileDigest[:]), identityPolicies...))
-		if err != nil {
-			log.Fatal(err)
-		}
-	case "verify-bundle":
-		// Load bundle
-		b, err := bundle.LoadJSONFromPath(*bundlePath)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		// Load artifact
-		file, err := os.Open(os.Args[len(os.Args)-1])
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		// Configure verification options
-		identityPolicies := []verify.PolicyOption{}
-		if *certOIDC != "" || *certSAN != "" {
-			certID, err := verify.NewShortCertificateIdentity(*certOIDC, *certSAN, "", "")
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-
-			identityPolicies = append(identityPolicies, verify.WithCertificateIdentity(certID))
-		}
-
-		// Load trust root
-		tr := getTrustedRoot(staging)
-
-		verifierConfig := []verify.VerifierOption{}
-		verifierConfig = append(verifierConfig, verify.WithSignedCertificateTimestamps(1))
-
-		// Check bundle and trusted root for signed timestamp information
-		bundleTimestamps, err := b.Timestamps()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-
-		if len(tr.TimestampingAuthorities()) > 0 && len(bundleTimestamps) > 0 {
-			verifierConfig = append(verifierConfig, verify.WithSignedTimestamps(1))
-		}
-
-		// Check bundle and trusted root for Tlog information
-		if len(tr.RekorLogs()) > 0 && b.HasInclusionPromise() {
-			verifierConfig = append(verifierConfig, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
-		}
+	case "verify", "verify-bundle":
+		os.Exit(cli.Execute(verifyArgs()))
+	default:
+		log.Fatalf("Unsupported command %s", os.Args[1])
+	}
+}
 
-		sev, err := verify.NewSignedEntityVerifier(tr, verifierConfig...)
-		if err != nil {
-			log.Fatal(err)
+// verifyArgs translates the arguments of the conformance verify commands to
+// those of the reference CLI, which performs the verification: "verify"
+// verifies a detached signature with verify-blob, and "verify-bundle" a
+// bundle with verify.
+func verifyArgs() []string {
+	artifact := os.Args[len(os.Args)-1]
+
+	var args []string
+	if os.Args[1] == "verify" {
+		args = append([]string{"verify-blob", artifact}, flagArgs("--signature", signaturePath)...)
+		args = append(args, flagArgs("--certificate", certPath)...)
+	} else {
+		if bundlePath == nil {
+			log.Fatal("--bundle is required")
 		}
+		args = []string{"verify", *bundlePath, "--artifact", artifact}
+	}
+	args = append(args, flagArgs("--certificate-identity", certSAN)...)
+	args = append(args, flagArgs("--certificate-oidc-issuer", certOIDC)...)
+	if trustedRootPath != nil {
+		args = append(args, "--trusted-root", *trustedRootPath)
+	} else if staging {
+		args = append(args, "--staging")
+	}
+	return args
+}
 
-		// Verify bundle
-		_, err = sev.Verify(b, verify.NewPolicy(verify.WithArtifact(file), identityPolicies...))
-		if err != nil {
-			log.Fatal(err)
-		}
-	default:
-		log.Fatalf("Unsupported command %s", os.Args[1])
+func flagArgs(name string, value *string) []string {
+	if value == nil {
+		return nil
 	}
+	return []string{name, *value}
 }
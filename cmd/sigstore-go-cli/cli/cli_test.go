@@ -0,0 +1,224 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/version"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	publicGoodTrustedRoot = "../../../examples/trusted-root-public-good.json"
+	stagingTrustedRoot    = "../../../pkg/testing/data/trusted-root-staging.json"
+	provenanceBundle      = "../../../pkg/testing/data/sigstore.js@2.0.0-provenanceBundle.json"
+
+	provenanceDigest = "46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c"
+	provenanceSAN    = "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"
+	provenanceIssuer = "https://token.actions.githubusercontent.com"
+)
+
+// run executes the CLI, returning its exit code and standard output.
+func run(t *testing.T, args ...string) (int, string) {
+	var stdout, stderr bytes.Buffer
+	cmd := NewRootCommand()
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	code := execute(cmd, args)
+	t.Log(stderr.String())
+	return code, stdout.String()
+}
+
+func writeTempFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "file.json")
+	assert.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestVerify(t *testing.T) {
+	verifyArgs := func(san, digest string) []string {
+		return []string{
+			"verify", provenanceBundle,
+			"--artifact-digest", digest,
+			"--artifact-digest-algorithm", "sha512",
+			"--certificate-identity", san,
+			"--certificate-oidc-issuer", provenanceIssuer,
+			"--trusted-root", publicGoodTrustedRoot,
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{
+			name:     "verifies",
+			args:     verifyArgs(provenanceSAN, provenanceDigest),
+			wantCode: ExitOK,
+		},
+		{
+			name:     "wrong identity",
+			args:     verifyArgs("https://github.com/sigstore/sigstore-js/.github/workflows/other.yml@refs/heads/main", provenanceDigest),
+			wantCode: ExitVerificationFailed,
+		},
+		{
+			name:     "wrong artifact",
+			args:     verifyArgs(provenanceSAN, "00"+provenanceDigest[2:]),
+			wantCode: ExitVerificationFailed,
+		},
+		{
+			name:     "untrusted root",
+			args:     append(verifyArgs(provenanceSAN, provenanceDigest)[:10], "--trusted-root", stagingTrustedRoot),
+			wantCode: ExitVerificationFailed,
+		},
+		{
+			name:     "invalid bundle",
+			args:     append([]string{"verify", writeTempFile(t, "{}")}, verifyArgs(provenanceSAN, provenanceDigest)[2:]...),
+			wantCode: ExitVerificationFailed,
+		},
+		{
+			name:     "missing bundle",
+			args:     append([]string{"verify", "does-not-exist.json"}, verifyArgs(provenanceSAN, provenanceDigest)[2:]...),
+			wantCode: ExitError,
+		},
+		{
+			name:     "missing identity",
+			args:     []string{"verify", provenanceBundle, "--artifact-digest", provenanceDigest, "--trusted-root", publicGoodTrustedRoot},
+			wantCode: ExitError,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, _ := run(t, test.args...)
+			assert.Equal(t, test.wantCode, code)
+		})
+	}
+}
+
+func TestVerifyJSON(t *testing.T) {
+	args := []string{
+		"verify", provenanceBundle,
+		"--artifact-digest", provenanceDigest,
+		"--artifact-digest-algorithm", "sha512",
+		"--certificate-identity", provenanceSAN,
+		"--certificate-oidc-issuer", provenanceIssuer,
+		"--trusted-root", publicGoodTrustedRoot,
+		"--json",
+	}
+
+	code, stdout := run(t, args...)
+	assert.Equal(t, ExitOK, code)
+	var result verifyResult
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.True(t, result.Verified)
+	if assert.NotNil(t, result.Result) && assert.NotNil(t, result.Result.Signature) {
+		assert.Equal(t, provenanceSAN, result.Result.Signature.Certificate.SubjectAlternativeName.Value)
+	}
+	assert.Equal(t, version.GetCapabilities().BundleMediaTypes, result.Verifier.BundleMediaTypes)
+
+	args[3] = "00" + provenanceDigest[2:]
+	code, stdout = run(t, args...)
+	assert.Equal(t, ExitVerificationFailed, code)
+	result = verifyResult{}
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &result))
+	assert.False(t, result.Verified)
+	assert.Nil(t, result.Result)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestVersion(t *testing.T) {
+	capabilities := version.GetCapabilities()
+
+	code, stdout := run(t, "version")
+	assert.Equal(t, ExitOK, code)
+	assert.Equal(t, "Version: "+capabilities.Version+"\n", stdout)
+
+	code, stdout = run(t, "version", "--verbose")
+	assert.Equal(t, ExitOK, code)
+	for _, values := range [][]string{capabilities.BundleMediaTypes, capabilities.TrustedRootMediaTypes, capabilities.TlogEntryTypes, capabilities.Features} {
+		assert.NotEmpty(t, values)
+		for _, value := range values {
+			assert.Contains(t, stdout, "  "+value+"\n")
+		}
+	}
+
+	code, stdout = run(t, "version", "--json")
+	assert.Equal(t, ExitOK, code)
+	var got version.Capabilities
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &got))
+	assert.Equal(t, capabilities, got)
+}
+
+func TestInspect(t *testing.T) {
+	code, stdout := run(t, "inspect", provenanceBundle)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "Predicate type: https://slsa.dev/provenance/v1")
+	assert.Contains(t, stdout, "Identity (URI): "+provenanceSAN)
+	assert.Contains(t, stdout, "Subject: pkg:npm/sigstore@2.0.0 sha512:"+provenanceDigest)
+
+	code, stdout = run(t, "inspect", "--json", provenanceBundle)
+	assert.Equal(t, ExitOK, code)
+	var summary bundleSummary
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &summary))
+	assert.Equal(t, "dsseEnvelope", summary.Content)
+	assert.Len(t, summary.TlogEntries, 1)
+	if assert.NotNil(t, summary.Certificate) {
+		assert.Equal(t, &certificate.Identity{Type: certificate.SubjectAlternativeNameTypeURI, Value: provenanceSAN}, summary.Certificate.Identity)
+		assert.Equal(t, provenanceIssuer, summary.Certificate.OIDCIssuer)
+	}
+
+	code, _ = run(t, "inspect", writeTempFile(t, "{}"))
+	assert.Equal(t, ExitVerificationFailed, code)
+
+	code, _ = run(t, "inspect", "does-not-exist.json")
+	assert.Equal(t, ExitError, code)
+}
+
+func TestTrustedRootValidate(t *testing.T) {
+	code, stdout := run(t, "trusted-root", "validate", publicGoodTrustedRoot)
+	assert.Equal(t, ExitOK, code)
+	assert.Contains(t, stdout, "rekor-log https://rekor.sigstore.dev")
+
+	code, _ = run(t, "trusted-root", "validate", writeTempFile(t, `{"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.2"}`))
+	assert.Equal(t, ExitVerificationFailed, code)
+
+	code, _ = run(t, "trusted-root", "validate", "does-not-exist.json")
+	assert.Equal(t, ExitError, code)
+}
+
+func TestTrustedRootDiff(t *testing.T) {
+	code, stdout := run(t, "trusted-root", "diff", publicGoodTrustedRoot, publicGoodTrustedRoot)
+	assert.Equal(t, ExitOK, code)
+	assert.Empty(t, stdout)
+
+	code, stdout = run(t, "trusted-root", "diff", "--json", publicGoodTrustedRoot, stagingTrustedRoot)
+	assert.Equal(t, ExitVerificationFailed, code)
+	var diff trustedRootDiff
+	assert.NoError(t, json.Unmarshal([]byte(stdout), &diff))
+	assert.NotEmpty(t, diff.Added)
+	assert.NotEmpty(t, diff.Removed)
+	for _, entry := range diff.Added {
+		assert.NotContains(t, entry.Name, "rekor.sigstore.dev")
+	}
+
+	code, _ = run(t, "trusted-root", "diff", publicGoodTrustedRoot, "does-not-exist.json")
+	assert.Equal(t, ExitError, code)
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// bundleSummary describes the contents of a bundle, without verifying any of
+// it.
+type bundleSummary struct {
+	MediaType        string                   `json:"mediaType"`
+	Content          string                   `json:"content"`
+	MessageDigest    string                   `json:"messageDigest,omitempty"`
+	PayloadType      string                   `json:"payloadType,omitempty"`
+	PredicateType    string                   `json:"predicateType,omitempty"`
+	Subjects         []string                 `json:"subjects,omitempty"`
+	Certificate      *certificateDescription  `json:"certificate,omitempty"`
+	PublicKeyHint    string                   `json:"publicKeyHint,omitempty"`
+	TlogEntries      []tlogEntryDescription   `json:"tlogEntries,omitempty"`
+	SignedTimestamps []signedTimestampSummary `json:"signedTimestamps,omitempty"`
+}
+
+type certificateDescription struct {
+	Identity   *certificate.Identity `json:"identity,omitempty"`
+	SubjectCN  string                `json:"subjectCommonName,omitempty"`
+	OIDCIssuer string                `json:"oidcIssuer,omitempty"`
+	Issuer     string                `json:"issuer"`
+	NotBefore  time.Time             `json:"notBefore"`
+	NotAfter   time.Time             `json:"notAfter"`
+}
+
+type tlogEntryDescription struct {
+	LogID            string    `json:"logID"` //nolint:tagliatelle
+	LogIndex         int64     `json:"logIndex"`
+	IntegratedTime   time.Time `json:"integratedTime"`
+	UUID             string    `json:"uuid"`
+	InclusionPromise bool      `json:"inclusionPromise"`
+	InclusionProof   bool      `json:"inclusionProof"`
+}
+
+type signedTimestampSummary struct {
+	GenTime time.Time `json:"genTime"`
+	TSA     string    `json:"tsa,omitempty"`
+}
+
+func newInspectCommand() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "inspect BUNDLE",
+		Short: "Describe the contents of a Sigstore bundle, without verifying it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := loadBundle(args[0])
+			if err != nil {
+				return err
+			}
+
+			summary, err := describeBundle(b)
+			if err != nil {
+				return &verificationFailure{err}
+			}
+
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), summary)
+			}
+			printBundleSummary(cmd.OutOrStdout(), summary)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the description as JSON")
+	return cmd
+}
+
+// describeBundle summarizes the bundle's signature, verification material,
+// transparency log entries and signed timestamps.
+func describeBundle(b *bundle.ProtobufBundle) (*bundleSummary, error) {
+	summary := &bundleSummary{MediaType: b.GetMediaType()}
+
+	sigContent, err := b.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	if msg := sigContent.MessageSignatureContent(); msg != nil {
+		summary.Content = "messageSignature"
+		summary.MessageDigest = fmt.Sprintf("%s:%s", msg.DigestAlgorithm(), hex.EncodeToString(msg.Digest()))
+	}
+	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+		summary.Content = "dsseEnvelope"
+		summary.PayloadType = envelope.RawEnvelope().PayloadType
+		statement, err := envelope.Statement()
+		if err == nil {
+			summary.PredicateType = statement.PredicateType
+			for _, subject := range statement.Subject {
+				algorithms := make([]string, 0, len(subject.Digest))
+				for algorithm := range subject.Digest {
+					algorithms = append(algorithms, algorithm)
+				}
+				sort.Strings(algorithms)
+				for _, algorithm := range algorithms {
+					summary.Subjects = append(summary.Subjects, fmt.Sprintf("%s %s:%s", subject.Name, algorithm, subject.Digest[algorithm]))
+				}
+			}
+		}
+	}
+
+	verificationContent, err := b.VerificationContent()
+	if err != nil {
+		return nil, err
+	}
+	if leaf, ok := verificationContent.HasCertificate(); ok {
+		description := &certificateDescription{
+			SubjectCN: leaf.Subject.CommonName,
+			Issuer:    leaf.Issuer.String(),
+			NotBefore: leaf.NotBefore,
+			NotAfter:  leaf.NotAfter,
+		}
+		// certificates from CAs other than Fulcio may have neither
+		if identity, err := certificate.SigningIdentity(&leaf); err == nil {
+			description.Identity = identity
+		}
+		if extensions, err := certificate.ParseExtensions(leaf.Extensions); err == nil {
+			description.OIDCIssuer = extensions.Issuer
+		}
+		summary.Certificate = description
+	}
+	if key, ok := verificationContent.HasPublicKey(); ok {
+		summary.PublicKeyHint = key.Hint()
+	}
+
+	entries, err := b.TlogEntries()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		summary.TlogEntries = append(summary.TlogEntries, tlogEntryDescription{
+			LogID:            hex.EncodeToString([]byte(entry.LogKeyID())),
+			LogIndex:         entry.LogIndex(),
+			IntegratedTime:   entry.IntegratedTime(),
+			UUID:             entry.UUID(),
+			InclusionPromise: entry.HasInclusionPromise(),
+			InclusionProof:   entry.HasInclusionProof(),
+		})
+	}
+
+	signedTimestamps, err := b.Timestamps()
+	if err != nil {
+		return nil, err
+	}
+	for _, signedTimestamp := range signedTimestamps {
+		ts, err := timestamp.ParseResponse(signedTimestamp)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse signed timestamp: %w", err)
+		}
+		tsSummary := signedTimestampSummary{GenTime: ts.Time}
+		if len(ts.Certificates) > 0 {
+			tsSummary.TSA = ts.Certificates[0].Subject.String()
+		}
+		summary.SignedTimestamps = append(summary.SignedTimestamps, tsSummary)
+	}
+
+	return summary, nil
+}
+
+func printBundleSummary(w io.Writer, summary *bundleSummary) {
+	fmt.Fprintf(w, "Media type: %s\n", summary.MediaType)
+	fmt.Fprintf(w, "Content: %s\n", summary.Content)
+	if summary.MessageDigest != "" {
+		fmt.Fprintf(w, "  Message digest: %s\n", summary.MessageDigest)
+	}
+	if summary.PayloadType != "" {
+		fmt.Fprintf(w, "  Payload type: %s\n", summary.PayloadType)
+	}
+	if summary.PredicateType != "" {
+		fmt.Fprintf(w, "  Predicate type: %s\n", summary.PredicateType)
+	}
+	for _, subject := range summary.Subjects {
+		fmt.Fprintf(w, "  Subject: %s\n", subject)
+	}
+
+	if c := summary.Certificate; c != nil {
+		fmt.Fprintln(w, "Certificate:")
+		if c.Identity != nil {
+			fmt.Fprintf(w, "  Identity (%s): %s\n", c.Identity.Type, c.Identity.Value)
+		}
+		if c.SubjectCN != "" {
+			fmt.Fprintf(w, "  Subject CN: %s\n", c.SubjectCN)
+		}
+		if c.OIDCIssuer != "" {
+			fmt.Fprintf(w, "  OIDC issuer: %s\n", c.OIDCIssuer)
+		}
+		fmt.Fprintf(w, "  Issued by: %s\n", c.Issuer)
+		fmt.Fprintf(w, "  Valid: %s to %s\n", c.NotBefore.UTC().Format(time.RFC3339), c.NotAfter.UTC().Format(time.RFC3339))
+	}
+	if summary.PublicKeyHint != "" {
+		fmt.Fprintf(w, "Public key hint: %s\n", summary.PublicKeyHint)
+	}
+
+	for _, entry := range summary.TlogEntries {
+		fmt.Fprintf(w, "Transparency log entry: index %d in log %s\n", entry.LogIndex, entry.LogID)
+		fmt.Fprintf(w, "  Integrated: %s\n", entry.IntegratedTime.UTC().Format(time.RFC3339))
+		fmt.Fprintf(w, "  Inclusion promise: %t, inclusion proof: %t\n", entry.InclusionPromise, entry.InclusionProof)
+	}
+	for _, ts := range summary.SignedTimestamps {
+		fmt.Fprintf(w, "Signed timestamp: %s", ts.GenTime.UTC().Format(time.RFC3339))
+		if ts.TSA != "" {
+			fmt.Fprintf(w, " by %s", ts.TSA)
+		}
+		fmt.Fprintln(w)
+	}
+}
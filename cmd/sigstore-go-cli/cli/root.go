@@ -0,0 +1,157 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements the commands of the sigstore-go reference CLI, which
+// is also the verification client of the sigstore-conformance test suite.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+	"github.com/sigstore/sigstore-go/pkg/version"
+)
+
+// Exit codes returned by Execute. A verification failure is distinguished
+// from an operational error, such as a missing file or a network failure,
+// so that callers can tell an untrusted artifact from a broken invocation.
+const (
+	ExitOK                 = 0
+	ExitVerificationFailed = 1
+	ExitError              = 2
+)
+
+// Version is reported by the version flag and command.
+var Version = version.Devel
+
+// verificationFailure is returned by commands when the input was processed
+// but is not trusted, as opposed to when it couldn't be processed at all.
+type verificationFailure struct {
+	err error
+}
+
+func (e *verificationFailure) Error() string {
+	return e.err.Error()
+}
+
+func (e *verificationFailure) Unwrap() error {
+	return e.err
+}
+
+// NewRootCommand returns the root command of the CLI.
+func NewRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "sigstore-go-cli",
+		Short:         "Verify and inspect Sigstore bundles and trusted roots",
+		Version:       Version,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	cmd.AddCommand(
+		newVerifyCommand(),
+		newVerifyBlobCommand(),
+		newInspectCommand(),
+		newTrustedRootCommand(),
+		newVersionCommand(),
+	)
+	return cmd
+}
+
+// Execute runs the CLI with the given arguments, excluding the program name,
+// and returns the process exit code.
+func Execute(args []string) int {
+	return execute(NewRootCommand(), args)
+}
+
+func execute(cmd *cobra.Command, args []string) int {
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	if err == nil {
+		return ExitOK
+	}
+
+	fmt.Fprintln(cmd.ErrOrStderr(), "Error:", err)
+	var failure *verificationFailure
+	if errors.As(err, &failure) {
+		return ExitVerificationFailed
+	}
+	return ExitError
+}
+
+// trustedRootFlags select the trusted root to verify against: a local file,
+// or the public good or staging instance's trusted root fetched with TUF.
+type trustedRootFlags struct {
+	path    string
+	staging bool
+}
+
+func (f *trustedRootFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "trusted-root", "", "path to a trusted root JSON file, instead of fetching one with TUF")
+	cmd.Flags().BoolVar(&f.staging, "staging", false, "fetch the trusted root of the Sigstore staging instance")
+	cmd.MarkFlagsMutuallyExclusive("trusted-root", "staging")
+}
+
+func (f *trustedRootFlags) load() (*root.TrustedRoot, error) {
+	if f.path != "" {
+		return root.NewTrustedRootFromPath(f.path)
+	}
+	return fetchTrustedRoot(f.staging)
+}
+
+// fetchTrustedRoot fetches the trusted root of the public good instance, or
+// of the staging instance, with TUF.
+func fetchTrustedRoot(staging bool) (*root.TrustedRoot, error) {
+	trustedRootJSON, err := fetchTrustedRootJSON(staging)
+	if err != nil {
+		return nil, err
+	}
+	return root.NewTrustedRootFromJSON(trustedRootJSON)
+}
+
+func fetchTrustedRootJSON(staging bool) ([]byte, error) {
+	opts := tuf.DefaultOptions()
+	if staging {
+		opts.Root = tuf.StagingRoot()
+		opts.RepositoryBaseURL = tuf.StagingMirror
+	}
+
+	client, err := tuf.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetTarget("trusted_root.json")
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "   ")
+	return encoder.Encode(v)
+}
+
+// readFile reads the file at path, with an error that names it.
+func readFile(path string) ([]byte, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return contents, nil
+}
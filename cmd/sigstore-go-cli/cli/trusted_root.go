@@ -0,0 +1,306 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// Kinds of trusted material in a trustedMaterialEntry.
+const (
+	kindFulcioCA = "fulcio-ca"
+	kindTSA      = "timestamp-authority"
+	kindRekorLog = "rekor-log"
+	kindCTLog    = "ct-log"
+)
+
+// trustedMaterialEntry is a single certificate authority or log of a trusted
+// root. ID is the log ID for logs, and for certificate authorities the
+// SHA-256 fingerprint of their most specific certificate, which Name is the
+// subject of: the leaf if there is one, else the first intermediate, else the
+// root. A zero ValidFrom or ValidUntil is open-ended.
+type trustedMaterialEntry struct {
+	Kind       string    `json:"kind"`
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ValidFrom  time.Time `json:"validFrom"`
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+func (e trustedMaterialEntry) key() string {
+	return e.Kind + "/" + e.ID
+}
+
+func (e trustedMaterialEntry) String() string {
+	s := fmt.Sprintf("%s %s (%s)", e.Kind, e.Name, e.ID)
+	if !e.ValidFrom.IsZero() {
+		s += " from " + e.ValidFrom.UTC().Format(time.RFC3339)
+	}
+	if !e.ValidUntil.IsZero() {
+		s += " until " + e.ValidUntil.UTC().Format(time.RFC3339)
+	}
+	return s
+}
+
+// trustedRootDiff lists what changed between two trusted roots. Entries with
+// the same kind and ID whose name or validity period differ are Changed, as
+// pairs of the old and new entry.
+type trustedRootDiff struct {
+	Added   []trustedMaterialEntry    `json:"added,omitempty"`
+	Removed []trustedMaterialEntry    `json:"removed,omitempty"`
+	Changed [][2]trustedMaterialEntry `json:"changed,omitempty"`
+}
+
+func (d trustedRootDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+func newTrustedRootCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trusted-root",
+		Short: "Validate, compare and fetch Sigstore trusted roots",
+	}
+	cmd.AddCommand(
+		newTrustedRootValidateCommand(),
+		newTrustedRootDiffCommand(),
+		newTrustedRootFetchCommand(),
+	)
+	return cmd
+}
+
+func newTrustedRootValidateCommand() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "validate FILE",
+		Short: "Check that a trusted root parses, and list its trusted material",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tr, err := loadTrustedRoot(args[0])
+			if err != nil {
+				return err
+			}
+
+			entries := trustedMaterialEntries(tr)
+			if len(entries) == 0 {
+				return &verificationFailure{fmt.Errorf("trusted root %s has no trusted material", args[0])}
+			}
+
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), entries)
+			}
+			for _, entry := range entries {
+				fmt.Fprintln(cmd.OutOrStdout(), entry)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the trusted material as JSON")
+	return cmd
+}
+
+func newTrustedRootDiffCommand() *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "Compare the trusted material of two trusted roots",
+		Long: `Compare the trusted material of two trusted roots.
+
+As with diff(1), the exit code is 0 if the trusted roots have the same trusted
+material, 1 if they differ, and 2 if either could not be read.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldRoot, err := loadTrustedRoot(args[0])
+			if err != nil {
+				return err
+			}
+			newRoot, err := loadTrustedRoot(args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := diffTrustedRoots(oldRoot, newRoot)
+			if jsonOutput {
+				if err := writeJSON(cmd.OutOrStdout(), diff); err != nil {
+					return err
+				}
+			} else {
+				printTrustedRootDiff(cmd.OutOrStdout(), diff)
+			}
+
+			if !diff.empty() {
+				return &verificationFailure{errors.New("trusted roots differ")}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the differences as JSON")
+	return cmd
+}
+
+func newTrustedRootFetchCommand() *cobra.Command {
+	var (
+		staging bool
+		output  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fetch",
+		Short: "Fetch the trusted root of the public good or staging instance with TUF",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			trustedRootJSON, err := fetchTrustedRootJSON(staging)
+			if err != nil {
+				return err
+			}
+			if _, err := root.NewTrustedRootFromJSON(trustedRootJSON); err != nil {
+				return &verificationFailure{err}
+			}
+
+			if output == "" {
+				_, err = cmd.OutOrStdout().Write(trustedRootJSON)
+				return err
+			}
+			return os.WriteFile(output, trustedRootJSON, 0600)
+		},
+	}
+
+	cmd.Flags().BoolVar(&staging, "staging", false, "fetch the trusted root of the Sigstore staging instance")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "write the trusted root to this file, instead of standard output")
+	return cmd
+}
+
+// loadTrustedRoot reads and parses the trusted root at path. A trusted root
+// that can't be parsed is a verification failure, as it is the input being
+// validated.
+func loadTrustedRoot(path string) (*root.TrustedRoot, error) {
+	contents, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tr, err := root.NewTrustedRootFromJSON(contents)
+	if err != nil {
+		return nil, &verificationFailure{fmt.Errorf("invalid trusted root %s: %w", path, err)}
+	}
+	return tr, nil
+}
+
+// trustedMaterialEntries lists the trusted root's certificate authorities and
+// logs, sorted by kind and ID.
+func trustedMaterialEntries(tr *root.TrustedRoot) []trustedMaterialEntry {
+	var entries []trustedMaterialEntry
+	for _, ca := range tr.FulcioCertificateAuthorities() {
+		entries = append(entries, certificateAuthorityEntry(kindFulcioCA, ca))
+	}
+	for _, ca := range tr.TimestampingAuthorities() {
+		entries = append(entries, certificateAuthorityEntry(kindTSA, ca))
+	}
+	for _, tlog := range tr.RekorLogs() {
+		entries = append(entries, transparencyLogEntry(kindRekorLog, tlog))
+	}
+	for _, tlog := range tr.CTLogs() {
+		entries = append(entries, transparencyLogEntry(kindCTLog, tlog))
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key() < entries[j].key()
+	})
+	return entries
+}
+
+func certificateAuthorityEntry(kind string, ca root.CertificateAuthority) trustedMaterialEntry {
+	entry := trustedMaterialEntry{
+		Kind:       kind,
+		ValidFrom:  ca.ValidityPeriodStart,
+		ValidUntil: ca.ValidityPeriodEnd,
+	}
+	cert := ca.Root
+	switch {
+	case ca.Leaf != nil:
+		cert = ca.Leaf
+	case len(ca.Intermediates) > 0:
+		cert = ca.Intermediates[0]
+	}
+	if cert != nil {
+		fingerprint := sha256.Sum256(cert.Raw)
+		entry.ID = hex.EncodeToString(fingerprint[:])
+		entry.Name = cert.Subject.String()
+	}
+	return entry
+}
+
+func transparencyLogEntry(kind string, tlog *root.TransparencyLog) trustedMaterialEntry {
+	return trustedMaterialEntry{
+		Kind:       kind,
+		ID:         hex.EncodeToString(tlog.ID),
+		Name:       tlog.BaseURL,
+		ValidFrom:  tlog.ValidityPeriodStart,
+		ValidUntil: tlog.ValidityPeriodEnd,
+	}
+}
+
+// diffTrustedRoots compares the trusted material of two trusted roots.
+func diffTrustedRoots(oldRoot, newRoot *root.TrustedRoot) trustedRootDiff {
+	oldEntries := make(map[string]trustedMaterialEntry)
+	for _, entry := range trustedMaterialEntries(oldRoot) {
+		oldEntries[entry.key()] = entry
+	}
+
+	var diff trustedRootDiff
+	newKeys := make(map[string]bool)
+	for _, entry := range trustedMaterialEntries(newRoot) {
+		newKeys[entry.key()] = true
+		oldEntry, ok := oldEntries[entry.key()]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, entry)
+		case oldEntry.Name != entry.Name || !oldEntry.ValidFrom.Equal(entry.ValidFrom) || !oldEntry.ValidUntil.Equal(entry.ValidUntil):
+			diff.Changed = append(diff.Changed, [2]trustedMaterialEntry{oldEntry, entry})
+		}
+	}
+	for _, entry := range trustedMaterialEntries(oldRoot) {
+		if !newKeys[entry.key()] {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+	return diff
+}
+
+func printTrustedRootDiff(w io.Writer, diff trustedRootDiff) {
+	for _, entry := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", entry)
+	}
+	for _, entry := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", entry)
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n  -> %s\n", change[0], change[1])
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore-go/pkg/version"
+)
+
+// identityFlags are the expected identity of the signing certificate.
+type identityFlags struct {
+	san       string
+	sanRegexp string
+	issuer    string
+}
+
+func (f *identityFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.san, "certificate-identity", "", "the expected identity in the signing certificate's SAN extension")
+	cmd.Flags().StringVar(&f.sanRegexp, "certificate-identity-regexp", "", "a regular expression the identity in the signing certificate's SAN extension must match")
+	cmd.Flags().StringVar(&f.issuer, "certificate-oidc-issuer", "", "the expected OIDC issuer of the signing certificate")
+	cmd.MarkFlagsOneRequired("certificate-identity", "certificate-identity-regexp")
+	_ = cmd.MarkFlagRequired("certificate-oidc-issuer")
+}
+
+func (f *identityFlags) policyOption() (verify.PolicyOption, error) {
+	certID, err := verify.NewShortCertificateIdentity(f.issuer, f.san, "", f.sanRegexp)
+	if err != nil {
+		return nil, err
+	}
+	return verify.WithCertificateIdentity(certID), nil
+}
+
+// verifyResult is written by the verify commands with --json. Result is set
+// when verification succeeds, Error when it fails. Verifier records what the
+// verification was done with, for audit trails.
+type verifyResult struct {
+	Verified bool                       `json:"verified"`
+	Result   *verify.VerificationResult `json:"result,omitempty"`
+	Error    string                     `json:"error,omitempty"`
+	Verifier version.Capabilities       `json:"verifier"`
+}
+
+func newVerifyCommand() *cobra.Command {
+	var (
+		artifactPath            string
+		artifactDigest          string
+		artifactDigestAlgorithm string
+		identity                identityFlags
+		trustedRoot             trustedRootFlags
+		jsonOutput              bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify BUNDLE",
+		Short: "Verify a Sigstore bundle against an artifact and the signer's identity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			b, err := loadBundle(args[0])
+			if err != nil {
+				return err
+			}
+
+			identityPolicy, err := identity.policyOption()
+			if err != nil {
+				return err
+			}
+
+			var artifactPolicy verify.ArtifactPolicyOption
+			if artifactDigest != "" {
+				digest, err := hex.DecodeString(artifactDigest)
+				if err != nil {
+					return fmt.Errorf("invalid artifact digest: %w", err)
+				}
+				artifactPolicy = verify.WithArtifactDigest(artifactDigestAlgorithm, digest)
+			} else {
+				artifact, err := os.Open(artifactPath)
+				if err != nil {
+					return err
+				}
+				defer artifact.Close()
+				artifactPolicy = verify.WithArtifact(artifact)
+			}
+
+			tr, err := trustedRoot.load()
+			if err != nil {
+				return err
+			}
+
+			verifierConfig, err := bundleVerifierOptions(b, tr)
+			if err != nil {
+				return reportVerification(cmd.OutOrStdout(), jsonOutput, nil, err)
+			}
+			sev, err := verify.NewSignedEntityVerifier(tr, verifierConfig...)
+			if err != nil {
+				return err
+			}
+
+			result, err := sev.Verify(b, verify.NewPolicy(artifactPolicy, identityPolicy))
+			return reportVerification(cmd.OutOrStdout(), jsonOutput, result, err)
+		},
+	}
+
+	cmd.Flags().StringVar(&artifactPath, "artifact", "", "path to the signed artifact")
+	cmd.Flags().StringVar(&artifactDigest, "artifact-digest", "", "hex-encoded digest of the signed artifact")
+	cmd.Flags().StringVar(&artifactDigestAlgorithm, "artifact-digest-algorithm", "sha256", "algorithm of --artifact-digest")
+	cmd.MarkFlagsOneRequired("artifact", "artifact-digest")
+	cmd.MarkFlagsMutuallyExclusive("artifact", "artifact-digest")
+	identity.register(cmd)
+	trustedRoot.register(cmd)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the verification result as JSON")
+	return cmd
+}
+
+func newVerifyBlobCommand() *cobra.Command {
+	var (
+		signaturePath   string
+		certificatePath string
+		identity        identityFlags
+		trustedRoot     trustedRootFlags
+		jsonOutput      bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify-blob ARTIFACT",
+		Short: "Verify a detached signature and signing certificate over an artifact",
+		Long: `Verify a detached signature and signing certificate over an artifact.
+
+The signature is base64-encoded and the certificate PEM-encoded, as written by
+"cosign sign-blob". Without a bundle there is no transparency log entry or
+signed timestamp to establish when the artifact was signed, so the
+certificate is only checked for an embedded signed certificate timestamp.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			artifact, err := readFile(args[0])
+			if err != nil {
+				return err
+			}
+			certPEM, err := readFile(certificatePath)
+			if err != nil {
+				return err
+			}
+			sigBase64, err := readFile(signaturePath)
+			if err != nil {
+				return err
+			}
+
+			b, err := detachedSignatureBundle(artifact, certPEM, sigBase64)
+			if err != nil {
+				return reportVerification(cmd.OutOrStdout(), jsonOutput, nil, err)
+			}
+
+			identityPolicy, err := identity.policyOption()
+			if err != nil {
+				return err
+			}
+
+			tr, err := trustedRoot.load()
+			if err != nil {
+				return err
+			}
+
+			sev, err := verify.NewSignedEntityVerifier(tr, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithSignedCertificateTimestamps(1))
+			if err != nil {
+				return err
+			}
+
+			digest := sha256.Sum256(artifact)
+			result, err := sev.Verify(b, verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), identityPolicy))
+			return reportVerification(cmd.OutOrStdout(), jsonOutput, result, err)
+		},
+	}
+
+	cmd.Flags().StringVar(&signaturePath, "signature", "", "path to the base64-encoded signature")
+	cmd.Flags().StringVar(&certificatePath, "certificate", "", "path to the PEM-encoded signing certificate")
+	_ = cmd.MarkFlagRequired("signature")
+	_ = cmd.MarkFlagRequired("certificate")
+	identity.register(cmd)
+	trustedRoot.register(cmd)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the verification result as JSON")
+	return cmd
+}
+
+// loadBundle reads and parses the bundle at path. A bundle that can't be
+// parsed is a verification failure, as it is the input being verified.
+func loadBundle(path string) (*bundle.ProtobufBundle, error) {
+	contents, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundle.ProtobufBundle{Bundle: new(protobundle.Bundle)}
+	if err := b.UnmarshalJSON(contents); err != nil {
+		return nil, &verificationFailure{fmt.Errorf("invalid bundle %s: %w", path, err)}
+	}
+	return b, nil
+}
+
+// bundleVerifierOptions requires the kinds of evidence the bundle carries and
+// the trusted root can verify: signed timestamps, and transparency log
+// entries with their integrated timestamps. SCTs are always required.
+func bundleVerifierOptions(b *bundle.ProtobufBundle, tr root.TrustedMaterial) ([]verify.VerifierOption, error) {
+	verifierConfig := []verify.VerifierOption{verify.WithSignedCertificateTimestamps(1)}
+	var haveTimestamps bool
+
+	bundleTimestamps, err := b.Timestamps()
+	if err != nil {
+		return nil, err
+	}
+	if len(tr.TimestampingAuthorities()) > 0 && len(bundleTimestamps) > 0 {
+		verifierConfig = append(verifierConfig, verify.WithSignedTimestamps(1))
+		haveTimestamps = true
+	}
+
+	if len(tr.RekorLogs()) > 0 && b.HasInclusionPromise() {
+		verifierConfig = append(verifierConfig, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+		haveTimestamps = true
+	}
+
+	if !haveTimestamps {
+		return nil, errors.New("bundle has no signed timestamp or transparency log entry that the trusted root can verify")
+	}
+	return verifierConfig, nil
+}
+
+// detachedSignatureBundle builds a v0.1 bundle from a detached signature over
+// the artifact and the certificate it was signed with.
+func detachedSignatureBundle(artifact, certPEM, sigBase64 []byte) (*bundle.ProtobufBundle, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, errors.New("failed to decode PEM certificate")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigBase64)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode signature: %w", err)
+	}
+	digest := sha256.Sum256(artifact)
+
+	return bundle.NewProtobufBundle(&protobundle.Bundle{
+		MediaType: "application/vnd.dev.sigstore.bundle+json;version=0.1",
+		VerificationMaterial: &protobundle.VerificationMaterial{
+			Content: &protobundle.VerificationMaterial_X509CertificateChain{
+				X509CertificateChain: &protocommon.X509CertificateChain{
+					Certificates: []*protocommon.X509Certificate{{RawBytes: certBlock.Bytes}},
+				},
+			},
+		},
+		Content: &protobundle.Bundle_MessageSignature{
+			MessageSignature: &protocommon.MessageSignature{
+				MessageDigest: &protocommon.HashOutput{
+					Algorithm: protocommon.HashAlgorithm_SHA2_256,
+					Digest:    digest[:],
+				},
+				Signature: sig,
+			},
+		},
+	})
+}
+
+// reportVerification writes the outcome of a verification to w and returns
+// verifyErr as a verification failure.
+func reportVerification(w io.Writer, jsonOutput bool, result *verify.VerificationResult, verifyErr error) error {
+	if jsonOutput {
+		output := verifyResult{Verified: verifyErr == nil, Result: result, Verifier: capabilities()}
+		if verifyErr != nil {
+			output.Error = verifyErr.Error()
+			output.Result = nil
+		}
+		if err := writeJSON(w, output); err != nil {
+			return err
+		}
+	} else if verifyErr == nil {
+		fmt.Fprintln(w, "Verification successful!")
+		if result.Signature != nil && result.Signature.Certificate != nil {
+			fmt.Fprintf(w, "Identity: %s\n", result.Signature.Certificate.SubjectAlternativeName.Value)
+			fmt.Fprintf(w, "Issuer: %s\n", result.Signature.Certificate.Issuer)
+		}
+		if !result.SigningTime.IsZero() {
+			fmt.Fprintf(w, "Signing time: %s\n", result.SigningTime)
+		}
+	}
+
+	if verifyErr != nil {
+		return &verificationFailure{verifyErr}
+	}
+	return nil
+}
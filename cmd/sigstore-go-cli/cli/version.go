@@ -0,0 +1,75 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sigstore/sigstore-go/pkg/version"
+)
+
+func newVersionCommand() *cobra.Command {
+	var verbose, jsonOutput bool
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, and with --verbose what it can verify",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c := capabilities()
+			if jsonOutput {
+				return writeJSON(cmd.OutOrStdout(), c)
+			}
+			printCapabilities(cmd.OutOrStdout(), c, verbose)
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "also print the bundle and trusted root media types, log entry types and features supported")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "write the version and capabilities as JSON")
+	return cmd
+}
+
+// capabilities returns the library's capabilities, with the version of the
+// CLI when it was built with one.
+func capabilities() version.Capabilities {
+	c := version.GetCapabilities()
+	if Version != version.Devel {
+		c.Version = Version
+	}
+	return c
+}
+
+func printCapabilities(w io.Writer, c version.Capabilities, verbose bool) {
+	fmt.Fprintf(w, "Version: %s\n", c.Version)
+	if !verbose {
+		return
+	}
+	for _, list := range []struct {
+		name   string
+		values []string
+	}{
+		{"Bundle media types", c.BundleMediaTypes},
+		{"Trusted root media types", c.TrustedRootMediaTypes},
+		{"Log entry types", c.TlogEntryTypes},
+		{"Features", c.Features},
+	} {
+		fmt.Fprintf(w, "%s:\n", list.name)
+		for _, value := range list.values {
+			fmt.Fprintf(w, "  %s\n", value)
+		}
+	}
+}
@@ -101,8 +101,8 @@ func run() error {
 	identityPolicies := []verify.PolicyOption{}
 	var artifactPolicy verify.ArtifactPolicyOption
 
-	if *requireCTlog {
-		verifierConfig = append(verifierConfig, verify.WithSignedCertificateTimestamps(1))
+	if !*requireCTlog {
+		verifierConfig = append(verifierConfig, verify.WithoutSCTVerification())
 	}
 
 	if *requireTimestamp {
@@ -17,9 +17,9 @@ package bundle
 import (
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
@@ -33,6 +33,10 @@ import (
 	"github.com/sigstore/sigstore-go/pkg/verify"
 )
 
+// ErrValidation is wrapped by the errors returned for a malformed bundle,
+// including the more specific errors below. Callers can rely on errors.Is
+// with any of them; they won't be removed or change meaning within a major
+// version.
 var ErrValidation = errors.New("validation error")
 var ErrUnsupportedMediaType = fmt.Errorf("%w: unsupported media type", ErrValidation)
 var ErrMissingVerificationMaterial = fmt.Errorf("%w: missing verification material", ErrValidation)
@@ -52,6 +56,9 @@ type ProtobufBundle struct {
 	*protobundle.Bundle
 	hasInclusionPromise bool
 	hasInclusionProof   bool
+	// envelopeJSON is the DSSE envelope as it appeared in the JSON the
+	// bundle was read from
+	envelopeJSON []byte
 }
 
 func NewProtobufBundle(pbundle *protobundle.Bundle) (*ProtobufBundle, error) {
@@ -70,6 +77,10 @@ func NewProtobufBundle(pbundle *protobundle.Bundle) (*ProtobufBundle, error) {
 }
 
 func (b *ProtobufBundle) validate() error {
+	if b.Bundle == nil {
+		return ErrValidationError(errors.New("bundle is nil"))
+	}
+
 	bundleVersion, err := getBundleVersion(b.Bundle.MediaType)
 	if err != nil {
 		return fmt.Errorf("error getting bundle version: %w", err)
@@ -86,15 +97,28 @@ func (b *ProtobufBundle) validate() error {
 		return err
 	}
 
+	// the flags are only set here, so that TlogEntries doesn't write to the
+	// bundle and a validated bundle can be verified concurrently
+	b.hasInclusionPromise = false
+	b.hasInclusionProof = false
+	for _, entry := range entries {
+		if entry.HasInclusionPromise() {
+			b.hasInclusionPromise = true
+		}
+		if entry.HasInclusionProof() {
+			b.hasInclusionProof = true
+		}
+	}
+
 	// if bundle version == v0.1, require inclusion promise
 	if semver.Compare(bundleVersion, "v0.1") == 0 {
 		if len(entries) > 0 && !b.hasInclusionPromise {
-			return errors.New("inclusion promises missing in bundle (required for bundle v0.1)")
+			return ErrValidationError(errors.New("inclusion promises missing in bundle (required for bundle v0.1)"))
 		}
 	} else {
 		// if bundle version >= v0.2, require inclusion proof
 		if len(entries) > 0 && !b.hasInclusionProof {
-			return errors.New("inclusion proof missing in bundle (required for bundle v0.2)")
+			return ErrValidationError(errors.New("inclusion proof missing in bundle (required for bundle v0.2)"))
 		}
 	}
 
@@ -103,7 +127,7 @@ func (b *ProtobufBundle) validate() error {
 		certs := b.Bundle.VerificationMaterial.GetX509CertificateChain()
 
 		if certs != nil {
-			return errors.New("verification material cannot be X.509 certificate chain (for bundle v0.3)")
+			return ErrValidationError(errors.New("verification material cannot be X.509 certificate chain (for bundle v0.3)"))
 		}
 	}
 
@@ -115,6 +139,24 @@ func (b *ProtobufBundle) validate() error {
 	return nil
 }
 
+// supportedVersions are the bundle versions this library verifies.
+var supportedVersions = []string{"v0.1", "v0.2", "v0.3"}
+
+// SupportedMediaTypes returns the media types of the bundle versions this
+// library verifies. Bundles of version 0.3 may also use the older
+// "+json;version=0.3" form of the media type.
+func SupportedMediaTypes() []string {
+	mediaTypes := make([]string, 0, len(supportedVersions))
+	for _, version := range supportedVersions {
+		mediaType, err := MediaTypeString(version)
+		if err != nil {
+			continue
+		}
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	return mediaTypes
+}
+
 // MediaTypeString returns a mediatype string for the specified bundle version.
 // The function returns an error if the resulting string does validate.
 func MediaTypeString(version string) (string, error) {
@@ -158,23 +200,6 @@ func getBundleVersion(mediaType string) (string, error) {
 	return "", fmt.Errorf("%w: %s", ErrUnsupportedMediaType, mediaType)
 }
 
-func LoadJSONFromPath(path string) (*ProtobufBundle, error) {
-	var bundle ProtobufBundle
-	bundle.Bundle = new(protobundle.Bundle)
-
-	contents, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	err = bundle.UnmarshalJSON(contents)
-	if err != nil {
-		return nil, err
-	}
-
-	return &bundle, nil
-}
-
 func (b *ProtobufBundle) MarshalJSON() ([]byte, error) {
 	return protojson.Marshal(b.Bundle)
 }
@@ -183,8 +208,9 @@ func (b *ProtobufBundle) UnmarshalJSON(data []byte) error {
 	b.Bundle = new(protobundle.Bundle)
 	err := protojson.Unmarshal(data, b.Bundle)
 	if err != nil {
-		return err
+		return ErrValidationError(err)
 	}
+	b.envelopeJSON = rawEnvelopeJSON(data)
 
 	err = b.validate()
 	if err != nil {
@@ -205,7 +231,7 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 		if len(certs) == 0 {
 			return nil, ErrMissingVerificationMaterial
 		}
-		parsedCert, err := x509.ParseCertificate(certs[0].RawBytes)
+		parsedCert, err := x509.ParseCertificate(certs[0].GetRawBytes())
 		if err != nil {
 			return nil, ErrValidationError(err)
 		}
@@ -214,7 +240,7 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 		}
 		return cert, nil
 	case *protobundle.VerificationMaterial_Certificate:
-		parsedCert, err := x509.ParseCertificate(content.Certificate.RawBytes)
+		parsedCert, err := x509.ParseCertificate(content.Certificate.GetRawBytes())
 		if err != nil {
 			return nil, ErrValidationError(err)
 		}
@@ -224,7 +250,7 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 		return cert, nil
 	case *protobundle.VerificationMaterial_PublicKey:
 		pk := &PublicKey{
-			hint: content.PublicKey.Hint,
+			hint: content.PublicKey.GetHint(),
 		}
 		return pk, nil
 
@@ -233,6 +259,35 @@ func (b *ProtobufBundle) VerificationContent() (verify.VerificationContent, erro
 	}
 }
 
+// CertificateChain returns the certificates in the bundle's verification
+// material, starting with the signing certificate. It returns nil if the
+// bundle was signed with a public key.
+func (b *ProtobufBundle) CertificateChain() ([]*x509.Certificate, error) {
+	if b.VerificationMaterial == nil {
+		return nil, ErrMissingVerificationMaterial
+	}
+
+	var rawCerts []*protocommon.X509Certificate
+	switch content := b.VerificationMaterial.GetContent().(type) {
+	case *protobundle.VerificationMaterial_X509CertificateChain:
+		rawCerts = content.X509CertificateChain.GetCertificates()
+	case *protobundle.VerificationMaterial_Certificate:
+		rawCerts = []*protocommon.X509Certificate{content.Certificate}
+	default:
+		return nil, nil
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, rawCert := range rawCerts {
+		cert, err := x509.ParseCertificate(rawCert.GetRawBytes())
+		if err != nil {
+			return nil, ErrValidationError(err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
 func (b *ProtobufBundle) HasInclusionPromise() bool {
 	return b.hasInclusionPromise
 }
@@ -253,13 +308,6 @@ func (b *ProtobufBundle) TlogEntries() ([]*tlog.Entry, error) {
 		if err != nil {
 			return nil, ErrValidationError(err)
 		}
-
-		if tlogEntries[i].HasInclusionPromise() {
-			b.hasInclusionPromise = true
-		}
-		if tlogEntries[i].HasInclusionProof() {
-			b.hasInclusionProof = true
-		}
 	}
 
 	return tlogEntries, nil
@@ -268,16 +316,19 @@ func (b *ProtobufBundle) TlogEntries() ([]*tlog.Entry, error) {
 func (b *ProtobufBundle) SignatureContent() (verify.SignatureContent, error) {
 	switch content := b.Bundle.Content.(type) { //nolint:gocritic
 	case *protobundle.Bundle_DsseEnvelope:
-		envelope, err := parseEnvelope(content.DsseEnvelope)
+		envelope, err := parseEnvelope(content.DsseEnvelope, b.envelopeJSON)
 		if err != nil {
 			return nil, err
 		}
 		return envelope, nil
 	case *protobundle.Bundle_MessageSignature:
+		// the message digest is optional, so it may be missing from the
+		// bundle
+		messageDigest := content.MessageSignature.GetMessageDigest()
 		return NewMessageSignature(
-			content.MessageSignature.MessageDigest.Digest,
-			protocommon.HashAlgorithm_name[int32(content.MessageSignature.MessageDigest.Algorithm)],
-			content.MessageSignature.Signature,
+			messageDigest.GetDigest(),
+			protocommon.HashAlgorithm_name[int32(messageDigest.GetAlgorithm())],
+			content.MessageSignature.GetSignature(),
 		), nil
 	}
 	return nil, ErrMissingVerificationMaterial
@@ -286,7 +337,7 @@ func (b *ProtobufBundle) SignatureContent() (verify.SignatureContent, error) {
 func (b *ProtobufBundle) Envelope() (*Envelope, error) {
 	switch content := b.Bundle.Content.(type) { //nolint:gocritic
 	case *protobundle.Bundle_DsseEnvelope:
-		envelope, err := parseEnvelope(content.DsseEnvelope)
+		envelope, err := parseEnvelope(content.DsseEnvelope, b.envelopeJSON)
 		if err != nil {
 			return nil, err
 		}
@@ -307,7 +358,7 @@ func (b *ProtobufBundle) Timestamps() ([][]byte, error) {
 	}
 
 	for _, timestamp := range b.VerificationMaterial.TimestampVerificationData.Rfc3161Timestamps {
-		signedTimestamps = append(signedTimestamps, timestamp.SignedTimestamp)
+		signedTimestamps = append(signedTimestamps, timestamp.GetSignedTimestamp())
 	}
 
 	return signedTimestamps, nil
@@ -322,7 +373,23 @@ func (b *ProtobufBundle) MinVersion(version string) bool {
 	return semver.Compare("v"+mediaTypeParts[1], "v"+version) >= 0
 }
 
-func parseEnvelope(input *protodsse.Envelope) (*Envelope, error) {
+// rawEnvelopeJSON returns the DSSE envelope of the bundle JSON in data, as it
+// appears there, or nil if it has none. protojson accepts the field under
+// its JSON name or its proto name.
+func rawEnvelopeJSON(data []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	for _, name := range []string{"dsseEnvelope", "dsse_envelope"} {
+		if envelope, ok := fields[name]; ok {
+			return envelope
+		}
+	}
+	return nil
+}
+
+func parseEnvelope(input *protodsse.Envelope, envelopeJSON []byte) (*Envelope, error) {
 	output := &dsse.Envelope{}
 	output.Payload = base64.StdEncoding.EncodeToString([]byte(input.GetPayload()))
 	output.PayloadType = string(input.GetPayloadType())
@@ -331,5 +398,5 @@ func parseEnvelope(input *protodsse.Envelope) (*Envelope, error) {
 		output.Signatures[i].KeyID = sig.GetKeyid()
 		output.Signatures[i].Sig = base64.StdEncoding.EncodeToString(sig.GetSig())
 	}
-	return &Envelope{Envelope: output}, nil
+	return &Envelope{Envelope: output, rawJSON: envelopeJSON}, nil
 }
@@ -15,9 +15,16 @@
 package bundle
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"os"
 	"testing"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/stretchr/testify/require"
 )
 
@@ -124,3 +131,194 @@ func TestMediaTypeString(t *testing.T) {
 		})
 	}
 }
+
+func TestSupportedMediaTypes(t *testing.T) {
+	mediaTypes := SupportedMediaTypes()
+	require.Len(t, mediaTypes, len(supportedVersions))
+	for i, mediaType := range mediaTypes {
+		version, err := getBundleVersion(mediaType)
+		require.NoError(t, err)
+		require.Equal(t, supportedVersions[i], version)
+	}
+}
+
+// loadTestBundle reads a bundle fixture without LoadJSONFromPath, which
+// WebAssembly builds leave out.
+func loadTestBundle(t *testing.T, path string) *ProtobufBundle {
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var b ProtobufBundle
+	require.NoError(t, b.UnmarshalJSON(contents))
+	return &b
+}
+
+func TestEntityCertChainPEM(t *testing.T) {
+	b := loadTestBundle(t, "../testing/data/sigstoreBundle.json")
+
+	chain, err := b.CertificateChain()
+	require.NoError(t, err)
+	require.Len(t, chain, 3)
+
+	chainPEM, err := EntityCertChainPEM(b)
+	require.NoError(t, err)
+
+	var parsed []*x509.Certificate
+	for block, rest := pem.Decode(chainPEM); block != nil; block, rest = pem.Decode(rest) {
+		require.Equal(t, "CERTIFICATE", block.Type)
+		cert, err := x509.ParseCertificate(block.Bytes)
+		require.NoError(t, err)
+		parsed = append(parsed, cert)
+	}
+
+	// the signing certificate and intermediate, without the root
+	require.Len(t, parsed, 2)
+	require.True(t, chain[0].Equal(parsed[0]))
+	require.True(t, chain[1].Equal(parsed[1]))
+}
+
+func TestEnvelopeJSON(t *testing.T) {
+	b := loadTestBundle(t, "../../examples/bundle-provenance.json")
+	envelope, err := b.Envelope()
+	require.NoError(t, err)
+
+	// the envelope as the bundle has it, which encoding/json doesn't
+	// reproduce: the bundle puts the payload before its type
+	envelopeJSON := envelope.EnvelopeJSON()
+	require.True(t, bytes.HasPrefix(envelopeJSON, []byte(`{"payload":"eyJfdHlwZSI6`)))
+	require.True(t, bytes.HasSuffix(envelopeJSON, []byte(`"keyid":""}]}`)))
+	reencoded, err := json.Marshal(envelope.RawEnvelope())
+	require.NoError(t, err)
+	require.NotEqual(t, reencoded, envelopeJSON)
+	require.JSONEq(t, string(reencoded), string(envelopeJSON))
+
+	sigContent, err := b.SignatureContent()
+	require.NoError(t, err)
+	require.Equal(t, envelopeJSON, sigContent.(*Envelope).EnvelopeJSON())
+
+	// a bundle that wasn't read from JSON has no envelope JSON
+	built, err := NewProtobufBundle(b.Bundle)
+	require.NoError(t, err)
+	envelope, err = built.Envelope()
+	require.NoError(t, err)
+	require.Nil(t, envelope.EnvelopeJSON())
+}
+
+func TestUnmarshalJSONValidationErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		json    string
+		wantErr error
+	}{
+		{
+			name:    "not JSON",
+			json:    "not json",
+			wantErr: ErrValidation,
+		},
+		{
+			name:    "unknown media type",
+			json:    `{"mediaType": "application/unknown"}`,
+			wantErr: ErrUnsupportedMediaType,
+		},
+		{
+			name:    "invalid log entry",
+			json:    `{"mediaType": "application/vnd.dev.sigstore.bundle+json;version=0.2", "verificationMaterial": {"tlogEntries": [{"logIndex": "1", "logId": {"keyId": "AAAA"}, "kindVersion": {"kind": "hashedrekord", "version": "0.0.1"}, "integratedTime": "1", "inclusionPromise": {"signedEntryTimestamp": "AAAA"}, "canonicalizedBody": "e30="}]}}`,
+			wantErr: ErrValidation,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var b ProtobufBundle
+			err := b.UnmarshalJSON([]byte(tt.json))
+			require.ErrorIs(t, err, tt.wantErr)
+			require.ErrorIs(t, err, ErrValidation)
+		})
+	}
+}
+
+func BenchmarkUnmarshalJSON(b *testing.B) {
+	var corpus [][]byte
+	for _, path := range []string{
+		"../testing/data/sigstoreBundle.json",
+		"../testing/data/sigstore.js@2.0.0-provenanceBundle.json",
+		"../../examples/bundle-provenance.json",
+	} {
+		contents, err := os.ReadFile(path)
+		require.NoError(b, err)
+		corpus = append(corpus, contents)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var bundle ProtobufBundle
+		if err := bundle.UnmarshalJSON(corpus[i%len(corpus)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// FuzzUnmarshalJSON checks that parsing untrusted bundles, and reading the
+// content of those that parse, never panics.
+func FuzzUnmarshalJSON(f *testing.F) {
+	for _, path := range []string{
+		"../testing/data/sigstoreBundle.json",
+		"../testing/data/sigstore.js@2.0.0-provenanceBundle.json",
+		"../../examples/bundle-provenance.json",
+	} {
+		contents, err := os.ReadFile(path)
+		require.NoError(f, err)
+		f.Add(contents)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var b ProtobufBundle
+		if err := b.UnmarshalJSON(data); err != nil {
+			return
+		}
+
+		_, _ = b.VerificationContent()
+		_, _ = b.CertificateChain()
+		_, _ = b.TlogEntries()
+		_, _ = b.Timestamps()
+		_, _ = InspectBundle(&b)
+		_, _ = b.MarshalJSON()
+		b.MinVersion("0.2")
+
+		sigContent, err := b.SignatureContent()
+		if err != nil {
+			return
+		}
+		sigContent.Signature()
+		if envelope := sigContent.EnvelopeContent(); envelope != nil {
+			statement, err := envelope.Statement()
+			if err == nil && statement == nil {
+				t.Fatal("Statement returned neither a statement nor an error")
+			}
+		}
+		if message := sigContent.MessageSignatureContent(); message != nil {
+			message.Digest()
+			message.DigestAlgorithm()
+		}
+	})
+}
+
+// FuzzEnvelopeStatement checks that decoding the in-toto statement of an
+// untrusted DSSE envelope never panics.
+func FuzzEnvelopeStatement(f *testing.F) {
+	provenance, err := os.ReadFile("../../examples/statement-provenance-sigstorejs1.3.0.json")
+	require.NoError(f, err)
+	f.Add(IntotoMediaType, provenance)
+	f.Add(IntotoMediaType, []byte("null"))
+
+	f.Fuzz(func(t *testing.T, payloadType string, payload []byte) {
+		envelope := &Envelope{Envelope: &dsse.Envelope{
+			PayloadType: payloadType,
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+		}}
+
+		statement, err := envelope.Statement()
+		if err == nil && statement == nil {
+			t.Fatal("Statement returned neither a statement nor an error")
+		}
+		envelope.Signature()
+	})
+}
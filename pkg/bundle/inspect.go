@@ -0,0 +1,111 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// BundleInfo describes the contents of a bundle, as returned by
+// InspectBundle.
+//
+// BundleInfo is UNTRUSTED: it is what the bundle claims about itself, without
+// any of it having been verified. It is meant for displaying a bundle, and
+// must not be used to make trust decisions; use a verify.SignedEntityVerifier
+// for those.
+type BundleInfo struct { // nolint: revive
+	MediaType string `json:"mediaType"`
+	// SignerIdentity is the Subject Alternative Name of the signing
+	// certificate, or empty if the bundle was signed with a public key
+	SignerIdentity string `json:"signerIdentity,omitempty"`
+	// Issuer is the OIDC issuer of the signing certificate
+	Issuer string `json:"issuer,omitempty"`
+	// PublicKeyHint identifies the public key the bundle was signed with, if
+	// it was not signed with a certificate
+	PublicKeyHint string `json:"publicKeyHint,omitempty"`
+	// TlogEntries describes the transparency log entries in the bundle
+	TlogEntries []TlogEntryInfo `json:"tlogEntries,omitempty"`
+	// SignedTimestamps is the number of RFC3161 signed timestamps in the
+	// bundle
+	SignedTimestamps int `json:"signedTimestamps"`
+}
+
+// TlogEntryInfo describes a transparency log entry in a bundle.
+type TlogEntryInfo struct {
+	// LogID is the hex-encoded ID of the log's key
+	LogID          string    `json:"logID"` //nolint:tagliatelle
+	LogIndex       int64     `json:"logIndex"`
+	IntegratedTime time.Time `json:"integratedTime"`
+	Kind           string    `json:"kind"`
+	Version        string    `json:"version"`
+}
+
+// InspectBundle returns a description of the bundle's contents: who claims to
+// have signed it, which transparency log entries it carries and whether it
+// has signed timestamps.
+//
+// InspectBundle performs NO cryptographic verification. The returned
+// BundleInfo is untrusted, and is only suitable for display, e.g. before or
+// in place of verifying the bundle.
+func InspectBundle(b *ProtobufBundle) (*BundleInfo, error) { // nolint: revive
+	if b == nil || b.Bundle == nil {
+		return nil, errors.New("no bundle to inspect")
+	}
+	if b.VerificationMaterial == nil {
+		return nil, ErrMissingVerificationMaterial
+	}
+
+	info := &BundleInfo{MediaType: b.MediaType}
+
+	switch content := b.VerificationMaterial.GetContent().(type) {
+	case *protobundle.VerificationMaterial_PublicKey:
+		info.PublicKeyHint = content.PublicKey.GetHint()
+	default:
+		certs, err := b.CertificateChain()
+		if err != nil {
+			return nil, err
+		}
+		if len(certs) == 0 {
+			return nil, ErrMissingVerificationMaterial
+		}
+		summary, err := certificate.SummarizeCertificate(certs[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize certificate: %w", err)
+		}
+		info.SignerIdentity = summary.SubjectAlternativeName.Value
+		info.Issuer = summary.Issuer
+	}
+
+	for _, entry := range b.VerificationMaterial.GetTlogEntries() {
+		info.TlogEntries = append(info.TlogEntries, TlogEntryInfo{
+			LogID:          hex.EncodeToString(entry.GetLogId().GetKeyId()),
+			LogIndex:       entry.GetLogIndex(),
+			IntegratedTime: time.Unix(entry.GetIntegratedTime(), 0),
+			Kind:           entry.GetKindVersion().GetKind(),
+			Version:        entry.GetKindVersion().GetVersion(),
+		})
+	}
+
+	info.SignedTimestamps = len(b.VerificationMaterial.GetTimestampVerificationData().GetRfc3161Timestamps())
+
+	return info, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"testing"
+	"time"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectBundle(t *testing.T) {
+	b := loadTestBundle(t, "../testing/data/sigstoreBundle.json")
+
+	info, err := InspectBundle(b)
+	require.NoError(t, err)
+	require.Equal(t, &BundleInfo{
+		MediaType:      "application/vnd.dev.sigstore.bundle+json;version=0.1",
+		SignerIdentity: "brian@dehamer.com",
+		Issuer:         "https://github.com/login/oauth",
+		TlogEntries: []TlogEntryInfo{
+			{
+				LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+				LogIndex:       6800908,
+				IntegratedTime: time.Unix(1668034836, 0),
+				Kind:           "intoto",
+				Version:        "0.0.2",
+			},
+		},
+		SignedTimestamps: 0,
+	}, info)
+
+	// A bundle signed with a public key has no signer identity
+	b.VerificationMaterial.Content = &protobundle.VerificationMaterial_PublicKey{
+		PublicKey: &protocommon.PublicKeyIdentifier{Hint: "key-hint"},
+	}
+	info, err = InspectBundle(b)
+	require.NoError(t, err)
+	require.Equal(t, "key-hint", info.PublicKeyHint)
+	require.Empty(t, info.SignerIdentity)
+	require.Empty(t, info.Issuer)
+
+	// Error: no verification material
+	b.VerificationMaterial = nil
+	_, err = InspectBundle(b)
+	require.ErrorIs(t, err, ErrMissingVerificationMaterial)
+}
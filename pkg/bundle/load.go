@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package bundle
+
+import (
+	"os"
+
+	protobundle "github.com/sigstore/protobuf-specs/gen/pb-go/bundle/v1"
+)
+
+// LoadJSONFromPath reads the bundle JSON at path. It is left out of
+// WebAssembly builds, whose verification path doesn't touch the filesystem;
+// there, unmarshal the bundle with UnmarshalJSON instead.
+func LoadJSONFromPath(path string) (*ProtobufBundle, error) {
+	var bundle ProtobufBundle
+	bundle.Bundle = new(protobundle.Bundle)
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = bundle.UnmarshalJSON(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
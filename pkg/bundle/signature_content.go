@@ -18,9 +18,10 @@ import (
 	"encoding/base64"
 	"encoding/json"
 
-	"github.com/in-toto/in-toto-golang/in_toto"
+	in_toto "github.com/in-toto/attestation/go/v1"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/sigstore-go/pkg/verify"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 const IntotoMediaType = "application/vnd.in-toto+json"
@@ -49,20 +50,44 @@ func NewMessageSignature(digest []byte, digestAlgorithm string, signature []byte
 
 type Envelope struct {
 	*dsse.Envelope
+	// rawJSON is the envelope as it was read from JSON, if it was
+	rawJSON []byte
 }
 
+// UnmarshalJSON decodes the envelope, keeping the JSON it was decoded from.
+func (e *Envelope) UnmarshalJSON(data []byte) error {
+	envelope := &dsse.Envelope{}
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return err
+	}
+	e.Envelope = envelope
+	e.rawJSON = append([]byte(nil), data...)
+	return nil
+}
+
+// EnvelopeJSON returns the envelope as it was read from JSON, either by
+// itself or in a bundle, or nil if it wasn't read from JSON. Timestamps over
+// the envelope cover these bytes, which re-encoding the envelope may not
+// reproduce.
+func (e *Envelope) EnvelopeJSON() []byte {
+	return e.rawJSON
+}
+
+// statementUnmarshalOptions ignores fields added by newer versions of the
+// in-toto statement, as decoding it into a Go struct did.
+var statementUnmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
 func (e *Envelope) Statement() (*in_toto.Statement, error) {
 	if e.PayloadType != IntotoMediaType {
 		return nil, ErrUnsupportedMediaType
 	}
 
-	var statement *in_toto.Statement
 	raw, err := e.DecodeB64Payload()
 	if err != nil {
 		return nil, ErrDecodingB64
 	}
-	err = json.Unmarshal(raw, &statement)
-	if err != nil {
+	statement := &in_toto.Statement{}
+	if err := statementUnmarshalOptions.Unmarshal(raw, statement); err != nil {
 		return nil, ErrDecodingJSON
 	}
 	return statement, nil
@@ -15,8 +15,11 @@
 package bundle
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"time"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
@@ -36,12 +39,16 @@ func (pk PublicKey) Hint() string {
 }
 
 func (c *Certificate) CompareKey(key any, _ root.TrustedMaterial) bool {
-	x509Key, ok := key.(*x509.Certificate)
-	if !ok {
-		return false
+	switch k := key.(type) {
+	case *x509.Certificate:
+		return c.Certificate.Equal(k)
+	case interface{ Equal(x crypto.PublicKey) bool }:
+		// Entries such as hashedrekord may log the signer's public key
+		// rather than its certificate, which must be the certificate's key.
+		return k.Equal(c.Certificate.PublicKey)
 	}
 
-	return c.Certificate.Equal(x509Key)
+	return false
 }
 
 func (c *Certificate) ValidAtTime(t time.Time, _ root.TrustedMaterial) bool {
@@ -86,3 +93,43 @@ func (pk *PublicKey) HasCertificate() (x509.Certificate, bool) {
 func (pk *PublicKey) HasPublicKey() (verify.PublicKeyProvider, bool) {
 	return *pk, true
 }
+
+// EntityCertChainPEM returns the entity's signing certificate followed by
+// any intermediate certificates it carries, PEM encoded, for use with
+// external tools such as openssl. Self-signed root certificates are left out;
+// those are trusted through the trusted root instead.
+func EntityCertChainPEM(entity verify.SignedEntity) ([]byte, error) {
+	var certs []*x509.Certificate
+	if chainProvider, ok := entity.(interface {
+		CertificateChain() ([]*x509.Certificate, error)
+	}); ok {
+		chain, err := chainProvider.CertificateChain()
+		if err != nil {
+			return nil, err
+		}
+		certs = chain
+	} else {
+		verificationContent, err := entity.VerificationContent()
+		if err != nil {
+			return nil, err
+		}
+		if leaf, ok := verificationContent.HasCertificate(); ok {
+			certs = []*x509.Certificate{&leaf}
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("entity was not signed with a certificate")
+	}
+
+	var chainPEM bytes.Buffer
+	for i, cert := range certs {
+		if i > 0 && bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			continue
+		}
+		err := pem.Encode(&chainPEM, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return chainPEM.Bytes(), nil
+}
@@ -15,6 +15,7 @@
 // This file is a verbatim copy of https://github.com/sigstore/fulcio/blob/3707d80bb25330bc7ffbd9702fb401cd643e36fa/pkg/certificate/extensions.go ,
 // EXCEPT:
 // - the parseExtensions func has been renamed ParseExtensions
+// - extension values are decoded with DecodeExtensionValue, whatever their OID
 
 package certificate
 
@@ -23,6 +24,7 @@ import (
 	"encoding/asn1"
 	"errors"
 	"fmt"
+	"unicode/utf8"
 )
 
 var (
@@ -145,86 +147,127 @@ func ParseExtensions(ext []pkix.Extension) (Extensions, error) {
 		switch {
 		// BEGIN: Deprecated
 		case e.Id.Equal(OIDIssuer):
-			out.Issuer = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.Issuer); err != nil {
+				return Extensions{}, err
+			}
 		case e.Id.Equal(OIDGitHubWorkflowTrigger):
-			out.GithubWorkflowTrigger = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.GithubWorkflowTrigger); err != nil {
+				return Extensions{}, err
+			}
 		case e.Id.Equal(OIDGitHubWorkflowSHA):
-			out.GithubWorkflowSHA = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.GithubWorkflowSHA); err != nil {
+				return Extensions{}, err
+			}
 		case e.Id.Equal(OIDGitHubWorkflowName):
-			out.GithubWorkflowName = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.GithubWorkflowName); err != nil {
+				return Extensions{}, err
+			}
 		case e.Id.Equal(OIDGitHubWorkflowRepository):
-			out.GithubWorkflowRepository = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.GithubWorkflowRepository); err != nil {
+				return Extensions{}, err
+			}
 		case e.Id.Equal(OIDGitHubWorkflowRef):
-			out.GithubWorkflowRef = string(e.Value)
+			if err := parseExtensionValue(e.Value, &out.GithubWorkflowRef); err != nil {
+				return Extensions{}, err
+			}
 		// END: Deprecated
 		case e.Id.Equal(OIDIssuerV2):
-			if err := ParseDERString(e.Value, &out.Issuer); err != nil {
+			if err := parseExtensionValue(e.Value, &out.Issuer); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDBuildSignerURI):
-			if err := ParseDERString(e.Value, &out.BuildSignerURI); err != nil {
+			if err := parseExtensionValue(e.Value, &out.BuildSignerURI); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDBuildSignerDigest):
-			if err := ParseDERString(e.Value, &out.BuildSignerDigest); err != nil {
+			if err := parseExtensionValue(e.Value, &out.BuildSignerDigest); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDRunnerEnvironment):
-			if err := ParseDERString(e.Value, &out.RunnerEnvironment); err != nil {
+			if err := parseExtensionValue(e.Value, &out.RunnerEnvironment); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryURI):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryURI); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryURI); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryDigest):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryDigest); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryDigest); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryRef):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryRef); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryRef); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryIdentifier):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryIdentifier); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryIdentifier); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryOwnerURI):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryOwnerURI); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryOwnerURI); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryOwnerIdentifier):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryOwnerIdentifier); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryOwnerIdentifier); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDBuildConfigURI):
-			if err := ParseDERString(e.Value, &out.BuildConfigURI); err != nil {
+			if err := parseExtensionValue(e.Value, &out.BuildConfigURI); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDBuildConfigDigest):
-			if err := ParseDERString(e.Value, &out.BuildConfigDigest); err != nil {
+			if err := parseExtensionValue(e.Value, &out.BuildConfigDigest); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDBuildTrigger):
-			if err := ParseDERString(e.Value, &out.BuildTrigger); err != nil {
+			if err := parseExtensionValue(e.Value, &out.BuildTrigger); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDRunInvocationURI):
-			if err := ParseDERString(e.Value, &out.RunInvocationURI); err != nil {
+			if err := parseExtensionValue(e.Value, &out.RunInvocationURI); err != nil {
 				return Extensions{}, err
 			}
 		case e.Id.Equal(OIDSourceRepositoryVisibilityAtSigning):
-			if err := ParseDERString(e.Value, &out.SourceRepositoryVisibilityAtSigning); err != nil {
+			if err := parseExtensionValue(e.Value, &out.SourceRepositoryVisibilityAtSigning); err != nil {
 				return Extensions{}, err
 			}
 		}
 	}
 
-	// We only ever return nil, but leaving error in place so that we can add
-	// more complex parsing of fields in a backwards compatible way if needed.
 	return out, nil
 }
 
+func parseExtensionValue(val []byte, parsedVal *string) error {
+	value, err := DecodeExtensionValue(val)
+	if err != nil {
+		return err
+	}
+	*parsedVal = value
+	return nil
+}
+
+// DecodeExtensionValue decodes the value of a Fulcio extension.
+//
+// Fulcio originally set extension values to the raw bytes of the string, as
+// the deprecated extensions still are. Extensions added since are set to a
+// DER-encoded UTF8String. Either encoding is accepted for any extension: the
+// value is decoded as a UTF8String if it is one, and taken as is otherwise.
+// Returns an error if the decoded value is not valid UTF-8.
+func DecodeExtensionValue(val []byte) (string, error) {
+	value := string(val)
+
+	var raw asn1.RawValue
+	rest, err := asn1.Unmarshal(val, &raw)
+	if err == nil && len(rest) == 0 && raw.Class == asn1.ClassUniversal && raw.Tag == asn1.TagUTF8String && !raw.IsCompound {
+		value = string(raw.Bytes)
+	}
+
+	if !utf8.ValidString(value) {
+		return "", errors.New("extension value is not valid UTF-8")
+	}
+	return value, nil
+}
+
 // ParseDERString decodes a DER-encoded string and puts the value in parsedVal.
 // Returns an error if the unmarshalling fails or if there are trailing bytes in the encoding.
 func ParseDERString(val []byte, parsedVal *string) error {
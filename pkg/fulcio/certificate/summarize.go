@@ -16,7 +16,10 @@ package certificate
 
 import (
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
 	"errors"
+	"fmt"
 	"reflect"
 )
 
@@ -30,42 +33,272 @@ const (
 	SubjectAlternativeNameTypeUnspecified SubjectAlternativeNameType = "Unspecified"
 	SubjectAlternativeNameTypeEmail       SubjectAlternativeNameType = "Email"
 	SubjectAlternativeNameTypeURI         SubjectAlternativeNameType = "URI"
+	SubjectAlternativeNameTypeDNS         SubjectAlternativeNameType = "DNS"
 	SubjectAlternativeNameTypeOther       SubjectAlternativeNameType = "Other"
 )
 
 type SubjectAlternativeName struct {
 	Type  SubjectAlternativeNameType `json:"type,omitempty""`
 	Value string                     `json:"value,omitempty"`
+	// OID is the dotted type-id of an otherName Subject Alternative Name
+	OID string `json:"oid,omitempty"`
 }
 
 type Summary struct {
 	CertificateIssuer      string                 `json:"certificateIssuer"`
 	SubjectAlternativeName SubjectAlternativeName `json:"subjectAlternativeName"`
+	// SubjectAlternativeNames holds every email, URI, DNS and otherName
+	// Subject Alternative Name of the certificate, in the order they appear
+	SubjectAlternativeNames []SubjectAlternativeName `json:"subjectAlternativeNames,omitempty"`
+	// SubjectCommonName is the certificate's Subject CN, which identifies
+	// certificates from CAs other than Fulcio
+	SubjectCommonName string `json:"subjectCommonName,omitempty"`
 	Extensions
+	// OtherExtensions holds the values of extensions under the Fulcio OID arc
+	// that this package does not know, keyed by their dotted OID
+	OtherExtensions map[string]string `json:"otherExtensions,omitempty"`
 }
 
+var (
+	oidFulcioExtensionArc     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1}
+	oidSubjectAlternativeName = asn1.ObjectIdentifier{2, 5, 29, 17}
+)
+
+// knownExtensions are the Fulcio extensions decoded by ParseExtensions.
+var knownExtensions = []asn1.ObjectIdentifier{
+	OIDIssuer,
+	OIDGitHubWorkflowTrigger,
+	OIDGitHubWorkflowSHA,
+	OIDGitHubWorkflowName,
+	OIDGitHubWorkflowRepository,
+	OIDGitHubWorkflowRef,
+	OIDIssuerV2,
+	OIDBuildSignerURI,
+	OIDBuildSignerDigest,
+	OIDRunnerEnvironment,
+	OIDSourceRepositoryURI,
+	OIDSourceRepositoryDigest,
+	OIDSourceRepositoryRef,
+	OIDSourceRepositoryIdentifier,
+	OIDSourceRepositoryOwnerURI,
+	OIDSourceRepositoryOwnerIdentifier,
+	OIDBuildConfigURI,
+	OIDBuildConfigDigest,
+	OIDBuildTrigger,
+	OIDRunInvocationURI,
+	OIDSourceRepositoryVisibilityAtSigning,
+}
+
+// SummarizeCertificate extracts the identity of a Fulcio certificate: its
+// Subject Alternative Name and the values of its Fulcio extensions.
 func SummarizeCertificate(cert *x509.Certificate) (Summary, error) {
+	return summarizeCertificate(cert, true)
+}
+
+// SummarizeBYOCertificate extracts the identity of a certificate issued by a
+// CA other than Fulcio. Unlike SummarizeCertificate, the certificate need not
+// have a Subject Alternative Name, as such certificates are often identified
+// by their Subject CN alone.
+func SummarizeBYOCertificate(cert *x509.Certificate) (Summary, error) {
+	return summarizeCertificate(cert, false)
+}
+
+func summarizeCertificate(cert *x509.Certificate, requireSAN bool) (Summary, error) {
 	extensions, err := ParseExtensions(cert.Extensions)
 
 	if err != nil {
 		return Summary{}, err
 	}
 
-	san := SubjectAlternativeName{}
+	sans, err := ParseSubjectAlternativeNames(cert)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	san := signingSubjectAlternativeName(cert, sans)
+	if san.Value == "" && requireSAN {
+		return Summary{}, errors.New("No Subject Alternative Name found")
+	}
+
+	otherExtensions, err := parseOtherExtensions(cert.Extensions)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	return Summary{
+		CertificateIssuer:       cert.Issuer.String(),
+		SubjectAlternativeName:  san,
+		SubjectAlternativeNames: sans,
+		SubjectCommonName:       cert.Subject.CommonName,
+		Extensions:              extensions,
+		OtherExtensions:         otherExtensions,
+	}, nil
+}
+
+// Identity is the identity a Fulcio certificate was issued to, taken from
+// its Subject Alternative Name. Type tells which field Value came from: an
+// email address for email-based OIDC identities, a URI for workload
+// identities such as SPIFFE IDs and CI workflows, or an otherName for
+// usernames, in which case OID holds the otherName's type-id.
+type Identity struct {
+	Type  SubjectAlternativeNameType `json:"type"`
+	Value string                     `json:"value"`
+	OID   string                     `json:"oid,omitempty"`
+}
 
+// SigningIdentity returns the identity the certificate was issued to. A URI
+// SAN is preferred over an email SAN, which is preferred over a Fulcio
+// username otherName SAN, matching SummarizeCertificate. An error is returned
+// if the certificate has none of these.
+func SigningIdentity(cert *x509.Certificate) (*Identity, error) {
+	sans, err := ParseSubjectAlternativeNames(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	san := signingSubjectAlternativeName(cert, sans)
+	if san.Value == "" {
+		return nil, errors.New("No Subject Alternative Name found")
+	}
+
+	return &Identity{Type: san.Type, Value: san.Value, OID: san.OID}, nil
+}
+
+// signingSubjectAlternativeName returns the Subject Alternative Name that
+// identifies the signer, or an empty SubjectAlternativeName if the
+// certificate has no URI, email or Fulcio username SAN.
+func signingSubjectAlternativeName(cert *x509.Certificate, sans []SubjectAlternativeName) SubjectAlternativeName {
 	switch {
 	case len(cert.URIs) > 0:
-		san.Type = SubjectAlternativeNameTypeURI
-		san.Value = cert.URIs[0].String()
+		return SubjectAlternativeName{Type: SubjectAlternativeNameTypeURI, Value: cert.URIs[0].String()}
 	case len(cert.EmailAddresses) > 0:
-		san.Type = SubjectAlternativeNameTypeEmail
-		san.Value = cert.EmailAddresses[0]
-	default:
-		// TODO: Support OtherName SANs i.e. https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md#1361415726417--othername-san
-		return Summary{}, errors.New("No Subject Alternative Name found")
+		return SubjectAlternativeName{Type: SubjectAlternativeNameTypeEmail, Value: cert.EmailAddresses[0]}
+	}
+	for _, name := range sans {
+		if name.Type == SubjectAlternativeNameTypeOther && name.OID == OIDOtherName.String() {
+			return name
+		}
+	}
+	return SubjectAlternativeName{}
+}
+
+// otherName is an otherName Subject Alternative Name, which Fulcio uses for
+// identities that are neither email addresses nor URIs, e.g. usernames.
+type otherName struct {
+	TypeID asn1.ObjectIdentifier
+	Value  string `asn1:"utf8,explicit,tag:0"`
+}
+
+// GeneralName tags of the Subject Alternative Name types that are parsed, see
+// RFC 5280 section 4.2.1.6.
+const (
+	sanTagOtherName = 0
+	sanTagEmail     = 1
+	sanTagDNS       = 2
+	sanTagURI       = 6
+)
+
+// ParseSubjectAlternativeNames returns the email, URI, DNS and otherName
+// Subject Alternative Names of the certificate, in the order they appear in
+// its Subject Alternative Name extension. Other name types, such as IP
+// addresses, are skipped.
+//
+// x509.Certificate does not surface otherName SANs, which Fulcio issues for
+// username identities; those are returned with their type-id OID and UTF-8
+// value. otherNames whose value is not a UTF8String are skipped, except for
+// the Fulcio username OID, for which this is an error.
+//
+// See https://github.com/sigstore/fulcio/blob/main/docs/oid-info.md#1361415726417--othername-san
+func ParseSubjectAlternativeNames(cert *x509.Certificate) ([]SubjectAlternativeName, error) {
+	var sans []SubjectAlternativeName
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAlternativeName) {
+			continue
+		}
+
+		var seq asn1.RawValue
+		rest, err := asn1.Unmarshal(ext.Value, &seq)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse Subject Alternative Name: %w", err)
+		}
+		if len(rest) != 0 || !seq.IsCompound || seq.Tag != asn1.TagSequence || seq.Class != asn1.ClassUniversal {
+			return nil, errors.New("unable to parse Subject Alternative Name: not a sequence")
+		}
+
+		rest = seq.Bytes
+		for len(rest) > 0 {
+			var name asn1.RawValue
+			rest, err = asn1.Unmarshal(rest, &name)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse Subject Alternative Name: %w", err)
+			}
+			if name.Class != asn1.ClassContextSpecific {
+				continue
+			}
+
+			switch name.Tag {
+			case sanTagEmail:
+				sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeEmail, Value: string(name.Bytes)})
+			case sanTagDNS:
+				sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeDNS, Value: string(name.Bytes)})
+			case sanTagURI:
+				sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeURI, Value: string(name.Bytes)})
+			case sanTagOtherName:
+				var typeID struct {
+					TypeID asn1.ObjectIdentifier
+					Value  asn1.RawValue `asn1:"explicit,tag:0"`
+				}
+				if _, err := asn1.UnmarshalWithParams(name.FullBytes, &typeID, "tag:0"); err != nil {
+					return nil, fmt.Errorf("unable to parse otherName Subject Alternative Name: %w", err)
+				}
+				var other otherName
+				if _, err := asn1.UnmarshalWithParams(name.FullBytes, &other, "tag:0"); err != nil {
+					if typeID.TypeID.Equal(OIDOtherName) {
+						return nil, fmt.Errorf("unable to parse otherName Subject Alternative Name: %w", err)
+					}
+					continue
+				}
+				sans = append(sans, SubjectAlternativeName{Type: SubjectAlternativeNameTypeOther, Value: other.Value, OID: other.TypeID.String()})
+			}
+		}
 	}
 
-	return Summary{CertificateIssuer: cert.Issuer.String(), SubjectAlternativeName: san, Extensions: extensions}, nil
+	return sans, nil
+}
+
+// parseOtherExtensions returns the values of the extensions under the Fulcio
+// OID arc that are not among knownExtensions, decoded with
+// DecodeExtensionValue.
+func parseOtherExtensions(extensions []pkix.Extension) (map[string]string, error) {
+	var other map[string]string
+	for _, ext := range extensions {
+		if !isFulcioExtension(ext.Id) || isKnownExtension(ext.Id) {
+			continue
+		}
+
+		value, err := DecodeExtensionValue(ext.Value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode extension %s: %w", ext.Id, err)
+		}
+		if other == nil {
+			other = make(map[string]string)
+		}
+		other[ext.Id.String()] = value
+	}
+	return other, nil
+}
+
+func isFulcioExtension(oid asn1.ObjectIdentifier) bool {
+	return len(oid) > len(oidFulcioExtensionArc) && oidFulcioExtensionArc.Equal(oid[:len(oidFulcioExtensionArc)])
+}
+
+func isKnownExtension(oid asn1.ObjectIdentifier) bool {
+	for _, known := range knownExtensions {
+		if oid.Equal(known) {
+			return true
+		}
+	}
+	return false
 }
 
 // CompareExtensions compares two Extensions structs and returns true if their
@@ -15,65 +15,74 @@
 package certificate_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
 	"github.com/sigstore/sigstore-go/pkg/testing/data"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestSummarizeCertificateWithActionsBundle(t *testing.T) {
-	entity := data.SigstoreJS200ProvenanceBundle(t)
+// fulcioCertificate returns a certificate with the given Subject Alternative
+// Names and extensions, issued the way Fulcio issues certificates.
+func fulcioCertificate(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
 
-	vc, err := entity.VerificationContent()
-	if err != nil {
-		t.Fatalf("failed to get verification content: %v", err)
+	issuer := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "sigstore-intermediate", Organization: []string{"sigstore.dev"}},
 	}
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now()
+	template.NotAfter = time.Now().Add(10 * time.Minute)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}
 
-	leaf, ok := vc.HasCertificate()
-
-	if !ok {
-		t.Fatalf("expected verification content to be a certificate chain")
-	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
 
-	cs, err := certificate.SummarizeCertificate(&leaf)
-	if err != nil {
-		t.Fatalf("failed to summarize: %v", err)
-	}
+func derExtension(t *testing.T, oid asn1.ObjectIdentifier, value string) pkix.Extension {
+	der, err := asn1.MarshalWithParams(value, "utf8")
+	assert.NoError(t, err)
+	return pkix.Extension{Id: oid, Value: der}
+}
 
-	expected := certificate.Summary{
-		CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
-		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"},
-		Extensions: certificate.Extensions{
-			Issuer:                              "https://token.actions.githubusercontent.com",
-			GithubWorkflowTrigger:               "push",
-			GithubWorkflowSHA:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
-			GithubWorkflowName:                  "Release",
-			GithubWorkflowRepository:            "sigstore/sigstore-js",
-			GithubWorkflowRef:                   "refs/heads/main",
-			BuildSignerURI:                      "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main",
-			BuildSignerDigest:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
-			RunnerEnvironment:                   "github-hosted",
-			SourceRepositoryURI:                 "https://github.com/sigstore/sigstore-js",
-			SourceRepositoryDigest:              "f0b49a04e5a62250e0f60fb128004a73110fe311",
-			SourceRepositoryRef:                 "refs/heads/main",
-			SourceRepositoryIdentifier:          "495574555",
-			SourceRepositoryOwnerURI:            "https://github.com/sigstore",
-			SourceRepositoryOwnerIdentifier:     "71096353",
-			BuildConfigURI:                      "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main",
-			BuildConfigDigest:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
-			BuildTrigger:                        "push",
-			RunInvocationURI:                    "https://github.com/sigstore/sigstore-js/actions/runs/5904696764/attempts/1",
-			SourceRepositoryVisibilityAtSigning: "public",
-		},
-	}
+func otherNameExtension(t *testing.T, value string) pkix.Extension {
+	return sanExtension(t, otherNameSAN(t, certificate.OIDOtherName, value))
+}
 
-	assert.Equal(t, expected, cs)
+// otherNameSAN returns an otherName GeneralName with a UTF8String value.
+func otherNameSAN(t *testing.T, typeID asn1.ObjectIdentifier, value string) asn1.RawValue {
+	name, err := asn1.MarshalWithParams(struct {
+		TypeID asn1.ObjectIdentifier
+		Value  string `asn1:"utf8,explicit,tag:0"`
+	}{TypeID: typeID, Value: value}, "tag:0")
+	assert.NoError(t, err)
+	return asn1.RawValue{FullBytes: name}
 }
 
-func TestSummarizeCertificateWithOauthBundle(t *testing.T) {
-	entity := data.SigstoreBundle(t)
+// sanExtension returns a Subject Alternative Name extension holding the given
+// GeneralNames, for SANs that x509.Certificate templates can't express.
+func sanExtension(t *testing.T, names ...asn1.RawValue) pkix.Extension {
+	san, err := asn1.Marshal(names)
+	assert.NoError(t, err)
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: san}
+}
 
+func bundleLeafCertificate(t *testing.T, entity *bundle.ProtobufBundle) *x509.Certificate {
 	vc, err := entity.VerificationContent()
 	if err != nil {
 		t.Fatalf("failed to get verification content: %v", err)
@@ -85,20 +94,448 @@ func TestSummarizeCertificateWithOauthBundle(t *testing.T) {
 		t.Fatalf("expected verification content to be a certificate chain")
 	}
 
-	cs, err := certificate.SummarizeCertificate(&leaf)
-	if err != nil {
-		t.Fatalf("failed to summarize: %v", err)
+	return &leaf
+}
+
+func TestSummarizeCertificate(t *testing.T) {
+	gitlabPipeline, err := url.Parse("https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		cert    func(t *testing.T) *x509.Certificate
+		want    certificate.Summary
+		wantErr bool
+	}{
+		{
+			// Issued in 2023, with both the raw deprecated extensions and the
+			// DER-encoded version 2 extensions
+			name: "GitHub Actions",
+			cert: func(t *testing.T) *x509.Certificate {
+				return bundleLeafCertificate(t, data.SigstoreJS200ProvenanceBundle(t))
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "URI", Value: "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "URI", Value: "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main"}},
+				Extensions: certificate.Extensions{
+					Issuer:                              "https://token.actions.githubusercontent.com",
+					GithubWorkflowTrigger:               "push",
+					GithubWorkflowSHA:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
+					GithubWorkflowName:                  "Release",
+					GithubWorkflowRepository:            "sigstore/sigstore-js",
+					GithubWorkflowRef:                   "refs/heads/main",
+					BuildSignerURI:                      "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main",
+					BuildSignerDigest:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
+					RunnerEnvironment:                   "github-hosted",
+					SourceRepositoryURI:                 "https://github.com/sigstore/sigstore-js",
+					SourceRepositoryDigest:              "f0b49a04e5a62250e0f60fb128004a73110fe311",
+					SourceRepositoryRef:                 "refs/heads/main",
+					SourceRepositoryIdentifier:          "495574555",
+					SourceRepositoryOwnerURI:            "https://github.com/sigstore",
+					SourceRepositoryOwnerIdentifier:     "71096353",
+					BuildConfigURI:                      "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main",
+					BuildConfigDigest:                   "f0b49a04e5a62250e0f60fb128004a73110fe311",
+					BuildTrigger:                        "push",
+					RunInvocationURI:                    "https://github.com/sigstore/sigstore-js/actions/runs/5904696764/attempts/1",
+					SourceRepositoryVisibilityAtSigning: "public",
+				},
+			},
+		},
+		{
+			// Issued in 2022, before the version 2 extensions, with a raw
+			// issuer extension
+			name: "email",
+			cert: func(t *testing.T) *x509.Certificate {
+				return bundleLeafCertificate(t, data.SigstoreBundle(t))
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "Email", Value: "brian@dehamer.com"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "Email", Value: "brian@dehamer.com"}},
+				Extensions: certificate.Extensions{
+					Issuer: "https://github.com/login/oauth",
+				},
+			},
+		},
+		{
+			// GitLab certificates only carry the version 2 extensions
+			name: "GitLab CI",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					URIs: []*url.URL{gitlabPipeline},
+					ExtraExtensions: []pkix.Extension{
+						derExtension(t, certificate.OIDIssuerV2, "https://gitlab.com"),
+						derExtension(t, certificate.OIDBuildSignerURI, "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main"),
+						derExtension(t, certificate.OIDBuildSignerDigest, "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"),
+						derExtension(t, certificate.OIDRunnerEnvironment, "gitlab-hosted"),
+						derExtension(t, certificate.OIDSourceRepositoryURI, "https://gitlab.com/sigstore/sigstore-go-test"),
+						derExtension(t, certificate.OIDSourceRepositoryDigest, "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"),
+						derExtension(t, certificate.OIDSourceRepositoryRef, "refs/heads/main"),
+						derExtension(t, certificate.OIDSourceRepositoryIdentifier, "12345678"),
+						derExtension(t, certificate.OIDSourceRepositoryOwnerURI, "https://gitlab.com/sigstore"),
+						derExtension(t, certificate.OIDSourceRepositoryOwnerIdentifier, "87654321"),
+						derExtension(t, certificate.OIDBuildConfigURI, "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main"),
+						derExtension(t, certificate.OIDBuildConfigDigest, "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"),
+						derExtension(t, certificate.OIDBuildTrigger, "push"),
+						derExtension(t, certificate.OIDRunInvocationURI, "https://gitlab.com/sigstore/sigstore-go-test/-/jobs/1234567890"),
+						derExtension(t, certificate.OIDSourceRepositoryVisibilityAtSigning, "public"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "URI", Value: "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "URI", Value: "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main"}},
+				Extensions: certificate.Extensions{
+					Issuer:                              "https://gitlab.com",
+					BuildSignerURI:                      "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main",
+					BuildSignerDigest:                   "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+					RunnerEnvironment:                   "gitlab-hosted",
+					SourceRepositoryURI:                 "https://gitlab.com/sigstore/sigstore-go-test",
+					SourceRepositoryDigest:              "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+					SourceRepositoryRef:                 "refs/heads/main",
+					SourceRepositoryIdentifier:          "12345678",
+					SourceRepositoryOwnerURI:            "https://gitlab.com/sigstore",
+					SourceRepositoryOwnerIdentifier:     "87654321",
+					BuildConfigURI:                      "https://gitlab.com/sigstore/sigstore-go-test//.gitlab-ci.yml@refs/heads/main",
+					BuildConfigDigest:                   "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678",
+					BuildTrigger:                        "push",
+					RunInvocationURI:                    "https://gitlab.com/sigstore/sigstore-go-test/-/jobs/1234567890",
+					SourceRepositoryVisibilityAtSigning: "public",
+				},
+			},
+		},
+		{
+			name: "otherName SAN",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					ExtraExtensions: []pkix.Extension{
+						otherNameExtension(t, "foo!example.com"),
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "Other", Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "Other", Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"}},
+				Extensions: certificate.Extensions{
+					Issuer: "https://example.com",
+				},
+			},
+		},
+		{
+			name: "username identity alongside other otherNames",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					ExtraExtensions: []pkix.Extension{
+						sanExtension(t,
+							otherNameSAN(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}, "foo@corp.example.com"),
+							otherNameSAN(t, certificate.OIDOtherName, "foo!example.com"),
+						),
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "Other", Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+					{Type: "Other", Value: "foo@corp.example.com", OID: "1.3.6.1.4.1.311.20.2.3"},
+					{Type: "Other", Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"},
+				},
+				Extensions: certificate.Extensions{
+					Issuer: "https://example.com",
+				},
+			},
+		},
+		{
+			name: "every SAN type",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					ExtraExtensions: []pkix.Extension{
+						sanExtension(t,
+							asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, Bytes: []byte("foo@example.com")},
+							asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, Bytes: []byte("example.com")},
+							asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 6, Bytes: []byte("https://example.com/foo")},
+							// IP addresses are not reported
+							asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 7, Bytes: []byte{127, 0, 0, 1}},
+							otherNameSAN(t, certificate.OIDOtherName, "foo!example.com"),
+						),
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "URI", Value: "https://example.com/foo"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+					{Type: "Email", Value: "foo@example.com"},
+					{Type: "DNS", Value: "example.com"},
+					{Type: "URI", Value: "https://example.com/foo"},
+					{Type: "Other", Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"},
+				},
+				Extensions: certificate.Extensions{
+					Issuer: "https://example.com",
+				},
+			},
+		},
+		{
+			name: "username otherName is not a UTF8String",
+			cert: func(t *testing.T) *x509.Certificate {
+				name, err := asn1.MarshalWithParams(struct {
+					TypeID asn1.ObjectIdentifier
+					Value  int `asn1:"explicit,tag:0"`
+				}{TypeID: certificate.OIDOtherName, Value: 42}, "tag:0")
+				assert.NoError(t, err)
+				return fulcioCertificate(t, &x509.Certificate{
+					ExtraExtensions: []pkix.Extension{
+						sanExtension(t, asn1.RawValue{FullBytes: name}),
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+					},
+				})
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown Fulcio extensions",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					EmailAddresses: []string{"foo@example.com"},
+					ExtraExtensions: []pkix.Extension{
+						{Id: certificate.OIDIssuer, Value: []byte("https://example.com")},
+						derExtension(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 99}, "der encoded"),
+						{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 100}, Value: []byte("raw")},
+						// outside the Fulcio arc, so not reported
+						derExtension(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1}, "ignored"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "Email", Value: "foo@example.com"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "Email", Value: "foo@example.com"}},
+				Extensions: certificate.Extensions{
+					Issuer: "https://example.com",
+				},
+				OtherExtensions: map[string]string{
+					"1.3.6.1.4.1.57264.1.99":  "der encoded",
+					"1.3.6.1.4.1.57264.1.100": "raw",
+				},
+			},
+		},
+		{
+			name: "DER-encoded deprecated extensions",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					EmailAddresses: []string{"foo@example.com"},
+					ExtraExtensions: []pkix.Extension{
+						derExtension(t, certificate.OIDIssuer, "https://example.com"),
+						derExtension(t, certificate.OIDGitHubWorkflowTrigger, "push"),
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "Email", Value: "foo@example.com"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "Email", Value: "foo@example.com"}},
+				Extensions: certificate.Extensions{
+					Issuer:                "https://example.com",
+					GithubWorkflowTrigger: "push",
+				},
+			},
+		},
+		{
+			name: "raw version 2 extensions",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					EmailAddresses: []string{"foo@example.com"},
+					ExtraExtensions: []pkix.Extension{
+						{Id: certificate.OIDIssuerV2, Value: []byte("https://example.com")},
+						{Id: certificate.OIDSourceRepositoryRef, Value: []byte("refs/heads/main")},
+					},
+				})
+			},
+			want: certificate.Summary{
+				CertificateIssuer:       "CN=sigstore-intermediate,O=sigstore.dev",
+				SubjectAlternativeName:  certificate.SubjectAlternativeName{Type: "Email", Value: "foo@example.com"},
+				SubjectAlternativeNames: []certificate.SubjectAlternativeName{{Type: "Email", Value: "foo@example.com"}},
+				Extensions: certificate.Extensions{
+					Issuer:              "https://example.com",
+					SourceRepositoryRef: "refs/heads/main",
+				},
+			},
+		},
+		{
+			name: "extension value is not UTF-8",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					EmailAddresses: []string{"foo@example.com"},
+					ExtraExtensions: []pkix.Extension{
+						{Id: certificate.OIDIssuer, Value: []byte{0xff, 0xfe, 0xfd}},
+					},
+				})
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown extension value is not UTF-8",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					EmailAddresses: []string{"foo@example.com"},
+					ExtraExtensions: []pkix.Extension{
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+						{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 99}, Value: []byte{0xff, 0xfe, 0xfd}},
+					},
+				})
+			},
+			wantErr: true,
+		},
+		{
+			name: "no Subject Alternative Name",
+			cert: func(t *testing.T) *x509.Certificate {
+				return fulcioCertificate(t, &x509.Certificate{
+					ExtraExtensions: []pkix.Extension{
+						derExtension(t, certificate.OIDIssuerV2, "https://example.com"),
+					},
+				})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := certificate.SummarizeCertificate(tt.cert(t))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, cs)
+		})
 	}
+}
+
+func TestSigningIdentity(t *testing.T) {
+	workload, err := url.Parse("spiffe://example.com/ns/default/sa/foo")
+	assert.NoError(t, err)
 
-	expected := certificate.Summary{
-		CertificateIssuer:      "CN=sigstore-intermediate,O=sigstore.dev",
-		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "Email", Value: "brian@dehamer.com"},
-		Extensions: certificate.Extensions{
-			Issuer: "https://github.com/login/oauth",
+	tests := []struct {
+		name    string
+		cert    *x509.Certificate
+		want    *certificate.Identity
+		wantErr bool
+	}{
+		{
+			name: "email",
+			cert: fulcioCertificate(t, &x509.Certificate{EmailAddresses: []string{"foo@example.com"}}),
+			want: &certificate.Identity{Type: certificate.SubjectAlternativeNameTypeEmail, Value: "foo@example.com"},
+		},
+		{
+			name: "URI",
+			cert: fulcioCertificate(t, &x509.Certificate{URIs: []*url.URL{workload}}),
+			want: &certificate.Identity{Type: certificate.SubjectAlternativeNameTypeURI, Value: "spiffe://example.com/ns/default/sa/foo"},
+		},
+		{
+			name: "otherName",
+			cert: fulcioCertificate(t, &x509.Certificate{ExtraExtensions: []pkix.Extension{otherNameExtension(t, "foo!example.com")}}),
+			want: &certificate.Identity{Type: certificate.SubjectAlternativeNameTypeOther, Value: "foo!example.com", OID: "1.3.6.1.4.1.57264.1.7"},
+		},
+		{
+			name: "otherName with another type-id is not an identity",
+			cert: fulcioCertificate(t, &x509.Certificate{
+				ExtraExtensions: []pkix.Extension{
+					sanExtension(t, otherNameSAN(t, asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}, "foo@corp.example.com")),
+				},
+			}),
+			wantErr: true,
+		},
+		{
+			name: "URI is preferred over email",
+			cert: fulcioCertificate(t, &x509.Certificate{EmailAddresses: []string{"foo@example.com"}, URIs: []*url.URL{workload}}),
+			want: &certificate.Identity{Type: certificate.SubjectAlternativeNameTypeURI, Value: "spiffe://example.com/ns/default/sa/foo"},
+		},
+		{
+			name:    "no SAN",
+			cert:    fulcioCertificate(t, &x509.Certificate{}),
+			wantErr: true,
 		},
 	}
 
-	assert.Equal(t, expected, cs)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := certificate.SigningIdentity(test.cert)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, identity)
+		})
+	}
+}
+
+func TestDecodeExtensionValue(t *testing.T) {
+	utf8String, err := asn1.MarshalWithParams("https://example.com", "utf8")
+	assert.NoError(t, err)
+	printableString, err := asn1.MarshalWithParams("example", "printable")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		value   []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "raw string",
+			value: []byte("https://example.com"),
+			want:  "https://example.com",
+		},
+		{
+			name:  "DER-encoded UTF8String",
+			value: utf8String,
+			want:  "https://example.com",
+		},
+		{
+			name:  "empty",
+			value: []byte{},
+			want:  "",
+		},
+		{
+			// Fulcio never used other string types, so this is a raw value
+			name:  "DER-encoded PrintableString",
+			value: printableString,
+			want:  string(printableString),
+		},
+		{
+			name:  "UTF8String with trailing bytes",
+			value: append(append([]byte{}, utf8String...), 'x'),
+			want:  string(utf8String) + "x",
+		},
+		{
+			name:    "invalid UTF-8",
+			value:   []byte{0xff, 0xfe, 0xfd},
+			wantErr: true,
+		},
+		{
+			name:    "UTF8String holding invalid UTF-8",
+			value:   []byte{0x0c, 0x02, 0xff, 0xfe},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := certificate.DecodeExtensionValue(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, value)
+		})
+	}
 }
 
 func TestCompareExtensions(t *testing.T) {
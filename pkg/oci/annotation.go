@@ -0,0 +1,43 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// WithAnnotatedArtifactDigest returns an artifact policy that verifies the
+// attestation against the digest, of the form algorithm:hex, in the
+// annotation with the key, for attestations stored with their subject's
+// digest in an OCI annotation rather than found by it. The annotations are
+// typically those of the referrer's descriptor or manifest. It is like
+// verify.WithArtifactDigest with the digest the annotation holds.
+//
+// The annotation must be present; the policy fails to build otherwise.
+func WithAnnotatedArtifactDigest(annotations map[string]string, key string) verify.ArtifactPolicyOption {
+	return func(p *verify.PolicyConfig) error {
+		digest, ok := annotations[key]
+		if !ok {
+			return fmt.Errorf("no %s annotation with the subject digest", key)
+		}
+		algorithm, digestBytes, err := parseDigest(digest)
+		if err != nil {
+			return fmt.Errorf("annotation %s: %w", key, err)
+		}
+		return verify.WithArtifactDigest(algorithm, digestBytes)(p)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithAnnotatedArtifactDigest(t *testing.T) {
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	trustedMaterial := publicKeyTrustedMaterial(t, keypair)
+
+	imageDigest := sha256.Sum256([]byte("image manifest"))
+	otherImageDigest := sha256.Sum256([]byte("other image manifest"))
+	statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"registry.example/app","digest":{"sha256":"%x"}}],"predicateType":"%s","predicate":{}}`, imageDigest, slsaPredicateType)
+	pb, err := sign.Bundle(&sign.DSSEData{Data: []byte(statement), PayloadType: "application/vnd.in-toto+json"}, keypair, sign.BundleOptions{})
+	require.NoError(t, err)
+	b, err := bundle.NewProtobufBundle(pb)
+	require.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithoutAnyObserverTimestampsInsecure())
+	require.NoError(t, err)
+
+	const key = "org.example.subject.digest"
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     string
+	}{
+		{
+			name:        "annotated subject digest",
+			annotations: map[string]string{key: fmt.Sprintf("sha256:%x", imageDigest)},
+		},
+		{
+			name:        "other subject digest",
+			annotations: map[string]string{key: fmt.Sprintf("sha256:%x", otherImageDigest)},
+			wantErr:     "failed to verify signature",
+		},
+		{
+			name:        "no annotation",
+			annotations: map[string]string{PredicateTypeAnnotation: slsaPredicateType},
+			wantErr:     "no org.example.subject.digest annotation",
+		},
+		{
+			name:        "not a digest",
+			annotations: map[string]string{key: "registry.example/app"},
+			wantErr:     "not of the form algorithm:hex",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(b, verify.NewPolicy(WithAnnotatedArtifactDigest(tt.annotations, key), verify.WithoutIdentitiesUnsafe()))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
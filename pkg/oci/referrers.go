@@ -0,0 +1,227 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci verifies Sigstore bundles attached to container images as OCI
+// referrers, such as the SBOM, provenance and vulnerability scan attestations
+// of an image.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+)
+
+// bundleMediaTypePrefix is the prefix of the media types of Sigstore bundles,
+// which is also the artifact type of the referrers that carry them.
+const bundleMediaTypePrefix = "application/vnd.dev.sigstore.bundle"
+
+// PredicateTypeAnnotation is the annotation on a referrer's descriptor that
+// names the predicate type of the attestation in its bundle.
+const PredicateTypeAnnotation = "dev.sigstore.bundle.predicateType"
+
+// Descriptor describes a manifest or blob in a registry, as in the OCI image
+// spec.
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// manifest is the part of an OCI image manifest needed to find the bundle in
+// a referrer.
+type manifest struct {
+	Layers []Descriptor `json:"layers"`
+}
+
+// Registry is the client that referrers are fetched with. Implement it with
+// the registry client and credentials of your choice.
+type Registry interface {
+	// Referrers returns the descriptors of the manifests in the repository
+	// that refer to the manifest with the digest, as the OCI referrers API
+	// lists them.
+	Referrers(ctx context.Context, repository, digest string) ([]Descriptor, error)
+	// Fetch returns the content of the manifest or blob in the repository
+	// that the descriptor describes.
+	Fetch(ctx context.Context, repository string, desc Descriptor) ([]byte, error)
+}
+
+// ReferrerResult is the outcome of verifying one referrer. Err is set when
+// the referrer couldn't be fetched or didn't verify, and Result otherwise.
+type ReferrerResult struct {
+	// Descriptor describes the referrer's manifest.
+	Descriptor Descriptor
+	// PredicateType is the predicate type of the verified attestation, or
+	// if it didn't verify, the one the referrer is annotated with.
+	PredicateType string
+	Result        *verify.VerificationResult
+	Err           error
+}
+
+// ReferrersVerifier verifies the Sigstore bundles attached to images as OCI
+// referrers.
+type ReferrersVerifier struct {
+	registry Registry
+	options  []verify.VerifierOption
+}
+
+// NewReferrersVerifier returns a ReferrersVerifier that fetches referrers
+// with the registry, and verifies their bundles with a SignedEntityVerifier
+// configured with the options.
+func NewReferrersVerifier(registry Registry, options ...verify.VerifierOption) *ReferrersVerifier {
+	return &ReferrersVerifier{registry: registry, options: options}
+}
+
+// VerifyAllReferrers fetches the Sigstore bundles that refer to the image
+// ref, of the form repository@algorithm:hex, and verifies each of them
+// against the trusted material. Each bundle must be an attestation about the
+// image: a statement with the image's digest as a subject. The policies,
+// such as WithCertificateIdentity, apply to every bundle.
+//
+// There is a result for each referrer that carries a bundle, whether or not
+// it verified; other referrers are skipped. An error is returned only if the
+// referrers couldn't be listed.
+func (v *ReferrersVerifier) VerifyAllReferrers(ctx context.Context, ref string, trustedMaterial root.TrustedMaterial, policies ...verify.PolicyOption) ([]ReferrerResult, error) {
+	repository, digest, ok := strings.Cut(ref, "@")
+	if !ok || repository == "" {
+		return nil, fmt.Errorf("%q is not a digest reference of the form repository@algorithm:hex", ref)
+	}
+	algorithm, digestBytes, err := parseDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, v.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers, err := v.registry.Referrers(ctx, repository, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers of %s: %w", ref, err)
+	}
+
+	var results []ReferrerResult
+	for _, referrer := range referrers {
+		if !strings.HasPrefix(referrer.ArtifactType, bundleMediaTypePrefix) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := ReferrerResult{
+			Descriptor:    referrer,
+			PredicateType: referrer.Annotations[PredicateTypeAnnotation],
+		}
+		b, err := v.fetchBundle(ctx, repository, referrer)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+
+		result.Result, result.Err = verifier.Verify(b, verify.NewPolicy(verify.WithArtifactDigest(algorithm, digestBytes), policies...))
+		if result.Result != nil && result.Result.Statement != nil {
+			result.PredicateType = result.Result.Statement.PredicateType
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// fetchBundle fetches the referrer's manifest, and the bundle in its layers.
+func (v *ReferrersVerifier) fetchBundle(ctx context.Context, repository string, referrer Descriptor) (*bundle.ProtobufBundle, error) {
+	manifestBytes, err := v.fetch(ctx, repository, referrer)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(manifestBytes, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", referrer.Digest, err)
+	}
+
+	for _, layer := range m.Layers {
+		if !strings.HasPrefix(layer.MediaType, bundleMediaTypePrefix) {
+			continue
+		}
+		bundleBytes, err := v.fetch(ctx, repository, layer)
+		if err != nil {
+			return nil, err
+		}
+		var b bundle.ProtobufBundle
+		if err := b.UnmarshalJSON(bundleBytes); err != nil {
+			return nil, fmt.Errorf("failed to parse bundle %s: %w", layer.Digest, err)
+		}
+		return &b, nil
+	}
+	return nil, fmt.Errorf("manifest %s has no bundle layer", referrer.Digest)
+}
+
+// fetch fetches the content the descriptor describes, and checks that it is
+// the content the descriptor names by its digest.
+func (v *ReferrersVerifier) fetch(ctx context.Context, repository string, desc Descriptor) ([]byte, error) {
+	content, err := v.registry.Fetch(ctx, repository, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	algorithm, want, err := parseDigest(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	var hasher hash.Hash
+	switch algorithm {
+	case "sha256":
+		hasher = sha256.New()
+	case "sha512":
+		hasher = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+	hasher.Write(content)
+	if got := hasher.Sum(nil); !bytes.Equal(got, want) {
+		return nil, fmt.Errorf("content fetched for %s has digest %s:%x", desc.Digest, algorithm, got)
+	}
+	return content, nil
+}
+
+// parseDigest splits an OCI digest, algorithm:hex, into its algorithm and
+// decoded bytes.
+func parseDigest(digest string) (string, []byte, error) {
+	algorithm, encoded, ok := strings.Cut(digest, ":")
+	if !ok {
+		return "", nil, fmt.Errorf("digest %q is not of the form algorithm:hex", digest)
+	}
+	digestBytes, err := hex.DecodeString(encoded)
+	if err != nil {
+		return "", nil, fmt.Errorf("digest %q is not hex encoded: %w", digest, err)
+	}
+	if len(digestBytes) == 0 {
+		return "", nil, errors.New("empty digest")
+	}
+	return algorithm, digestBytes, nil
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/sign"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	spdxPredicateType = "https://spdx.dev/Document"
+	slsaPredicateType = "https://slsa.dev/provenance/v1"
+)
+
+// fakeRegistry serves referrers and content from memory.
+type fakeRegistry struct {
+	referrers map[string][]Descriptor
+	content   map[string][]byte
+	err       error
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{referrers: map[string][]Descriptor{}, content: map[string][]byte{}}
+}
+
+func (r *fakeRegistry) Referrers(_ context.Context, repository, digest string) ([]Descriptor, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.referrers[repository+"@"+digest], nil
+}
+
+func (r *fakeRegistry) Fetch(_ context.Context, _ string, desc Descriptor) ([]byte, error) {
+	content, ok := r.content[desc.Digest]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return content, nil
+}
+
+func (r *fakeRegistry) push(mediaType string, content []byte) Descriptor {
+	digest := sha256.Sum256(content)
+	desc := Descriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(digest[:]), Size: int64(len(content))}
+	r.content[desc.Digest] = content
+	return desc
+}
+
+// attach pushes a manifest with the artifact as its layer, as a referrer of
+// the image.
+func (r *fakeRegistry) attach(t *testing.T, image, artifactType string, artifact []byte, annotations map[string]string) Descriptor {
+	layer := r.push(artifactType, artifact)
+	manifestJSON, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"artifactType":  artifactType,
+		"layers":        []Descriptor{layer},
+	})
+	require.NoError(t, err)
+	desc := r.push("application/vnd.oci.image.manifest.v1+json", manifestJSON)
+	desc.ArtifactType = artifactType
+	desc.Annotations = annotations
+	r.referrers[image] = append(r.referrers[image], desc)
+	return desc
+}
+
+// publicKeyTrustedMaterial returns trusted material that trusts the
+// keypair's public key.
+func publicKeyTrustedMaterial(t *testing.T, keypair sign.Keypair) root.TrustedMaterial {
+	t.Helper()
+	publicKeyPEM, err := keypair.GetPublicKeyPem()
+	require.NoError(t, err)
+	publicKey, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(publicKeyPEM))
+	require.NoError(t, err)
+	verifier, err := signature.LoadVerifier(publicKey, crypto.SHA256)
+	require.NoError(t, err)
+	return root.NewTrustedPublicKeyMaterialFromMapping(map[string]*root.ExpiringKey{
+		string(keypair.GetHint()): root.NewExpiringKey(verifier, time.Time{}, time.Time{}),
+	})
+}
+
+func TestVerifyAllReferrers(t *testing.T) {
+	keypair, err := sign.NewEphemeralKeypair(nil)
+	require.NoError(t, err)
+	trustedMaterial := publicKeyTrustedMaterial(t, keypair)
+
+	imageDigest := sha256.Sum256([]byte("image manifest"))
+	otherImageDigest := sha256.Sum256([]byte("other image manifest"))
+	image := "registry.example/app@sha256:" + hex.EncodeToString(imageDigest[:])
+
+	// attestation returns a bundle attesting to the image with the digest
+	attestation := func(predicateType string, digest [32]byte) []byte {
+		statement := fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v1","subject":[{"name":"registry.example/app","digest":{"sha256":"%x"}}],"predicateType":"%s","predicate":{}}`, digest, predicateType)
+		pb, err := sign.Bundle(&sign.DSSEData{Data: []byte(statement), PayloadType: "application/vnd.in-toto+json"}, keypair, sign.BundleOptions{})
+		require.NoError(t, err)
+		bundleJSON, err := protojson.Marshal(pb)
+		require.NoError(t, err)
+		return bundleJSON
+	}
+	const bundleType = "application/vnd.dev.sigstore.bundle.v0.3+json"
+	annotated := func(predicateType string) map[string]string {
+		return map[string]string{PredicateTypeAnnotation: predicateType}
+	}
+
+	registry := newFakeRegistry()
+	sbom := registry.attach(t, image, bundleType, attestation(spdxPredicateType, imageDigest), annotated(spdxPredicateType))
+	provenance := registry.attach(t, image, bundleType, attestation(slsaPredicateType, imageDigest), nil)
+	// referrers that aren't bundles are skipped
+	registry.attach(t, image, "application/spdx+json", []byte("{}"), nil)
+
+	referrersVerifier := NewReferrersVerifier(registry, verify.WithoutAnyObserverTimestampsInsecure())
+	results, err := referrersVerifier.VerifyAllReferrers(context.Background(), image, trustedMaterial, verify.WithoutIdentitiesUnsafe())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, want := range []struct {
+		descriptor    Descriptor
+		predicateType string
+	}{
+		{sbom, spdxPredicateType},
+		{provenance, slsaPredicateType},
+	} {
+		assert.NoError(t, results[i].Err)
+		assert.Equal(t, want.descriptor, results[i].Descriptor)
+		assert.Equal(t, want.predicateType, results[i].PredicateType)
+		assert.NotNil(t, results[i].Result)
+	}
+
+	t.Run("failures are reported per referrer", func(t *testing.T) {
+		registry := newFakeRegistry()
+		registry.attach(t, image, bundleType, attestation(spdxPredicateType, imageDigest), nil)
+		registry.attach(t, image, bundleType, attestation(slsaPredicateType, otherImageDigest), annotated(slsaPredicateType))
+		tampered := registry.attach(t, image, bundleType, attestation(slsaPredicateType, imageDigest), nil)
+		registry.content[tampered.Digest] = append(registry.content[tampered.Digest], ' ')
+
+		results, err := NewReferrersVerifier(registry, verify.WithoutAnyObserverTimestampsInsecure()).VerifyAllReferrers(context.Background(), image, trustedMaterial, verify.WithoutIdentitiesUnsafe())
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.NoError(t, results[0].Err)
+		// an attestation about another image
+		assert.Error(t, results[1].Err)
+		assert.Nil(t, results[1].Result)
+		assert.Equal(t, slsaPredicateType, results[1].PredicateType)
+		// content that doesn't match its digest
+		assert.ErrorContains(t, results[2].Err, "has digest")
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := referrersVerifier.VerifyAllReferrers(context.Background(), "registry.example/app:latest", trustedMaterial, verify.WithoutIdentitiesUnsafe())
+		assert.Error(t, err)
+
+		failing := newFakeRegistry()
+		failing.err = errors.New("unauthorized")
+		_, err = NewReferrersVerifier(failing, verify.WithoutAnyObserverTimestampsInsecure()).VerifyAllReferrers(context.Background(), image, trustedMaterial, verify.WithoutIdentitiesUnsafe())
+		assert.ErrorIs(t, err, failing.err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = referrersVerifier.VerifyAllReferrers(ctx, image, trustedMaterial, verify.WithoutIdentitiesUnsafe())
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
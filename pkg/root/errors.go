@@ -0,0 +1,40 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import "errors"
+
+// These errors are returned, wrapped, by lookups in and parsing of trusted
+// material, and by the verifiers that use it. They are part of the API: they
+// won't be removed or change meaning within a major version, so callers can
+// rely on errors.Is to detect them. The text of the wrapping errors is not
+// part of the API.
+var (
+	// ErrUnknownLog is returned when a transparency log isn't in the
+	// trusted material.
+	ErrUnknownLog = errors.New("transparency log not found in trusted material")
+	// ErrExpiredTrustMaterial is returned when the trusted material has the
+	// log or authority, but none of its keys were valid at the time needed.
+	ErrExpiredTrustMaterial = errors.New("trusted material not valid at the requested time")
+	// ErrInvalidTrustedRoot is returned when a trusted root can't be parsed.
+	ErrInvalidTrustedRoot = errors.New("invalid trusted root")
+	// ErrPEMEncodedCertificate is returned when a certificate's rawBytes
+	// hold PEM that isn't a single certificate. rawBytes must be DER.
+	ErrPEMEncodedCertificate = errors.New("certificate rawBytes must be DER encoded, not PEM")
+	// ErrLogIDMismatch is returned when a transparency log's ID isn't the
+	// SHA-256 digest of its public key, which means the trusted material is
+	// corrupt.
+	ErrLogIDMismatch = errors.New("transparency log ID does not match its public key")
+)
@@ -0,0 +1,29 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package root
+
+// Refresh replaces the trusted root as the periodic refresh from TUF does,
+// for the tests outside the package.
+func (l *LiveTrustedRoot) Refresh(tr *TrustedRoot) {
+	l.refresh(tr)
+}
+
+// RefreshJSON refreshes the trusted root with the JSON fetched from TUF, as
+// the periodic refresh does.
+func (l *LiveTrustedRoot) RefreshJSON(rootJSON []byte) error {
+	return l.refreshJSON(rootJSON)
+}
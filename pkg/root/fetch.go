@@ -0,0 +1,244 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package root
+
+import (
+	"crypto/x509"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/tuf"
+)
+
+// The functions that read trusted roots from the filesystem or fetch them with
+// TUF are left out of WebAssembly builds, so that verification there has no
+// filesystem or network access. Parse trusted roots with
+// NewTrustedRootFromJSON instead.
+
+func NewTrustedRootFromPath(path string, opts ...ParseOption) (*TrustedRoot, error) {
+	trustedrootJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTrustedRootFromJSON(trustedrootJSON, opts...)
+}
+
+// ValidateTrustedRootFiles parses and validates each of the trusted roots at
+// paths concurrently, e.g. to check the trusted roots changed by a pull
+// request in CI. The result maps every path to the error reading, parsing
+// or validating it with TrustedRoot.Validate, or to nil if it is valid.
+func ValidateTrustedRootFiles(paths []string, opts ...ParseOption) map[string]error {
+	results := make(map[string]error, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			err := validateTrustedRootFile(path, opts...)
+			mu.Lock()
+			defer mu.Unlock()
+			results[path] = err
+		}(path)
+	}
+	wg.Wait()
+	return results
+}
+
+func validateTrustedRootFile(path string, opts ...ParseOption) error {
+	tr, err := NewTrustedRootFromPath(path, opts...)
+	if err != nil {
+		return err
+	}
+	return tr.Validate()
+}
+
+// FetchTrustedRoot fetches the Sigstore trusted root from TUF and returns it.
+func FetchTrustedRoot() (*TrustedRoot, error) {
+	return FetchTrustedRootWithOptions(tuf.DefaultOptions())
+}
+
+// FetchTrustedRootWithOptions fetches the trusted root from TUF with the given options and returns it.
+func FetchTrustedRootWithOptions(opts *tuf.Options) (*TrustedRoot, error) {
+	client, err := tuf.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return GetTrustedRoot(client)
+}
+
+// GetTrustedRoot returns the trusted root
+func GetTrustedRoot(c *tuf.Client) (*TrustedRoot, error) {
+	jsonBytes, err := c.GetTarget("trusted_root.json")
+	if err != nil {
+		return nil, err
+	}
+	return NewTrustedRootFromJSON(jsonBytes)
+}
+
+// LiveTrustedRoot is a wrapper around TrustedRoot that periodically
+// refreshes the trusted root from TUF. This is needed for long-running
+// processes to ensure that the trusted root does not expire. When the trusted
+// root fetched is byte-for-byte the one it holds, it keeps that one rather
+// than parsing it again, so verifiers keep the state they derived from it.
+type LiveTrustedRoot struct {
+	*TrustedRoot
+	mu sync.RWMutex
+}
+
+// NewLiveTrustedRoot returns a LiveTrustedRoot that will periodically
+// refresh the trusted root from TUF.
+func NewLiveTrustedRoot(opts *tuf.Options) (*LiveTrustedRoot, error) {
+	client, err := tuf.New(opts)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := GetTrustedRoot(client)
+	if err != nil {
+		return nil, err
+	}
+	ltr := &LiveTrustedRoot{
+		TrustedRoot: tr,
+		mu:          sync.RWMutex{},
+	}
+	ticker := time.NewTicker(time.Hour * 24)
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				client, err = tuf.New(opts)
+				if err != nil {
+					log.Printf("error creating TUF client: %v", err)
+					continue
+				}
+				jsonBytes, err := client.GetTarget("trusted_root.json")
+				if err != nil {
+					log.Printf("error fetching trusted root: %v", err)
+					continue
+				}
+				if err := ltr.refreshJSON(jsonBytes); err != nil {
+					log.Printf("error parsing trusted root: %v", err)
+				}
+			}
+		}
+	}()
+	return ltr, nil
+}
+
+// refreshJSON replaces the trusted root with the one rootJSON holds, unless
+// rootJSON is what the current one was parsed from.
+func (l *LiveTrustedRoot) refreshJSON(rootJSON []byte) error {
+	if fingerprint(rootJSON) == l.Fingerprint() {
+		return nil
+	}
+	tr, err := NewTrustedRootFromJSON(rootJSON)
+	if err != nil {
+		return err
+	}
+	l.refresh(tr)
+	return nil
+}
+
+// refresh replaces the trusted root with tr, once no method is reading the
+// old one.
+func (l *LiveTrustedRoot) refresh(tr *TrustedRoot) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.TrustedRoot = tr
+}
+
+func (l *LiveTrustedRoot) TimestampingAuthorities() []CertificateAuthority {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.TimestampingAuthorities()
+}
+
+func (l *LiveTrustedRoot) FulcioCertificateAuthorities() []CertificateAuthority {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.FulcioCertificateAuthorities()
+}
+
+func (l *LiveTrustedRoot) RekorLogs() map[string]*TransparencyLog {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.RekorLogs()
+}
+
+func (l *LiveTrustedRoot) CTLogs() map[string]*TransparencyLog {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.CTLogs()
+}
+
+func (l *LiveTrustedRoot) Fingerprint() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.Fingerprint()
+}
+
+func (l *LiveTrustedRoot) MarshalJSON() ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.MarshalJSON()
+}
+
+func (l *LiveTrustedRoot) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.PublicKeyVerifier(keyID)
+}
+
+func (l *LiveTrustedRoot) TlogVerifierAt(logID []byte, t time.Time) (*TransparencyLog, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.TlogVerifierAt(logID, t)
+}
+
+func (l *LiveTrustedRoot) TlogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.TlogVerifiersAt(logID, t)
+}
+
+func (l *LiveTrustedRoot) CTLogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.CTLogVerifiersAt(logID, t)
+}
+
+func (l *LiveTrustedRoot) AllCertificates() []*x509.Certificate {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.AllCertificates()
+}
+
+func (l *LiveTrustedRoot) Validate() error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.Validate()
+}
+
+func (l *LiveTrustedRoot) ToProtobuf() (*prototrustroot.TrustedRoot, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.TrustedRoot.ToProtobuf()
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package root
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTrustedRootFiles(t *testing.T) {
+	dir := t.TempDir()
+	mismatchedLogID := filepath.Join(dir, "mismatched-log-id.json")
+	assert.NoError(t, os.WriteFile(mismatchedLogID, modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		ctlog := root["ctlogs"].([]interface{})[0].(map[string]interface{})
+		ctlog["logId"].(map[string]interface{})["keyId"] = "AAAA"
+	}), 0o600))
+	notJSON := filepath.Join(dir, "not-json.json")
+	assert.NoError(t, os.WriteFile(notJSON, []byte("not a trusted root"), 0o600))
+	missing := filepath.Join(dir, "missing.json")
+
+	results := ValidateTrustedRootFiles([]string{
+		"../../examples/trusted-root-public-good.json",
+		"../testing/data/trusted-root-staging.json",
+		mismatchedLogID,
+		notJSON,
+		missing,
+	})
+	assert.Len(t, results, 5)
+	assert.NoError(t, results["../../examples/trusted-root-public-good.json"])
+	assert.NoError(t, results["../testing/data/trusted-root-staging.json"])
+	assert.ErrorIs(t, results[mismatchedLogID], ErrLogIDMismatch)
+	assert.Error(t, results[notJSON])
+	assert.ErrorIs(t, results[missing], os.ErrNotExist)
+
+	// strict parsing fails on the mismatched log ID before validation
+	results = ValidateTrustedRootFiles([]string{mismatchedLogID}, WithStrictLogIDs())
+	assert.ErrorIs(t, results[mismatchedLogID], ErrLogIDMismatch)
+
+	assert.Empty(t, ValidateTrustedRootFiles(nil))
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package root_test
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// virtualTrustedRoot returns a trusted root with the Fulcio CAs and CT logs
+// of the virtual Sigstore.
+func virtualTrustedRoot(t *testing.T, virtualSigstore *ca.VirtualSigstore) *root.TrustedRoot {
+	t.Helper()
+	pbTrustedRoot := &prototrustroot.TrustedRoot{MediaType: root.TrustedRootMediaType01}
+	for _, fulcioCA := range virtualSigstore.FulcioCertificateAuthorities() {
+		pbTrustedRoot.CertificateAuthorities = append(pbTrustedRoot.CertificateAuthorities, fulcioCA.ToProtobuf())
+	}
+	for _, ctLog := range virtualSigstore.CTLogs() {
+		pbCTLog, err := ctLog.ToProtobuf()
+		require.NoError(t, err)
+		pbTrustedRoot.Ctlogs = append(pbTrustedRoot.Ctlogs, pbCTLog)
+	}
+	trustedRoot, err := root.NewTrustedRootFromProtobuf(pbTrustedRoot)
+	require.NoError(t, err)
+	return trustedRoot
+}
+
+// TestLiveTrustedRootRefresh verifies with a live trusted root while it is
+// refreshed, which the race detector checks is safe.
+func TestLiveTrustedRootRefresh(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	require.NoError(t, err)
+
+	trustedRoots := []*root.TrustedRoot{
+		virtualTrustedRoot(t, virtualSigstore),
+		virtualTrustedRoot(t, virtualSigstore),
+	}
+	liveTrustedRoot := &root.LiveTrustedRoot{TrustedRoot: trustedRoots[0]}
+
+	const refreshes = 100
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < refreshes; i++ {
+			liveTrustedRoot.Refresh(trustedRoots[i%len(trustedRoots)])
+		}
+	}()
+
+	for i := 0; i < refreshes; i++ {
+		assert.NoError(t, verify.VerifySignedCertificateTimestamp(leaf, 1, liveTrustedRoot))
+		assert.NotEmpty(t, liveTrustedRoot.AllCertificates())
+		assert.NoError(t, liveTrustedRoot.Validate())
+		_, err := liveTrustedRoot.ToProtobuf()
+		assert.NoError(t, err)
+	}
+	wg.Wait()
+}
+
+// TestLiveTrustedRootRefreshUnchanged checks that a live trusted root keeps
+// the trusted root it holds when refreshed with the same bytes, and that it
+// takes a changed one, sharing nothing with the old one.
+func TestLiveTrustedRootRefreshUnchanged(t *testing.T) {
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	require.NoError(t, err)
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	require.NoError(t, err)
+
+	publicGood, err := root.NewTrustedRootFromJSON(publicGoodJSON)
+	require.NoError(t, err)
+	liveTrustedRoot := &root.LiveTrustedRoot{TrustedRoot: publicGood}
+
+	// refreshing with the same bytes reuses the parsed trusted root
+	require.NoError(t, liveTrustedRoot.RefreshJSON(append([]byte(nil), publicGoodJSON...)))
+	assert.Same(t, publicGood, liveTrustedRoot.TrustedRoot)
+
+	// a changed trusted root is parsed, and nothing is shared with the
+	// previous one
+	require.NoError(t, liveTrustedRoot.RefreshJSON(stagingJSON))
+	staging := liveTrustedRoot.TrustedRoot
+	assert.NotSame(t, publicGood, staging)
+	assert.True(t, root.IsStagingTrustedRoot(staging))
+	for _, stagingCert := range staging.AllCertificates() {
+		for _, publicGoodCert := range publicGood.AllCertificates() {
+			assert.NotSame(t, publicGoodCert, stagingCert)
+		}
+	}
+
+	// an invalid trusted root leaves the current one in place
+	assert.Error(t, liveTrustedRoot.RefreshJSON([]byte("{")))
+	assert.Same(t, staging, liveTrustedRoot.TrustedRoot)
+
+	// the reuse is the live trusted root's own, so other callers still get
+	// a trusted root of their own
+	parsed, err := root.NewTrustedRootFromJSON(stagingJSON)
+	require.NoError(t, err)
+	assert.NotSame(t, staging, parsed)
+}
+
+func BenchmarkLiveTrustedRootRefresh(b *testing.B) {
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	publicGood, err := root.NewTrustedRootFromJSON(publicGoodJSON)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// refreshing with an unchanged trusted root only hashes it
+	b.Run("unchanged", func(b *testing.B) {
+		liveTrustedRoot := &root.LiveTrustedRoot{TrustedRoot: publicGood}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := liveTrustedRoot.RefreshJSON(publicGoodJSON); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// alternating between trusted roots parses every one
+	b.Run("changed", func(b *testing.B) {
+		liveTrustedRoot := &root.LiveTrustedRoot{TrustedRoot: publicGood}
+		b.ReportAllocs()
+		roots := [][]byte{stagingJSON, publicGoodJSON}
+		for i := 0; i < b.N; i++ {
+			if err := liveTrustedRoot.RefreshJSON(roots[i%2]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -15,6 +15,8 @@
 package root
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
@@ -56,6 +58,8 @@ type TrustedMaterialCollection []TrustedMaterial
 // Ensure types implement interfaces
 var _ TrustedMaterial = &BaseTrustedMaterial{}
 var _ TrustedMaterial = TrustedMaterialCollection{}
+var _ TlogKeyVersions = TrustedMaterialCollection{}
+var _ CTLogKeyVersions = TrustedMaterialCollection{}
 
 func (tmc TrustedMaterialCollection) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
 	for _, tm := range tmc {
@@ -103,6 +107,85 @@ func (tmc TrustedMaterialCollection) CTLogs() map[string]*TransparencyLog {
 	return rekorLogs
 }
 
+// TlogVerifiersAt returns the versions of the Rekor log's key valid at time t
+// across every member of the collection. Members that do not list key
+// versions contribute their RekorLogs() entry if it is valid at t.
+func (tmc TrustedMaterialCollection) TlogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	return tmc.logVersionsAt("rekor", logID, t, func(tm TrustedMaterial) (logVersionsFunc, map[string]*TransparencyLog) {
+		if keyVersions, ok := tm.(TlogKeyVersions); ok {
+			return keyVersions.TlogVerifiersAt, nil
+		}
+		return nil, tm.RekorLogs()
+	})
+}
+
+// CTLogVerifiersAt returns the CT logs listed under the log ID whose
+// validity period includes time t, across every member of the collection.
+// Members that do not list versions contribute their CTLogs() entry if it is
+// valid at t.
+func (tmc TrustedMaterialCollection) CTLogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	return tmc.logVersionsAt("CT", logID, t, func(tm TrustedMaterial) (logVersionsFunc, map[string]*TransparencyLog) {
+		if keyVersions, ok := tm.(CTLogKeyVersions); ok {
+			return keyVersions.CTLogVerifiersAt, nil
+		}
+		return nil, tm.CTLogs()
+	})
+}
+
+type logVersionsFunc func(logID []byte, t time.Time) ([]*TransparencyLog, error)
+
+// logVersionsAt gathers the versions of a log valid at time t from each
+// member, which either lists its versions or only has one log per ID.
+func (tmc TrustedMaterialCollection) logVersionsAt(kind string, logID []byte, t time.Time, logsOf func(TrustedMaterial) (logVersionsFunc, map[string]*TransparencyLog)) ([]*TransparencyLog, error) {
+	var versions []*TransparencyLog
+	var known bool
+	for _, tm := range tmc {
+		versionsAt, logs := logsOf(tm)
+		if versionsAt != nil {
+			tmVersions, err := versionsAt(logID, t)
+			if err == nil {
+				versions = append(versions, tmVersions...)
+			}
+			known = known || err == nil || errors.Is(err, ErrExpiredTrustMaterial)
+			continue
+		}
+		if tlog, ok := logs[hex.EncodeToString(logID)]; ok {
+			known = true
+			if tlog.ValidAtTime(t) {
+				versions = append(versions, tlog)
+			}
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("%w: %s log %x", ErrUnknownLog, kind, logID)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: no key for %s log %x valid at %s", ErrExpiredTrustMaterial, kind, logID, t.Format(time.RFC3339))
+	}
+	return versions, nil
+}
+
+// TlogKeyVersions is implemented by trusted material that can list every
+// version of a Rekor log's key, such as a TrustedRoot that lists the old and
+// new keys of a rotated log under the same log ID.
+type TlogKeyVersions interface {
+	TlogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error)
+}
+
+// CTLogKeyVersions is implemented by trusted material that can list every
+// certificate transparency log under a log ID with its validity period, such
+// as a TrustedRoot whose CT logs are sharded by time.
+type CTLogKeyVersions interface {
+	CTLogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error)
+}
+
+// Fingerprinted is implemented by trusted material that can identify its
+// content, such as a TrustedRoot. Trusted material with the same fingerprint
+// holds the same trust material, so state derived from it can be reused.
+type Fingerprinted interface {
+	Fingerprint() string
+}
+
 type ValidityPeriodChecker interface {
 	ValidAtTime(time.Time) bool
 }
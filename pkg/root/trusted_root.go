@@ -15,31 +15,65 @@
 package root
 
 import (
+	"bytes"
+	"container/list"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
-	"github.com/sigstore/sigstore-go/pkg/tuf"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const TrustedRootMediaType01 = "application/vnd.dev.sigstore.trustedroot+json;version=0.1"
 
+// SupportedMediaTypes returns the media types of the trusted roots this
+// library parses.
+func SupportedMediaTypes() []string {
+	return []string{TrustedRootMediaType01}
+}
+
+func isSupportedMediaType(mediaType string) bool {
+	for _, supported := range SupportedMediaTypes() {
+		if mediaType == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// SigstoreStagingDomain is the domain the services of the Sigstore staging
+// instance are hosted under, e.g. https://rekor.sigstage.dev.
+const SigstoreStagingDomain = "sigstage.dev"
+
 type TrustedRoot struct {
 	BaseTrustedMaterial
 	trustedRoot             *prototrustroot.TrustedRoot
 	rekorLogs               map[string]*TransparencyLog
+	rekorLogVersions        map[string][]*TransparencyLog
 	fulcioCertAuthorities   []CertificateAuthority
 	ctLogs                  map[string]*TransparencyLog
+	ctLogVersions           map[string][]*TransparencyLog
 	timestampingAuthorities []CertificateAuthority
+	// fingerprint identifies the bytes the trusted root was parsed from
+	fingerprint string
 }
 
 type CertificateAuthority struct {
@@ -48,6 +82,8 @@ type CertificateAuthority struct {
 	Leaf                *x509.Certificate
 	ValidityPeriodStart time.Time
 	ValidityPeriodEnd   time.Time
+	// URI is the base URL of the CA's service, if the trusted root gives it
+	URI string
 }
 
 type TransparencyLog struct {
@@ -58,10 +94,19 @@ type TransparencyLog struct {
 	// This is the hash algorithm used by the Merkle tree
 	HashFunc  crypto.Hash
 	PublicKey crypto.PublicKey
-	// The hash algorithm used during signature creation
+	// The hash algorithm used during signature creation. It is zero for
+	// Ed25519 keys, which hash the message themselves, and for other keys
+	// zero means SHA-256.
 	SignatureHashFunc crypto.Hash
+	// RSAPSS is set for an RSA key that signs with RSASSA-PSS rather than
+	// PKCS #1 v1.5.
+	RSAPSS bool
 }
 
+var _ TlogKeyVersions = &TrustedRoot{}
+var _ CTLogKeyVersions = &TrustedRoot{}
+var _ Fingerprinted = &TrustedRoot{}
+
 func (tr *TrustedRoot) TimestampingAuthorities() []CertificateAuthority {
 	return tr.timestampingAuthorities
 }
@@ -78,113 +123,551 @@ func (tr *TrustedRoot) CTLogs() map[string]*TransparencyLog {
 	return tr.ctLogs
 }
 
-func NewTrustedRootFromProtobuf(protobufTrustedRoot *prototrustroot.TrustedRoot) (trustedRoot *TrustedRoot, err error) {
-	if protobufTrustedRoot.GetMediaType() != TrustedRootMediaType01 {
-		return nil, fmt.Errorf("unsupported TrustedRoot media type: %s", protobufTrustedRoot.GetMediaType())
+// Fingerprint returns the hex-encoded SHA-256 digest of the JSON the trusted
+// root was parsed from or, for a trusted root built from a protobuf, of its
+// deterministic binary encoding. Two trusted roots with the same fingerprint
+// hold the same trust material.
+func (tr *TrustedRoot) Fingerprint() string {
+	return tr.fingerprint
+}
+
+// AllCertificates returns every certificate of the trusted root's Fulcio
+// and timestamping certificate authorities, such as to audit their validity
+// periods, keys and signature algorithms. Certificates are listed Fulcio
+// authorities first, each authority's root, intermediates and leaf in turn.
+// A certificate shared by several authorities is listed once.
+func (tr *TrustedRoot) AllCertificates() []*x509.Certificate {
+	var certs []*x509.Certificate
+	seen := make(map[string]bool)
+	add := func(cert *x509.Certificate) {
+		if cert == nil || seen[string(cert.Raw)] {
+			return
+		}
+		seen[string(cert.Raw)] = true
+		certs = append(certs, cert)
 	}
 
-	trustedRoot = &TrustedRoot{trustedRoot: protobufTrustedRoot}
-	trustedRoot.rekorLogs, err = ParseTransparencyLogs(protobufTrustedRoot.GetTlogs())
+	for _, authorities := range [][]CertificateAuthority{tr.fulcioCertAuthorities, tr.timestampingAuthorities} {
+		for _, ca := range authorities {
+			add(ca.Root)
+			for _, intermediate := range ca.Intermediates {
+				add(intermediate)
+			}
+			add(ca.Leaf)
+		}
+	}
+	return certs
+}
+
+// TlogVerifierAt returns the version of the Rekor log's key that was valid at
+// time t. A trusted root may list several key versions under the same log ID
+// as the log's key is rotated; the first listed version valid at t is
+// returned.
+func (tr *TrustedRoot) TlogVerifierAt(logID []byte, t time.Time) (*TransparencyLog, error) {
+	versions, err := tr.TlogVerifiersAt(logID, t)
 	if err != nil {
 		return nil, err
 	}
+	return versions[0], nil
+}
 
-	trustedRoot.fulcioCertAuthorities, err = ParseCertificateAuthorities(protobufTrustedRoot.GetCertificateAuthorities())
+// TlogVerifiersAt returns every version of the Rekor log's key that was valid
+// at time t, in the order they are listed in the trusted root. While a log's
+// key is being rotated the validity periods of the old and new versions may
+// overlap, and an entry integrated in that window may be signed by either.
+func (tr *TrustedRoot) TlogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	return logVersionsAt(tr.rekorLogVersions, "rekor", logID, t)
+}
+
+// CTLogVerifiersAt returns every certificate transparency log listed under
+// the log ID whose validity period includes time t, in the order they are
+// listed in the trusted root. CT logs are sharded by time, so the log that
+// issued an SCT is the one whose validity period includes its timestamp.
+func (tr *TrustedRoot) CTLogVerifiersAt(logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	return logVersionsAt(tr.ctLogVersions, "CT", logID, t)
+}
+
+// logVersionsAt returns the versions of the log valid at time t.
+func logVersionsAt(logVersions map[string][]*TransparencyLog, kind string, logID []byte, t time.Time) ([]*TransparencyLog, error) {
+	versions, ok := logVersions[hex.EncodeToString(logID)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s log %x", ErrUnknownLog, kind, logID)
+	}
+	var valid []*TransparencyLog
+	for _, tlog := range versions {
+		if tlog.ValidAtTime(t) {
+			valid = append(valid, tlog)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, fmt.Errorf("%w: no key for %s log %x valid at %s", ErrExpiredTrustMaterial, kind, logID, t.Format(time.RFC3339))
+	}
+	return valid, nil
+}
+
+// IsStagingTrustedRoot reports whether tr is the trusted root of the Sigstore
+// staging instance, i.e. whether every one of its Rekor logs is hosted under
+// SigstoreStagingDomain.
+//
+// The staging trusted root has the same schema and media type as the public
+// good instance's, and parses the same way. It differs in its service URLs and
+// key material, and its first certificate transparency log has an RSA key in
+// the deprecated PKCS1_RSA_PKCS1V5 format.
+func IsStagingTrustedRoot(tr *TrustedRoot) bool {
+	if tr == nil || len(tr.rekorLogVersions) == 0 {
+		return false
+	}
+	for _, versions := range tr.rekorLogVersions {
+		for _, tlog := range versions {
+			baseURL, err := url.Parse(tlog.BaseURL)
+			if err != nil {
+				return false
+			}
+			host := baseURL.Hostname()
+			if host != SigstoreStagingDomain && !strings.HasSuffix(host, "."+SigstoreStagingDomain) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ParseOption configures how a trusted root is parsed.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	strictLogIDs bool
+}
+
+// WithStrictLogIDs checks that the ID of every transparency log in the
+// trusted root matches its public key, with TransparencyLog.VerifyLogID, and
+// rejects the trusted root if not.
+func WithStrictLogIDs() ParseOption {
+	return func(o *parseOptions) {
+		o.strictLogIDs = true
+	}
+}
+
+func newParseOptions(opts []ParseOption) *parseOptions {
+	o := &parseOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Validate checks the trusted root for problems that parsing accepts but
+// that make it unusable or unsafe to verify against:
+//   - it has no transparency logs or certificate authorities
+//   - a transparency log's ID isn't the digest of its public key
+//   - a validity period ends before it starts
+//   - a certificate authority's chain has a certificate that none of the
+//     others issued, other than a self-signed root
+//
+// Every problem found is returned, joined, and wraps ErrInvalidTrustedRoot.
+func (tr *TrustedRoot) Validate() error {
+	var errs []error
+	if len(tr.rekorLogVersions) == 0 && len(tr.ctLogs) == 0 && len(tr.fulcioCertAuthorities) == 0 && len(tr.timestampingAuthorities) == 0 {
+		errs = append(errs, fmt.Errorf("%w: no transparency logs or certificate authorities", ErrInvalidTrustedRoot))
+	}
+
+	for _, keyID := range sortedKeys(tr.rekorLogVersions) {
+		for _, tlog := range tr.rekorLogVersions[keyID] {
+			if err := validateTransparencyLog(tlog); err != nil {
+				errs = append(errs, fmt.Errorf("%w: rekor log %s: %w", ErrInvalidTrustedRoot, keyID, err))
+			}
+		}
+	}
+	for _, keyID := range sortedKeys(tr.ctLogs) {
+		if err := validateTransparencyLog(tr.ctLogs[keyID]); err != nil {
+			errs = append(errs, fmt.Errorf("%w: ct log %s: %w", ErrInvalidTrustedRoot, keyID, err))
+		}
+	}
+
+	for i, ca := range tr.fulcioCertAuthorities {
+		if err := validateCertificateAuthority(&ca); err != nil {
+			errs = append(errs, fmt.Errorf("%w: certificate authority %d: %w", ErrInvalidTrustedRoot, i, err))
+		}
+	}
+	for i, ca := range tr.timestampingAuthorities {
+		if err := validateCertificateAuthority(&ca); err != nil {
+			errs = append(errs, fmt.Errorf("%w: timestamp authority %d: %w", ErrInvalidTrustedRoot, i, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateTransparencyLog(tlog *TransparencyLog) error {
+	if err := tlog.VerifyLogID(); err != nil {
+		return err
+	}
+	return validatePeriod(tlog.ValidityPeriodStart, tlog.ValidityPeriodEnd)
+}
+
+func validateCertificateAuthority(ca *CertificateAuthority) error {
+	if err := validatePeriod(ca.ValidityPeriodStart, ca.ValidityPeriodEnd); err != nil {
+		return err
+	}
+	chain := append([]*x509.Certificate{ca.Root}, ca.Intermediates...)
+	if ca.Leaf != nil {
+		chain = append(chain, ca.Leaf)
+	}
+	for _, cert := range chain {
+		if cert == ca.Root && isSelfSigned(cert) {
+			continue
+		}
+		if !issuedWithin(cert, chain) {
+			return fmt.Errorf("certificate %q was not issued by another certificate in the chain", cert.Subject.String())
+		}
+	}
+	return nil
+}
+
+func validatePeriod(start, end time.Time) error {
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return fmt.Errorf("validity period ends at %s, before it starts at %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// check runs the checks the options ask for on a parsed trusted root. It
+// runs on memoized trusted roots too, which may have been parsed with other
+// options.
+func (o *parseOptions) check(tr *TrustedRoot) error {
+	if !o.strictLogIDs {
+		return nil
+	}
+	for _, versions := range tr.rekorLogVersions {
+		for _, tlog := range versions {
+			if err := tlog.VerifyLogID(); err != nil {
+				return fmt.Errorf("%w: rekor log: %w", ErrInvalidTrustedRoot, err)
+			}
+		}
+	}
+	for _, ctlog := range tr.ctLogs {
+		if err := ctlog.VerifyLogID(); err != nil {
+			return fmt.Errorf("%w: ct log: %w", ErrInvalidTrustedRoot, err)
+		}
+	}
+	return nil
+}
+
+// parsePKIXLogKey parses a log's PKIX public key, checking that it is the
+// type of key its details declare, and returns the hash function the log
+// signs with.
+func parsePKIXLogKey(details protocommon.PublicKeyDetails, rawBytes []byte) (crypto.PublicKey, crypto.Hash, error) {
+	var curve elliptic.Curve
+	var rsaBits int
+	var hashFunc crypto.Hash
+	switch details {
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256:
+		curve, hashFunc = elliptic.P256(), crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384:
+		curve, hashFunc = elliptic.P384(), crypto.SHA384
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512:
+		curve, hashFunc = elliptic.P521(), crypto.SHA512
+	case protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256:
+		rsaBits, hashFunc = 2048, crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256:
+		rsaBits, hashFunc = 3072, crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256:
+		rsaBits, hashFunc = 4096, crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256:
+		rsaBits, hashFunc = 2048, crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256:
+		rsaBits, hashFunc = 3072, crypto.SHA256
+	case protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256:
+		rsaBits, hashFunc = 4096, crypto.SHA256
+	default:
+		return nil, 0, fmt.Errorf("unsupported public key type: %s", details)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(rawBytes)
 	if err != nil {
+		return nil, 0, err
+	}
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if key.Curve != curve {
+			return nil, 0, fmt.Errorf("tlog public key is ECDSA %s, not %s", key.Curve.Params().Name, details)
+		}
+	case *rsa.PublicKey:
+		if key.N.BitLen() != rsaBits {
+			return nil, 0, fmt.Errorf("tlog public key is %d-bit RSA, not %s", key.N.BitLen(), details)
+		}
+	default:
+		return nil, 0, fmt.Errorf("tlog public key is %T, not %s", key, details)
+	}
+	return key, hashFunc, nil
+}
+
+// isRSAPSS reports whether the key details are for an RSA key that signs
+// with RSASSA-PSS.
+func isRSAPSS(details protocommon.PublicKeyDetails) bool {
+	switch details {
+	case protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256:
+		return true
+	default:
+		return false
+	}
+}
+
+func NewTrustedRootFromProtobuf(protobufTrustedRoot *prototrustroot.TrustedRoot, opts ...ParseOption) (trustedRoot *TrustedRoot, err error) {
+	pbBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(protobufTrustedRoot)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTrustedRoot, err)
+	}
+	tr, err := newTrustedRootFromProtobuf(protobufTrustedRoot, fingerprint(pbBytes))
+	if err != nil {
+		return nil, err
+	}
+	if err := newParseOptions(opts).check(tr); err != nil {
 		return nil, err
 	}
+	return tr, nil
+}
+
+func newTrustedRootFromProtobuf(protobufTrustedRoot *prototrustroot.TrustedRoot, fp string) (trustedRoot *TrustedRoot, err error) {
+	if !isSupportedMediaType(protobufTrustedRoot.GetMediaType()) {
+		return nil, fmt.Errorf("%w: unsupported media type: %s", ErrInvalidTrustedRoot, protobufTrustedRoot.GetMediaType())
+	}
+
+	trustedRoot = &TrustedRoot{trustedRoot: protobufTrustedRoot, fingerprint: fp}
+	trustedRoot.rekorLogs, err = ParseTransparencyLogs(protobufTrustedRoot.GetTlogs())
+	if err != nil {
+		return nil, fmt.Errorf("%w: rekor log: %w", ErrInvalidTrustedRoot, err)
+	}
+
+	trustedRoot.rekorLogVersions, err = parseTransparencyLogVersions(protobufTrustedRoot.GetTlogs())
+	if err != nil {
+		return nil, fmt.Errorf("%w: rekor log: %w", ErrInvalidTrustedRoot, err)
+	}
+
+	trustedRoot.fulcioCertAuthorities, err = ParseCertificateAuthorities(protobufTrustedRoot.GetCertificateAuthorities())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTrustedRoot, err)
+	}
 
 	trustedRoot.timestampingAuthorities, err = ParseCertificateAuthorities(protobufTrustedRoot.GetTimestampAuthorities())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTrustedRoot, err)
 	}
 
 	trustedRoot.ctLogs, err = ParseTransparencyLogs(protobufTrustedRoot.GetCtlogs())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: CT log: %w", ErrInvalidTrustedRoot, err)
+	}
+
+	trustedRoot.ctLogVersions, err = parseTransparencyLogVersions(protobufTrustedRoot.GetCtlogs())
+	if err != nil {
+		return nil, fmt.Errorf("%w: CT log: %w", ErrInvalidTrustedRoot, err)
 	}
 
 	return trustedRoot, nil
 }
 
+// ParseTransparencyLogs parses the transparency logs of a trusted root,
+// keyed by their hex-encoded log ID. When a log lists several key versions
+// under the same ID, the one listed last is kept; TlogVerifierAt picks
+// between them by time instead.
 func ParseTransparencyLogs(tlogs []*prototrustroot.TransparencyLogInstance) (transparencyLogs map[string]*TransparencyLog, err error) {
 	transparencyLogs = make(map[string]*TransparencyLog)
 	for _, tlog := range tlogs {
-		if tlog.GetHashAlgorithm() != protocommon.HashAlgorithm_SHA2_256 {
-			return nil, fmt.Errorf("unsupported tlog hash algorithm: %s", tlog.GetHashAlgorithm())
-		}
-		if tlog.GetLogId() == nil {
-			return nil, fmt.Errorf("tlog missing log ID")
+		transparencyLog, err := ParseTransparencyLog(tlog)
+		if err != nil {
+			return nil, err
 		}
-		if tlog.GetLogId().GetKeyId() == nil {
-			return nil, fmt.Errorf("tlog missing log ID key ID")
+		transparencyLogs[hex.EncodeToString(transparencyLog.ID)] = transparencyLog
+	}
+	return transparencyLogs, nil
+}
+
+// parseTransparencyLogVersions returns every key version listed for each log
+// ID, in the order they appear in the trusted root.
+func parseTransparencyLogVersions(tlogs []*prototrustroot.TransparencyLogInstance) (map[string][]*TransparencyLog, error) {
+	versions := make(map[string][]*TransparencyLog)
+	for _, tlog := range tlogs {
+		transparencyLog, err := ParseTransparencyLog(tlog)
+		if err != nil {
+			return nil, err
 		}
-		encodedKeyID := hex.EncodeToString(tlog.GetLogId().GetKeyId())
+		encodedKeyID := hex.EncodeToString(transparencyLog.ID)
+		versions[encodedKeyID] = append(versions[encodedKeyID], transparencyLog)
+	}
+	return versions, nil
+}
+
+// ParseTransparencyLog parses a single transparency log instance of a
+// trusted root, returning an error if it is missing its log ID or public
+// key, or uses a hash function or key type that isn't supported.
+func ParseTransparencyLog(tlog *prototrustroot.TransparencyLogInstance) (*TransparencyLog, error) {
+	if tlog.GetLogId() == nil {
+		return nil, fmt.Errorf("tlog missing log ID")
+	}
+	if tlog.GetLogId().GetKeyId() == nil {
+		return nil, fmt.Errorf("tlog missing log ID key ID")
+	}
+
+	if tlog.GetPublicKey() == nil {
+		return nil, fmt.Errorf("tlog missing public key")
+	}
+	if tlog.GetPublicKey().GetRawBytes() == nil {
+		return nil, fmt.Errorf("tlog missing public key raw bytes")
+	}
 
-		if tlog.GetPublicKey() == nil {
-			return nil, fmt.Errorf("tlog missing public key")
+	var hashFunc crypto.Hash
+	switch tlog.GetHashAlgorithm() {
+	case protocommon.HashAlgorithm_SHA2_256:
+		hashFunc = crypto.SHA256
+	case protocommon.HashAlgorithm_SHA2_384:
+		hashFunc = crypto.SHA384
+	case protocommon.HashAlgorithm_SHA2_512:
+		hashFunc = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("%s: unsupported hash function for the tlog: %s", tlog.GetBaseUrl(), tlog.GetHashAlgorithm())
+	}
+
+	var transparencyLog *TransparencyLog
+	switch tlog.GetPublicKey().GetKeyDetails() {
+	case protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+		protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384,
+		protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512,
+		protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256,
+		protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256:
+		key, signatureHashFunc, err := parsePKIXLogKey(tlog.GetPublicKey().GetKeyDetails(), tlog.GetPublicKey().GetRawBytes())
+		if err != nil {
+			return nil, err
 		}
-		if tlog.GetPublicKey().GetRawBytes() == nil {
-			return nil, fmt.Errorf("tlog missing public key raw bytes")
+		transparencyLog = &TransparencyLog{
+			BaseURL:           tlog.GetBaseUrl(),
+			ID:                tlog.GetLogId().GetKeyId(),
+			HashFunc:          hashFunc,
+			PublicKey:         key,
+			SignatureHashFunc: signatureHashFunc,
+			RSAPSS:            isRSAPSS(tlog.GetPublicKey().GetKeyDetails()),
 		}
-
-		var hashFunc crypto.Hash
-		switch tlog.GetHashAlgorithm() {
-		case protocommon.HashAlgorithm_SHA2_256:
-			hashFunc = crypto.SHA256
-		default:
-			return nil, fmt.Errorf("unsupported hash function for the tlog")
+	case protocommon.PublicKeyDetails_PKIX_ED25519:
+		key, err := x509.ParsePKIXPublicKey(tlog.GetPublicKey().GetRawBytes())
+		if err != nil {
+			return nil, err
 		}
-
-		switch tlog.GetPublicKey().GetKeyDetails() {
-		case protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256:
-			key, err := x509.ParsePKIXPublicKey(tlog.GetPublicKey().GetRawBytes())
-			if err != nil {
-				return nil, err
-			}
-			var ecKey *ecdsa.PublicKey
-			var ok bool
-			if ecKey, ok = key.(*ecdsa.PublicKey); !ok {
-				return nil, fmt.Errorf("tlog public key is not ECDSA P256")
-			}
-			transparencyLogs[encodedKeyID] = &TransparencyLog{
-				BaseURL:           tlog.GetBaseUrl(),
-				ID:                tlog.GetLogId().GetKeyId(),
-				HashFunc:          hashFunc,
-				PublicKey:         ecKey,
-				SignatureHashFunc: crypto.SHA256,
-			}
-		// This key format is deprecated, but currently in use for Sigstore staging instance
-		case protocommon.PublicKeyDetails_PKCS1_RSA_PKCS1V5: //nolint:staticcheck
-			key, err := x509.ParsePKCS1PublicKey(tlog.GetPublicKey().GetRawBytes())
-			if err != nil {
-				return nil, err
-			}
-			transparencyLogs[encodedKeyID] = &TransparencyLog{
-				BaseURL:           tlog.GetBaseUrl(),
-				ID:                tlog.GetLogId().GetKeyId(),
-				HashFunc:          hashFunc,
-				PublicKey:         key,
-				SignatureHashFunc: crypto.SHA256,
-			}
-		default:
-			return nil, fmt.Errorf("unsupported tlog public key type: %s", tlog.GetPublicKey().GetKeyDetails())
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("tlog public key is not Ed25519")
+		}
+		// Ed25519 signs the whole message rather than a digest of it, so
+		// the signature hash function is left as crypto.Hash(0)
+		transparencyLog = &TransparencyLog{
+			BaseURL:   tlog.GetBaseUrl(),
+			ID:        tlog.GetLogId().GetKeyId(),
+			HashFunc:  hashFunc,
+			PublicKey: edKey,
+		}
+	// This key format is deprecated, but currently in use for Sigstore staging instance
+	case protocommon.PublicKeyDetails_PKCS1_RSA_PKCS1V5: //nolint:staticcheck
+		key, err := x509.ParsePKCS1PublicKey(tlog.GetPublicKey().GetRawBytes())
+		if err != nil {
+			return nil, err
+		}
+		transparencyLog = &TransparencyLog{
+			BaseURL:           tlog.GetBaseUrl(),
+			ID:                tlog.GetLogId().GetKeyId(),
+			HashFunc:          hashFunc,
+			PublicKey:         key,
+			SignatureHashFunc: crypto.SHA256,
 		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported public key type: %s", tlog.GetBaseUrl(), tlog.GetPublicKey().GetKeyDetails())
+	}
 
-		if validFor := tlog.GetPublicKey().GetValidFor(); validFor != nil {
-			if validFor.GetStart() != nil {
-				transparencyLogs[encodedKeyID].ValidityPeriodStart = validFor.GetStart().AsTime()
-			} else {
-				return nil, fmt.Errorf("tlog missing public key validity period start time")
-			}
-			if validFor.GetEnd() != nil {
-				transparencyLogs[encodedKeyID].ValidityPeriodEnd = validFor.GetEnd().AsTime()
-			}
+	if validFor := tlog.GetPublicKey().GetValidFor(); validFor != nil {
+		if validFor.GetStart() != nil {
+			transparencyLog.ValidityPeriodStart = validFor.GetStart().AsTime()
 		} else {
-			return nil, fmt.Errorf("tlog missing public key validity period")
+			return nil, fmt.Errorf("tlog missing public key validity period start time")
+		}
+		if validFor.GetEnd() != nil {
+			transparencyLog.ValidityPeriodEnd = validFor.GetEnd().AsTime()
 		}
+	} else {
+		return nil, fmt.Errorf("tlog missing public key validity period")
 	}
-	return transparencyLogs, nil
+
+	return transparencyLog, nil
+}
+
+// VerifyLogID checks that the log's ID is the SHA-256 digest of the DER
+// SubjectPublicKeyInfo of its public key, as both Rekor and certificate
+// transparency logs derive it. A mismatch means the trusted material is
+// corrupt.
+func (tl *TransparencyLog) VerifyLogID() error {
+	der, err := x509.MarshalPKIXPublicKey(tl.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLogIDMismatch, err)
+	}
+	digest := sha256.Sum256(der)
+	if !bytes.Equal(digest[:], tl.ID) {
+		return fmt.Errorf("%w: log ID %x, public key digest %x", ErrLogIDMismatch, tl.ID, digest)
+	}
+	return nil
+}
+
+// ValidAtTime reports whether the log's key was valid at the given time.
+func (tl *TransparencyLog) ValidAtTime(t time.Time) bool {
+	if !tl.ValidityPeriodStart.IsZero() && t.Before(tl.ValidityPeriodStart) {
+		return false
+	}
+	if !tl.ValidityPeriodEnd.IsZero() && t.After(tl.ValidityPeriodEnd) {
+		return false
+	}
+	return true
+}
+
+// RootPublicKeyMatches reports whether the public key of the CA's root
+// certificate is pub, comparing their DER-encoded SubjectPublicKeyInfo. This
+// allows a CA to be pinned by its key rather than by its full certificate.
+func (ca *CertificateAuthority) RootPublicKeyMatches(pub crypto.PublicKey) (bool, error) {
+	if ca.Root == nil {
+		return false, errors.New("certificate authority has no root certificate")
+	}
+	rootDER, err := x509.MarshalPKIXPublicKey(ca.Root.PublicKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal root public key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal public key: %w", err)
+	}
+	return bytes.Equal(rootDER, pubDER), nil
+}
+
+// ValidAtTime reports whether the CA was trusted at the given time, according
+// to the validity period the trusted root gives it. This is independent of the
+// validity of the CA's certificates.
+func (ca *CertificateAuthority) ValidAtTime(t time.Time) bool {
+	if !ca.ValidityPeriodStart.IsZero() && t.Before(ca.ValidityPeriodStart) {
+		return false
+	}
+	if !ca.ValidityPeriodEnd.IsZero() && t.After(ca.ValidityPeriodEnd) {
+		return false
+	}
+	return true
 }
 
 func ParseCertificateAuthorities(certAuthorities []*prototrustroot.CertificateAuthority) (certificateAuthorities []CertificateAuthority, err error) {
@@ -212,21 +695,20 @@ func ParseCertificateAuthority(certAuthority *prototrustroot.CertificateAuthorit
 		return nil, fmt.Errorf("CertificateAuthority cert chain is empty")
 	}
 
-	certificateAuthority = &CertificateAuthority{}
-	for i, cert := range certChain.GetCertificates() {
-		parsedCert, err := x509.ParseCertificate(cert.RawBytes)
+	certs := make([]*x509.Certificate, 0, chainLen)
+	for _, cert := range certChain.GetCertificates() {
+		parsedCert, err := parseRawCertificate(cert.GetRawBytes())
 		if err != nil {
 			return nil, err
 		}
-		switch {
-		case i == 0 && !parsedCert.IsCA:
-			certificateAuthority.Leaf = parsedCert
-		case i < chainLen-1:
-			certificateAuthority.Intermediates = append(certificateAuthority.Intermediates, parsedCert)
-		case i == chainLen-1:
-			certificateAuthority.Root = parsedCert
-		}
+		certs = append(certs, parsedCert)
 	}
+
+	certificateAuthority, err = classifyCertificates(certs)
+	if err != nil {
+		return nil, err
+	}
+
 	validFor := certAuthority.GetValidFor()
 	if validFor != nil {
 		start := validFor.GetStart()
@@ -239,136 +721,458 @@ func ParseCertificateAuthority(certAuthority *prototrustroot.CertificateAuthorit
 		}
 	}
 
-	// TODO: Should we inspect/enforce ca.Subject and ca.Uri?
+	certificateAuthority.URI = certAuthority.GetUri()
+
+	// TODO: Should we inspect/enforce ca.Subject?
 	// TODO: Handle validity period (ca.ValidFor)
 
 	return certificateAuthority, nil
 }
 
-func NewTrustedRootFromPath(path string) (*TrustedRoot, error) {
-	trustedrootJSON, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+// parseRawCertificate parses the rawBytes of a certificate in a trusted root,
+// which the spec defines as DER. Hand-authored trusted roots sometimes embed a
+// PEM certificate instead, which is decoded rather than failing with an ASN.1
+// error that doesn't point at the encoding.
+func parseRawCertificate(rawBytes []byte) (*x509.Certificate, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(rawBytes), []byte("-----BEGIN")) {
+		block, rest := pem.Decode(rawBytes)
+		if block == nil {
+			return nil, fmt.Errorf("%w: invalid PEM", ErrPEMEncodedCertificate)
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("%w: found a PEM %s block", ErrPEMEncodedCertificate, block.Type)
+		}
+		if len(bytes.TrimSpace(rest)) > 0 {
+			return nil, fmt.Errorf("%w: found more than one PEM block; list each certificate of the chain separately", ErrPEMEncodedCertificate)
+		}
+		rawBytes = block.Bytes
+	}
+	return x509.ParseCertificate(rawBytes)
+}
+
+// classifyCertificates sorts the certificates of a CertificateAuthority's
+// chain into its leaf, intermediates and root, independent of the order they
+// are listed in. The root is a self-signed certificate, or failing that the
+// CA certificate not issued by any other certificate in the chain. Other CA
+// certificates, including cross-signed ones, are intermediates.
+func classifyCertificates(certs []*x509.Certificate) (*CertificateAuthority, error) {
+	if len(certs) == 1 {
+		// a lone certificate is a root that leaves chain to directly, with no
+		// intermediates
+		return &CertificateAuthority{Root: certs[0]}, nil
+	}
+
+	certificateAuthority := &CertificateAuthority{}
+
+	var cas []*x509.Certificate
+	for _, cert := range certs {
+		if cert.IsCA {
+			cas = append(cas, cert)
+			continue
+		}
+		if certificateAuthority.Leaf != nil {
+			return nil, fmt.Errorf("CertificateAuthority cert chain has more than one leaf certificate")
+		}
+		certificateAuthority.Leaf = cert
+	}
+	if len(cas) == 0 {
+		return nil, fmt.Errorf("CertificateAuthority cert chain has no CA certificate")
+	}
+
+	rootIndex := -1
+	for i, cert := range cas {
+		if isSelfSigned(cert) {
+			rootIndex = i
+		}
+	}
+	if rootIndex == -1 {
+		for i, cert := range cas {
+			if !issuedWithin(cert, cas) {
+				rootIndex = i
+			}
+		}
+	}
+	if rootIndex == -1 {
+		rootIndex = len(cas) - 1
 	}
 
-	return NewTrustedRootFromJSON(trustedrootJSON)
+	certificateAuthority.Root = cas[rootIndex]
+	for i, cert := range cas {
+		if i != rootIndex {
+			certificateAuthority.Intermediates = append(certificateAuthority.Intermediates, cert)
+		}
+	}
+
+	return certificateAuthority, nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// issuedWithin reports whether cert was issued by another of the given certs.
+func issuedWithin(cert *x509.Certificate, certs []*x509.Certificate) bool {
+	for _, issuer := range certs {
+		if issuer != cert && cert.CheckSignatureFrom(issuer) == nil {
+			return true
+		}
+	}
+	return false
 }
 
 // NewTrustedRootFromJSON returns the Sigstore trusted root.
-func NewTrustedRootFromJSON(rootJSON []byte) (*TrustedRoot, error) {
+//
+// Once SetTrustedRootCacheSize turns the cache on, the trusted roots last
+// parsed are remembered by their fingerprint, the SHA-256 digest of
+// rootJSON: when rootJSON is byte-for-byte the same as one of them, that
+// TrustedRoot is returned rather than parsing its certificates and keys
+// again. This makes reloading an unchanged trusted root nearly free, and
+// lets verifiers keep the state they derived from it.
+//
+// A cached TrustedRoot is shared with every other caller that parses the
+// same bytes, and must be treated as read-only: don't modify it, or the
+// certificates, keys and maps it returns. Without the cache, each call
+// returns a TrustedRoot of its own; a LiveTrustedRoot keeps an unchanged
+// trusted root on its own either way.
+func NewTrustedRootFromJSON(rootJSON []byte, opts ...ParseOption) (*TrustedRoot, error) {
+	o := newParseOptions(opts)
+	fp := fingerprint(rootJSON)
+	if tr := parsedTrustedRoots.get(fp); tr != nil {
+		if err := o.check(tr); err != nil {
+			return nil, err
+		}
+		return tr, nil
+	}
+
 	pbTrustedRoot, err := NewTrustedRootProtobuf(rootJSON)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewTrustedRootFromProtobuf(pbTrustedRoot)
+	tr, err := newTrustedRootFromProtobuf(pbTrustedRoot, fp)
+	if err != nil {
+		return nil, err
+	}
+	parsedTrustedRoots.add(tr)
+	if err := o.check(tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+func fingerprint(b []byte) string {
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
+
+// DefaultTrustedRootCacheSize is how many parsed trusted roots
+// NewTrustedRootFromJSON remembers, unless SetTrustedRootCacheSize is called:
+// none, so that callers don't share TrustedRoots unless they opt in.
+const DefaultTrustedRootCacheSize = 0
+
+// SetTrustedRootCacheSize sets how many parsed trusted roots
+// NewTrustedRootFromJSON remembers, evicting the least recently used ones
+// beyond that. Services that reload the same trusted roots repeatedly can
+// turn it on to avoid parsing them again, provided nothing in the process
+// modifies the TrustedRoots it returns; a size less than one turns the
+// cache off.
+func SetTrustedRootCacheSize(size int) {
+	parsedTrustedRoots.resize(size)
+}
+
+// trustedRootCache holds the trusted roots last parsed from JSON, by
+// fingerprint, and evicts the least recently used when full.
+type trustedRootCache struct {
+	mu   sync.Mutex
+	size int
+	// lru holds the *TrustedRoot entries, most recently used first
+	lru           *list.List
+	byFingerprint map[string]*list.Element
+}
+
+var parsedTrustedRoots = newTrustedRootCache(DefaultTrustedRootCacheSize)
+
+func newTrustedRootCache(size int) *trustedRootCache {
+	return &trustedRootCache{
+		size:          size,
+		lru:           list.New(),
+		byFingerprint: make(map[string]*list.Element),
+	}
+}
+
+// get returns the remembered trusted root with the given fingerprint, or
+// nil if there is none.
+func (c *trustedRootCache) get(fingerprint string) *TrustedRoot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.byFingerprint[fingerprint]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*TrustedRoot)
+}
+
+func (c *trustedRootCache) add(tr *TrustedRoot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.byFingerprint[tr.fingerprint]; ok {
+		element.Value = tr
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.byFingerprint[tr.fingerprint] = c.lru.PushFront(tr)
+	c.evict()
+}
+
+func (c *trustedRootCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.size = size
+	c.evict()
+}
+
+// evict drops the least recently used trusted roots beyond the cache size.
+// The caller must hold c.mu.
+func (c *trustedRootCache) evict() {
+	for c.lru.Len() > 0 && c.lru.Len() > c.size {
+		element := c.lru.Back()
+		c.lru.Remove(element)
+		delete(c.byFingerprint, element.Value.(*TrustedRoot).fingerprint)
+	}
 }
 
+// NewTrustedRootFromBase64 returns the Sigstore trusted root from its
+// base64-encoded JSON, as is often passed in through an environment variable.
+// Both the standard and URL-safe alphabets are accepted, with or without
+// padding.
+func NewTrustedRootFromBase64(s string, opts ...ParseOption) (*TrustedRoot, error) {
+	s = strings.TrimSpace(s)
+	encodings := []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	}
+	for _, encoding := range encodings {
+		rootJSON, err := encoding.DecodeString(s)
+		if err == nil {
+			return NewTrustedRootFromJSON(rootJSON, opts...)
+		}
+	}
+	return nil, fmt.Errorf("%w: not valid base64", ErrInvalidTrustedRoot)
+}
+
+// trustedRootUnmarshalOptions ignores fields and enum values added by newer
+// minor versions of the protobuf-specs schema, so that trusted roots
+// distributed ahead of a protobuf-specs upgrade still parse. Anything this
+// version relies on is still validated when the protobuf is converted into a
+// TrustedRoot.
+var trustedRootUnmarshalOptions = protojson.UnmarshalOptions{DiscardUnknown: true}
+
 // NewTrustedRootProtobuf returns the Sigstore trusted root as a protobuf.
 func NewTrustedRootProtobuf(rootJSON []byte) (*prototrustroot.TrustedRoot, error) {
 	pbTrustedRoot := &prototrustroot.TrustedRoot{}
-	err := protojson.Unmarshal(rootJSON, pbTrustedRoot)
+	err := trustedRootUnmarshalOptions.Unmarshal(rootJSON, pbTrustedRoot)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTrustedRoot, err)
 	}
 	return pbTrustedRoot, nil
 }
 
-// FetchTrustedRoot fetches the Sigstore trusted root from TUF and returns it.
-func FetchTrustedRoot() (*TrustedRoot, error) {
-	return FetchTrustedRootWithOptions(tuf.DefaultOptions())
-}
-
-// FetchTrustedRootWithOptions fetches the trusted root from TUF with the given options and returns it.
-func FetchTrustedRootWithOptions(opts *tuf.Options) (*TrustedRoot, error) {
-	client, err := tuf.New(opts)
+// ToProtobuf returns the trusted root as a protobuf, rebuilt from its parsed
+// transparency logs and certificate authorities, so that trusted roots can
+// be serialized again. It is semantically equivalent to the protobuf the
+// trusted root was parsed from, except that:
+//
+//   - each log's versions are listed in order of their validity period
+//     start, and logs in order of their first version
+//   - log keys are encoded as PKIX, including RSA keys given in the
+//     deprecated PKCS1_RSA_PKCS1V5 format that have a standard key size
+//   - a certificate authority's subject is taken from its root certificate
+func (tr *TrustedRoot) ToProtobuf() (*prototrustroot.TrustedRoot, error) {
+	tlogs, err := transparencyLogsToProtobuf(tr.rekorLogVersions)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("rekor log: %w", err)
 	}
-	return GetTrustedRoot(client)
+	ctlogs, err := transparencyLogsToProtobuf(tr.ctLogVersions)
+	if err != nil {
+		return nil, fmt.Errorf("CT log: %w", err)
+	}
+
+	return &prototrustroot.TrustedRoot{
+		MediaType:              TrustedRootMediaType01,
+		Tlogs:                  tlogs,
+		CertificateAuthorities: certificateAuthoritiesToProtobuf(tr.fulcioCertAuthorities),
+		Ctlogs:                 ctlogs,
+		TimestampAuthorities:   certificateAuthoritiesToProtobuf(tr.timestampingAuthorities),
+	}, nil
 }
 
-// GetTrustedRoot returns the trusted root
-func GetTrustedRoot(c *tuf.Client) (*TrustedRoot, error) {
-	jsonBytes, err := c.GetTarget("trusted_root.json")
+// MarshalJSON returns the trusted root as trusted root JSON, see ToProtobuf.
+func (tr *TrustedRoot) MarshalJSON() ([]byte, error) {
+	pbTrustedRoot, err := tr.ToProtobuf()
 	if err != nil {
 		return nil, err
 	}
-	return NewTrustedRootFromJSON(jsonBytes)
+	return protojson.Marshal(pbTrustedRoot)
 }
 
-// LiveTrustedRoot is a wrapper around TrustedRoot that periodically
-// refreshes the trusted root from TUF. This is needed for long-running
-// processes to ensure that the trusted root does not expire.
-type LiveTrustedRoot struct {
-	*TrustedRoot
-	mu sync.RWMutex
+// transparencyLogsToProtobuf returns every version of the logs as protobuf
+// log instances, ordered by the start of their validity periods.
+func transparencyLogsToProtobuf(logVersions map[string][]*TransparencyLog) ([]*prototrustroot.TransparencyLogInstance, error) {
+	var transparencyLogs []*TransparencyLog
+	for _, versions := range logVersions {
+		transparencyLogs = append(transparencyLogs, versions...)
+	}
+	sort.SliceStable(transparencyLogs, func(i, j int) bool {
+		if !transparencyLogs[i].ValidityPeriodStart.Equal(transparencyLogs[j].ValidityPeriodStart) {
+			return transparencyLogs[i].ValidityPeriodStart.Before(transparencyLogs[j].ValidityPeriodStart)
+		}
+		return bytes.Compare(transparencyLogs[i].ID, transparencyLogs[j].ID) < 0
+	})
+
+	tlogs := make([]*prototrustroot.TransparencyLogInstance, 0, len(transparencyLogs))
+	for _, transparencyLog := range transparencyLogs {
+		tlog, err := transparencyLog.ToProtobuf()
+		if err != nil {
+			return nil, err
+		}
+		tlogs = append(tlogs, tlog)
+	}
+	return tlogs, nil
 }
 
-// NewLiveTrustedRoot returns a LiveTrustedRoot that will periodically
-// refresh the trusted root from TUF.
-func NewLiveTrustedRoot(opts *tuf.Options) (*LiveTrustedRoot, error) {
-	client, err := tuf.New(opts)
-	if err != nil {
-		return nil, err
+// ToProtobuf returns the log as a protobuf log instance, the inverse of
+// ParseTransparencyLog.
+func (tl *TransparencyLog) ToProtobuf() (*prototrustroot.TransparencyLogInstance, error) {
+	var hashAlgorithm protocommon.HashAlgorithm
+	switch tl.HashFunc {
+	case crypto.SHA256:
+		hashAlgorithm = protocommon.HashAlgorithm_SHA2_256
+	case crypto.SHA384:
+		hashAlgorithm = protocommon.HashAlgorithm_SHA2_384
+	case crypto.SHA512:
+		hashAlgorithm = protocommon.HashAlgorithm_SHA2_512
+	default:
+		return nil, fmt.Errorf("%s: unsupported hash function for the tlog: %s", tl.BaseURL, tl.HashFunc)
 	}
-	tr, err := GetTrustedRoot(client)
+
+	keyDetails, rawBytes, err := logKeyToProtobuf(tl.PublicKey, tl.RSAPSS)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", tl.BaseURL, err)
 	}
-	ltr := &LiveTrustedRoot{
-		TrustedRoot: tr,
-		mu:          sync.RWMutex{},
-	}
-	ticker := time.NewTicker(time.Hour * 24)
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				client, err = tuf.New(opts)
-				if err != nil {
-					log.Printf("error creating TUF client: %v", err)
-				}
-				newTr, err := GetTrustedRoot(client)
-				if err != nil {
-					log.Printf("error fetching trusted root: %v", err)
-					continue
-				}
-				ltr.mu.Lock()
-				ltr.TrustedRoot = newTr
-				ltr.mu.Unlock()
-			}
-		}
-	}()
-	return ltr, nil
-}
 
-func (l *LiveTrustedRoot) TimestampingAuthorities() []CertificateAuthority {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.TimestampingAuthorities()
+	return &prototrustroot.TransparencyLogInstance{
+		BaseUrl:       tl.BaseURL,
+		HashAlgorithm: hashAlgorithm,
+		PublicKey: &protocommon.PublicKey{
+			RawBytes:   rawBytes,
+			KeyDetails: keyDetails,
+			ValidFor:   timeRangeToProtobuf(tl.ValidityPeriodStart, tl.ValidityPeriodEnd),
+		},
+		LogId: &protocommon.LogId{KeyId: tl.ID},
+	}, nil
 }
 
-func (l *LiveTrustedRoot) FulcioCertificateAuthorities() []CertificateAuthority {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.FulcioCertificateAuthorities()
+// logKeyToProtobuf returns the key details and raw bytes that
+// ParseTransparencyLog parses into the log's public key.
+func logKeyToProtobuf(publicKey crypto.PublicKey, rsaPSS bool) (protocommon.PublicKeyDetails, []byte, error) {
+	var keyDetails protocommon.PublicKeyDetails
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			keyDetails = protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256
+		case elliptic.P384():
+			keyDetails = protocommon.PublicKeyDetails_PKIX_ECDSA_P384_SHA_384
+		case elliptic.P521():
+			keyDetails = protocommon.PublicKeyDetails_PKIX_ECDSA_P521_SHA_512
+		default:
+			return 0, nil, fmt.Errorf("unsupported tlog public key curve: %s", key.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		keyDetails = protocommon.PublicKeyDetails_PKIX_ED25519
+	case *rsa.PublicKey:
+		switch {
+		case rsaPSS && key.N.BitLen() == 2048:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PSS_2048_SHA256
+		case rsaPSS && key.N.BitLen() == 3072:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PSS_3072_SHA256
+		case rsaPSS && key.N.BitLen() == 4096:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PSS_4096_SHA256
+		case rsaPSS:
+			return 0, nil, fmt.Errorf("unsupported tlog public key size for RSA-PSS: %d", key.N.BitLen())
+		case key.N.BitLen() == 2048:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_2048_SHA256
+		case key.N.BitLen() == 3072:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_3072_SHA256
+		case key.N.BitLen() == 4096:
+			keyDetails = protocommon.PublicKeyDetails_PKIX_RSA_PKCS1V15_4096_SHA256
+		default:
+			// only the deprecated format allows other key sizes
+			return protocommon.PublicKeyDetails_PKCS1_RSA_PKCS1V5, x509.MarshalPKCS1PublicKey(key), nil //nolint:staticcheck
+		}
+	default:
+		return 0, nil, fmt.Errorf("unsupported tlog public key type: %T", publicKey)
+	}
+
+	rawBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return 0, nil, err
+	}
+	return keyDetails, rawBytes, nil
 }
 
-func (l *LiveTrustedRoot) RekorLogs() map[string]*TransparencyLog {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.RekorLogs()
+// certificateAuthoritiesToProtobuf returns the CAs as protobuf certificate
+// authorities, in the same order.
+func certificateAuthoritiesToProtobuf(certificateAuthorities []CertificateAuthority) []*prototrustroot.CertificateAuthority {
+	pbCertificateAuthorities := make([]*prototrustroot.CertificateAuthority, 0, len(certificateAuthorities))
+	for i := range certificateAuthorities {
+		pbCertificateAuthorities = append(pbCertificateAuthorities, certificateAuthorities[i].ToProtobuf())
+	}
+	return pbCertificateAuthorities
 }
 
-func (l *LiveTrustedRoot) CTLogs() map[string]*TransparencyLog {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.CTLogs()
+// ToProtobuf returns the CA as a protobuf certificate authority, with its
+// certificate chain ordered from the leaf to the root.
+func (ca *CertificateAuthority) ToProtobuf() *prototrustroot.CertificateAuthority {
+	var chain []*x509.Certificate
+	if ca.Leaf != nil {
+		chain = append(chain, ca.Leaf)
+	}
+	chain = append(chain, ca.Intermediates...)
+	chain = append(chain, ca.Root)
+
+	certificates := make([]*protocommon.X509Certificate, 0, len(chain))
+	for _, cert := range chain {
+		certificates = append(certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+	}
+
+	pbCertificateAuthority := &prototrustroot.CertificateAuthority{
+		Subject:   &protocommon.DistinguishedName{CommonName: ca.Root.Subject.CommonName},
+		Uri:       ca.URI,
+		CertChain: &protocommon.X509CertificateChain{Certificates: certificates},
+		ValidFor:  timeRangeToProtobuf(ca.ValidityPeriodStart, ca.ValidityPeriodEnd),
+	}
+	if len(ca.Root.Subject.Organization) > 0 {
+		pbCertificateAuthority.Subject.Organization = ca.Root.Subject.Organization[0]
+	}
+	return pbCertificateAuthority
 }
 
-func (l *LiveTrustedRoot) PublicKeyVerifier(keyID string) (TimeConstrainedVerifier, error) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
-	return l.TrustedRoot.PublicKeyVerifier(keyID)
+// timeRangeToProtobuf returns a validity period as a protobuf time range,
+// leaving out unset times, or nil if neither is set.
+func timeRangeToProtobuf(start, end time.Time) *protocommon.TimeRange {
+	if start.IsZero() && end.IsZero() {
+		return nil
+	}
+	timeRange := &protocommon.TimeRange{}
+	if !start.IsZero() {
+		timeRange.Start = timestamppb.New(start)
+	}
+	if !end.IsZero() {
+		timeRange.End = timestamppb.New(end)
+	}
+	return timeRange
 }
@@ -15,16 +15,32 @@
 package root
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
 	"os"
 	"testing"
 	"time"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
 	"github.com/sigstore/sigstore/pkg/signature"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestGetSigstoreTrustedRoot(t *testing.T) {
@@ -36,6 +52,167 @@ func TestGetSigstoreTrustedRoot(t *testing.T) {
 	assert.NotNil(t, trustedRoot)
 }
 
+func TestTrustedRootToProtobuf(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	want, err := NewTrustedRootProtobuf(trustedrootJSON)
+	assert.NoError(t, err)
+
+	trustedRoot, err := NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+	got, err := trustedRoot.ToProtobuf()
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(want, got), "got %s, want %s", protojson.Format(got), protojson.Format(want))
+
+	// the JSON parses back into an equivalent trusted root
+	marshaled, err := json.Marshal(trustedRoot)
+	assert.NoError(t, err)
+	roundTripped, err := NewTrustedRootFromJSON(marshaled)
+	assert.NoError(t, err)
+	got, err = roundTripped.ToProtobuf()
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(want, got))
+	assert.Equal(t, trustedRoot.FulcioCertificateAuthorities(), roundTripped.FulcioCertificateAuthorities())
+	assert.Equal(t, trustedRoot.RekorLogs(), roundTripped.RekorLogs())
+}
+
+func TestGetStagingTrustedRoot(t *testing.T) {
+	// The fixture has the layout of the staging trusted root, with generated
+	// key material
+	trustedrootJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	assert.NoError(t, err)
+
+	trustedRoot, err := NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+	assert.True(t, IsStagingTrustedRoot(trustedRoot))
+
+	assert.Len(t, trustedRoot.RekorLogs(), 1)
+	assert.Len(t, trustedRoot.FulcioCertificateAuthorities(), 1)
+	assert.Len(t, trustedRoot.TimestampingAuthorities(), 1)
+	assert.Len(t, trustedRoot.CTLogs(), 2)
+	for _, ctlog := range trustedRoot.CTLogs() {
+		assert.Contains(t, ctlog.BaseURL, SigstoreStagingDomain)
+	}
+
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	publicGood, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.False(t, IsStagingTrustedRoot(publicGood))
+
+	assert.False(t, IsStagingTrustedRoot(nil))
+	assert.False(t, IsStagingTrustedRoot(&TrustedRoot{}))
+}
+
+func TestAllCertificates(t *testing.T) {
+	trustedRootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+
+	// the fixture lists a Fulcio CA with a root, one with a root and an
+	// intermediate, and a TSA with a root, an intermediate and a leaf
+	var pbTrustedRoot struct {
+		CertificateAuthorities []struct {
+			CertChain struct {
+				Certificates []struct {
+					RawBytes []byte `json:"rawBytes"`
+				} `json:"certificates"`
+			} `json:"certChain"`
+		} `json:"certificateAuthorities"`
+		TimestampAuthorities []struct {
+			CertChain struct {
+				Certificates []struct {
+					RawBytes []byte `json:"rawBytes"`
+				} `json:"certificates"`
+			} `json:"certChain"`
+		} `json:"timestampAuthorities"`
+	}
+	assert.NoError(t, json.Unmarshal(trustedRootJSON, &pbTrustedRoot))
+	var want [][]byte
+	for _, ca := range pbTrustedRoot.CertificateAuthorities {
+		for _, cert := range ca.CertChain.Certificates {
+			want = append(want, cert.RawBytes)
+		}
+	}
+	for _, ca := range pbTrustedRoot.TimestampAuthorities {
+		for _, cert := range ca.CertChain.Certificates {
+			want = append(want, cert.RawBytes)
+		}
+	}
+
+	certs := trustedRoot.AllCertificates()
+	assert.Len(t, certs, 6)
+	var got [][]byte
+	for _, cert := range certs {
+		got = append(got, cert.Raw)
+	}
+	assert.ElementsMatch(t, want, got)
+
+	// a certificate shared by two authorities is listed once
+	fulcioCA := trustedRoot.FulcioCertificateAuthorities()[0]
+	shared := &TrustedRoot{
+		fulcioCertAuthorities:   []CertificateAuthority{fulcioCA},
+		timestampingAuthorities: []CertificateAuthority{{Root: fulcioCA.Root}},
+	}
+	assert.Equal(t, []*x509.Certificate{fulcioCA.Root}, shared.AllCertificates())
+
+	assert.Empty(t, (&TrustedRoot{}).AllCertificates())
+}
+
+func TestTrustedRootFromNewerSchema(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+
+	// add fields that a newer minor version of the schema could introduce
+	var newerRoot map[string]interface{}
+	assert.NoError(t, json.Unmarshal(trustedrootJSON, &newerRoot))
+	newerRoot["signingConfig"] = map[string]interface{}{"caUrl": "https://fulcio.sigstore.dev"}
+	tlog := newerRoot["tlogs"].([]interface{})[0].(map[string]interface{})
+	tlog["operator"] = "sigstore.dev"
+	tlog["publicKey"].(map[string]interface{})["keyUsage"] = "LOG_SIGNING"
+	ca := newerRoot["certificateAuthorities"].([]interface{})[0].(map[string]interface{})
+	ca["operator"] = "sigstore.dev"
+	newerJSON, err := json.Marshal(newerRoot)
+	assert.NoError(t, err)
+
+	_, err = NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+
+	trustedRoot, err := NewTrustedRootFromJSON(newerJSON)
+	assert.NoError(t, err)
+	assert.Len(t, trustedRoot.RekorLogs(), 1)
+	assert.Len(t, trustedRoot.FulcioCertificateAuthorities(), 2)
+}
+
+func TestNewTrustedRootFromBase64(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name     string
+		encoding *base64.Encoding
+	}{
+		{name: "standard", encoding: base64.StdEncoding},
+		{name: "standard without padding", encoding: base64.RawStdEncoding},
+		{name: "URL-safe", encoding: base64.URLEncoding},
+		{name: "URL-safe without padding", encoding: base64.RawURLEncoding},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			trustedRoot, err := NewTrustedRootFromBase64(test.encoding.EncodeToString(trustedrootJSON) + "\n")
+			assert.NoError(t, err)
+			assert.NotNil(t, trustedRoot)
+			assert.Len(t, trustedRoot.FulcioCertificateAuthorities(), 2)
+		})
+	}
+
+	_, err = NewTrustedRootFromBase64("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+
+	_, err = NewTrustedRootFromBase64(base64.StdEncoding.EncodeToString([]byte("not json")))
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+}
+
 type singleKeyVerifier struct {
 	BaseTrustedMaterial
 	verifier TimeConstrainedVerifier
@@ -73,3 +250,952 @@ func TestTrustedMaterialCollection(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, verifier, verifier2)
 }
+
+func generateTestCert(t *testing.T, name string, isCA bool, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert
+}
+
+func certificateAuthorityProto(certs ...*x509.Certificate) *prototrustroot.CertificateAuthority {
+	chain := &protocommon.X509CertificateChain{}
+	for _, cert := range certs {
+		chain.Certificates = append(chain.Certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+	}
+	return &prototrustroot.CertificateAuthority{CertChain: chain}
+}
+
+func TestParseCertificateAuthorityChainOrder(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		return key
+	}
+
+	rootKey, intermediateKey, leafKey := newKey(), newKey(), newKey()
+	rootCert := generateTestCert(t, "root", true, rootKey, nil, nil)
+	intermediateCert := generateTestCert(t, "intermediate", true, intermediateKey, rootCert, rootKey)
+	leafCert := generateTestCert(t, "leaf", false, leafKey, intermediateCert, intermediateKey)
+
+	// the intermediate's issuer, cross-signed by another root
+	otherRootKey := newKey()
+	otherRootCert := generateTestCert(t, "other root", true, otherRootKey, nil, nil)
+	crossSignedCert := generateTestCert(t, "root", true, rootKey, otherRootCert, otherRootKey)
+
+	for _, test := range []struct {
+		name              string
+		chain             []*x509.Certificate
+		wantRoot          *x509.Certificate
+		wantIntermediates []*x509.Certificate
+		wantLeaf          *x509.Certificate
+	}{
+		{
+			name:              "leaf first",
+			chain:             []*x509.Certificate{leafCert, intermediateCert, rootCert},
+			wantRoot:          rootCert,
+			wantIntermediates: []*x509.Certificate{intermediateCert},
+			wantLeaf:          leafCert,
+		},
+		{
+			name:              "root first",
+			chain:             []*x509.Certificate{rootCert, intermediateCert, leafCert},
+			wantRoot:          rootCert,
+			wantIntermediates: []*x509.Certificate{intermediateCert},
+			wantLeaf:          leafCert,
+		},
+		{
+			name:              "shuffled",
+			chain:             []*x509.Certificate{intermediateCert, leafCert, rootCert},
+			wantRoot:          rootCert,
+			wantIntermediates: []*x509.Certificate{intermediateCert},
+			wantLeaf:          leafCert,
+		},
+		{
+			name:              "no leaf",
+			chain:             []*x509.Certificate{rootCert, intermediateCert},
+			wantRoot:          rootCert,
+			wantIntermediates: []*x509.Certificate{intermediateCert},
+		},
+		{
+			name:     "single root",
+			chain:    []*x509.Certificate{rootCert},
+			wantRoot: rootCert,
+		},
+		{
+			name:              "cross-signed intermediate",
+			chain:             []*x509.Certificate{otherRootCert, crossSignedCert, intermediateCert, leafCert},
+			wantRoot:          otherRootCert,
+			wantIntermediates: []*x509.Certificate{crossSignedCert, intermediateCert},
+			wantLeaf:          leafCert,
+		},
+		{
+			name:              "cross-signed intermediate without its root",
+			chain:             []*x509.Certificate{leafCert, intermediateCert, crossSignedCert},
+			wantRoot:          crossSignedCert,
+			wantIntermediates: []*x509.Certificate{intermediateCert},
+			wantLeaf:          leafCert,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ca, err := ParseCertificateAuthority(certificateAuthorityProto(test.chain...))
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantRoot.Raw, ca.Root.Raw)
+			assert.Len(t, ca.Intermediates, len(test.wantIntermediates))
+			for i, intermediate := range test.wantIntermediates {
+				assert.Equal(t, intermediate.Raw, ca.Intermediates[i].Raw)
+			}
+			if test.wantLeaf == nil {
+				assert.Nil(t, ca.Leaf)
+			} else {
+				assert.Equal(t, test.wantLeaf.Raw, ca.Leaf.Raw)
+			}
+
+			if ca.Leaf != nil {
+				roots := x509.NewCertPool()
+				roots.AddCert(ca.Root)
+				intermediates := x509.NewCertPool()
+				for _, cert := range ca.Intermediates {
+					intermediates.AddCert(cert)
+				}
+				_, err = ca.Leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	_, err := ParseCertificateAuthority(certificateAuthorityProto(leafCert, intermediateCert, leafCert))
+	assert.Error(t, err)
+}
+
+func TestTlogVerifierAt(t *testing.T) {
+	logID := []byte("rotated log")
+	rotation := time.Now().Add(-24 * time.Hour)
+
+	keyVersion := func(start, end time.Time) (*ecdsa.PrivateKey, *prototrustroot.TransparencyLogInstance) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		pubBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+		assert.NoError(t, err)
+		validFor := &protocommon.TimeRange{Start: timestamppb.New(start)}
+		if !end.IsZero() {
+			validFor.End = timestamppb.New(end)
+		}
+		return key, &prototrustroot.TransparencyLogInstance{
+			BaseUrl:       "https://rekor.example.com",
+			HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+			PublicKey: &protocommon.PublicKey{
+				RawBytes:   pubBytes,
+				KeyDetails: protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+				ValidFor:   validFor,
+			},
+			LogId: &protocommon.LogId{KeyId: logID},
+		}
+	}
+
+	oldKey, oldVersion := keyVersion(rotation.Add(-365*24*time.Hour), rotation)
+	newKey, newVersion := keyVersion(rotation, time.Time{})
+
+	trustedRoot, err := NewTrustedRootFromProtobuf(&prototrustroot.TrustedRoot{
+		MediaType: TrustedRootMediaType01,
+		Tlogs:     []*prototrustroot.TransparencyLogInstance{oldVersion, newVersion},
+	})
+	assert.NoError(t, err)
+
+	tlog, err := trustedRoot.TlogVerifierAt(logID, rotation.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, oldKey.PublicKey.Equal(tlog.PublicKey))
+
+	tlog, err = trustedRoot.TlogVerifierAt(logID, rotation.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, newKey.PublicKey.Equal(tlog.PublicKey))
+
+	_, err = trustedRoot.TlogVerifierAt(logID, rotation.Add(-2*365*24*time.Hour))
+	assert.ErrorIs(t, err, ErrExpiredTrustMaterial)
+
+	_, err = trustedRoot.TlogVerifierAt([]byte("unknown log"), rotation)
+	assert.ErrorIs(t, err, ErrUnknownLog)
+
+	// a collection tells the two apart as well
+	collection := TrustedMaterialCollection{trustedRoot}
+	_, err = collection.TlogVerifiersAt(logID, rotation.Add(-2*365*24*time.Hour))
+	assert.ErrorIs(t, err, ErrExpiredTrustMaterial)
+	_, err = collection.TlogVerifiersAt([]byte("unknown log"), rotation)
+	assert.ErrorIs(t, err, ErrUnknownLog)
+
+	_, err = NewTrustedRootFromProtobuf(&prototrustroot.TrustedRoot{MediaType: "application/unknown"})
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+
+	// both versions are valid at the moment of rotation
+	versions, err := trustedRoot.TlogVerifiersAt(logID, rotation)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+	assert.True(t, oldKey.PublicKey.Equal(versions[0].PublicKey))
+	assert.True(t, newKey.PublicKey.Equal(versions[1].PublicKey))
+}
+
+func TestTransparencyLogValidityPeriod(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+
+	trustedRoot, err := NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+
+	// a validity period without an end
+	rekor := trustedRoot.RekorLogs()["c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d"]
+	if assert.NotNil(t, rekor) {
+		assert.Equal(t, time.Date(2021, 1, 12, 11, 53, 27, 0, time.UTC), rekor.ValidityPeriodStart.UTC())
+		assert.True(t, rekor.ValidityPeriodEnd.IsZero())
+	}
+
+	// a validity period with both ends
+	ctfeTest := trustedRoot.CTLogs()["086092f02852ff6845d1d16b27849c456718ac163dc338d26de6bc2206366f72"]
+	if assert.NotNil(t, ctfeTest) {
+		assert.Equal(t, time.Date(2021, 3, 14, 0, 0, 0, 0, time.UTC), ctfeTest.ValidityPeriodStart.UTC())
+		assert.Equal(t, time.Date(2022, 10, 31, 23, 59, 59, 999000000, time.UTC), ctfeTest.ValidityPeriodEnd.UTC())
+		assert.False(t, ctfeTest.ValidAtTime(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	// each log keeps its own validity period
+	ctfe2022 := trustedRoot.CTLogs()["dd3d306ac6c7113263191e1c99673702a24a5eb8de3cadff878a72802f29ee8e"]
+	if assert.NotNil(t, ctfe2022) {
+		assert.Equal(t, time.Date(2022, 10, 20, 0, 0, 0, 0, time.UTC), ctfe2022.ValidityPeriodStart.UTC())
+		assert.True(t, ctfe2022.ValidityPeriodEnd.IsZero())
+	}
+}
+
+func TestTransparencyLogValidFor(t *testing.T) {
+	start := time.Date(2021, 1, 12, 11, 53, 27, 0, time.UTC)
+	end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		validFor  map[string]interface{}
+		wantErr   bool
+		validAt   []time.Time
+		invalidAt []time.Time
+	}{
+		{
+			name:      "start only",
+			validFor:  map[string]interface{}{"start": start.Format(time.RFC3339)},
+			validAt:   []time.Time{start, end.AddDate(10, 0, 0)},
+			invalidAt: []time.Time{start.Add(-time.Second)},
+		},
+		{
+			name:      "start and end",
+			validFor:  map[string]interface{}{"start": start.Format(time.RFC3339), "end": end.Format(time.RFC3339)},
+			validAt:   []time.Time{start, end},
+			invalidAt: []time.Time{start.Add(-time.Second), end.Add(time.Second)},
+		},
+		{
+			// a validity period must say when the key started being used
+			name:     "end only",
+			validFor: map[string]interface{}{"end": end.Format(time.RFC3339)},
+			wantErr:  true,
+		},
+		{
+			name:    "neither",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedRootJSON := modifiedTrustedRoot(t, func(root map[string]interface{}) {
+				publicKey := root["tlogs"].([]interface{})[0].(map[string]interface{})["publicKey"].(map[string]interface{})
+				if tt.validFor == nil {
+					delete(publicKey, "validFor")
+				} else {
+					publicKey["validFor"] = tt.validFor
+				}
+			})
+
+			trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, trustedRoot.RekorLogs(), 1)
+			for _, tlog := range trustedRoot.RekorLogs() {
+				for _, at := range tt.validAt {
+					assert.True(t, tlog.ValidAtTime(at), "should be valid at %s", at)
+				}
+				for _, at := range tt.invalidAt {
+					assert.False(t, tlog.ValidAtTime(at), "should not be valid at %s", at)
+				}
+			}
+		})
+	}
+}
+
+func TestCTLogsFromProtobuf(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	pbTrustedRoot, err := NewTrustedRootProtobuf(trustedrootJSON)
+	assert.NoError(t, err)
+
+	trustedRoot, err := NewTrustedRootFromProtobuf(pbTrustedRoot)
+	assert.NoError(t, err)
+
+	// both the old and the new CTFE key are kept, with their validity
+	// periods
+	ctLogs := trustedRoot.CTLogs()
+	assert.Len(t, ctLogs, len(pbTrustedRoot.GetCtlogs()))
+	for _, pbCTLog := range pbTrustedRoot.GetCtlogs() {
+		ctLog := ctLogs[hex.EncodeToString(pbCTLog.GetLogId().GetKeyId())]
+		if assert.NotNil(t, ctLog) {
+			assert.Equal(t, pbCTLog.GetBaseUrl(), ctLog.BaseURL)
+			assert.Equal(t, pbCTLog.GetPublicKey().GetValidFor().GetStart().AsTime(), ctLog.ValidityPeriodStart)
+			if end := pbCTLog.GetPublicKey().GetValidFor().GetEnd(); end != nil {
+				assert.Equal(t, end.AsTime(), ctLog.ValidityPeriodEnd)
+			} else {
+				assert.True(t, ctLog.ValidityPeriodEnd.IsZero())
+			}
+		}
+	}
+
+	// and the key of each is selected by time
+	oldLogID, err := hex.DecodeString("086092f02852ff6845d1d16b27849c456718ac163dc338d26de6bc2206366f72")
+	assert.NoError(t, err)
+	_, err = trustedRoot.CTLogVerifiersAt(oldLogID, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	_, err = trustedRoot.CTLogVerifiersAt(oldLogID, time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrExpiredTrustMaterial)
+	_, err = trustedRoot.CTLogVerifiersAt([]byte("unknown log"), time.Now())
+	assert.ErrorIs(t, err, ErrUnknownLog)
+}
+
+func TestUnsupportedCTLogKeyType(t *testing.T) {
+	trustedRootJSON := modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		ctlog := root["ctlogs"].([]interface{})[1].(map[string]interface{})
+		ctlog["publicKey"].(map[string]interface{})["keyDetails"] = "PKIX_ED25519_PH"
+	})
+
+	_, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+	assert.ErrorContains(t, err, "CT log: https://ctfe.sigstore.dev/2022: unsupported public key type: PKIX_ED25519_PH")
+}
+
+func TestTransparencyLogKeyTypes(t *testing.T) {
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	p521Key, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name       string
+		keyDetails string
+		publicKey  crypto.PublicKey
+		wantHash   crypto.Hash
+		wantPSS    bool
+		wantErr    string
+	}{
+		{
+			name:       "ECDSA P-256",
+			keyDetails: "PKIX_ECDSA_P256_SHA_256",
+			publicKey:  p256Key.Public(),
+			wantHash:   crypto.SHA256,
+		},
+		{
+			name:       "ECDSA P-384",
+			keyDetails: "PKIX_ECDSA_P384_SHA_384",
+			publicKey:  p384Key.Public(),
+			wantHash:   crypto.SHA384,
+		},
+		{
+			name:       "ECDSA P-521",
+			keyDetails: "PKIX_ECDSA_P521_SHA_512",
+			publicKey:  p521Key.Public(),
+			wantHash:   crypto.SHA512,
+		},
+		{
+			name:       "RSA 2048",
+			keyDetails: "PKIX_RSA_PKCS1V15_2048_SHA256",
+			publicKey:  rsaKey.Public(),
+			wantHash:   crypto.SHA256,
+		},
+		{
+			name:       "RSA-PSS 2048",
+			keyDetails: "PKIX_RSA_PSS_2048_SHA256",
+			publicKey:  rsaKey.Public(),
+			wantHash:   crypto.SHA256,
+			wantPSS:    true,
+		},
+		{
+			name:       "P-256 details with P-384 key",
+			keyDetails: "PKIX_ECDSA_P256_SHA_256",
+			publicKey:  p384Key.Public(),
+			wantErr:    "tlog public key is ECDSA P-384, not PKIX_ECDSA_P256_SHA_256",
+		},
+		{
+			name:       "ECDSA details with RSA key",
+			keyDetails: "PKIX_ECDSA_P384_SHA_384",
+			publicKey:  rsaKey.Public(),
+			wantErr:    "tlog public key is 2048-bit RSA, not PKIX_ECDSA_P384_SHA_384",
+		},
+		{
+			name:       "RSA modulus size mismatch",
+			keyDetails: "PKIX_RSA_PKCS1V15_3072_SHA256",
+			publicKey:  rsaKey.Public(),
+			wantErr:    "tlog public key is 2048-bit RSA, not PKIX_RSA_PKCS1V15_3072_SHA256",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			der, err := x509.MarshalPKIXPublicKey(tt.publicKey)
+			assert.NoError(t, err)
+			logID := sha256.Sum256(der)
+
+			trustedRootJSON := modifiedTrustedRoot(t, func(root map[string]interface{}) {
+				tlog := root["tlogs"].([]interface{})[0].(map[string]interface{})
+				tlog["publicKey"].(map[string]interface{})["rawBytes"] = base64.StdEncoding.EncodeToString(der)
+				tlog["publicKey"].(map[string]interface{})["keyDetails"] = tt.keyDetails
+				tlog["logId"].(map[string]interface{})["keyId"] = base64.StdEncoding.EncodeToString(logID[:])
+			})
+			trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			tlog, ok := trustedRoot.RekorLogs()[hex.EncodeToString(logID[:])]
+			assert.True(t, ok)
+			assert.Equal(t, tt.publicKey, tlog.PublicKey)
+			assert.Equal(t, tt.wantHash, tlog.SignatureHashFunc)
+			assert.Equal(t, tt.wantPSS, tlog.RSAPSS)
+			assert.Equal(t, crypto.SHA256, tlog.HashFunc)
+		})
+	}
+}
+
+func TestTransparencyLogHashAlgorithms(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NoError(t, err)
+	logID := sha256.Sum256(der)
+
+	// a second log, whose Merkle tree hashes with SHA-384, alongside the
+	// SHA-256 one
+	trustedRootJSON := modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		tlogs := root["tlogs"].([]interface{})
+		tlogs = append(tlogs, map[string]interface{}{
+			"baseUrl":       "https://rekor.example.com",
+			"hashAlgorithm": "SHA2_384",
+			"publicKey": map[string]interface{}{
+				"rawBytes":   base64.StdEncoding.EncodeToString(der),
+				"keyDetails": "PKIX_ECDSA_P256_SHA_256",
+				"validFor":   map[string]interface{}{"start": "2021-01-12T11:53:27Z"},
+			},
+			"logId": map[string]interface{}{"keyId": base64.StdEncoding.EncodeToString(logID[:])},
+		})
+		root["tlogs"] = tlogs
+	})
+	trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+
+	rekorLogs := trustedRoot.RekorLogs()
+	assert.Len(t, rekorLogs, 2)
+	for _, tlog := range rekorLogs {
+		if tlog.BaseURL == "https://rekor.example.com" {
+			assert.Equal(t, crypto.SHA384, tlog.HashFunc)
+		} else {
+			assert.Equal(t, crypto.SHA256, tlog.HashFunc)
+		}
+		assert.Equal(t, crypto.SHA256, tlog.SignatureHashFunc)
+	}
+
+	trustedRootJSON = modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		root["tlogs"].([]interface{})[0].(map[string]interface{})["hashAlgorithm"] = "SHA3_256"
+	})
+	_, err = NewTrustedRootFromJSON(trustedRootJSON)
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+	assert.ErrorContains(t, err, "https://rekor.sigstore.dev: unsupported hash function for the tlog: SHA3_256")
+}
+
+func TestEd25519TransparencyLog(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	assert.NoError(t, err)
+	logID := sha256.Sum256(der)
+
+	trustedRootJSON := modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		tlog := root["tlogs"].([]interface{})[0].(map[string]interface{})
+		tlog["publicKey"].(map[string]interface{})["rawBytes"] = base64.StdEncoding.EncodeToString(der)
+		tlog["publicKey"].(map[string]interface{})["keyDetails"] = "PKIX_ED25519"
+		tlog["logId"].(map[string]interface{})["keyId"] = base64.StdEncoding.EncodeToString(logID[:])
+	})
+	trustedRoot, err := NewTrustedRootFromJSON(trustedRootJSON)
+	assert.NoError(t, err)
+
+	tlog, ok := trustedRoot.RekorLogs()[hex.EncodeToString(logID[:])]
+	assert.True(t, ok)
+	assert.Equal(t, publicKey, tlog.PublicKey)
+	assert.NoError(t, tlog.VerifyLogID())
+
+	verifier, err := signature.LoadVerifier(tlog.PublicKey, tlog.SignatureHashFunc)
+	assert.NoError(t, err)
+	message := []byte("checkpoint")
+	assert.NoError(t, verifier.VerifySignature(bytes.NewReader(ed25519.Sign(privateKey, message)), bytes.NewReader(message)))
+
+	// the key must be an Ed25519 key
+	trustedRootJSON = modifiedTrustedRoot(t, func(root map[string]interface{}) {
+		tlog := root["tlogs"].([]interface{})[0].(map[string]interface{})
+		tlog["publicKey"].(map[string]interface{})["keyDetails"] = "PKIX_ED25519"
+	})
+	_, err = NewTrustedRootFromJSON(trustedRootJSON)
+	assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+}
+
+func TestRootPublicKeyMatches(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	trustedRoot, err := NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+
+	for _, ca := range trustedRoot.FulcioCertificateAuthorities() {
+		matches, err := ca.RootPublicKeyMatches(ca.Root.PublicKey)
+		assert.NoError(t, err)
+		assert.True(t, matches)
+	}
+
+	ca := trustedRoot.FulcioCertificateAuthorities()[0]
+	otherKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	matches, err := ca.RootPublicKeyMatches(otherKey.Public())
+	assert.NoError(t, err)
+	assert.False(t, matches)
+
+	// the root's key does not match an intermediate's
+	if len(ca.Intermediates) > 0 {
+		matches, err = ca.RootPublicKeyMatches(ca.Intermediates[0].PublicKey)
+		assert.NoError(t, err)
+		assert.False(t, matches)
+	}
+
+	_, err = ca.RootPublicKeyMatches("not a public key")
+	assert.Error(t, err)
+
+	_, err = (&CertificateAuthority{}).RootPublicKeyMatches(otherKey.Public())
+	assert.Error(t, err)
+}
+
+func TestVerifyLogID(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	assert.NoError(t, err)
+
+	// the staging root has an RSA CT log key in PKCS#1 form
+	for _, rootJSON := range [][]byte{trustedrootJSON, stagingJSON} {
+		trustedRoot, err := NewTrustedRootFromJSON(rootJSON, WithStrictLogIDs())
+		assert.NoError(t, err)
+		for _, tlog := range trustedRoot.RekorLogs() {
+			assert.NoError(t, tlog.VerifyLogID())
+		}
+		for _, ctlog := range trustedRoot.CTLogs() {
+			assert.NoError(t, ctlog.VerifyLogID())
+		}
+	}
+
+	// withLogID returns the trusted root with the ID of its first log of the
+	// given kind replaced
+	withLogID := func(kind string, id []byte) []byte {
+		var root map[string]interface{}
+		assert.NoError(t, json.Unmarshal(trustedrootJSON, &root))
+		log := root[kind].([]interface{})[0].(map[string]interface{})
+		log["logId"].(map[string]interface{})["keyId"] = base64.StdEncoding.EncodeToString(id)
+		modified, err := json.Marshal(root)
+		assert.NoError(t, err)
+		return modified
+	}
+	mismatchedID := make([]byte, 32)
+
+	for _, kind := range []string{"tlogs", "ctlogs"} {
+		t.Run(kind, func(t *testing.T) {
+			rootJSON := withLogID(kind, mismatchedID)
+
+			// the ID is only checked when asked to
+			trustedRoot, err := NewTrustedRootFromJSON(rootJSON)
+			assert.NoError(t, err)
+			logs := trustedRoot.RekorLogs()
+			if kind == "ctlogs" {
+				logs = trustedRoot.CTLogs()
+			}
+			tlog, ok := logs["0000000000000000000000000000000000000000000000000000000000000000"]
+			assert.True(t, ok)
+			assert.ErrorIs(t, tlog.VerifyLogID(), ErrLogIDMismatch)
+
+			// including when the trusted root was parsed before without it
+			_, err = NewTrustedRootFromJSON(rootJSON, WithStrictLogIDs())
+			assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+			assert.ErrorIs(t, err, ErrLogIDMismatch)
+
+			pb, err := NewTrustedRootProtobuf(rootJSON)
+			assert.NoError(t, err)
+			_, err = NewTrustedRootFromProtobuf(pb, WithStrictLogIDs())
+			assert.ErrorIs(t, err, ErrLogIDMismatch)
+		})
+	}
+
+	assert.ErrorIs(t, (&TransparencyLog{PublicKey: "not a public key"}).VerifyLogID(), ErrLogIDMismatch)
+}
+
+// modifiedTrustedRoot returns the public good trusted root as modified by
+// modify.
+func modifiedTrustedRoot(t *testing.T, modify func(root map[string]interface{})) []byte {
+	t.Helper()
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	var root map[string]interface{}
+	assert.NoError(t, json.Unmarshal(trustedrootJSON, &root))
+	modify(root)
+	modified, err := json.Marshal(root)
+	assert.NoError(t, err)
+	return modified
+}
+
+// unrelatedCACertificate returns a CA certificate issued by a key that isn't
+// in any trusted root.
+func unrelatedCACertificate(t *testing.T) []byte {
+	t.Helper()
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	issuer := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "unrelated root"}}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "unrelated intermediate"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+	assert.NoError(t, err)
+	return der
+}
+
+func TestValidate(t *testing.T) {
+	for _, path := range []string{"../../examples/trusted-root-public-good.json", "../testing/data/trusted-root-staging.json"} {
+		rootJSON, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		trustedRoot, err := NewTrustedRootFromJSON(rootJSON)
+		assert.NoError(t, err)
+		assert.NoError(t, trustedRoot.Validate(), path)
+	}
+
+	unrelated := unrelatedCACertificate(t)
+	tests := []struct {
+		name    string
+		modify  func(root map[string]interface{})
+		wantErr string
+	}{
+		{
+			name: "mismatched log ID",
+			modify: func(root map[string]interface{}) {
+				tlog := root["tlogs"].([]interface{})[0].(map[string]interface{})
+				tlog["logId"].(map[string]interface{})["keyId"] = base64.StdEncoding.EncodeToString(make([]byte, 32))
+			},
+			wantErr: "rekor log 0000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name: "log validity period ends before it starts",
+			modify: func(root map[string]interface{}) {
+				ctlog := root["ctlogs"].([]interface{})[0].(map[string]interface{})
+				ctlog["publicKey"].(map[string]interface{})["validFor"].(map[string]interface{})["end"] = "2020-01-01T00:00:00Z"
+			},
+			wantErr: "ct log",
+		},
+		{
+			name: "certificate not issued within the chain",
+			modify: func(root map[string]interface{}) {
+				ca := root["certificateAuthorities"].([]interface{})[1].(map[string]interface{})
+				chain := ca["certChain"].(map[string]interface{})
+				chain["certificates"] = append([]interface{}{map[string]interface{}{"rawBytes": base64.StdEncoding.EncodeToString(unrelated)}}, chain["certificates"].([]interface{})...)
+			},
+			wantErr: `certificate authority 1: certificate "CN=unrelated intermediate" was not issued by another certificate in the chain`,
+		},
+		{
+			name: "empty",
+			modify: func(root map[string]interface{}) {
+				for _, kind := range []string{"tlogs", "ctlogs", "certificateAuthorities", "timestampAuthorities"} {
+					delete(root, kind)
+				}
+			},
+			wantErr: "no transparency logs or certificate authorities",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedRoot, err := NewTrustedRootFromJSON(modifiedTrustedRoot(t, tt.modify))
+			assert.NoError(t, err)
+			err = trustedRoot.Validate()
+			assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestTrustedRootWithPEMCertificates(t *testing.T) {
+	trustedrootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	want, err := NewTrustedRootFromJSON(trustedrootJSON)
+	assert.NoError(t, err)
+
+	// withRawBytes returns the trusted root with the rawBytes of the first
+	// certificate authority's first certificate replaced by f of its DER
+	withRawBytes := func(f func(der []byte) []byte) []byte {
+		var root map[string]interface{}
+		assert.NoError(t, json.Unmarshal(trustedrootJSON, &root))
+		ca := root["certificateAuthorities"].([]interface{})[0].(map[string]interface{})
+		cert := ca["certChain"].(map[string]interface{})["certificates"].([]interface{})[0].(map[string]interface{})
+		der, err := base64.StdEncoding.DecodeString(cert["rawBytes"].(string))
+		assert.NoError(t, err)
+		cert["rawBytes"] = base64.StdEncoding.EncodeToString(f(der))
+		modified, err := json.Marshal(root)
+		assert.NoError(t, err)
+		return modified
+	}
+	pemCertificate := func(der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	for _, test := range []struct {
+		name    string
+		encode  func(der []byte) []byte
+		wantErr bool
+	}{
+		{
+			name:   "PEM certificate",
+			encode: pemCertificate,
+		},
+		{
+			name: "PEM certificate with surrounding whitespace",
+			encode: func(der []byte) []byte {
+				return append(append([]byte("\n"), pemCertificate(der)...), "\n\n"...)
+			},
+		},
+		{
+			name: "PEM block that isn't a certificate",
+			encode: func(der []byte) []byte {
+				return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+			},
+			wantErr: true,
+		},
+		{
+			name: "more than one PEM certificate",
+			encode: func(der []byte) []byte {
+				return append(pemCertificate(der), pemCertificate(der)...)
+			},
+			wantErr: true,
+		},
+		{
+			name: "truncated PEM",
+			encode: func(der []byte) []byte {
+				return pemCertificate(der)[:40]
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			trustedRoot, err := NewTrustedRootFromJSON(withRawBytes(test.encode))
+			if test.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidTrustedRoot)
+				assert.ErrorIs(t, err, ErrPEMEncodedCertificate)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, want.FulcioCertificateAuthorities(), trustedRoot.FulcioCertificateAuthorities())
+		})
+	}
+}
+
+func TestTrustedRootFingerprint(t *testing.T) {
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	assert.NoError(t, err)
+
+	publicGood, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.Len(t, publicGood.Fingerprint(), 64)
+	publicGoodAgain, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, publicGood.Fingerprint(), publicGoodAgain.Fingerprint())
+
+	staging, err := NewTrustedRootFromJSON(stagingJSON)
+	assert.NoError(t, err)
+	assert.NotEqual(t, publicGood.Fingerprint(), staging.Fingerprint())
+
+	// the fingerprint is of the bytes, so the same content in a different
+	// encoding only misses a reuse
+	var rootJSON map[string]interface{}
+	assert.NoError(t, json.Unmarshal(publicGoodJSON, &rootJSON))
+	reencodedJSON, err := json.Marshal(rootJSON)
+	assert.NoError(t, err)
+	reencoded, err := NewTrustedRootFromJSON(reencodedJSON)
+	assert.NoError(t, err)
+	assert.NotEqual(t, publicGood.Fingerprint(), reencoded.Fingerprint())
+	assert.Equal(t, len(publicGood.RekorLogs()), len(reencoded.RekorLogs()))
+
+	// trusted roots built from a protobuf are fingerprinted too
+	pbTrustedRoot, err := NewTrustedRootProtobuf(publicGoodJSON)
+	assert.NoError(t, err)
+	fromProtobuf, err := NewTrustedRootFromProtobuf(pbTrustedRoot)
+	assert.NoError(t, err)
+	assert.Len(t, fromProtobuf.Fingerprint(), 64)
+}
+
+func TestTrustedRootCacheSize(t *testing.T) {
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	assert.NoError(t, err)
+	t.Cleanup(func() { SetTrustedRootCacheSize(DefaultTrustedRootCacheSize) })
+
+	// by default, every caller gets a trusted root of its own
+	first, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	second, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	// with room for both, alternating between them hits the cache
+	SetTrustedRootCacheSize(2)
+	publicGood, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	staging, err := NewTrustedRootFromJSON(stagingJSON)
+	assert.NoError(t, err)
+	publicGoodAgain, err := NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.Same(t, publicGood, publicGoodAgain)
+	stagingAgain, err := NewTrustedRootFromJSON(stagingJSON)
+	assert.NoError(t, err)
+	assert.Same(t, staging, stagingAgain)
+
+	// shrinking evicts the least recently used, here public good
+	SetTrustedRootCacheSize(1)
+	stagingAgain, err = NewTrustedRootFromJSON(stagingJSON)
+	assert.NoError(t, err)
+	assert.Same(t, staging, stagingAgain)
+	publicGoodAgain, err = NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.NotSame(t, publicGood, publicGoodAgain)
+
+	// a cache of size zero parses every time
+	SetTrustedRootCacheSize(0)
+	first, err = NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	second, err = NewTrustedRootFromJSON(publicGoodJSON)
+	assert.NoError(t, err)
+	assert.NotSame(t, publicGoodAgain, first)
+	assert.NotSame(t, first, second)
+	assert.Equal(t, first.Fingerprint(), second.Fingerprint())
+}
+
+func BenchmarkNewTrustedRootFromJSON(b *testing.B) {
+	publicGoodJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	stagingJSON, err := os.ReadFile("../testing/data/trusted-root-staging.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// without the cache, reloading an unchanged trusted root parses it
+	b.Run("unchanged", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewTrustedRootFromJSON(publicGoodJSON); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// with it, reloading an unchanged trusted root only hashes it
+	b.Run("unchanged, cached", func(b *testing.B) {
+		SetTrustedRootCacheSize(1)
+		defer SetTrustedRootCacheSize(DefaultTrustedRootCacheSize)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := NewTrustedRootFromJSON(publicGoodJSON); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// alternating between trusted roots parses every one
+	b.Run("changed", func(b *testing.B) {
+		b.ReportAllocs()
+		roots := [][]byte{publicGoodJSON, stagingJSON}
+		for i := 0; i < b.N; i++ {
+			if _, err := NewTrustedRootFromJSON(roots[i%2]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// with a cache big enough for both, alternating only hashes them
+	b.Run("changed, cached", func(b *testing.B) {
+		SetTrustedRootCacheSize(2)
+		defer SetTrustedRootCacheSize(DefaultTrustedRootCacheSize)
+		b.ReportAllocs()
+		roots := [][]byte{publicGoodJSON, stagingJSON}
+		for i := 0; i < b.N; i++ {
+			if _, err := NewTrustedRootFromJSON(roots[i%2]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// FuzzNewTrustedRootFromJSON checks that parsing an untrusted trusted root,
+// and using the trust material of one that parses, never panics.
+func FuzzNewTrustedRootFromJSON(f *testing.F) {
+	for _, path := range []string{
+		"../../examples/trusted-root-public-good.json",
+		"../testing/data/trusted-root-staging.json",
+	} {
+		contents, err := os.ReadFile(path)
+		assert.NoError(f, err)
+		f.Add(contents)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		trustedRoot, err := NewTrustedRootFromJSON(data)
+		if err != nil {
+			return
+		}
+
+		trustedRoot.AllCertificates()
+		IsStagingTrustedRoot(trustedRoot)
+		for _, ca := range trustedRoot.FulcioCertificateAuthorities() {
+			ca.ValidAtTime(time.Now())
+		}
+		for _, tlog := range trustedRoot.RekorLogs() {
+			_, _ = trustedRoot.TlogVerifiersAt(tlog.ID, time.Now())
+		}
+	})
+}
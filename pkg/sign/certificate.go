@@ -17,6 +17,7 @@ package sign
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
@@ -45,6 +46,9 @@ type FulcioOptions struct {
 	LibraryVersion string
 	// Optional Transport (for dependency injection)
 	Transport http.RoundTripper
+	// Optional HTTP client (for dependency injection); if set, Timeout and
+	// Transport are ignored
+	Client *http.Client
 }
 
 type jsonWebToken struct {
@@ -66,19 +70,47 @@ type publicKey struct {
 }
 
 type fulcioResponse struct {
-	SctCertWithChain signedCertificateEmbeddedSct `json:"signedCertificateEmbeddedSct"`
+	SctCertWithChain signedCertificateEmbeddedSct  `json:"signedCertificateEmbeddedSct"`
+	DetachedSctCert  *signedCertificateDetachedSct `json:"signedCertificateDetachedSct,omitempty"`
 }
 
 type signedCertificateEmbeddedSct struct {
 	Chain chain `json:"chain"`
 }
 
+type signedCertificateDetachedSct struct {
+	Chain                      chain  `json:"chain"`
+	SignedCertificateTimestamp []byte `json:"signedCertificateTimestamp"`
+}
+
 type chain struct {
 	Certificates []string `json:"certificates"`
 }
 
+type fulcioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// SigningCertificate is a code signing certificate issued by Fulcio.
+type SigningCertificate struct {
+	// DER-encoded code signing certificate
+	Certificate []byte
+	// DER-encoded intermediate certificates, ordered from the code signing
+	// certificate towards the root; the root itself is left out
+	Intermediates [][]byte
+	// Signed certificate timestamp, as returned by Fulcio, if it was not
+	// embedded in the certificate
+	DetachedSCT []byte
+}
+
 func NewFulcio(opts *FulcioOptions) *Fulcio {
 	fulcio := &Fulcio{options: opts}
+	if opts.Client != nil {
+		fulcio.client = opts.Client
+		return fulcio
+	}
+
 	fulcio.client = &http.Client{
 		Transport: opts.Transport,
 	}
@@ -95,6 +127,20 @@ func NewFulcio(opts *FulcioOptions) *Fulcio {
 
 // Returns DER-encoded code signing certificate
 func (f *Fulcio) GetCertificate(ctx context.Context, keypair Keypair, identityToken string) ([]byte, error) {
+	signingCert, err := f.GetSigningCertificate(ctx, keypair, identityToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return signingCert.Certificate, nil
+}
+
+// GetSigningCertificate requests a code signing certificate for the keypair
+// from Fulcio, returning it along with its intermediate certificates.
+//
+// Both the JSON response, with the SCT either embedded in the certificate or
+// detached from it, and a PEM-encoded certificate chain are accepted.
+func (f *Fulcio) GetSigningCertificate(ctx context.Context, keypair Keypair, identityToken string) (*SigningCertificate, error) {
 	// Get JWT from identity token
 	//
 	// Note that the contents of this token are untrusted. Fulcio will perform
@@ -150,7 +196,7 @@ func (f *Fulcio) GetCertificate(ctx context.Context, keypair Keypair, identityTo
 	var response *http.Response
 
 	for attempts <= f.options.Retries {
-		request, err := http.NewRequest("POST", f.options.BaseURL+"/api/v2/signingCert", bytes.NewBuffer(requestJSON))
+		request, err := http.NewRequestWithContext(ctx, "POST", f.options.BaseURL+"/api/v2/signingCert", bytes.NewBuffer(requestJSON))
 		if err != nil {
 			return nil, err
 		}
@@ -168,6 +214,12 @@ func (f *Fulcio) GetCertificate(ctx context.Context, keypair Keypair, identityTo
 			break
 		}
 
+		if attempts == f.options.Retries {
+			// Out of retries, so keep the response body for the error
+			break
+		}
+		response.Body.Close()
+
 		delay := time.Duration(math.Pow(2, float64(attempts)))
 		timer := time.NewTimer(delay * time.Second)
 		select {
@@ -179,31 +231,119 @@ func (f *Fulcio) GetCertificate(ctx context.Context, keypair Keypair, identityTo
 		attempts++
 	}
 
+	defer response.Body.Close()
+
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("Fulcio returned %d: %s", response.StatusCode, string(body))
+	// Fulcio answers with 201 Created, but accept any successful status
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("Fulcio returned %d %s: %s", response.StatusCode, http.StatusText(response.StatusCode), fulcioErrorMessage(body))
+	}
+
+	return parseFulcioResponse(body, response.Header.Get("SCT"))
+}
+
+// parseFulcioResponse reads the certificate chain from a Fulcio response
+// body, which is either JSON or a PEM-encoded certificate chain. The latter
+// may come with a detached SCT in the SCT header, base64 encoded.
+func parseFulcioResponse(body []byte, sctHeader string) (*SigningCertificate, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("-----BEGIN")) {
+		signingCert, err := parseCertificateChain([]string{string(body)})
+		if err != nil {
+			return nil, err
+		}
+
+		if sctHeader != "" {
+			signingCert.DetachedSCT, err = base64.StdEncoding.DecodeString(sctHeader)
+			if err != nil {
+				return nil, fmt.Errorf("unable to decode Fulcio SCT header: %w", err)
+			}
+		}
+
+		return signingCert, nil
 	}
 
-	// Assemble bundle from Fulcio response
 	var fulcioResp fulcioResponse
-	err = json.Unmarshal(body, &fulcioResp)
+	err := json.Unmarshal(body, &fulcioResp)
 	if err != nil {
 		return nil, err
 	}
 
-	certs := fulcioResp.SctCertWithChain.Chain.Certificates
+	if fulcioResp.DetachedSctCert != nil {
+		signingCert, err := parseCertificateChain(fulcioResp.DetachedSctCert.Chain.Certificates)
+		if err != nil {
+			return nil, err
+		}
+		if len(fulcioResp.DetachedSctCert.SignedCertificateTimestamp) == 0 {
+			return nil, errors.New("Fulcio returned a detached SCT response without an SCT")
+		}
+		signingCert.DetachedSCT = fulcioResp.DetachedSctCert.SignedCertificateTimestamp
+
+		return signingCert, nil
+	}
+
+	return parseCertificateChain(fulcioResp.SctCertWithChain.Chain.Certificates)
+}
+
+// parseCertificateChain decodes a chain of PEM-encoded certificates, leaf
+// first. Each string may hold one or more certificates.
+func parseCertificateChain(pemCerts []string) (*SigningCertificate, error) {
+	var certs []*x509.Certificate
+	for _, pemCert := range pemCerts {
+		rest := []byte(pemCert)
+		for {
+			var certBlock *pem.Block
+			certBlock, rest = pem.Decode(rest)
+			if certBlock == nil {
+				break
+			}
+			if certBlock.Type != "CERTIFICATE" {
+				return nil, fmt.Errorf("unexpected PEM block type %q in Fulcio response", certBlock.Type)
+			}
+			cert, err := x509.ParseCertificate(certBlock.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse Fulcio certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+		if len(bytes.TrimSpace(rest)) != 0 {
+			return nil, errors.New("unable to parse Fulcio certificate")
+		}
+	}
+
 	if len(certs) == 0 {
 		return nil, errors.New("Fulcio returned no certificates")
 	}
 
-	certBlock, _ := pem.Decode([]byte(certs[0]))
-	if certBlock == nil {
-		return nil, errors.New("unable to parse Fulcio certificate")
+	signingCert := &SigningCertificate{Certificate: certs[0].Raw}
+	for _, cert := range certs[1:] {
+		if isSelfSigned(cert) {
+			continue
+		}
+		signingCert.Intermediates = append(signingCert.Intermediates, cert.Raw)
+	}
+
+	return signingCert, nil
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// fulcioErrorMessage returns the message of a Fulcio error response, or the
+// response body if it is not one.
+func fulcioErrorMessage(body []byte) string {
+	var fulcioErr fulcioError
+	if err := json.Unmarshal(body, &fulcioErr); err == nil && fulcioErr.Message != "" {
+		return fulcioErr.Message
 	}
 
-	return certBlock.Bytes, nil
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		return "empty response body"
+	}
+	return message
 }
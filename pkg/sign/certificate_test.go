@@ -17,14 +17,22 @@ package sign
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 )
@@ -134,3 +142,227 @@ func Test_GetCertificate(t *testing.T) {
 	assert.Nil(t, cert)
 	assert.NotNil(t, err)
 }
+
+func certificatePEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: cert.Raw,
+	}))
+}
+
+func Test_GetSigningCertificateResponses(t *testing.T) {
+	virtualSigstoreOnce.Do(setupVirtualSigstore)
+	assert.NoError(t, virtualSigstoreErr)
+
+	leafCert, _, err := virtualSigstore.GenerateLeafCert("identity", "issuer")
+	assert.NoError(t, err)
+
+	fulcioCA := virtualSigstore.FulcioCertificateAuthorities()[0]
+	chainPEMs := []string{certificatePEM(leafCert), certificatePEM(fulcioCA.Intermediates[0]), certificatePEM(fulcioCA.Root)}
+	sct := []byte(`{"sct_version":0,"id":"aWQ=","timestamp":1,"extensions":"","signature":"c2ln"}`)
+
+	embeddedJSON, err := json.Marshal(map[string]any{
+		"signedCertificateEmbeddedSct": map[string]any{
+			"chain": map[string]any{"certificates": chainPEMs},
+		},
+	})
+	assert.NoError(t, err)
+
+	detachedJSON, err := json.Marshal(map[string]any{
+		"signedCertificateDetachedSct": map[string]any{
+			"chain":                      map[string]any{"certificates": chainPEMs},
+			"signedCertificateTimestamp": base64.StdEncoding.EncodeToString(sct),
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		status      int
+		header      http.Header
+		body        string
+		wantSCT     []byte
+		wantErr     bool
+		wantErrText string
+	}{
+		{
+			name:   "embedded SCT",
+			status: http.StatusCreated,
+			body:   string(embeddedJSON),
+		},
+		{
+			name:    "detached SCT",
+			status:  http.StatusCreated,
+			body:    string(detachedJSON),
+			wantSCT: sct,
+		},
+		{
+			name:    "PEM chain with SCT header",
+			status:  http.StatusCreated,
+			header:  http.Header{"Content-Type": {"application/pem-certificate-chain"}, "SCT": {base64.StdEncoding.EncodeToString(sct)}},
+			body:    strings.Join(chainPEMs, ""),
+			wantSCT: sct,
+		},
+		{
+			name:   "200 OK",
+			status: http.StatusOK,
+			body:   string(embeddedJSON),
+		},
+		{
+			name:   "PEM chain without SCT header",
+			status: http.StatusCreated,
+			header: http.Header{"Content-Type": {"application/pem-certificate-chain"}},
+			body:   strings.Join(chainPEMs, ""),
+		},
+		{
+			name:    "no certificates",
+			status:  http.StatusCreated,
+			body:    `{"signedCertificateEmbeddedSct":{"chain":{"certificates":[]}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "detached SCT response without SCT",
+			status:  http.StatusCreated,
+			body:    `{"signedCertificateDetachedSct":{"chain":{"certificates":["` + strings.ReplaceAll(chainPEMs[0], "\n", "\\n") + `"]}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed certificate",
+			status:  http.StatusCreated,
+			body:    "-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydGlmaWNhdGU=\n-----END CERTIFICATE-----\n",
+			wantErr: true,
+		},
+		{
+			name:        "API error",
+			status:      http.StatusBadRequest,
+			body:        `{"code":3,"message":"There was an error processing the identity token","details":[]}`,
+			wantErr:     true,
+			wantErrText: "Fulcio returned 400 Bad Request: There was an error processing the identity token",
+		},
+		{
+			name:        "plain text error",
+			status:      http.StatusUnauthorized,
+			body:        "invalid bearer token\n",
+			wantErr:     true,
+			wantErrText: "Fulcio returned 401 Unauthorized: invalid bearer token",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v2/signingCert", r.URL.Path)
+				for key, values := range tt.header {
+					w.Header()[key] = values
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			fulcio := NewFulcio(&FulcioOptions{BaseURL: server.URL, Client: server.Client()})
+			keypair, err := NewEphemeralKeypair(nil)
+			assert.NoError(t, err)
+
+			signingCert, err := fulcio.GetSigningCertificate(context.TODO(), keypair, "idtoken.eyJzdWIiOiJzdWJqZWN0In0K.stuff")
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantErrText != "" {
+					assert.EqualError(t, err, tt.wantErrText)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, leafCert.Raw, signingCert.Certificate)
+			// The self-signed root is not an intermediate
+			assert.Equal(t, [][]byte{fulcioCA.Intermediates[0].Raw}, signingCert.Intermediates)
+			assert.Equal(t, tt.wantSCT, signingCert.DetachedSCT)
+		})
+	}
+}
+
+func Test_GetSigningCertificateFromVirtualFulcio(t *testing.T) {
+	virtualSigstoreOnce.Do(setupVirtualSigstore)
+	assert.NoError(t, virtualSigstoreErr)
+
+	fulcioCA := virtualSigstore.FulcioCertificateAuthorities()[0]
+
+	// A fake Fulcio, checking the proof of possession and issuing the
+	// certificate from the virtual CA
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer idtoken.eyJzdWIiOiJzdWJqZWN0In0K.stuff" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":16,"message":"There was an error processing the identity token"}`))
+			return
+		}
+
+		var certRequest fulcioCertRequest
+		if err := json.NewDecoder(r.Body).Decode(&certRequest); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		pub, err := cryptoutils.UnmarshalPEMToPublicKey([]byte(certRequest.PublicKeyRequest.PublicKey.Content))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		proof, err := base64.StdEncoding.DecodeString(certRequest.PublicKeyRequest.ProofOfPossession)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		digest := sha256.Sum256([]byte("subject"))
+		ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+		if !ok || !ecdsa.VerifyASN1(ecdsaPub, digest[:], proof) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":3,"message":"The signature supplied in the request could not be verified"}`))
+			return
+		}
+
+		leafCert, err := virtualSigstore.IssueLeafCert("subject", "issuer", pub)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		responseStruct := fulcioResponse{
+			SctCertWithChain: signedCertificateEmbeddedSct{
+				Chain: chain{
+					Certificates: []string{certificatePEM(leafCert), certificatePEM(fulcioCA.Intermediates[0]), certificatePEM(fulcioCA.Root)},
+				},
+			},
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(responseStruct)
+	}))
+	defer server.Close()
+
+	fulcio := NewFulcio(&FulcioOptions{BaseURL: server.URL, Client: server.Client()})
+	keypair, err := NewEphemeralKeypair(nil)
+	assert.NoError(t, err)
+
+	signingCert, err := fulcio.GetSigningCertificate(context.TODO(), keypair, "idtoken.eyJzdWIiOiJzdWJqZWN0In0K.stuff")
+	assert.NoError(t, err)
+
+	leafCert, err := x509.ParseCertificate(signingCert.Certificate)
+	assert.NoError(t, err)
+	assert.True(t, keypair.privateKey.PublicKey.Equal(leafCert.PublicKey))
+
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range signingCert.Intermediates {
+		cert, err := x509.ParseCertificate(intermediate)
+		assert.NoError(t, err)
+		intermediates.AddCert(cert)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(fulcioCA.Root)
+	_, err = leafCert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   leafCert.NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	assert.NoError(t, err)
+
+	// Error: Fulcio rejects the identity token
+	_, err = fulcio.GetSigningCertificate(context.TODO(), keypair, "idtoken.eyJzdWIiOiJvdGhlciJ9.stuff")
+	assert.EqualError(t, err, "Fulcio returned 401 Unauthorized: There was an error processing the identity token")
+}
@@ -18,9 +18,14 @@ import (
 	"bytes"
 	"context"
 	"crypto"
-	"crypto/sha256"
+	"crypto/rand"
+	_ "crypto/sha256" // register hash functions for the message imprint
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"time"
 
 	"github.com/digitorus/timestamp"
@@ -43,6 +48,9 @@ type TimestampAuthorityOptions struct {
 	LibraryVersion string
 	// Optional client (for dependency injection)
 	Client TSAClient
+	// Optional hash algorithm for the message imprint: SHA-256 (default),
+	// SHA-384 or SHA-512
+	Hash crypto.Hash
 }
 
 type TimestampAuthority struct {
@@ -54,12 +62,29 @@ func NewTimestampAuthority(opts *TimestampAuthorityOptions) *TimestampAuthority
 }
 
 func (ta *TimestampAuthority) GetTimestamp(ctx context.Context, signature []byte) ([]byte, error) {
-	signatureHash := sha256.Sum256(signature)
+	hash := ta.options.Hash
+	switch hash {
+	case 0:
+		hash = crypto.SHA256
+	case crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return nil, fmt.Errorf("unsupported timestamp message imprint hash algorithm: %s", hash)
+	}
+
+	hasher := hash.New()
+	hasher.Write(signature)
+	signatureHash := hasher.Sum(nil)
+
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
 
 	req := &timestamp.Request{
 		Certificates:  true,
-		HashAlgorithm: crypto.SHA256,
-		HashedMessage: signatureHash[:],
+		HashAlgorithm: hash,
+		HashedMessage: signatureHash,
+		Nonce:         nonce,
 	}
 	reqBytes, err := req.Marshal()
 	if err != nil {
@@ -108,11 +133,19 @@ func (ta *TimestampAuthority) GetTimestamp(ctx context.Context, signature []byte
 		return nil, err
 	}
 
-	_, err = timestamp.ParseResponse(respBytes.Bytes())
+	ts, err := timestamp.ParseResponse(respBytes.Bytes())
 	if err != nil {
 		return nil, err
 	}
 
+	// Ensure the response answers our request and was not replayed
+	if ts.Nonce == nil || ts.Nonce.Cmp(nonce) != 0 {
+		return nil, errors.New("timestamp response nonce does not match request")
+	}
+	if ts.HashAlgorithm != hash || !bytes.Equal(ts.HashedMessage, signatureHash) {
+		return nil, errors.New("timestamp response message imprint does not match request")
+	}
+
 	return respBytes.Bytes(), nil
 }
 
@@ -16,10 +16,12 @@ package sign
 
 import (
 	"context"
+	"crypto"
 	"errors"
 	"io"
 	"testing"
 
+	"github.com/digitorus/timestamp"
 	tsagenclient "github.com/sigstore/timestamp-authority/pkg/generated/client/timestamp"
 	"github.com/stretchr/testify/assert"
 )
@@ -35,7 +37,7 @@ func getTSAResponse(params *tsagenclient.GetTimestampResponseParams, writer io.W
 		return nil, err
 	}
 
-	tsBytes, err := virtualSigstore.TimestampResponse(req)
+	tsBytes, err := virtualSigstore.TimestampResponseForRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -90,3 +92,82 @@ func Test_GetTimestamp(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.NotNil(t, err)
 }
+
+type replayTSA struct {
+	response []byte
+}
+
+func (r *replayTSA) GetTimestampResponse(_ *tsagenclient.GetTimestampResponseParams, writer io.Writer, _ ...tsagenclient.ClientOption) (*tsagenclient.GetTimestampResponseCreated, error) {
+	_, err := writer.Write(r.response)
+	return nil, err
+}
+
+func Test_GetTimestampHashAlgorithms(t *testing.T) {
+	ctx := context.TODO()
+	signature := []byte("somestuff")
+
+	for _, test := range []struct {
+		name     string
+		hash     crypto.Hash
+		wantHash crypto.Hash
+		wantErr  bool
+	}{
+		{
+			name:     "default",
+			wantHash: crypto.SHA256,
+		},
+		{
+			name:     "SHA-256",
+			hash:     crypto.SHA256,
+			wantHash: crypto.SHA256,
+		},
+		{
+			name:     "SHA-384",
+			hash:     crypto.SHA384,
+			wantHash: crypto.SHA384,
+		},
+		{
+			name:     "SHA-512",
+			hash:     crypto.SHA512,
+			wantHash: crypto.SHA512,
+		},
+		{
+			name:    "SHA-1",
+			hash:    crypto.SHA1,
+			wantErr: true,
+		},
+		{
+			name:    "MD5",
+			hash:    crypto.MD5,
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tsa := NewTimestampAuthority(&TimestampAuthorityOptions{Retries: 1, Client: &mockTSAClient{}, Hash: test.hash})
+			resp, err := tsa.GetTimestamp(ctx, signature)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			ts, err := timestamp.ParseResponse(resp)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantHash, ts.HashAlgorithm)
+		})
+	}
+}
+
+func Test_GetTimestampNonceMismatch(t *testing.T) {
+	ctx := context.TODO()
+	signature := []byte("somestuff")
+
+	tsa := NewTimestampAuthority(&TimestampAuthorityOptions{Retries: 1, Client: &mockTSAClient{}})
+	resp, err := tsa.GetTimestamp(ctx, signature)
+	assert.NoError(t, err)
+
+	// a response to an earlier request carries a different nonce
+	replayed := NewTimestampAuthority(&TimestampAuthorityOptions{Retries: 1, Client: &replayTSA{response: resp}})
+	_, err = replayed.GetTimestamp(ctx, signature)
+	assert.ErrorContains(t, err, "nonce")
+}
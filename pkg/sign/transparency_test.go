@@ -30,6 +30,9 @@ import (
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
 	"github.com/stretchr/testify/assert"
+
+	// mockRekor returns intoto entries
+	_ "github.com/sigstore/rekor/pkg/types/intoto/v0.0.2"
 )
 
 var envelopeBody []byte
@@ -19,8 +19,11 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec
 	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -28,14 +31,22 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
 	"time"
 
 	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/digitorus/pkcs7"
 	"github.com/digitorus/timestamp"
 	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/sigstore/rekor/pkg/generated/models"
 	"github.com/sigstore/rekor/pkg/pki"
@@ -43,14 +54,18 @@ import (
 	"github.com/sigstore/rekor/pkg/types/hashedrekord"
 	"github.com/sigstore/rekor/pkg/types/intoto"
 	"github.com/sigstore/rekor/pkg/types/rekord"
+	rekorutil "github.com/sigstore/rekor/pkg/util"
 	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/tlog"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/sigstore/sigstore/pkg/cryptoutils"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
+	"github.com/sigstore/sigstore/pkg/signature/options"
 	tsx509 "github.com/sigstore/timestamp-authority/pkg/x509"
+	"github.com/transparency-dev/merkle/rfc6962"
 )
 
 type VirtualSigstore struct {
@@ -58,13 +73,151 @@ type VirtualSigstore struct {
 	fulcioIntermediateKey *ecdsa.PrivateKey
 	tsaCA                 root.CertificateAuthority
 	tsaLeafKey            *ecdsa.PrivateKey
-	rekorKey              *ecdsa.PrivateKey
+	rekorKey              crypto.Signer
+	rekorRSAPSS           bool
 	ctlogKey              *ecdsa.PrivateKey
+	leafKeyCurve          elliptic.Curve
 	publicKeyVerifier     map[string]root.TimeConstrainedVerifier
 }
 
+// VirtualSigstoreOption customizes the keys of a VirtualSigstore, e.g. to
+// produce one that uses algorithms a verifier should reject.
+type VirtualSigstoreOption func(*virtualSigstoreOptions)
+
+type virtualSigstoreOptions struct {
+	leafKeyCurve    elliptic.Curve
+	tsaLeafKeyCurve elliptic.Curve
+	rekorKeyCurve   elliptic.Curve
+	rekorEd25519    bool
+	rekorRSABits    int
+	rekorRSAPSS     bool
+	tsaLeafOptions  []TSALeafOption
+}
+
+// WithLeafKeyCurve sets the curve of the keys that signing certificates are
+// issued for. The default is P-256.
+func WithLeafKeyCurve(curve elliptic.Curve) VirtualSigstoreOption {
+	return func(o *virtualSigstoreOptions) {
+		o.leafKeyCurve = curve
+	}
+}
+
+// WithTSALeafKeyCurve sets the curve of the TSA's signing key. The default
+// is P-256.
+func WithTSALeafKeyCurve(curve elliptic.Curve) VirtualSigstoreOption {
+	return func(o *virtualSigstoreOptions) {
+		o.tsaLeafKeyCurve = curve
+	}
+}
+
+// WithRekorKeyCurve sets the curve of the transparency log's key. The
+// default is P-256.
+func WithRekorKeyCurve(curve elliptic.Curve) VirtualSigstoreOption {
+	return func(o *virtualSigstoreOptions) {
+		o.rekorKeyCurve = curve
+	}
+}
+
+// WithEd25519RekorKey gives the transparency log an Ed25519 key, as some
+// private Rekor deployments use, rather than an ECDSA key.
+func WithEd25519RekorKey() VirtualSigstoreOption {
+	return func(o *virtualSigstoreOptions) {
+		o.rekorEd25519 = true
+	}
+}
+
+// WithRSARekorKey gives the transparency log an RSA key of the given size,
+// which signs with RSASSA-PSS if pss is set and PKCS #1 v1.5 otherwise.
+func WithRSARekorKey(bits int, pss bool) VirtualSigstoreOption {
+	return func(o *virtualSigstoreOptions) {
+		o.rekorRSABits = bits
+		o.rekorRSAPSS = pss
+	}
+}
+
+// TSALeafOption customizes the TSA leaf certificate minted by
+// GenerateTSALeafCert, e.g. to produce a non-conforming certificate.
+type TSALeafOption func(*x509.Certificate)
+
+// WithTSALeafIsCA marks the TSA leaf certificate as a CA.
+func WithTSALeafIsCA() TSALeafOption {
+	return func(template *x509.Certificate) {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	}
+}
+
+// WithoutTSALeafEKU omits the extended key usage extension from the TSA leaf
+// certificate.
+func WithoutTSALeafEKU() TSALeafOption {
+	return func(template *x509.Certificate) {
+		template.ExtKeyUsage = nil
+		template.ExtraExtensions = nil
+	}
+}
+
+// WithNonCriticalTSALeafEKU marks the TSA leaf certificate's extended key
+// usage extension as non-critical.
+func WithNonCriticalTSALeafEKU() TSALeafOption {
+	return func(template *x509.Certificate) {
+		for i := range template.ExtraExtensions {
+			template.ExtraExtensions[i].Critical = false
+		}
+	}
+}
+
+// WithTSALeafEKU sets the key purposes in the TSA leaf certificate's critical
+// extended key usage extension.
+func WithTSALeafEKU(keyPurposes ...asn1.ObjectIdentifier) TSALeafOption {
+	return func(template *x509.Certificate) {
+		ekuExt, err := asn1.Marshal(keyPurposes)
+		if err != nil {
+			panic(err)
+		}
+		template.ExtKeyUsage = nil
+		template.ExtraExtensions = []pkix.Extension{
+			{
+				Id:       asn1.ObjectIdentifier{2, 5, 29, 37},
+				Critical: true,
+				Value:    ekuExt,
+			},
+		}
+	}
+}
+
+// WithTSALeafValidity sets the TSA leaf certificate's validity period.
+func WithTSALeafValidity(notBefore, notAfter time.Time) TSALeafOption {
+	return func(template *x509.Certificate) {
+		template.NotBefore = notBefore
+		template.NotAfter = notAfter
+	}
+}
+
 func NewVirtualSigstore() (*VirtualSigstore, error) {
-	ss := &VirtualSigstore{fulcioCA: root.CertificateAuthority{}, tsaCA: root.CertificateAuthority{}}
+	return NewVirtualSigstoreWithOptions()
+}
+
+// NewVirtualSigstoreWithTSALeafOptions returns a VirtualSigstore whose TSA
+// leaf certificate is customized by the given options.
+func NewVirtualSigstoreWithTSALeafOptions(opts ...TSALeafOption) (*VirtualSigstore, error) {
+	return NewVirtualSigstoreWithOptions(func(o *virtualSigstoreOptions) {
+		o.tsaLeafOptions = opts
+	})
+}
+
+// NewVirtualSigstoreWithOptions returns a VirtualSigstore whose keys are
+// customized by the given options.
+func NewVirtualSigstoreWithOptions(opts ...VirtualSigstoreOption) (*VirtualSigstore, error) {
+	o := virtualSigstoreOptions{
+		leafKeyCurve:    elliptic.P256(),
+		tsaLeafKeyCurve: elliptic.P256(),
+		rekorKeyCurve:   elliptic.P256(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	ss := &VirtualSigstore{fulcioCA: root.CertificateAuthority{}, tsaCA: root.CertificateAuthority{}, leafKeyCurve: o.leafKeyCurve}
 
 	rootCert, rootKey, err := GenerateRootCa()
 	if err != nil {
@@ -82,11 +235,11 @@ func NewVirtualSigstore() (*VirtualSigstore, error) {
 		return nil, err
 	}
 	ss.tsaCA.Intermediates = []*x509.Certificate{tsaIntermediateCert}
-	tsaLeafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tsaLeafKey, err := ecdsa.GenerateKey(o.tsaLeafKeyCurve, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
-	tsaLeafCert, err := GenerateTSALeafCert(time.Now().Add(-5*time.Minute), tsaLeafKey, tsaIntermediateCert, tsaIntermediateKey)
+	tsaLeafCert, err := GenerateTSALeafCert(time.Now().Add(-5*time.Minute), tsaLeafKey, tsaIntermediateCert, tsaIntermediateKey, o.tsaLeafOptions...)
 	if err != nil {
 		return nil, err
 	}
@@ -98,7 +251,15 @@ func NewVirtualSigstore() (*VirtualSigstore, error) {
 	ss.tsaCA.ValidityPeriodStart = time.Now().Add(-5 * time.Hour)
 	ss.tsaCA.ValidityPeriodEnd = time.Now().Add(time.Hour)
 
-	ss.rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	switch {
+	case o.rekorEd25519:
+		_, ss.rekorKey, err = ed25519.GenerateKey(rand.Reader)
+	case o.rekorRSABits > 0:
+		ss.rekorKey, err = rsa.GenerateKey(rand.Reader, o.rekorRSABits)
+		ss.rekorRSAPSS = o.rekorRSAPSS
+	default:
+		ss.rekorKey, err = ecdsa.GenerateKey(o.rekorKeyCurve, rand.Reader)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +291,12 @@ func (ca *VirtualSigstore) rekorSignPayload(payload tlog.RekorPayload) ([]byte,
 	if err != nil {
 		return nil, err
 	}
-	signer, err := signature.LoadECDSASignerVerifier(ca.rekorKey, crypto.SHA256)
+	var signer signature.SignerVerifier
+	if ca.rekorRSAPSS {
+		signer, err = signature.LoadRSAPSSSignerVerifier(ca.rekorKey.(*rsa.PrivateKey), crypto.SHA256, nil)
+	} else {
+		signer, err = signature.LoadSignerVerifier(ca.rekorKey, crypto.SHA256)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -142,17 +308,23 @@ func (ca *VirtualSigstore) rekorSignPayload(payload tlog.RekorPayload) ([]byte,
 }
 
 func (ca *VirtualSigstore) GenerateLeafCert(identity, issuer string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
-	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	privKey, err := ecdsa.GenerateKey(ca.leafKeyCurve, rand.Reader)
 	if err != nil {
 		return nil, nil, err
 	}
-	leafCert, err := GenerateLeafCert(identity, issuer, time.Now(), privKey, ca.fulcioCA.Intermediates[0], ca.fulcioIntermediateKey)
+	leafCert, err := GenerateLeafCertWithSCT(identity, issuer, time.Now(), privKey, ca.fulcioCA.Intermediates[0], ca.fulcioIntermediateKey, ca.ctlogKey)
 	if err != nil {
 		return nil, nil, err
 	}
 	return leafCert, privKey, nil
 }
 
+// IssueLeafCert issues a leaf certificate for the given public key, as
+// Fulcio does in response to a signing certificate request.
+func (ca *VirtualSigstore) IssueLeafCert(identity, issuer string, pub crypto.PublicKey) (*x509.Certificate, error) {
+	return generateLeafCertWithSCT(identity, issuer, time.Now(), pub, ca.fulcioCA.Intermediates[0], ca.fulcioIntermediateKey, ca.ctlogKey)
+}
+
 func (ca *VirtualSigstore) Attest(identity, issuer string, envelopeBody []byte) (*TestEntity, error) {
 	// The timing here is important. We need to attest at a time when the leaf
 	// certificate is valid, so we match what GenerateLeafCert() does, above
@@ -160,6 +332,16 @@ func (ca *VirtualSigstore) Attest(identity, issuer string, envelopeBody []byte)
 }
 
 func (ca *VirtualSigstore) AttestAtTime(identity, issuer string, envelopeBody []byte, integratedTime time.Time) (*TestEntity, error) {
+	return ca.attest(identity, issuer, "application/vnd.in-toto+json", envelopeBody, integratedTime)
+}
+
+// AttestWithPayloadType is like Attest, but signs the envelope body with the
+// given DSSE payload type instead of the in-toto type.
+func (ca *VirtualSigstore) AttestWithPayloadType(identity, issuer, payloadType string, envelopeBody []byte) (*TestEntity, error) {
+	return ca.attest(identity, issuer, payloadType, envelopeBody, time.Now().Add(5*time.Minute))
+}
+
+func (ca *VirtualSigstore) attest(identity, issuer, payloadType string, envelopeBody []byte, integratedTime time.Time) (*TestEntity, error) {
 	leafCert, leafPrivKey, err := ca.GenerateLeafCert(identity, issuer)
 	if err != nil {
 		return nil, err
@@ -178,7 +360,7 @@ func (ca *VirtualSigstore) AttestAtTime(identity, issuer string, envelopeBody []
 		return nil, err
 	}
 
-	envelope, err := dsseSigner.SignPayload(context.TODO(), "application/vnd.in-toto+json", envelopeBody)
+	envelope, err := dsseSigner.SignPayload(context.TODO(), payloadType, envelopeBody)
 	if err != nil {
 		return nil, err
 	}
@@ -188,7 +370,7 @@ func (ca *VirtualSigstore) AttestAtTime(identity, issuer string, envelopeBody []
 		return nil, err
 	}
 
-	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey)
+	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, 0, time.Now(), 0)
 	if err != nil {
 		return nil, err
 	}
@@ -211,93 +393,188 @@ func (ca *VirtualSigstore) Sign(identity, issuer string, artifact []byte) (*Test
 }
 
 func (ca *VirtualSigstore) SignAtTime(identity, issuer string, artifact []byte, integratedTime time.Time) (*TestEntity, error) {
-	leafCert, leafPrivKey, err := ca.GenerateLeafCert(identity, issuer)
+	return ca.signAtTime(identity, issuer, artifact, integratedTime, false, nil)
+}
+
+// SignWithLoggedPublicKey signs the artifact like Sign, but the hashedrekord
+// entry records a public key instead of the signing certificate. If
+// loggedSigner is nil, the signing certificate's public key is recorded.
+// Otherwise the artifact is signed with loggedSigner, whose public key is
+// recorded, as if signed with a key other than the certificate's.
+func (ca *VirtualSigstore) SignWithLoggedPublicKey(identity, issuer string, artifact []byte, loggedSigner crypto.Signer) (*TestEntity, error) {
+	return ca.signAtTime(identity, issuer, artifact, time.Now().Add(5*time.Minute), true, loggedSigner)
+}
+
+// SignWithBYOCertificate signs the artifact like Sign, but with a year-long
+// certificate as an enterprise CA would issue: identified by its Subject CN
+// and, if email is not empty, an email Subject Alternative Name, and without
+// Fulcio extensions or an SCT.
+func (ca *VirtualSigstore) SignWithBYOCertificate(commonName, email string, artifact []byte) (*TestEntity, error) {
+	leafPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, err
 	}
-
-	signer, err := signature.LoadECDSASignerVerifier(leafPrivKey, crypto.SHA256)
+	leafCert, err := createCertificate(byoLeafCertTemplate(commonName, email, time.Now().Add(-time.Hour)), ca.fulcioCA.Intermediates[0], leafPrivKey.Public(), ca.fulcioIntermediateKey)
 	if err != nil {
 		return nil, err
 	}
+	return ca.signWithLeafCert(leafCert, leafPrivKey, artifact, time.Now().Add(5*time.Minute), false, nil)
+}
 
-	digest := sha256.Sum256(artifact)
-	sig, err := signer.SignMessage(bytes.NewReader(artifact))
+// SignWithExternalCertificate signs the artifact like SignWithBYOCertificate,
+// but with a certificate issued by issuer rather than the virtual Sigstore's
+// Fulcio CA, e.g. a corporate CA for hardware tokens. The signature is still
+// logged and timestamped by the virtual Sigstore.
+func (ca *VirtualSigstore) SignWithExternalCertificate(commonName, email string, issuer *x509.Certificate, issuerKey crypto.Signer, artifact []byte) (*TestEntity, error) {
+	leafPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
 		return nil, err
 	}
-
-	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey)
+	leafCert, err := createCertificate(byoLeafCertTemplate(commonName, email, time.Now().Add(-time.Hour)), issuer, leafPrivKey.Public(), issuerKey)
 	if err != nil {
 		return nil, err
 	}
+	return ca.signWithLeafCert(leafCert, leafPrivKey, artifact, time.Now().Add(5*time.Minute), false, nil)
+}
 
-	entry, err := ca.generateTlogEntryHashedRekord(leafCert, artifact, sig, integratedTime.Unix())
+func (ca *VirtualSigstore) signAtTime(identity, issuer string, artifact []byte, integratedTime time.Time, logPublicKey bool, loggedSigner crypto.Signer) (*TestEntity, error) {
+	leafCert, leafPrivKey, err := ca.GenerateLeafCert(identity, issuer)
 	if err != nil {
 		return nil, err
 	}
-
-	return &TestEntity{
-		certChain:        []*x509.Certificate{leafCert, ca.fulcioCA.Intermediates[0], ca.fulcioCA.Root},
-		timestamps:       [][]byte{tsr},
-		messageSignature: bundle.NewMessageSignature(digest[:], "SHA2_256", sig),
-		tlogEntries:      []*tlog.Entry{entry},
-	}, nil
+	return ca.signWithLeafCert(leafCert, leafPrivKey, artifact, integratedTime, logPublicKey, loggedSigner)
 }
 
-func (ca *VirtualSigstore) GenerateTlogEntry(leafCert *x509.Certificate, envelope *dsse.Envelope, sig []byte, integratedTime int64) (*tlog.Entry, error) {
-	leafCertPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
+func (ca *VirtualSigstore) signWithLeafCert(leafCert *x509.Certificate, leafPrivKey *ecdsa.PrivateKey, artifact []byte, integratedTime time.Time, logPublicKey bool, loggedSigner crypto.Signer) (*TestEntity, error) {
+	signer, err := signature.LoadECDSASignerVerifier(leafPrivKey, crypto.SHA256)
 	if err != nil {
 		return nil, err
 	}
 
-	envelopeBytes, err := json.Marshal(envelope)
+	digest := sha256.Sum256(artifact)
+	sig, err := signer.SignMessage(bytes.NewReader(artifact))
 	if err != nil {
 		return nil, err
 	}
+	if loggedSigner != nil {
+		sig, err = loggedSigner.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	rekorBody, err := generateRekorEntry(intoto.KIND, intoto.New().DefaultVersion(), envelopeBytes, leafCertPem, sig)
+	tsr, err := generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, 0, time.Now(), 0)
 	if err != nil {
 		return nil, err
 	}
 
-	rekorLogID, err := getLogID(ca.rekorKey.Public())
+	verifierPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
 	if err != nil {
 		return nil, err
 	}
+	if logPublicKey {
+		loggedKey := leafCert.PublicKey
+		if loggedSigner != nil {
+			loggedKey = loggedSigner.Public()
+		}
+		verifierPem, err = cryptoutils.MarshalPublicKeyToPEM(loggedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	rekorLogIDRaw, err := hex.DecodeString(rekorLogID)
+	entry, err := ca.generateTlogEntryHashedRekord(verifierPem, artifact, sig, integratedTime.Unix())
 	if err != nil {
 		return nil, err
 	}
 
-	logIndex := int64(1000)
+	return &TestEntity{
+		certChain:        []*x509.Certificate{leafCert, ca.fulcioCA.Intermediates[0], ca.fulcioCA.Root},
+		timestamps:       [][]byte{tsr},
+		messageSignature: bundle.NewMessageSignature(digest[:], "SHA2_256", sig),
+		tlogEntries:      []*tlog.Entry{entry},
+	}, nil
+}
 
-	b := createRekorBundle(rekorLogID, integratedTime, logIndex, rekorBody)
-	set, err := ca.rekorSignPayload(*b)
+// ProveInclusion returns a copy of the entity whose log entries carry an
+// inclusion proof instead of an inclusion promise, as from a tile-based
+// log. Each entry is the only leaf of its tree, and the checkpoint the
+// proof leads to is signed by the log now, with a "Timestamp:" line.
+func (ca *VirtualSigstore) ProveInclusion(entity *TestEntity) (*TestEntity, error) {
+	rekorSigner, err := signature.LoadSignerVerifier(ca.rekorKey, crypto.SHA256)
 	if err != nil {
 		return nil, err
 	}
 
-	rekorBodyRaw, err := base64.StdEncoding.DecodeString(rekorBody)
+	proved := *entity
+	proved.proofOnly = true
+	proved.tlogEntries = nil
+	for _, entry := range entity.tlogEntries {
+		rootHash := tlog.LeafHash(entry.CanonicalizedBody())
+		// Rekor adds the time the checkpoint was signed as a note extension
+		// line, which rekorutil.CreateAndSignCheckpoint doesn't
+		signedCheckpoint, err := rekorutil.CreateSignedCheckpoint(rekorutil.Checkpoint{
+			Origin:       "virtual-rekor - 1",
+			Size:         1,
+			Hash:         rootHash,
+			OtherContent: []string{fmt.Sprintf("Timestamp: %d", time.Now().UnixNano())},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := signedCheckpoint.Sign("virtual-rekor", rekorSigner, options.WithContext(context.Background())); err != nil {
+			return nil, err
+		}
+		checkpoint, err := signedCheckpoint.SignedNote.MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		provedEntry, err := tlog.NewEntry(entry.CanonicalizedBody(), entry.IntegratedTime().Unix(), entry.LogIndex(), []byte(entry.LogKeyID()), nil, &models.InclusionProof{
+			LogIndex:   swag.Int64(0),
+			RootHash:   swag.String(hex.EncodeToString(rootHash)),
+			TreeSize:   swag.Int64(1),
+			Hashes:     []string{},
+			Checkpoint: swag.String(string(checkpoint)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		proved.tlogEntries = append(proved.tlogEntries, provedEntry)
+	}
+	return &proved, nil
+}
+
+func (ca *VirtualSigstore) GenerateTlogEntry(leafCert *x509.Certificate, envelope *dsse.Envelope, sig []byte, integratedTime int64) (*tlog.Entry, error) {
+	leafCertPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
 	if err != nil {
 		return nil, err
 	}
 
-	return tlog.NewEntry(rekorBodyRaw, integratedTime, logIndex, rekorLogIDRaw, set, nil)
-}
+	envelopeBytes, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
 
-func (ca *VirtualSigstore) generateTlogEntryHashedRekord(leafCert *x509.Certificate, artifact []byte, sig []byte, integratedTime int64) (*tlog.Entry, error) {
-	leafCertPem, err := cryptoutils.MarshalCertificateToPEM(leafCert)
+	rekorBody, err := generateIntotoEntry(envelope, envelopeBytes, leafCertPem)
 	if err != nil {
 		return nil, err
 	}
 
-	rekorBody, err := generateRekorEntry(hashedrekord.KIND, hashedrekord.New().DefaultVersion(), artifact, leafCertPem, sig)
+	return ca.logEntry(rekorBody, integratedTime)
+}
+
+func (ca *VirtualSigstore) generateTlogEntryHashedRekord(verifierPem []byte, artifact []byte, sig []byte, integratedTime int64) (*tlog.Entry, error) {
+	rekorBody, err := generateRekorEntry(hashedrekord.KIND, hashedrekord.New().DefaultVersion(), artifact, verifierPem, sig)
 	if err != nil {
 		return nil, err
 	}
 
+	return ca.logEntry(rekorBody, integratedTime)
+}
+
+// logEntry returns the log entry with the base64-encoded body, with an
+// inclusion promise signed by the log.
+func (ca *VirtualSigstore) logEntry(rekorBody string, integratedTime int64) (*tlog.Entry, error) {
 	rekorLogID, err := getLogID(ca.rekorKey.Public())
 	if err != nil {
 		return nil, err
@@ -324,6 +601,23 @@ func (ca *VirtualSigstore) generateTlogEntryHashedRekord(leafCert *x509.Certific
 	return tlog.NewEntry(rekorBodyRaw, integratedTime, logIndex, rekorLogIDRaw, set, nil)
 }
 
+// LogEntryBody returns a copy of the entity whose log entry has the given
+// body instead, such as that of a custom entry type, integrated at the same
+// time with an inclusion promise signed by the log.
+func (ca *VirtualSigstore) LogEntryBody(entity *TestEntity, body []byte) (*TestEntity, error) {
+	if len(entity.tlogEntries) == 0 {
+		return nil, errors.New("entity has no log entry")
+	}
+	entry, err := ca.logEntry(base64.StdEncoding.EncodeToString(body), entity.tlogEntries[0].IntegratedTime().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	logged := *entity
+	logged.tlogEntries = []*tlog.Entry{entry}
+	return &logged, nil
+}
+
 func (ca *VirtualSigstore) PublicKeyVerifier(keyID string) (root.TimeConstrainedVerifier, error) {
 	v, ok := ca.publicKeyVerifier[keyID]
 	if !ok {
@@ -345,13 +639,60 @@ func generateRekorEntry(kind, version string, artifact []byte, cert []byte, sig
 	return base64.StdEncoding.EncodeToString(entryBytes), nil
 }
 
+// generateIntotoEntry returns the canonicalized body of an intoto v0.0.2
+// entry for the envelope, as the log records it. It is built from Rekor's
+// models as Rekor's intoto type would, since that type imports a package
+// that doesn't build for WebAssembly, where these tests also run.
+func generateIntotoEntry(envelope *dsse.Envelope, envelopeBytes []byte, certPem []byte) (string, error) {
+	publicKey := strfmt.Base64(certPem)
+	content := &models.IntotoV002SchemaContent{
+		Envelope: &models.IntotoV002SchemaContentEnvelope{
+			PayloadType: swag.String(envelope.PayloadType),
+		},
+	}
+	for _, sig := range envelope.Signatures {
+		// Rekor records the signature as the bytes of its base64 encoding
+		sigBytes := strfmt.Base64(sig.Sig)
+		content.Envelope.Signatures = append(content.Envelope.Signatures, &models.IntotoV002SchemaContentEnvelopeSignaturesItems0{
+			Keyid:     sig.KeyID,
+			Sig:       &sigBytes,
+			PublicKey: &publicKey,
+		})
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return "", err
+	}
+	envelopeHash := sha256.Sum256(envelopeBytes)
+	payloadHash := sha256.Sum256(payload)
+	content.Hash = &models.IntotoV002SchemaContentHash{
+		Algorithm: swag.String(models.IntotoV002SchemaContentHashAlgorithmSha256),
+		Value:     swag.String(hex.EncodeToString(envelopeHash[:])),
+	}
+	content.PayloadHash = &models.IntotoV002SchemaContentPayloadHash{
+		Algorithm: swag.String(models.IntotoV002SchemaContentPayloadHashAlgorithmSha256),
+		Value:     swag.String(hex.EncodeToString(payloadHash[:])),
+	}
+
+	entry := models.Intoto{
+		APIVersion: swag.String(intoto.New().DefaultVersion()),
+		Spec:       &models.IntotoV002Schema{Content: content},
+	}
+	entryBytes, err := json.Marshal(&entry)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(entryBytes), nil
+}
+
 func createEntry(ctx context.Context, kind, apiVersion string, blobBytes, certBytes, sigBytes []byte) (types.EntryImpl, error) {
 	props := types.ArtifactProperties{
 		PublicKeyBytes: [][]byte{certBytes},
 		PKIFormat:      string(pki.X509),
 	}
 	switch kind {
-	case rekord.KIND, intoto.KIND:
+	case rekord.KIND:
 		props.ArtifactBytes = blobBytes
 		props.SignatureBytes = sigBytes
 	case hashedrekord.KIND:
@@ -392,21 +733,59 @@ func createRekorBundle(logID string, integratedTime int64, logIndex int64, rekor
 }
 
 func (ca *VirtualSigstore) TimestampResponse(sig []byte) ([]byte, error) {
-	return generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey)
+	return generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, 0, time.Now(), 0)
+}
+
+// TimestampResponseWithAccuracy returns a timestamp response whose TSTInfo
+// declares the given accuracy.
+func (ca *VirtualSigstore) TimestampResponseWithAccuracy(sig []byte, accuracy time.Duration) ([]byte, error) {
+	return generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, 0, time.Now(), accuracy)
+}
+
+// TimestampResponseAtTime returns a timestamp response whose TSTInfo
+// declares the given genTime.
+func (ca *VirtualSigstore) TimestampResponseAtTime(sig []byte, genTime time.Time) ([]byte, error) {
+	return generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, 0, genTime, 0)
+}
+
+// TimestampResponseWithHash returns a timestamp response whose message
+// imprint is computed with the given hash algorithm.
+func (ca *VirtualSigstore) TimestampResponseWithHash(sig []byte, imprintHash crypto.Hash) ([]byte, error) {
+	return generateTimestampingResponse(sig, ca.tsaCA.Leaf, ca.tsaLeafKey, imprintHash, time.Now(), 0)
 }
 
-func generateTimestampingResponse(sig []byte, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey) ([]byte, error) {
-	var hash crypto.Hash
+// TimestampResponseForRequest answers a DER-encoded RFC 3161 timestamp
+// request, echoing its message imprint and nonce.
+func (ca *VirtualSigstore) TimestampResponseForRequest(tsq []byte) ([]byte, error) {
+	req, err := timestamp.ParseRequest(tsq)
+	if err != nil {
+		return nil, err
+	}
+	return respondToTimestampRequest(req, ca.tsaCA.Leaf, ca.tsaLeafKey, time.Now(), 0)
+}
+
+func tsaSigningHash(tsaKey *ecdsa.PrivateKey) crypto.Hash {
 	switch tsaKey.Curve {
-	case elliptic.P256():
-		hash = crypto.SHA256
 	case elliptic.P384():
-		hash = crypto.SHA384
+		return crypto.SHA384
 	case elliptic.P521():
-		hash = crypto.SHA512
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
+
+// generateTimestampingResponse timestamps sig. A zero imprintHash computes
+// the message imprint with the hash the TSA signs with.
+func generateTimestampingResponse(sig []byte, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey, imprintHash crypto.Hash, genTime time.Time, accuracy time.Duration) ([]byte, error) {
+	if imprintHash == 0 {
+		imprintHash = tsaSigningHash(tsaKey)
+	}
+	if imprintHash == crypto.SHA1 {
+		return assembleTimestampResponse(sig, imprintHash, tsaCert, tsaKey, genTime, nil)
 	}
 	tsq, err := timestamp.CreateRequest(bytes.NewReader(sig), &timestamp.RequestOptions{
-		Hash: hash,
+		Hash: imprintHash,
 	})
 	if err != nil {
 		return nil, err
@@ -417,17 +796,138 @@ func generateTimestampingResponse(sig []byte, tsaCert *x509.Certificate, tsaKey
 		return nil, err
 	}
 
+	return respondToTimestampRequest(req, tsaCert, tsaKey, genTime, accuracy)
+}
+
+func respondToTimestampRequest(req *timestamp.Request, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey, genTime time.Time, accuracy time.Duration) ([]byte, error) {
 	tsTemplate := timestamp.Timestamp{
 		HashAlgorithm:   req.HashAlgorithm,
 		HashedMessage:   req.HashedMessage,
-		Time:            time.Now(),
+		Time:            genTime,
+		Accuracy:        accuracy,
+		Nonce:           req.Nonce,
 		Policy:          asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 2},
 		Ordering:        false,
 		Qualified:       false,
 		ExtraExtensions: req.Extensions,
 	}
 
-	return tsTemplate.CreateResponseWithOpts(tsaCert, tsaKey, hash)
+	return tsTemplate.CreateResponseWithOpts(tsaCert, tsaKey, tsaSigningHash(tsaKey))
+}
+
+// GenerateTimestampResponse timestamps sig with a token signed by tsaCert
+// that embeds certs, in the order given.
+func GenerateTimestampResponse(sig []byte, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey, certs []*x509.Certificate) ([]byte, error) {
+	return assembleTimestampResponse(sig, tsaSigningHash(tsaKey), tsaCert, tsaKey, time.Now(), certs)
+}
+
+// assembleTimestampResponse timestamps sig, embedding certs in the token in
+// the order given. digitorus/timestamp identifies the TSA certificate with
+// an ESSCertIDv2, which it won't compute with SHA-1, and only embeds the TSA
+// certificate followed by its chain, so the token is assembled here with an
+// RFC 2634 ESSCertID instead.
+func assembleTimestampResponse(sig []byte, imprintHash crypto.Hash, tsaCert *x509.Certificate, tsaKey *ecdsa.PrivateKey, genTime time.Time, certs []*x509.Certificate) ([]byte, error) {
+	type messageImprint struct {
+		HashAlgorithm pkix.AlgorithmIdentifier
+		HashedMessage []byte
+	}
+	type tstInfo struct {
+		Version        int
+		Policy         asn1.ObjectIdentifier
+		MessageImprint messageImprint
+		SerialNumber   *big.Int
+		Time           time.Time `asn1:"generalized"`
+	}
+	type essCertID struct {
+		CertHash []byte
+	}
+	type signingCertificate struct {
+		Certs []essCertID
+	}
+	type pkiStatusInfo struct {
+		Status int
+	}
+	type timestampResponse struct {
+		Status         pkiStatusInfo
+		TimeStampToken asn1.RawValue
+	}
+
+	var imprintAlgorithm asn1.ObjectIdentifier
+	switch imprintHash {
+	case crypto.SHA1:
+		imprintAlgorithm = pkcs7.OIDDigestAlgorithmSHA1
+	case crypto.SHA384:
+		imprintAlgorithm = pkcs7.OIDDigestAlgorithmSHA384
+	case crypto.SHA512:
+		imprintAlgorithm = pkcs7.OIDDigestAlgorithmSHA512
+	default:
+		imprintAlgorithm = pkcs7.OIDDigestAlgorithmSHA256
+	}
+	hasher := imprintHash.New()
+	hasher.Write(sig)
+	info, err := asn1.Marshal(tstInfo{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 2},
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: imprintAlgorithm, Parameters: asn1.NullRawValue},
+			HashedMessage: hasher.Sum(nil),
+		},
+		SerialNumber: big.NewInt(1),
+		Time:         genTime.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	certHash := sha1.Sum(tsaCert.Raw) //nolint:gosec
+	signingCert, err := asn1.Marshal(signingCertificate{Certs: []essCertID{{CertHash: certHash[:]}}})
+	if err != nil {
+		return nil, err
+	}
+
+	signedData, err := pkcs7.NewSignedData(info)
+	if err != nil {
+		return nil, err
+	}
+	switch tsaSigningHash(tsaKey) {
+	case crypto.SHA384:
+		signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA384)
+	case crypto.SHA512:
+		signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA512)
+	default:
+		signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+	}
+	// OID for id-ct-TSTInfo
+	signedData.SetContentType(asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4})
+	signedData.GetSignedData().Version = 3
+	err = signedData.AddSigner(tsaCert, tsaKey, pkcs7.SignerInfoConfig{
+		ExtraSignedAttributes: []pkcs7.Attribute{{
+			// OID for the id-aa-signingCertificate attribute
+			Type:  asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 12},
+			Value: asn1.RawValue{FullBytes: signingCert},
+		}},
+		SkipCertificates: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		signedData.AddCertificate(cert)
+	}
+	token, err := signedData.Finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(timestampResponse{TimeStampToken: asn1.RawValue{FullBytes: token}})
+}
+
+// SetTSAValidityPeriod sets the validity period of the TSA in the trusted
+// material the VirtualSigstore provides. A zero start or end leaves that
+// side of the period open.
+func (ca *VirtualSigstore) SetTSAValidityPeriod(start, end time.Time) {
+	ca.tsaCA.ValidityPeriodStart = start
+	ca.tsaCA.ValidityPeriodEnd = end
 }
 
 func (ca *VirtualSigstore) TimestampingAuthorities() []root.CertificateAuthority {
@@ -444,13 +944,19 @@ func (ca *VirtualSigstore) RekorLogs() map[string]*root.TransparencyLog {
 	if err != nil {
 		panic(err)
 	}
+	// like a parsed trusted root, the log ID is the raw key ID
+	rawLogID, err := hex.DecodeString(logID)
+	if err != nil {
+		panic(err)
+	}
 	verifiers[logID] = &root.TransparencyLog{
 		BaseURL:             "test",
-		ID:                  []byte(logID),
+		ID:                  rawLogID,
 		ValidityPeriodStart: time.Now().Add(-time.Hour),
 		ValidityPeriodEnd:   time.Now().Add(time.Hour),
 		HashFunc:            crypto.SHA256,
 		PublicKey:           ca.rekorKey.Public(),
+		RSAPSS:              ca.rekorRSAPSS,
 	}
 	return verifiers
 }
@@ -461,9 +967,13 @@ func (ca *VirtualSigstore) CTLogs() map[string]*root.TransparencyLog {
 	if err != nil {
 		panic(err)
 	}
+	rawLogID, err := hex.DecodeString(logID)
+	if err != nil {
+		panic(err)
+	}
 	verifiers[logID] = &root.TransparencyLog{
 		BaseURL:             "test",
-		ID:                  []byte(logID),
+		ID:                  rawLogID,
 		ValidityPeriodStart: time.Now().Add(-time.Hour),
 		ValidityPeriodEnd:   time.Now().Add(time.Hour),
 		HashFunc:            crypto.SHA256,
@@ -472,24 +982,121 @@ func (ca *VirtualSigstore) CTLogs() map[string]*root.TransparencyLog {
 	return verifiers
 }
 
+// CTInclusionProof returns a proof that the virtual CT log included the
+// leaf certificate it issued an SCT for, in a tree alongside entries for
+// other certificates, with a tree head signed by the log.
+func (ca *VirtualSigstore) CTInclusionProof(leafCert *x509.Certificate) (*verify.CTInclusionProof, error) {
+	scts, err := x509util.ParseSCTsFromCertificate(leafCert.Raw)
+	if err != nil {
+		return nil, err
+	}
+	if len(scts) == 0 {
+		return nil, fmt.Errorf("leaf certificate has no SCT")
+	}
+	chain, err := ctx509.ParseCertificates(append(append([]byte(nil), leafCert.Raw...), ca.fulcioCA.Intermediates[0].Raw...))
+	if err != nil {
+		return nil, err
+	}
+	leaf, err := ct.MerkleTreeLeafForEmbeddedSCT(chain, scts[0].Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	leafHash, err := ct.LeafHashForLeaf(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	const treeSize, leafIndex = 7, 5
+	leafHashes := make([][]byte, treeSize)
+	for i := range leafHashes {
+		leafHashes[i] = rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("other certificate %d", i)))
+	}
+	leafHashes[leafIndex] = leafHash[:]
+
+	sth := ct.SignedTreeHead{
+		Version:   ct.V1,
+		TreeSize:  treeSize,
+		Timestamp: uint64(time.Now().UnixMilli()),
+	}
+	copy(sth.SHA256RootHash[:], merkleTreeHash(leafHashes))
+	signatureInput, err := ct.SerializeSTHSignatureInput(sth)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(signatureInput)
+	signature, err := ecdsa.SignASN1(rand.Reader, ca.ctlogKey, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	sth.TreeHeadSignature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+		Signature: signature,
+	}
+
+	return &verify.CTInclusionProof{
+		LogID:          scts[0].LogID.KeyID[:],
+		LeafIndex:      leafIndex,
+		Hashes:         merkleAuditPath(leafIndex, leafHashes),
+		SignedTreeHead: sth,
+	}, nil
+}
+
+// merkleTreeHash returns the RFC 6962 Merkle tree hash of the leaves.
+func merkleTreeHash(leafHashes [][]byte) []byte {
+	if len(leafHashes) == 1 {
+		return leafHashes[0]
+	}
+	k := merkleSplit(len(leafHashes))
+	return rfc6962.DefaultHasher.HashChildren(merkleTreeHash(leafHashes[:k]), merkleTreeHash(leafHashes[k:]))
+}
+
+// merkleAuditPath returns the RFC 6962 audit path of the leaf at index.
+func merkleAuditPath(index int, leafHashes [][]byte) [][]byte {
+	if len(leafHashes) <= 1 {
+		return nil
+	}
+	k := merkleSplit(len(leafHashes))
+	if index < k {
+		return append(merkleAuditPath(index, leafHashes[:k]), merkleTreeHash(leafHashes[k:]))
+	}
+	return append(merkleAuditPath(index-k, leafHashes[k:]), merkleTreeHash(leafHashes[:k]))
+}
+
+// merkleSplit returns the largest power of two smaller than n.
+func merkleSplit(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
 type TestEntity struct {
 	certChain        []*x509.Certificate
 	envelope         *dsse.Envelope
 	messageSignature *bundle.MessageSignature
 	timestamps       [][]byte
 	tlogEntries      []*tlog.Entry
+	// proofOnly marks log entries with inclusion proofs but no promises
+	proofOnly bool
 }
 
 func (e *TestEntity) VerificationContent() (verify.VerificationContent, error) {
 	return &bundle.Certificate{Certificate: e.certChain[0]}, nil
 }
 
+// CertificateChain returns the entity's certificates, starting with the
+// signing certificate, like a bundle carrying its chain.
+func (e *TestEntity) CertificateChain() ([]*x509.Certificate, error) {
+	return e.certChain, nil
+}
+
 func (e *TestEntity) HasInclusionPromise() bool {
-	return true
+	return !e.proofOnly
 }
 
 func (e *TestEntity) HasInclusionProof() bool {
-	return false
+	return e.proofOnly
 }
 
 func (e *TestEntity) SignatureContent() (verify.SignatureContent, error) {
@@ -607,14 +1214,120 @@ func GenerateTSAIntermediate(rootTemplate *x509.Certificate, rootPriv crypto.Sig
 
 func GenerateLeafCert(subject string, oidcIssuer string, expiration time.Time, priv *ecdsa.PrivateKey,
 	parentTemplate *x509.Certificate, parentPriv crypto.Signer) (*x509.Certificate, error) {
-	certTemplate := &x509.Certificate{
-		SerialNumber:   big.NewInt(1),
-		EmailAddresses: []string{subject},
-		NotBefore:      expiration,
-		NotAfter:       expiration.Add(10 * time.Minute),
-		KeyUsage:       x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
-		IsCA:           false,
+	return createCertificate(leafCertTemplate(subject, oidcIssuer, expiration), parentTemplate, &priv.PublicKey, parentPriv)
+}
+
+// GenerateLeafCertWithSCT returns a leaf certificate like GenerateLeafCert,
+// with an SCT from the CT log with the given key embedded in it, as Fulcio
+// does.
+func GenerateLeafCertWithSCT(subject string, oidcIssuer string, expiration time.Time, priv *ecdsa.PrivateKey,
+	parent *x509.Certificate, parentPriv crypto.Signer, ctlogKey *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	return generateLeafCertWithSCT(subject, oidcIssuer, expiration, &priv.PublicKey, parent, parentPriv, ctlogKey)
+}
+
+func generateLeafCertWithSCT(subject string, oidcIssuer string, expiration time.Time, pub crypto.PublicKey,
+	parent *x509.Certificate, parentPriv crypto.Signer, ctlogKey *ecdsa.PrivateKey) (*x509.Certificate, error) {
+	// The SCT signs over the certificate without the SCT extension, which
+	// is this certificate as long as the extension is added last
+	precert, err := createCertificate(leafCertTemplate(subject, oidcIssuer, expiration), parent, pub, parentPriv)
+	if err != nil {
+		return nil, err
+	}
+
+	sctExtension, err := embeddedSCTExtension(precert, parent, ctlogKey)
+	if err != nil {
+		return nil, err
+	}
+
+	certTemplate := leafCertTemplate(subject, oidcIssuer, expiration)
+	certTemplate.ExtraExtensions = append(certTemplate.ExtraExtensions, sctExtension)
+
+	return createCertificate(certTemplate, parent, pub, parentPriv)
+}
+
+// embeddedSCTExtension returns the SCT list extension for the given leaf
+// certificate, holding a single SCT signed by the CT log key.
+func embeddedSCTExtension(precert, issuer *x509.Certificate, ctlogKey *ecdsa.PrivateKey) (pkix.Extension, error) {
+	ctPrecert, err := ctx509.ParseCertificate(precert.Raw)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	ctIssuer, err := ctx509.ParseCertificate(issuer.Raw)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	logKey, err := x509.MarshalPKIXPublicKey(ctlogKey.Public())
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256(logKey)},
+		Timestamp:  uint64(time.Now().UnixMilli()),
+	}
+
+	// The precertificate has no SCT list extension yet, so build the leaf
+	// from its TBSCertificate directly rather than with
+	// ct.MerkleTreeLeafForEmbeddedSCT, which expects to remove one
+	leaf := ct.MerkleTreeLeaf{
+		Version:  ct.V1,
+		LeafType: ct.TimestampedEntryLeafType,
+		TimestampedEntry: &ct.TimestampedEntry{
+			Timestamp: sct.Timestamp,
+			EntryType: ct.PrecertLogEntryType,
+			PrecertEntry: &ct.PreCert{
+				IssuerKeyHash:  sha256.Sum256(ctIssuer.RawSubjectPublicKeyInfo),
+				TBSCertificate: ctPrecert.RawTBSCertificate,
+			},
+		},
+	}
+	signatureInput, err := ct.SerializeSCTSignatureInput(sct, ct.LogEntry{Leaf: leaf})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	digest := sha256.Sum256(signatureInput)
+	signature, err := ecdsa.SignASN1(rand.Reader, ctlogKey, digest[:])
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	sct.Signature = ct.DigitallySigned{
+		Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+		Signature: signature,
+	}
+
+	serializedSCT, err := cttls.Marshal(sct)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	sctList, err := cttls.Marshal(ctx509.SignedCertificateTimestampList{SCTList: []ctx509.SerializedSCT{{Val: serializedSCT}}})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	value, err := asn1.Marshal(sctList)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{
+		// OID for the embedded SCT list extension
+		Id:    asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2},
+		Value: value,
+	}, nil
+}
+
+// leafCertTemplate returns the template of a Fulcio leaf certificate for the
+// subject. A subject of the form user!domain is a username, which Fulcio
+// puts in a critical otherName SAN rather than an email SAN.
+func leafCertTemplate(subject string, oidcIssuer string, expiration time.Time) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    expiration,
+		NotAfter:     expiration.Add(10 * time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		IsCA:         false,
 		ExtraExtensions: []pkix.Extension{{
 			// OID for OIDC Issuer extension
 			Id:       asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1},
@@ -623,16 +1336,51 @@ func GenerateLeafCert(subject string, oidcIssuer string, expiration time.Time, p
 		},
 		},
 	}
+	if strings.Contains(subject, "!") {
+		template.ExtraExtensions = append(template.ExtraExtensions, usernameSANExtension(subject))
+	} else {
+		template.EmailAddresses = []string{subject}
+	}
+	return template
+}
 
-	cert, err := createCertificate(certTemplate, parentTemplate, &priv.PublicKey, parentPriv)
+// usernameSANExtension returns a critical Subject Alternative Name extension
+// holding the username as a Fulcio otherName SAN.
+func usernameSANExtension(username string) pkix.Extension {
+	name, err := asn1.MarshalWithParams(struct {
+		TypeID asn1.ObjectIdentifier
+		Value  string `asn1:"utf8,explicit,tag:0"`
+	}{TypeID: certificate.OIDOtherName, Value: username}, "tag:0")
 	if err != nil {
-		return nil, err
+		panic(err)
+	}
+	san, err := asn1.Marshal([]asn1.RawValue{{FullBytes: name}})
+	if err != nil {
+		panic(err)
 	}
+	return pkix.Extension{Id: asn1.ObjectIdentifier{2, 5, 29, 17}, Critical: true, Value: san}
+}
 
-	return cert, nil
+func byoLeafCertTemplate(commonName, email string, notBefore time.Time) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"Example Enterprise"},
+		},
+		NotBefore:   notBefore,
+		NotAfter:    notBefore.AddDate(1, 0, 0),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		IsCA:        false,
+	}
+	if email != "" {
+		template.EmailAddresses = []string{email}
+	}
+	return template
 }
 
-func GenerateTSALeafCert(expiration time.Time, priv *ecdsa.PrivateKey, parentTemplate *x509.Certificate, parentPriv crypto.Signer) (*x509.Certificate, error) {
+func GenerateTSALeafCert(expiration time.Time, priv *ecdsa.PrivateKey, parentTemplate *x509.Certificate, parentPriv crypto.Signer, opts ...TSALeafOption) (*x509.Certificate, error) {
 	timestampExt, err := asn1.Marshal([]asn1.ObjectIdentifier{tsx509.EKUTimestampingOID})
 	if err != nil {
 		return nil, err
@@ -655,6 +1403,10 @@ func GenerateTSALeafCert(expiration time.Time, priv *ecdsa.PrivateKey, parentTem
 		},
 	}
 
+	for _, opt := range opts {
+		opt(certTemplate)
+	}
+
 	cert, err := createCertificate(certTemplate, parentTemplate, &priv.PublicKey, parentPriv)
 	if err != nil {
 		return nil, err
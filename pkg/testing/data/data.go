@@ -65,7 +65,7 @@ func SigstoreJS200ProvenanceBundle(t *testing.T) *bundle.ProtobufBundle {
 	return TestBundle(t, SigstoreJS200ProvenanceBundleRaw)
 }
 
-func PublicGoodTrustedMaterialRoot(t *testing.T) *root.TrustedRoot {
+func PublicGoodTrustedMaterialRoot(t testing.TB) *root.TrustedRoot {
 	trustedrootJSON, _ := os.ReadFile("../../examples/trusted-root-public-good.json")
 	trustedRoot, _ := root.NewTrustedRootFromJSON(trustedrootJSON)
 
@@ -16,9 +16,12 @@ package tlog
 
 import (
 	"bytes"
-	"context"
+	"crypto"
 	"crypto/ecdsa"
-	"crypto/sha256"
+	"crypto/ed25519"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512" // for logs that sign with SHA2-384 or SHA2-512
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
@@ -26,6 +29,9 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
@@ -34,12 +40,11 @@ import (
 	"github.com/go-openapi/swag"
 	v1 "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
 	"github.com/sigstore/rekor/pkg/generated/models"
-	"github.com/sigstore/rekor/pkg/types"
-	dsse_v001 "github.com/sigstore/rekor/pkg/types/dsse/v0.0.1"
 	hashedrekord_v001 "github.com/sigstore/rekor/pkg/types/hashedrekord/v0.0.1"
-	intoto_v002 "github.com/sigstore/rekor/pkg/types/intoto/v0.0.2"
 	rekorVerify "github.com/sigstore/rekor/pkg/verify"
 	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
 )
@@ -47,7 +52,8 @@ import (
 type Entry struct {
 	kind                 string
 	version              string
-	rekorEntry           types.EntryImpl
+	body                 []byte
+	rekorEntry           any
 	logEntryAnon         models.LogEntryAnon
 	signedEntryTimestamp []byte
 }
@@ -62,25 +68,42 @@ type RekorPayload struct {
 var ErrNilValue = errors.New("validation error: nil value in transaction log entry")
 
 func NewEntry(body []byte, integratedTime int64, logIndex int64, logID []byte, signedEntryTimestamp []byte, inclusionProof *models.InclusionProof) (*Entry, error) {
-	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(body), runtime.JSONConsumer())
-	if err != nil {
-		return nil, err
-	}
-	rekorEntry, err := types.UnmarshalEntry(pe)
-	if err != nil {
-		return nil, err
-	}
-
 	entry := &Entry{
-		rekorEntry: rekorEntry,
+		body: body,
 		logEntryAnon: models.LogEntryAnon{
 			Body:           base64.StdEncoding.EncodeToString(body),
 			IntegratedTime: swag.Int64(integratedTime),
 			LogIndex:       swag.Int64(logIndex),
 			LogID:          swag.String(string(logID)),
 		},
-		kind:    pe.Kind(),
-		version: rekorEntry.APIVersion(),
+	}
+
+	pe, err := models.UnmarshalProposedEntry(bytes.NewReader(body), runtime.JSONConsumer())
+	if err == nil {
+		entry.rekorEntry, entry.version, err = unmarshalEntry(pe)
+	}
+	if err == nil {
+		entry.kind = pe.Kind()
+	} else {
+		// Entry types unknown to Rekor can still be handled if registered
+		// with RegisterRekorEntryType
+		var header struct {
+			Kind       string `json:"kind"`
+			APIVersion string `json:"apiVersion"`
+		}
+		if jsonErr := json.Unmarshal(body, &header); jsonErr != nil {
+			return nil, err
+		}
+		// a body that claims a built-in type must parse as one, or it
+		// would skip the schema validation in ValidateEntry
+		if isBuiltinEntryType(header.Kind, header.APIVersion) {
+			return nil, err
+		}
+		if _, ok := lookupEntryType(header.Kind, header.APIVersion); !ok {
+			return nil, err
+		}
+		entry.kind = header.Kind
+		entry.version = header.APIVersion
 	}
 
 	if len(signedEntryTimestamp) > 0 {
@@ -96,7 +119,7 @@ func NewEntry(body []byte, integratedTime int64, logIndex int64, logID []byte, s
 	return entry, nil
 }
 
-// ParseEntry decodes the entry bytes to a specific entry type (types.EntryImpl).
+// ParseEntry decodes the entry bytes to a specific entry type.
 func ParseEntry(protoEntry *v1.TransparencyLogEntry) (entry *Entry, err error) {
 	if protoEntry == nil ||
 		protoEntry.CanonicalizedBody == nil ||
@@ -116,21 +139,7 @@ func ParseEntry(protoEntry *v1.TransparencyLogEntry) (entry *Entry, err error) {
 	var inclusionProof *models.InclusionProof
 
 	if protoEntry.InclusionProof != nil {
-		var hashes []string
-
-		for _, v := range protoEntry.InclusionProof.Hashes {
-			hashes = append(hashes, hex.EncodeToString(v))
-		}
-
-		rootHash := hex.EncodeToString(protoEntry.InclusionProof.RootHash)
-
-		inclusionProof = &models.InclusionProof{
-			LogIndex:   swag.Int64(protoEntry.InclusionProof.LogIndex),
-			RootHash:   &rootHash,
-			TreeSize:   swag.Int64(protoEntry.InclusionProof.TreeSize),
-			Hashes:     hashes,
-			Checkpoint: swag.String(protoEntry.InclusionProof.Checkpoint.Envelope),
-		}
+		inclusionProof = inclusionProofModel(protoEntry.InclusionProof)
 	}
 
 	entry, err = NewEntry(protoEntry.CanonicalizedBody, protoEntry.IntegratedTime, protoEntry.LogIndex, protoEntry.LogId.KeyId, signedEntryTimestamp, inclusionProof)
@@ -145,9 +154,42 @@ func ParseEntry(protoEntry *v1.TransparencyLogEntry) (entry *Entry, err error) {
 	return entry, nil
 }
 
+func inclusionProofModel(proof *v1.InclusionProof) *models.InclusionProof {
+	var hashes []string
+
+	for _, v := range proof.Hashes {
+		hashes = append(hashes, hex.EncodeToString(v))
+	}
+
+	rootHash := hex.EncodeToString(proof.RootHash)
+
+	return &models.InclusionProof{
+		LogIndex:   swag.Int64(proof.LogIndex),
+		RootHash:   &rootHash,
+		TreeSize:   swag.Int64(proof.TreeSize),
+		Hashes:     hashes,
+		Checkpoint: swag.String(proof.GetCheckpoint().GetEnvelope()),
+	}
+}
+
+// WithInclusionProof returns a copy of the entry with the inclusion proof,
+// replacing any it had, for a proof fetched from the log separately from the
+// entry. The proof is only checked by VerifyInclusion.
+func (entry *Entry) WithInclusionProof(proof *v1.InclusionProof) *Entry {
+	withProof := *entry
+	withProof.logEntryAnon.Verification = &models.LogEntryAnonVerification{
+		InclusionProof: inclusionProofModel(proof),
+	}
+	return &withProof
+}
+
 func ValidateEntry(entry *Entry) error {
+	if err := validateReconstructedEntry(entry); err != nil {
+		return err
+	}
+
 	switch e := entry.rekorEntry.(type) {
-	case *dsse_v001.V001Entry:
+	case *dsseV001Entry:
 		err := e.DSSEObj.Validate(strfmt.Default)
 		if err != nil {
 			return err
@@ -157,11 +199,13 @@ func ValidateEntry(entry *Entry) error {
 		if err != nil {
 			return err
 		}
-	case *intoto_v002.V002Entry:
+	case *intotoV002Entry:
 		err := e.IntotoObj.Validate(strfmt.Default)
 		if err != nil {
 			return err
 		}
+	case nil:
+		// custom entry type, validated by its registered reconstructor
 	default:
 		return fmt.Errorf("unsupported entry type: %T", e)
 	}
@@ -175,20 +219,35 @@ func (entry *Entry) IntegratedTime() time.Time {
 
 func (entry *Entry) Signature() []byte {
 	switch e := entry.rekorEntry.(type) {
-	case *dsse_v001.V001Entry:
-		sigBytes, err := base64.StdEncoding.DecodeString(*e.DSSEObj.Signatures[0].Signature)
+	case *dsseV001Entry:
+		sig := firstDSSESignature(e)
+		if sig == nil || sig.Signature == nil {
+			return []byte{}
+		}
+		sigBytes, err := base64.StdEncoding.DecodeString(*sig.Signature)
 		if err != nil {
 			return []byte{}
 		}
 		return sigBytes
 	case *hashedrekord_v001.V001Entry:
+		if e.HashedRekordObj.Signature == nil {
+			return []byte{}
+		}
 		return e.HashedRekordObj.Signature.Content
-	case *intoto_v002.V002Entry:
-		sigBytes, err := base64.StdEncoding.DecodeString(string(*e.IntotoObj.Content.Envelope.Signatures[0].Sig))
+	case *intotoV002Entry:
+		sig := firstIntotoSignature(e)
+		if sig == nil || sig.Sig == nil {
+			return []byte{}
+		}
+		sigBytes, err := base64.StdEncoding.DecodeString(string(*sig.Sig))
 		if err != nil {
 			return []byte{}
 		}
 		return sigBytes
+	case nil:
+		if sig, _ := customVerificationMaterial(entry); sig != nil {
+			return sig
+		}
 	}
 
 	return []byte{}
@@ -198,15 +257,27 @@ func (entry *Entry) PublicKey() any {
 	var pemString []byte
 
 	switch e := entry.rekorEntry.(type) {
-	case *dsse_v001.V001Entry:
-		pemString = []byte(*e.DSSEObj.Signatures[0].Verifier)
+	case nil:
+		_, publicKey := customVerificationMaterial(entry)
+		return publicKey
+	case *dsseV001Entry:
+		if sig := firstDSSESignature(e); sig != nil && sig.Verifier != nil {
+			pemString = []byte(*sig.Verifier)
+		}
 	case *hashedrekord_v001.V001Entry:
-		pemString = []byte(e.HashedRekordObj.Signature.PublicKey.Content)
-	case *intoto_v002.V002Entry:
-		pemString = []byte(*e.IntotoObj.Content.Envelope.Signatures[0].PublicKey)
+		if sig := e.HashedRekordObj.Signature; sig != nil && sig.PublicKey != nil {
+			pemString = []byte(sig.PublicKey.Content)
+		}
+	case *intotoV002Entry:
+		if sig := firstIntotoSignature(e); sig != nil && sig.PublicKey != nil {
+			pemString = []byte(*sig.PublicKey)
+		}
 	}
 
 	certBlock, _ := pem.Decode(pemString)
+	if certBlock == nil {
+		return nil
+	}
 
 	var pk any
 	var err error
@@ -222,6 +293,90 @@ func (entry *Entry) PublicKey() any {
 	return pk
 }
 
+// HashAlgorithms returns the algorithms, such as "sha256", of the digests in
+// the entry's body. It returns nil for custom entry types.
+func (entry *Entry) HashAlgorithms() []string {
+	var algorithms []*string
+	switch e := entry.rekorEntry.(type) {
+	case *dsseV001Entry:
+		if e.DSSEObj.EnvelopeHash != nil {
+			algorithms = append(algorithms, e.DSSEObj.EnvelopeHash.Algorithm)
+		}
+		if e.DSSEObj.PayloadHash != nil {
+			algorithms = append(algorithms, e.DSSEObj.PayloadHash.Algorithm)
+		}
+	case *hashedrekord_v001.V001Entry:
+		if e.HashedRekordObj.Data != nil && e.HashedRekordObj.Data.Hash != nil {
+			algorithms = append(algorithms, e.HashedRekordObj.Data.Hash.Algorithm)
+		}
+	case *intotoV002Entry:
+		if content := e.IntotoObj.Content; content != nil {
+			if content.Hash != nil {
+				algorithms = append(algorithms, content.Hash.Algorithm)
+			}
+			if content.PayloadHash != nil {
+				algorithms = append(algorithms, content.PayloadHash.Algorithm)
+			}
+		}
+	}
+
+	var names []string
+	for _, algorithm := range algorithms {
+		if algorithm != nil {
+			names = append(names, *algorithm)
+		}
+	}
+	return names
+}
+
+// PayloadHash returns the digest of the DSSE payload recorded by a dsse or
+// intoto entry, along with its algorithm, such as "sha256". It returns an
+// empty algorithm and a nil digest for other entry types, and for entries
+// that don't record a payload hash.
+//
+// The entry's envelope hash isn't exposed: it covers the envelope as it was
+// serialized for submission, which a bundle doesn't preserve.
+func (entry *Entry) PayloadHash() (string, []byte, error) {
+	var algorithm, value *string
+	switch e := entry.rekorEntry.(type) {
+	case *dsseV001Entry:
+		if e.DSSEObj.PayloadHash != nil {
+			algorithm, value = e.DSSEObj.PayloadHash.Algorithm, e.DSSEObj.PayloadHash.Value
+		}
+	case *intotoV002Entry:
+		if content := e.IntotoObj.Content; content != nil && content.PayloadHash != nil {
+			algorithm, value = content.PayloadHash.Algorithm, content.PayloadHash.Value
+		}
+	}
+	if algorithm == nil || value == nil {
+		return "", nil, nil
+	}
+
+	digest, err := hex.DecodeString(*value)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to decode payload hash: %w", err)
+	}
+	return *algorithm, digest, nil
+}
+
+// firstDSSESignature returns the first signature of a dsse entry, or nil if
+// it has none.
+func firstDSSESignature(e *dsseV001Entry) *models.DSSEV001SchemaSignaturesItems0 {
+	if len(e.DSSEObj.Signatures) == 0 {
+		return nil
+	}
+	return e.DSSEObj.Signatures[0]
+}
+
+// firstIntotoSignature returns the first signature of an intoto entry's
+// envelope, or nil if it has none.
+func firstIntotoSignature(e *intotoV002Entry) *models.IntotoV002SchemaContentEnvelopeSignaturesItems0 {
+	if e.IntotoObj.Content == nil || e.IntotoObj.Content.Envelope == nil || len(e.IntotoObj.Content.Envelope.Signatures) == 0 {
+		return nil
+	}
+	return e.IntotoObj.Content.Envelope.Signatures[0]
+}
+
 func (entry *Entry) LogKeyID() string {
 	return *entry.logEntryAnon.LogID
 }
@@ -234,6 +389,17 @@ func (entry *Entry) Body() any {
 	return entry.logEntryAnon.Body
 }
 
+// CanonicalizedBody returns the entry body as it was submitted to the log.
+func (entry *Entry) CanonicalizedBody() []byte {
+	return entry.body
+}
+
+// UUID returns the entry's UUID, the hex-encoded Merkle tree leaf hash of its
+// body. Rekor also accepts it prefixed with the ID of the log's shard.
+func (entry *Entry) UUID() string {
+	return hex.EncodeToString(LeafHash(entry.CanonicalizedBody()))
+}
+
 func (entry *Entry) HasInclusionPromise() bool {
 	return entry.signedEntryTimestamp != nil
 }
@@ -242,8 +408,50 @@ func (entry *Entry) HasInclusionProof() bool {
 	return entry.logEntryAnon.Verification != nil
 }
 
+// CheckpointTime returns the time in the checkpoint of the entry's inclusion
+// proof, from the "Timestamp:" line, in nanoseconds since the Unix epoch,
+// that Rekor adds to its checkpoint notes. ok is false if the entry has no
+// inclusion proof, or its checkpoint has no timestamp. The time is only
+// authenticated once the checkpoint is verified with VerifyInclusion.
+func (entry *Entry) CheckpointTime() (t time.Time, ok bool, err error) {
+	if !entry.HasInclusionProof() || entry.logEntryAnon.Verification.InclusionProof == nil {
+		return time.Time{}, false, nil
+	}
+	checkpoint := swag.StringValue(entry.logEntryAnon.Verification.InclusionProof.Checkpoint)
+
+	// the note's text ends at the blank line before its signatures, and
+	// its extension lines follow the origin, tree size and root hash
+	text, _, _ := strings.Cut(checkpoint, "\n\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) < 3 {
+		return time.Time{}, false, nil
+	}
+	for _, line := range lines[3:] {
+		value, found := strings.CutPrefix(line, "Timestamp: ")
+		if !found {
+			continue
+		}
+		nanos, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid checkpoint timestamp %q: %w", value, err)
+		}
+		return time.Unix(0, nanos), true, nil
+	}
+	return time.Time{}, false, nil
+}
+
 func VerifyInclusion(entry *Entry, verifier signature.Verifier) error {
-	err := rekorVerify.VerifyInclusion(context.TODO(), &entry.logEntryAnon)
+	return VerifyInclusionWithHash(entry, verifier, crypto.SHA256)
+}
+
+// VerifyInclusionWithHash is VerifyInclusion for a log whose Merkle tree
+// hashes with hashFunc, as in its TransparencyLog.HashFunc. Zero is
+// SHA-256.
+func VerifyInclusionWithHash(entry *Entry, verifier signature.Verifier, hashFunc crypto.Hash) error {
+	if hashFunc == 0 {
+		hashFunc = crypto.SHA256
+	}
+	err := verifyInclusion(entry, hashFunc)
 	if err != nil {
 		return err
 	}
@@ -256,40 +464,194 @@ func VerifyInclusion(entry *Entry, verifier signature.Verifier) error {
 	return nil
 }
 
-func VerifySET(entry *Entry, verifiers map[string]*root.TransparencyLog) error {
-	rekorPayload := RekorPayload{
-		Body:           entry.logEntryAnon.Body,
-		IntegratedTime: *entry.logEntryAnon.IntegratedTime,
-		LogIndex:       *entry.logEntryAnon.LogIndex,
-		LogID:          hex.EncodeToString([]byte(*entry.logEntryAnon.LogID)),
+// verifyInclusion verifies the entry's inclusion proof in a Merkle tree
+// that hashes with hashFunc, against the leaf hash its registered
+// reconstructor computes. Rekor's own verification only supports SHA-256,
+// and hashes the body as given.
+func verifyInclusion(entry *Entry, hashFunc crypto.Hash) error {
+	if !hashFunc.Available() {
+		return fmt.Errorf("unsupported log hash function: %v", hashFunc)
+	}
+	if !entry.HasInclusionProof() || entry.logEntryAnon.Verification.InclusionProof == nil {
+		return errors.New("entry has no inclusion proof")
 	}
+	inclusionProof := entry.logEntryAnon.Verification.InclusionProof
 
+	rootHash, err := hex.DecodeString(swag.StringValue(inclusionProof.RootHash))
+	if err != nil {
+		return fmt.Errorf("decoding inclusion proof root hash: %w", err)
+	}
+	hashes := make([][]byte, len(inclusionProof.Hashes))
+	for i, h := range inclusionProof.Hashes {
+		hashes[i], err = hex.DecodeString(h)
+		if err != nil {
+			return fmt.Errorf("decoding inclusion proof hash: %w", err)
+		}
+	}
+
+	leafHash, err := reconstructedLeafHash(entry, hashFunc)
+	if err != nil {
+		return err
+	}
+	return proof.VerifyInclusion(rfc6962.New(hashFunc), uint64(swag.Int64Value(inclusionProof.LogIndex)), uint64(swag.Int64Value(inclusionProof.TreeSize)), leafHash, hashes, rootHash)
+}
+
+func VerifySET(entry *Entry, verifiers map[string]*root.TransparencyLog) error {
 	verifier, ok := verifiers[hex.EncodeToString([]byte(*entry.logEntryAnon.LogID))]
 	if !ok {
-		return errors.New("rekor log public key not found for payload")
+		return fmt.Errorf("%w: rekor log public key not found for payload", root.ErrUnknownLog)
 	}
+	return verifySET(entry, verifier)
+}
+
+// VerifySETWithKeyVersions verifies the entry's SET against each of the given
+// versions of its log's key, and succeeds if any of them verifies it. This is
+// needed while a log's key is rotated, when more than one key version may be
+// valid at the entry's integrated time.
+func VerifySETWithKeyVersions(entry *Entry, versions []*root.TransparencyLog) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("%w: rekor log public key not found for payload", root.ErrUnknownLog)
+	}
+	var errs []error
+	for _, verifier := range versions {
+		if !bytes.Equal(verifier.ID, []byte(*entry.logEntryAnon.LogID)) {
+			errs = append(errs, fmt.Errorf("rekor log key %x does not match entry log ID", verifier.ID))
+			continue
+		}
+		err := verifySET(entry, verifier)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func verifySET(entry *Entry, verifier *root.TransparencyLog) error {
 	if verifier.ValidityPeriodStart.IsZero() {
 		return errors.New("rekor validity period start time not set")
 	}
 	if (verifier.ValidityPeriodStart.After(entry.IntegratedTime())) ||
 		(!verifier.ValidityPeriodEnd.IsZero() && verifier.ValidityPeriodEnd.Before(entry.IntegratedTime())) {
-		return errors.New("rekor log public key not valid at payload integrated time")
+		return fmt.Errorf("%w: rekor log public key not valid at payload integrated time", root.ErrExpiredTrustMaterial)
 	}
 
-	contents, err := json.Marshal(rekorPayload)
-	if err != nil {
-		return fmt.Errorf("marshaling: %w", err)
+	buf := rekorPayloadPool.Get().(*[]byte)
+	defer rekorPayloadPool.Put(buf)
+	canonicalized, ok := appendCanonicalRekorPayload((*buf)[:0], entry)
+	if ok {
+		*buf = canonicalized
+	} else {
+		contents, err := json.Marshal(RekorPayload{
+			Body:           entry.logEntryAnon.Body,
+			IntegratedTime: *entry.logEntryAnon.IntegratedTime,
+			LogIndex:       *entry.logEntryAnon.LogIndex,
+			LogID:          hex.EncodeToString([]byte(*entry.logEntryAnon.LogID)),
+		})
+		if err != nil {
+			return fmt.Errorf("marshaling: %w", err)
+		}
+		canonicalized, err = jsoncanonicalizer.Transform(contents)
+		if err != nil {
+			return fmt.Errorf("canonicalizing: %w", err)
+		}
 	}
-	canonicalized, err := jsoncanonicalizer.Transform(contents)
-	if err != nil {
-		return fmt.Errorf("canonicalizing: %w", err)
+
+	// an unset signature hash function is the log's default of SHA-256
+	hashFunc := verifier.SignatureHashFunc
+	if hashFunc == 0 {
+		hashFunc = crypto.SHA256
 	}
 
-	hash := sha256.Sum256(canonicalized)
-	if ecdsaPublicKey, ok := verifier.PublicKey.(*ecdsa.PublicKey); !ok {
+	switch publicKey := verifier.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		digest, err := setDigest(hashFunc, canonicalized)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(publicKey, digest, entry.signedEntryTimestamp) {
+			return errors.New("unable to verify SET")
+		}
+	case *rsa.PublicKey:
+		digest, err := setDigest(hashFunc, canonicalized)
+		if err != nil {
+			return err
+		}
+		if verifier.RSAPSS {
+			err = rsa.VerifyPSS(publicKey, hashFunc, digest, entry.signedEntryTimestamp, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+		} else {
+			err = rsa.VerifyPKCS1v15(publicKey, hashFunc, digest, entry.signedEntryTimestamp)
+		}
+		if err != nil {
+			return errors.New("unable to verify SET")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(publicKey, canonicalized, entry.signedEntryTimestamp) {
+			return errors.New("unable to verify SET")
+		}
+	default:
 		return fmt.Errorf("unsupported public key type: %T", verifier.PublicKey)
-	} else if !ecdsa.VerifyASN1(ecdsaPublicKey, hash[:], entry.signedEntryTimestamp) {
-		return errors.New("unable to verify SET")
 	}
 	return nil
 }
+
+// setDigest hashes a canonicalized SET payload with the hash function the
+// log signs with.
+func setDigest(hashFunc crypto.Hash, canonicalized []byte) ([]byte, error) {
+	if !hashFunc.Available() {
+		return nil, fmt.Errorf("unsupported SET hash function: %v", hashFunc)
+	}
+	h := hashFunc.New()
+	h.Write(canonicalized)
+	return h.Sum(nil), nil
+}
+
+// maxExactJSONInteger is the largest integer that JSON canonicalization,
+// which serializes numbers as IEEE 754 doubles, reproduces exactly.
+const maxExactJSONInteger = 1<<53 - 1
+
+// rekorPayloadPool holds the buffers SET payloads are canonicalized into.
+var rekorPayloadPool = sync.Pool{
+	New: func() any {
+		return new([]byte)
+	},
+}
+
+// appendCanonicalRekorPayload appends the RFC 8785 canonical JSON of the
+// entry's SET payload to dst, the same bytes as canonicalizing the JSON of a
+// RekorPayload, without encoding the payload twice. It returns false if the
+// payload has a body or number that needs the general canonicalizer.
+func appendCanonicalRekorPayload(dst []byte, entry *Entry) ([]byte, bool) {
+	body, ok := entry.logEntryAnon.Body.(string)
+	if !ok {
+		return dst, false
+	}
+	for i := 0; i < len(body); i++ {
+		// the body is base64, which never needs escaping
+		if c := body[i]; c < 0x20 || c >= 0x80 || c == '"' || c == '\\' {
+			return dst, false
+		}
+	}
+	integratedTime := *entry.logEntryAnon.IntegratedTime
+	logIndex := *entry.logEntryAnon.LogIndex
+	if integratedTime < -maxExactJSONInteger || integratedTime > maxExactJSONInteger ||
+		logIndex < -maxExactJSONInteger || logIndex > maxExactJSONInteger {
+		return dst, false
+	}
+
+	// keys are in the canonical order, sorted by their UTF-16 code units
+	dst = append(dst, `{"body":"`...)
+	dst = append(dst, body...)
+	dst = append(dst, `","integratedTime":`...)
+	dst = strconv.AppendInt(dst, integratedTime, 10)
+	dst = append(dst, `,"logID":"`...)
+	logID := *entry.logEntryAnon.LogID
+	for i := 0; i < len(logID); i++ {
+		dst = append(dst, hexDigits[logID[i]>>4], hexDigits[logID[i]&0x0f])
+	}
+	dst = append(dst, `","logIndex":`...)
+	dst = strconv.AppendInt(dst, logIndex, 10)
+	return append(dst, '}'), true
+}
+
+const hexDigits = "0123456789abcdef"
@@ -0,0 +1,604 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cyberphone/json-canonicalization/go/src/webpki.org/jsoncanonicalizer"
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	rekorutil "github.com/sigstore/rekor/pkg/util"
+	"github.com/sigstore/sigstore/pkg/signature"
+	"github.com/stretchr/testify/assert"
+	"github.com/transparency-dev/merkle/rfc6962"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+func TestCustomEntryType(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"custom","spec":{"data":"hello"}}`)
+
+	// entries of unregistered types can't be parsed
+	_, err := NewEntry(body, time.Now().Unix(), 1, []byte("logid"), nil, nil)
+	assert.Error(t, err)
+
+	RegisterRekorEntryType("custom", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		canonicalBody, err := jsoncanonicalizer.Transform(entry.CanonicalizedBody())
+		if err != nil {
+			return nil, nil, err
+		}
+		return canonicalBody, LeafHash(canonicalBody), nil
+	})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	pubBytes, err := x509.MarshalPKIXPublicKey(key.Public())
+	assert.NoError(t, err)
+	logID := sha256.Sum256(pubBytes)
+
+	integratedTime := time.Now().Unix()
+	payload, err := json.Marshal(RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime,
+		LogIndex:       1,
+		LogID:          hex.EncodeToString(logID[:]),
+	})
+	assert.NoError(t, err)
+	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	assert.NoError(t, err)
+	digest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(t, err)
+
+	entry, err := NewEntry(body, integratedTime, 1, logID[:], set, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, ValidateEntry(entry))
+	assert.Nil(t, entry.PublicKey())
+
+	tlogs := map[string]*root.TransparencyLog{
+		hex.EncodeToString(logID[:]): {
+			ID:                  logID[:],
+			ValidityPeriodStart: time.Now().Add(-time.Hour),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key.Public(),
+			SignatureHashFunc:   crypto.SHA256,
+		},
+	}
+	assert.NoError(t, VerifySET(entry, tlogs))
+
+	// a body that is not canonical does not match its reconstruction
+	nonCanonicalBody := []byte(`{"kind":"custom","apiVersion":"0.0.1","spec":{"data":"hello"}}`)
+	entry, err = NewEntry(nonCanonicalBody, integratedTime, 1, logID[:], set, nil)
+	assert.NoError(t, err)
+	assert.Error(t, ValidateEntry(entry))
+}
+
+func TestCustomEntryTypeLeafHash(t *testing.T) {
+	// the log computes the leaf hash of these entries over more than the body
+	body := []byte(`{"apiVersion":"0.0.1","kind":"leafhash","spec":{}}`)
+	leafHash := LeafHash(append([]byte("prefix:"), body...))
+	RegisterRekorEntryType("leafhash", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), LeafHash(append([]byte("prefix:"), entry.CanonicalizedBody()...)), nil
+	})
+
+	for _, tt := range []struct {
+		name     string
+		rootHash []byte
+		wantErr  bool
+	}{
+		{name: "reconstructed leaf hash", rootHash: leafHash},
+		{name: "leaf hash of the body", rootHash: LeafHash(body), wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// the entry is the only leaf of the tree
+			entry, err := NewEntry(body, time.Now().Unix(), 1, []byte("logid"), nil, &models.InclusionProof{
+				LogIndex: swag.Int64(0),
+				RootHash: swag.String(hex.EncodeToString(tt.rootHash)),
+				TreeSize: swag.Int64(1),
+				Hashes:   []string{},
+			})
+			assert.NoError(t, err)
+			assert.NoError(t, ValidateEntry(entry))
+
+			err = verifyInclusion(entry, crypto.SHA256)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestVerifySETWithKeyVersions(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"rotated","spec":{"data":"hello"}}`)
+	RegisterRekorEntryType("rotated", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), LeafHash(entry.CanonicalizedBody()), nil
+	})
+
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	logID := []byte("rotated log")
+
+	// the entry is integrated while both key versions are valid, and its SET
+	// is signed by the old key
+	integratedTime := time.Now().Unix()
+	payload, err := json.Marshal(RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime,
+		LogIndex:       1,
+		LogID:          hex.EncodeToString(logID),
+	})
+	assert.NoError(t, err)
+	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	assert.NoError(t, err)
+	digest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, oldKey, digest[:])
+	assert.NoError(t, err)
+
+	entry, err := NewEntry(body, integratedTime, 1, logID, set, nil)
+	assert.NoError(t, err)
+
+	keyVersion := func(key *ecdsa.PrivateKey, start, end time.Time) *root.TransparencyLog {
+		return &root.TransparencyLog{
+			ID:                  logID,
+			ValidityPeriodStart: start,
+			ValidityPeriodEnd:   end,
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key.Public(),
+			SignatureHashFunc:   crypto.SHA256,
+		}
+	}
+	oldVersion := keyVersion(oldKey, time.Now().Add(-24*time.Hour), time.Now().Add(time.Hour))
+	newVersion := keyVersion(newKey, time.Now().Add(-time.Hour), time.Time{})
+
+	// the new key is listed first, and is the only one VerifySET considers
+	assert.Error(t, VerifySET(entry, map[string]*root.TransparencyLog{hex.EncodeToString(logID): newVersion}))
+	assert.NoError(t, VerifySETWithKeyVersions(entry, []*root.TransparencyLog{newVersion, oldVersion}))
+
+	assert.Error(t, VerifySETWithKeyVersions(entry, []*root.TransparencyLog{newVersion}))
+	assert.Error(t, VerifySETWithKeyVersions(entry, nil))
+
+	// a key version for a different log is not tried
+	otherLog := keyVersion(oldKey, time.Now().Add(-24*time.Hour), time.Time{})
+	otherLog.ID = []byte("other log")
+	assert.Error(t, VerifySETWithKeyVersions(entry, []*root.TransparencyLog{otherLog}))
+}
+
+func TestVerifySETEd25519(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"ed25519","spec":{"data":"hello"}}`)
+	RegisterRekorEntryType("ed25519", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), LeafHash(entry.CanonicalizedBody()), nil
+	})
+	t.Cleanup(func() {
+		entryTypesMu.Lock()
+		defer entryTypesMu.Unlock()
+		delete(entryTypes, entryTypeKey("ed25519", "0.0.1"))
+	})
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	logID := []byte("ed25519 log")
+
+	integratedTime := time.Now().Unix()
+	payload, err := json.Marshal(RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime,
+		LogIndex:       1,
+		LogID:          hex.EncodeToString(logID),
+	})
+	assert.NoError(t, err)
+	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	assert.NoError(t, err)
+
+	// Ed25519 signs the canonicalized payload itself, not its digest
+	entry, err := NewEntry(body, integratedTime, 1, logID, ed25519.Sign(privateKey, canonicalized), nil)
+	assert.NoError(t, err)
+	verifiers := map[string]*root.TransparencyLog{
+		hex.EncodeToString(logID): {
+			ID:                  logID,
+			ValidityPeriodStart: time.Now().Add(-time.Hour),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           publicKey,
+		},
+	}
+	assert.NoError(t, VerifySET(entry, verifiers))
+
+	digest := sha256.Sum256(canonicalized)
+	entry, err = NewEntry(body, integratedTime, 1, logID, ed25519.Sign(privateKey, digest[:]), nil)
+	assert.NoError(t, err)
+	assert.Error(t, VerifySET(entry, verifiers))
+}
+
+func TestVerifySETSignatureHashFunc(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"hashfunc","spec":{"data":"hello"}}`)
+	RegisterRekorEntryType("hashfunc", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), LeafHash(entry.CanonicalizedBody()), nil
+	})
+	logID := []byte("hash function log")
+	integratedTime := time.Now().Unix()
+	payload, err := json.Marshal(RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime,
+		LogIndex:       1,
+		LogID:          hex.EncodeToString(logID),
+	})
+	assert.NoError(t, err)
+	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	assert.NoError(t, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		signer   crypto.Signer
+		hashFunc crypto.Hash
+	}{
+		{name: "ECDSA P-384 with SHA-384", signer: p384Key, hashFunc: crypto.SHA384},
+		{name: "RSA with SHA-256", signer: rsaKey, hashFunc: crypto.SHA256},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			h := tt.hashFunc.New()
+			h.Write(canonicalized)
+			set, err := tt.signer.Sign(rand.Reader, h.Sum(nil), tt.hashFunc)
+			assert.NoError(t, err)
+			entry, err := NewEntry(body, integratedTime, 1, logID, set, nil)
+			assert.NoError(t, err)
+
+			tlog := &root.TransparencyLog{
+				ID:                  logID,
+				ValidityPeriodStart: time.Now().Add(-time.Hour),
+				HashFunc:            crypto.SHA256,
+				PublicKey:           tt.signer.Public(),
+				SignatureHashFunc:   tt.hashFunc,
+			}
+			assert.NoError(t, VerifySET(entry, map[string]*root.TransparencyLog{hex.EncodeToString(logID): tlog}))
+
+			tlog.SignatureHashFunc = crypto.SHA512
+			assert.Error(t, VerifySET(entry, map[string]*root.TransparencyLog{hex.EncodeToString(logID): tlog}))
+		})
+	}
+}
+
+func TestVerifyInclusionWithHash(t *testing.T) {
+	body := []byte(`{"apiVersion":"0.0.1","kind":"sha384tree","spec":{"data":"hello"}}`)
+	RegisterRekorEntryType("sha384tree", "0.0.1", func(entry *Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), LeafHash(entry.CanonicalizedBody()), nil
+	})
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	signer, err := signature.LoadECDSASignerVerifier(key, crypto.SHA256)
+	assert.NoError(t, err)
+
+	// a tree of two leaves, hashed with SHA-384, the entry's first
+	hasher := rfc6962.New(crypto.SHA384)
+	otherLeaf := hasher.HashLeaf([]byte("other entry"))
+	rootHash := hasher.HashChildren(hasher.HashLeaf(body), otherLeaf)
+	checkpoint, err := rekorutil.CreateAndSignCheckpoint(context.Background(), "rekor.example.com", 1, 2, rootHash, signer)
+	assert.NoError(t, err)
+
+	entry, err := NewEntry(body, time.Now().Unix(), 0, []byte("sha384 log"), nil, &models.InclusionProof{
+		LogIndex:   swag.Int64(0),
+		RootHash:   swag.String(hex.EncodeToString(rootHash)),
+		TreeSize:   swag.Int64(2),
+		Hashes:     []string{hex.EncodeToString(otherLeaf)},
+		Checkpoint: swag.String(string(checkpoint)),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, VerifyInclusionWithHash(entry, signer, crypto.SHA384))
+	assert.Error(t, VerifyInclusionWithHash(entry, signer, crypto.SHA256))
+	assert.Error(t, VerifyInclusion(entry, signer))
+}
+
+func TestAppendCanonicalRekorPayload(t *testing.T) {
+	RegisterRekorEntryType("canonical", "0.0.1", reconstructEntry)
+	body := []byte(`{"apiVersion":"0.0.1","kind":"canonical","spec":{"data":"hello"}}`)
+	logID := sha256.Sum256([]byte("log"))
+
+	tests := []struct {
+		name           string
+		integratedTime int64
+		logIndex       int64
+		body           any
+		wantOK         bool
+	}{
+		{
+			name:           "typical entry",
+			integratedTime: time.Now().Unix(),
+			logIndex:       25579,
+			wantOK:         true,
+		},
+		{
+			name:           "zero values",
+			integratedTime: 0,
+			logIndex:       0,
+			wantOK:         true,
+		},
+		{
+			name:           "largest exact integers",
+			integratedTime: maxExactJSONInteger,
+			logIndex:       -maxExactJSONInteger,
+			wantOK:         true,
+		},
+		{
+			name:           "inexact integer",
+			integratedTime: time.Now().Unix(),
+			logIndex:       maxExactJSONInteger + 1,
+		},
+		{
+			name:           "body that needs escaping",
+			integratedTime: time.Now().Unix(),
+			logIndex:       1,
+			body:           `"quoted"`,
+		},
+		{
+			name:           "body that isn't a string",
+			integratedTime: time.Now().Unix(),
+			logIndex:       1,
+			body:           map[string]string{"body": "value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := NewEntry(body, tt.integratedTime, tt.logIndex, logID[:], nil, nil)
+			assert.NoError(t, err)
+			if tt.body != nil {
+				entry.logEntryAnon.Body = tt.body
+			}
+
+			got, ok := appendCanonicalRekorPayload(nil, entry)
+			assert.Equal(t, tt.wantOK, ok)
+			if !ok {
+				return
+			}
+
+			payload, err := json.Marshal(RekorPayload{
+				Body:           entry.logEntryAnon.Body,
+				IntegratedTime: tt.integratedTime,
+				LogIndex:       tt.logIndex,
+				LogID:          hex.EncodeToString(logID[:]),
+			})
+			assert.NoError(t, err)
+			want, err := jsoncanonicalizer.Transform(payload)
+			assert.NoError(t, err)
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+
+	// once its buffer is large enough, canonicalizing a payload doesn't
+	// allocate
+	entry, err := NewEntry(body, time.Now().Unix(), 1, logID[:], nil, nil)
+	assert.NoError(t, err)
+	buf, _ := appendCanonicalRekorPayload(nil, entry)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf, _ = appendCanonicalRekorPayload(buf[:0], entry)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestBuiltinEntryTypeMustParse(t *testing.T) {
+	// a hashedrekord body that doesn't match Rekor's schema is rejected,
+	// rather than handled as a custom entry type
+	body := []byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"data":"hello"}}`)
+	_, err := NewEntry(body, time.Now().Unix(), 1, []byte("logid"), nil, nil)
+	assert.Error(t, err)
+}
+
+func TestCheckpointTime(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		checkpoint string
+		wantTime   time.Time
+		wantOK     bool
+		wantErr    bool
+	}{
+		{
+			name:       "with timestamp",
+			checkpoint: "rekor.sigstore.dev - 2605736670972794746\n27657875\nv+7gOn1wovHHKBEVizJ5FFgTKUBCN9UxLo5KQ1Jz8cw=\nTimestamp: 1692374735595899989\n\n— rekor.sigstore.dev wNI9ajBE\n",
+			wantTime:   time.Unix(0, 1692374735595899989),
+			wantOK:     true,
+		},
+		{
+			name:       "without timestamp",
+			checkpoint: "rekor.sigstore.dev - 2605736670972794746\n27657875\nv+7gOn1wovHHKBEVizJ5FFgTKUBCN9UxLo5KQ1Jz8cw=\n\n— rekor.sigstore.dev wNI9ajBE\n",
+		},
+		{
+			name:       "timestamp in signature",
+			checkpoint: "rekor.sigstore.dev - 2605736670972794746\n27657875\nv+7gOn1wovHHKBEVizJ5FFgTKUBCN9UxLo5KQ1Jz8cw=\n\nTimestamp: 1692374735595899989\n",
+		},
+		{
+			name:       "invalid timestamp",
+			checkpoint: "rekor.sigstore.dev - 2605736670972794746\n27657875\nv+7gOn1wovHHKBEVizJ5FFgTKUBCN9UxLo5KQ1Jz8cw=\nTimestamp: yesterday\n\n— rekor.sigstore.dev wNI9ajBE\n",
+			wantErr:    true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &Entry{}
+			entry.logEntryAnon.Verification = &models.LogEntryAnonVerification{
+				InclusionProof: &models.InclusionProof{Checkpoint: swag.String(tt.checkpoint)},
+			}
+			checkpointTime, ok, err := entry.CheckpointTime()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.True(t, tt.wantTime.Equal(checkpointTime))
+		})
+	}
+
+	// an entry without an inclusion proof has no checkpoint
+	_, ok, err := (&Entry{}).CheckpointTime()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+// fixtureEntries returns the transparency log entries of the test bundles.
+func fixtureEntries(t testing.TB) []fixtureEntry {
+	var entries []fixtureEntry
+	for _, path := range []string{
+		"../testing/data/sigstoreBundle.json",
+		"../testing/data/sigstore.js@2.0.0-provenanceBundle.json",
+	} {
+		contents, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		var bundle struct {
+			VerificationMaterial struct {
+				TlogEntries []fixtureEntry `json:"tlogEntries"`
+			} `json:"verificationMaterial"`
+		}
+		assert.NoError(t, json.Unmarshal(contents, &bundle))
+		entries = append(entries, bundle.VerificationMaterial.TlogEntries...)
+	}
+	return entries
+}
+
+type fixtureEntry struct {
+	CanonicalizedBody []byte `json:"canonicalizedBody"`
+	InclusionProof    *struct {
+		LogIndex   int64    `json:"logIndex,string"`
+		RootHash   []byte   `json:"rootHash"`
+		TreeSize   int64    `json:"treeSize,string"`
+		Hashes     [][]byte `json:"hashes"`
+		Checkpoint struct {
+			Envelope string `json:"envelope"`
+		} `json:"checkpoint"`
+	} `json:"inclusionProof"`
+}
+
+// FuzzNewEntry checks that parsing an untrusted entry body, and reading the
+// signature and public key of one that parses, never panics.
+func FuzzNewEntry(f *testing.F) {
+	for _, entry := range fixtureEntries(f) {
+		f.Add(entry.CanonicalizedBody)
+	}
+	f.Add([]byte(`{"apiVersion":"0.0.1","kind":"hashedrekord","spec":{"data":{"hash":{"algorithm":"sha256","value":"0000000000000000000000000000000000000000000000000000000000000000"}},"signature":{"content":"c2ln","publicKey":{"content":"a2V5"}}}}`))
+	f.Add([]byte(`{"apiVersion":"0.0.1","kind":"dsse","spec":{"envelopeHash":{"algorithm":"sha256","value":"0000000000000000000000000000000000000000000000000000000000000000"},"payloadHash":{"algorithm":"sha256","value":"0000000000000000000000000000000000000000000000000000000000000000"},"signatures":[{"signature":"c2ln","verifier":"a2V5"}]}}`))
+
+	f.Fuzz(func(_ *testing.T, body []byte) {
+		entry, err := NewEntry(body, 1, 1, []byte("logid"), nil, nil)
+		if err != nil {
+			return
+		}
+
+		_ = ValidateEntry(entry)
+		entry.Signature()
+		entry.PublicKey()
+		entry.UUID()
+	})
+}
+
+// FuzzVerifyInclusion checks that verifying the inclusion proof and
+// checkpoint of an untrusted entry never panics.
+func FuzzVerifyInclusion(f *testing.F) {
+	var hashes []string
+	for _, entry := range fixtureEntries(f) {
+		if entry.InclusionProof == nil {
+			continue
+		}
+		proof := entry.InclusionProof
+		f.Add(entry.CanonicalizedBody, proof.Checkpoint.Envelope, proof.RootHash, proof.LogIndex, proof.TreeSize)
+		for _, hash := range proof.Hashes {
+			hashes = append(hashes, hex.EncodeToString(hash))
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(f, err)
+	verifier, err := signature.LoadVerifier(key.Public(), crypto.SHA256)
+	assert.NoError(f, err)
+
+	f.Fuzz(func(_ *testing.T, body []byte, checkpoint string, rootHash []byte, logIndex, treeSize int64) {
+		inclusionProof := &models.InclusionProof{
+			LogIndex:   swag.Int64(logIndex),
+			RootHash:   swag.String(hex.EncodeToString(rootHash)),
+			TreeSize:   swag.Int64(treeSize),
+			Hashes:     hashes,
+			Checkpoint: swag.String(checkpoint),
+		}
+		entry, err := NewEntry(body, 1, logIndex, []byte("logid"), nil, inclusionProof)
+		if err != nil {
+			return
+		}
+
+		_ = VerifyInclusion(entry, verifier)
+	})
+}
+
+func BenchmarkVerifySET(b *testing.B) {
+	RegisterRekorEntryType("canonical", "0.0.1", reconstructEntry)
+	body := []byte(`{"apiVersion":"0.0.1","kind":"canonical","spec":{"data":"hello"}}`)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(b, err)
+	logID := sha256.Sum256([]byte("log"))
+
+	integratedTime := time.Now().Unix()
+	payload, err := json.Marshal(RekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime,
+		LogIndex:       25579,
+		LogID:          hex.EncodeToString(logID[:]),
+	})
+	assert.NoError(b, err)
+	canonicalized, err := jsoncanonicalizer.Transform(payload)
+	assert.NoError(b, err)
+	digest := sha256.Sum256(canonicalized)
+	set, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.NoError(b, err)
+
+	entry, err := NewEntry(body, integratedTime, 25579, logID[:], set, nil)
+	assert.NoError(b, err)
+	tlogs := map[string]*root.TransparencyLog{
+		hex.EncodeToString(logID[:]): {
+			ID:                  logID[:],
+			ValidityPeriodStart: time.Now().Add(-time.Hour),
+			HashFunc:            crypto.SHA256,
+			PublicKey:           key.Public(),
+			SignatureHashFunc:   crypto.SHA256,
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := VerifySET(entry, tlogs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
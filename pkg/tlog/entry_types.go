@@ -0,0 +1,113 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/go-openapi/swag"
+	"github.com/sigstore/rekor/pkg/generated/models"
+	"github.com/sigstore/rekor/pkg/types"
+)
+
+// The bodies of dsse and intoto entries are parsed with Rekor's generated
+// models rather than its dsse and intoto types, which import in-toto-golang,
+// which doesn't build for WebAssembly. They are validated as Rekor's types
+// validate entries read from the log, except that the signatures of an
+// intoto entry carrying its envelope's payload aren't verified here: the
+// signed entity's envelope is verified, and matched to the entry, instead.
+
+// dsseV001Entry is the body of a dsse v0.0.1 log entry.
+type dsseV001Entry struct {
+	DSSEObj models.DSSEV001Schema
+}
+
+// intotoV002Entry is the body of an intoto v0.0.2 log entry.
+type intotoV002Entry struct {
+	IntotoObj models.IntotoV002Schema
+}
+
+// unmarshalEntry parses the body of a log entry of one of the built-in
+// types, returning it with its API version.
+func unmarshalEntry(pe models.ProposedEntry) (any, string, error) {
+	switch pe := pe.(type) {
+	case *models.DSSE:
+		return unmarshalDSSEEntry(pe)
+	case *models.Intoto:
+		return unmarshalIntotoEntry(pe)
+	}
+
+	rekorEntry, err := types.UnmarshalEntry(pe)
+	if err != nil {
+		return nil, "", err
+	}
+	return rekorEntry, rekorEntry.APIVersion(), nil
+}
+
+func unmarshalDSSEEntry(pe *models.DSSE) (any, string, error) {
+	version := swag.StringValue(pe.APIVersion)
+	if version != "0.0.1" {
+		return nil, "", fmt.Errorf("unsupported dsse entry version: %s", version)
+	}
+
+	e := &dsseV001Entry{}
+	if err := types.DecodeEntry(pe.Spec, &e.DSSEObj); err != nil {
+		return nil, "", err
+	}
+	if err := e.DSSEObj.Validate(strfmt.Default); err != nil {
+		return nil, "", err
+	}
+	// an entry read from the log holds the fields the log computed from the
+	// proposed content, rather than the content itself
+	if e.DSSEObj.EnvelopeHash == nil || e.DSSEObj.PayloadHash == nil || len(e.DSSEObj.Signatures) == 0 {
+		return nil, "", errors.New("dsse entry must have envelopeHash, payloadHash, and signatures")
+	}
+	return e, version, nil
+}
+
+func unmarshalIntotoEntry(pe *models.Intoto) (any, string, error) {
+	version := swag.StringValue(pe.APIVersion)
+	if version != "0.0.2" {
+		return nil, "", fmt.Errorf("unsupported intoto entry version: %s", version)
+	}
+
+	e := &intotoV002Entry{}
+	if err := types.DecodeEntry(pe.Spec, &e.IntotoObj); err != nil {
+		return nil, "", err
+	}
+	if err := e.IntotoObj.Validate(strfmt.Default); err != nil {
+		return nil, "", err
+	}
+
+	// the log drops the payload of the envelope it records, keeping its hash;
+	// compute the hash for an entry that still carries it, as Rekor does
+	if payload := e.IntotoObj.Content.Envelope.Payload; len(payload) > 0 {
+		decodedPayload, err := base64.StdEncoding.DecodeString(string(payload))
+		if err != nil {
+			return nil, "", fmt.Errorf("could not decode envelope payload: %w", err)
+		}
+		digest := sha256.Sum256(decodedPayload)
+		e.IntotoObj.Content.PayloadHash = &models.IntotoV002SchemaContentPayloadHash{
+			Algorithm: swag.String(models.IntotoV002SchemaContentPayloadHashAlgorithmSha256),
+			Value:     swag.String(hex.EncodeToString(digest[:])),
+		}
+	}
+	return e, version, nil
+}
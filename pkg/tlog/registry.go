@@ -0,0 +1,194 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlog
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sigstore/rekor/pkg/types/dsse"
+	"github.com/sigstore/rekor/pkg/types/hashedrekord"
+	"github.com/sigstore/rekor/pkg/types/intoto"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// EntryReconstructor rebuilds the canonicalized body of a log entry of a
+// given kind and version, along with the RFC 6962 leaf hash the log computed
+// over it. The leaf hash is what the entry's inclusion proof is verified
+// against.
+type EntryReconstructor func(entry *Entry) (canonicalBody []byte, leafHash []byte, err error)
+
+// EntryVerificationMaterial extracts the signature a log entry of a custom
+// type records over the artifact, and the certificate or public key that
+// verifies it, as Entry.Signature and Entry.PublicKey return them. Without
+// it, entries of the type can't be matched to the signed entity logged.
+type EntryVerificationMaterial func(entry *Entry) (signature []byte, publicKey any, err error)
+
+// EntryTypeOption configures a custom entry type registered with
+// RegisterRekorEntryType.
+type EntryTypeOption func(*entryType)
+
+// WithEntryVerificationMaterial sets how the signature and the certificate
+// or public key are read from entries of the type.
+func WithEntryVerificationMaterial(material EntryVerificationMaterial) EntryTypeOption {
+	return func(t *entryType) {
+		t.verificationMaterial = material
+	}
+}
+
+type entryType struct {
+	reconstruct          EntryReconstructor
+	verificationMaterial EntryVerificationMaterial
+}
+
+var (
+	entryTypesMu sync.RWMutex
+	entryTypes   = make(map[string]*entryType)
+)
+
+// builtinEntryTypes are the kinds and versions that Rekor's own types parse
+// and ValidateEntry checks against their schema.
+var builtinEntryTypes = map[string]bool{
+	entryTypeKey(dsse.KIND, "0.0.1"):         true,
+	entryTypeKey(hashedrekord.KIND, "0.0.1"): true,
+	entryTypeKey(intoto.KIND, "0.0.2"):       true,
+}
+
+func init() {
+	for key := range builtinEntryTypes {
+		entryTypes[key] = &entryType{reconstruct: reconstructEntry}
+	}
+}
+
+// RegisterRekorEntryType registers a reconstructor for Rekor entries of the
+// given kind and version, so that entries of that type can be parsed and
+// verified. Registering a kind and version again replaces the previous
+// reconstructor.
+func RegisterRekorEntryType(kind, version string, reconstructor EntryReconstructor, opts ...EntryTypeOption) {
+	t := &entryType{reconstruct: reconstructor}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	entryTypesMu.Lock()
+	defer entryTypesMu.Unlock()
+	entryTypes[entryTypeKey(kind, version)] = t
+}
+
+// RegisteredEntryTypes returns the kinds and versions of Rekor entries that
+// can be parsed and verified, as "kind/version", sorted. It includes those
+// added with RegisterRekorEntryType.
+func RegisteredEntryTypes() []string {
+	entryTypesMu.RLock()
+	defer entryTypesMu.RUnlock()
+	types := make([]string, 0, len(entryTypes))
+	for key := range entryTypes {
+		types = append(types, key)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func lookupEntryType(kind, version string) (*entryType, bool) {
+	entryTypesMu.RLock()
+	defer entryTypesMu.RUnlock()
+	t, ok := entryTypes[entryTypeKey(kind, version)]
+	return t, ok
+}
+
+func isBuiltinEntryType(kind, version string) bool {
+	return builtinEntryTypes[entryTypeKey(kind, version)]
+}
+
+func entryTypeKey(kind, version string) string {
+	return kind + "/" + version
+}
+
+// reconstructEntry is the reconstructor for the built-in entry types, whose
+// bodies are validated against their Rekor schema by ValidateEntry.
+func reconstructEntry(entry *Entry) ([]byte, []byte, error) {
+	return entry.CanonicalizedBody(), LeafHash(entry.CanonicalizedBody()), nil
+}
+
+// LeafHash returns the RFC 6962 Merkle tree leaf hash of a log entry body.
+func LeafHash(body []byte) []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{0x00})
+	hasher.Write(body)
+	return hasher.Sum(nil)
+}
+
+// reconstruct rebuilds the entry's canonicalized body and leaf hash with the
+// reconstructor registered for its kind and version.
+func reconstruct(entry *Entry) ([]byte, []byte, error) {
+	t, ok := lookupEntryType(entry.kind, entry.version)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported entry type: %s/%s", entry.kind, entry.version)
+	}
+
+	canonicalBody, leafHash, err := t.reconstruct(entry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reconstructing %s/%s entry: %w", entry.kind, entry.version, err)
+	}
+	return canonicalBody, leafHash, nil
+}
+
+// validateReconstructedEntry checks that the registered reconstructor for the
+// entry's kind and version reproduces the body the log signed.
+func validateReconstructedEntry(entry *Entry) error {
+	canonicalBody, _, err := reconstruct(entry)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(canonicalBody, entry.CanonicalizedBody()) {
+		return errors.New("entry body does not match reconstructed canonical body")
+	}
+	return nil
+}
+
+// reconstructedLeafHash returns the leaf hash to verify the entry's
+// inclusion proof against: the one its reconstructor returns for a tree
+// hashed with SHA-256, and otherwise the reconstructed body hashed with
+// hashFunc.
+func reconstructedLeafHash(entry *Entry, hashFunc crypto.Hash) ([]byte, error) {
+	canonicalBody, leafHash, err := reconstruct(entry)
+	if err != nil {
+		return nil, err
+	}
+	if hashFunc == crypto.SHA256 {
+		return leafHash, nil
+	}
+	return rfc6962.New(hashFunc).HashLeaf(canonicalBody), nil
+}
+
+// customVerificationMaterial returns the signature and the certificate or
+// public key of an entry of a custom type, or nils if its type was
+// registered without WithEntryVerificationMaterial.
+func customVerificationMaterial(entry *Entry) ([]byte, any) {
+	t, ok := lookupEntryType(entry.kind, entry.version)
+	if !ok || t.verificationMaterial == nil {
+		return nil, nil
+	}
+	sig, publicKey, err := t.verificationMaterial(entry)
+	if err != nil {
+		return nil, nil
+	}
+	return sig, publicKey
+}
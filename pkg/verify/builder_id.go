@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+)
+
+// SLSA provenance predicate types whose builder ID NewBuilderIDPolicy can
+// check.
+const (
+	slsaProvenanceV01 = "https://slsa.dev/provenance/v0.1"
+	slsaProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+	slsaProvenanceV1  = "https://slsa.dev/provenance/v1"
+)
+
+// NewBuilderIDPolicy allows the caller of Verify to enforce that the
+// SignedEntity's SLSA provenance was produced by one of the allowed builders.
+// The builder ID is read from the predicate's builder.id for provenance
+// v0.1 and v0.2, and from runDetails.builder.id for provenance v1, and must
+// equal one of allowed exactly.
+//
+// If this policy is enabled, but the SignedEntity does not contain an in-toto
+// statement with a SLSA provenance predicate, verification will fail.
+func NewBuilderIDPolicy(allowed []string) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if len(allowed) == 0 {
+			return errors.New("builder ID allowlist must not be empty")
+		}
+		for _, id := range allowed {
+			if id == "" {
+				return errors.New("builder ID allowlist must not contain an empty builder ID")
+			}
+		}
+		if p.allowedBuilderIDs != nil {
+			return errors.New("only one invocation of NewBuilderIDPolicy is allowed")
+		}
+
+		p.allowedBuilderIDs = allowed
+		return nil
+	}
+}
+
+// verifyBuilderID checks that the statement's SLSA provenance names one of
+// the allowed builders.
+func verifyBuilderID(statement *in_toto.Statement, allowed []string) (string, error) {
+	builderID, err := provenanceBuilderID(statement)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range allowed {
+		if builderID == id {
+			return builderID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrBuilderIDNotAllowed, builderID)
+}
+
+// provenanceBuilderID returns the builder ID of the statement's SLSA
+// provenance predicate.
+func provenanceBuilderID(statement *in_toto.Statement) (string, error) {
+	if statement == nil {
+		return "", errors.New("no in-toto statement to check the builder ID of")
+	}
+
+	var path []string
+	switch statement.PredicateType {
+	case slsaProvenanceV01, slsaProvenanceV02:
+		path = []string{"builder", "id"}
+	case slsaProvenanceV1:
+		path = []string{"runDetails", "builder", "id"}
+	default:
+		return "", fmt.Errorf("predicate type %q is not SLSA provenance", statement.PredicateType)
+	}
+
+	var node interface{} = statement.GetPredicate().AsMap()
+	for _, key := range path {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			node = nil
+			break
+		}
+		node = object[key]
+	}
+	builderID, ok := node.(string)
+	if !ok || builderID == "" {
+		return "", fmt.Errorf("%s provenance has no builder ID", statement.PredicateType)
+	}
+	return builderID, nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilderIDPolicy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifactDigest := sha256.Sum256([]byte("artifact"))
+	attest := func(predicateType, predicate string) verify.SignedEntity {
+		statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"%s","subject":[{"name":"artifact","digest":{"sha256":"%s"}}],"predicate":%s}`,
+			predicateType, hex.EncodeToString(artifactDigest[:]), predicate))
+		entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+		assert.NoError(t, err)
+		return entity
+	}
+
+	const (
+		githubBuilder = "https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml@refs/tags/v1.9.0"
+		otherBuilder  = "https://example.com/builder@v1"
+	)
+	v1Provenance := attest("https://slsa.dev/provenance/v1", fmt.Sprintf(`{"buildDefinition":{},"runDetails":{"builder":{"id":"%s"}}}`, githubBuilder))
+	v02Provenance := attest("https://slsa.dev/provenance/v0.2", fmt.Sprintf(`{"builder":{"id":"%s"},"buildType":"https://example.com/build"}`, githubBuilder))
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		entity      verify.SignedEntity
+		allowed     []string
+		wantErr     bool
+		wantErrIs   error
+		wantOptsErr bool
+	}{
+		{
+			name:    "allowed v1 builder",
+			entity:  v1Provenance,
+			allowed: []string{otherBuilder, githubBuilder},
+		},
+		{
+			name:    "allowed v0.2 builder",
+			entity:  v02Provenance,
+			allowed: []string{githubBuilder},
+		},
+		{
+			name:      "disallowed v1 builder",
+			entity:    v1Provenance,
+			allowed:   []string{otherBuilder},
+			wantErr:   true,
+			wantErrIs: verify.ErrBuilderIDNotAllowed,
+		},
+		{
+			name:      "disallowed v0.2 builder",
+			entity:    v02Provenance,
+			allowed:   []string{otherBuilder},
+			wantErr:   true,
+			wantErrIs: verify.ErrBuilderIDNotAllowed,
+		},
+		{
+			name:    "builder ID must match exactly",
+			entity:  v1Provenance,
+			allowed: []string{"https://github.com/slsa-framework/slsa-github-generator/.github/workflows/generator_generic_slsa3.yml"},
+			wantErr: true,
+		},
+		{
+			name:    "v0.2 location in v1 provenance",
+			entity:  attest("https://slsa.dev/provenance/v1", fmt.Sprintf(`{"builder":{"id":"%s"}}`, githubBuilder)),
+			allowed: []string{githubBuilder},
+			wantErr: true,
+		},
+		{
+			name:    "not SLSA provenance",
+			entity:  attest("customFoo", fmt.Sprintf(`{"builder":{"id":"%s"}}`, githubBuilder)),
+			allowed: []string{githubBuilder},
+			wantErr: true,
+		},
+		{
+			name:        "empty allowlist",
+			entity:      v1Provenance,
+			wantOptsErr: true,
+		},
+		{
+			name:        "empty builder ID in allowlist",
+			entity:      v1Provenance,
+			allowed:     []string{githubBuilder, ""},
+			wantOptsErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), verify.NewBuilderIDPolicy(tt.allowed))
+			_, err := policy.BuildConfig()
+			if tt.wantOptsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(tt.entity, policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Error: only one allowlist may be given
+	_, err = verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), verify.NewBuilderIDPolicy([]string{githubBuilder}), verify.NewBuilderIDPolicy([]string{otherBuilder})).BuildConfig()
+	assert.Error(t, err)
+}
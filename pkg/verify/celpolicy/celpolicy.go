@@ -0,0 +1,146 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package celpolicy checks verified in-toto statements against policies
+// written as CEL expressions. It is kept apart from the verify package so
+// that only callers that use it depend on the CEL engine.
+package celpolicy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	in_toto "github.com/in-toto/attestation/go/v1"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewCELPolicy allows the caller of Verify to enforce that the
+// SignedEntity's in-toto statement satisfies a CEL expression, which must
+// evaluate to a bool. The expression can refer to these variables:
+//
+//   - subject: the statement's subjects, each with a name and a map of
+//     digests
+//   - predicateType: the statement's predicate type
+//   - predicate: the statement's predicate
+//   - certificate: the signing certificate's summary, keyed as in its JSON
+//     encoding, or an empty map if the entity was signed with a key
+//
+// For example, `predicateType == "https://slsa.dev/provenance/v1" &&
+// certificate.sourceRepositoryURI == "https://github.com/myorg/app"`. The
+// expression is compiled when the policy is built, and verification fails
+// with verify.ErrStatementPolicyFailed if it evaluates to false.
+func NewCELPolicy(expression string) verify.PolicyOption {
+	predicate, err := newPredicate(expression)
+	if err != nil {
+		return func(*verify.PolicyConfig) error {
+			return err
+		}
+	}
+	return verify.WithStatementPredicate(predicate)
+}
+
+// newPredicate compiles the expression into a StatementPredicate.
+func newPredicate(expression string) (verify.StatementPredicate, error) {
+	if expression == "" {
+		return nil, errors.New("CEL expression must not be empty")
+	}
+
+	env, err := cel.NewEnv(
+		cel.Variable("subject", cel.ListType(cel.DynType)),
+		cel.Variable("predicateType", cel.StringType),
+		cel.Variable("predicate", cel.DynType),
+		cel.Variable("certificate", cel.MapType(cel.StringType, cel.DynType)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", issues.Err())
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) {
+		return nil, fmt.Errorf("CEL expression must evaluate to a bool, not %s", ast.OutputType())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CEL expression: %w", err)
+	}
+
+	return func(statement *in_toto.Statement, signer *certificate.Summary) error {
+		activation, err := variables(statement, signer)
+		if err != nil {
+			return err
+		}
+		out, _, err := program.Eval(activation)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate CEL expression: %w", err)
+		}
+		if passed, ok := out.Value().(bool); !ok || !passed {
+			return fmt.Errorf("%w: %s", verify.ErrStatementPolicyFailed, expression)
+		}
+		return nil
+	}, nil
+}
+
+// variables returns the statement and signer as the expression's variables,
+// in the shape of their JSON encoding.
+func variables(statement *in_toto.Statement, signer *certificate.Summary) (map[string]any, error) {
+	encoded, err := protojson.Marshal(statement)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode statement: %w", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode statement: %w", err)
+	}
+	subject, ok := fields["subject"]
+	if !ok {
+		subject = []any{}
+	}
+	predicate := fields["predicate"]
+	signerSummary := map[string]any{}
+	if signer != nil {
+		value, err := asJSONValue(signer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode certificate summary: %w", err)
+		}
+		signerSummary = value.(map[string]any)
+	}
+
+	return map[string]any{
+		"subject":       subject,
+		"predicateType": statement.PredicateType,
+		"predicate":     predicate,
+		"certificate":   signerSummary,
+	}, nil
+}
+
+// asJSONValue converts v to the maps, slices and scalars of its JSON
+// encoding.
+func asJSONValue(v any) (any, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	err = json.Unmarshal(encoded, &value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
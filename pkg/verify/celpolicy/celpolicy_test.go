@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package celpolicy_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/sigstore/sigstore-go/pkg/verify/celpolicy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCELPolicy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"%s"}}],"predicate":{"level":3}}`,
+		hex.EncodeToString(artifactDigest[:])))
+	attestation, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	messageSignature, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		entity      verify.SignedEntity
+		expression  string
+		wantErr     bool
+		wantErrIs   error
+		wantOptsErr bool
+	}{
+		{
+			name:       "predicate type",
+			entity:     attestation,
+			expression: `predicateType == "customFoo"`,
+		},
+		{
+			name:       "subject and predicate",
+			entity:     attestation,
+			expression: `subject.exists(s, s.name == "subject") && predicate.level >= 3`,
+		},
+		{
+			name:       "certificate identity",
+			entity:     attestation,
+			expression: `certificate.subjectAlternativeName.value == "foo@example.com"`,
+		},
+		{
+			name:       "other predicate type",
+			entity:     attestation,
+			expression: `predicateType == "https://slsa.dev/provenance/v1"`,
+			wantErr:    true,
+			wantErrIs:  verify.ErrStatementPolicyFailed,
+		},
+		{
+			name:       "other identity",
+			entity:     attestation,
+			expression: `certificate.subjectAlternativeName.value == "bar@example.com"`,
+			wantErr:    true,
+			wantErrIs:  verify.ErrStatementPolicyFailed,
+		},
+		{
+			name:       "no statement",
+			entity:     messageSignature,
+			expression: `predicateType == "customFoo"`,
+			wantErr:    true,
+		},
+		{
+			name:        "empty expression",
+			entity:      attestation,
+			wantOptsErr: true,
+		},
+		{
+			name:        "malformed expression",
+			entity:      attestation,
+			expression:  `predicateType ==`,
+			wantOptsErr: true,
+		},
+		{
+			name:        "not a bool",
+			entity:      attestation,
+			expression:  `predicateType`,
+			wantOptsErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), celpolicy.NewCELPolicy(tt.expression))
+			_, err := policy.BuildConfig()
+			if tt.wantOptsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(tt.entity, policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
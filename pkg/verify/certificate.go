@@ -15,19 +15,48 @@
 package verify
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/x509"
+	"encoding/asn1"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
 	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
+// VerifyLeafCertificate verifies that the leaf certificate chains to one of
+// the trusted material's certificate authorities at the signing time given by
+// observerTimestamp. Only CAs that the trusted material trusted at that time
+// are candidates to anchor the chain, even if their certificates would
+// otherwise validate it, and the chain is verified as of that time.
 func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificate, trustedMaterial root.TrustedMaterial) error { // nolint: revive
+	return verifyLeafCertificateAt(observerTimestamp, observerTimestamp, leafCert, trustedMaterial, -1)
+}
+
+// verifyLeafCertificateAt verifies the leaf certificate's chain as of
+// verificationTime, anchored only by CAs trusted at signingTime. The two
+// differ only when verificationTime has been clamped to the leaf's NotBefore,
+// which must not extend the CAs' validity windows. If maxPathLen is not
+// negative, chains with more intermediates than that are rejected.
+//
+// x509.Certificate.Verify enforces the BasicConstraints path length of every
+// CA certificate in the chain, so a chain violating one never verifies.
+func verifyLeafCertificateAt(signingTime, verificationTime time.Time, leafCert x509.Certificate, trustedMaterial root.TrustedMaterial, maxPathLen int) error {
+	leafCert = withUsernameSANHandled(leafCert)
+	chainErr := &ChainVerificationError{}
 	for _, ca := range trustedMaterial.FulcioCertificateAuthorities() {
-		if !ca.ValidityPeriodStart.IsZero() && observerTimestamp.Before(ca.ValidityPeriodStart) {
-			continue
+		candidate := ChainCandidateFailure{}
+		if ca.Root != nil {
+			candidate.Subject = ca.Root.Subject.String()
 		}
-		if !ca.ValidityPeriodEnd.IsZero() && observerTimestamp.After(ca.ValidityPeriodEnd) {
+
+		if !ca.ValidAtTime(signingTime) {
+			candidate.Reason = ChainFailureCANotTrusted
+			chainErr.Candidates = append(chainErr.Candidates, candidate)
 			continue
 		}
 
@@ -43,7 +72,7 @@ func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificat
 		// > For a signature with a given certificate to be considered valid, it must have a timestamp while every certificate in the chain up to the root is valid (the so-called “hybrid model” of certificate verification per Braun et al. (2013)).
 
 		opts := x509.VerifyOptions{
-			CurrentTime:   observerTimestamp,
+			CurrentTime:   verificationTime,
 			Roots:         rootCertPool,
 			Intermediates: intermediateCertPool,
 			KeyUsages: []x509.ExtKeyUsage{
@@ -51,11 +80,426 @@ func VerifyLeafCertificate(observerTimestamp time.Time, leafCert x509.Certificat
 			},
 		}
 
-		_, err := leafCert.Verify(opts)
+		chains, err := leafCert.Verify(opts)
 		if err == nil {
+			err = checkPathLength(&leafCert, chains, maxPathLen)
+		}
+		if err != nil {
+			candidate.Reason = chainFailureReason(err)
+			candidate.Err = err
+			chainErr.Candidates = append(chainErr.Candidates, candidate)
+			continue
+		}
+
+		// A leaf issued before the CA's validity period began was either
+		// backdated or issued by a CA that was not yet trusted.
+		if !ca.ValidityPeriodStart.IsZero() && leafCert.NotBefore.Before(ca.ValidityPeriodStart) {
+			candidate.Reason = ChainFailureIssuedBeforeCA
+			chainErr.Candidates = append(chainErr.Candidates, candidate)
+			continue
+		}
+
+		return nil
+	}
+
+	return chainErr
+}
+
+// AdditionalRootsSubject is the Subject of the ChainCandidateFailure for the
+// roots configured with WithAdditionalRoots.
+const AdditionalRootsSubject = "additional roots"
+
+// verifyLeafCertificateWithRoots verifies the leaf certificate's chain to one
+// of roots as of verificationTime, returning why it failed if it did.
+func verifyLeafCertificateWithRoots(verificationTime time.Time, leafCert x509.Certificate, roots *x509.CertPool, maxPathLen int) (ChainCandidateFailure, bool) {
+	candidate := ChainCandidateFailure{Subject: AdditionalRootsSubject}
+	leafCert = withUsernameSANHandled(leafCert)
+	chains, err := leafCert.Verify(x509.VerifyOptions{
+		CurrentTime: verificationTime,
+		Roots:       roots,
+		KeyUsages: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageCodeSigning,
+		},
+	})
+	if err == nil {
+		err = checkPathLength(&leafCert, chains, maxPathLen)
+	}
+	if err != nil {
+		candidate.Reason = chainFailureReason(err)
+		candidate.Err = err
+		return candidate, false
+	}
+	return candidate, true
+}
+
+// withUsernameSANHandled returns the leaf certificate with its critical
+// Subject Alternative Name extension marked as handled if it holds a Fulcio
+// username otherName SAN. Fulcio issues username certificates with an empty
+// subject and a critical SAN extension holding only the otherName, which
+// x509.Certificate.Verify would reject as an unhandled critical extension
+// because the x509 package doesn't parse otherNames.
+func withUsernameSANHandled(leafCert x509.Certificate) x509.Certificate {
+	var unhandled []asn1.ObjectIdentifier
+	for _, oid := range leafCert.UnhandledCriticalExtensions {
+		if !oid.Equal(oidSubjectAlternativeName) {
+			unhandled = append(unhandled, oid)
+		}
+	}
+	if len(unhandled) == len(leafCert.UnhandledCriticalExtensions) {
+		return leafCert
+	}
+
+	sans, err := certificate.ParseSubjectAlternativeNames(&leafCert)
+	if err != nil {
+		return leafCert
+	}
+	for _, san := range sans {
+		if san.Type == certificate.SubjectAlternativeNameTypeOther && san.OID == certificate.OIDOtherName.String() {
+			leafCert.UnhandledCriticalExtensions = unhandled
+			return leafCert
+		}
+	}
+	return leafCert
+}
+
+// checkPathLength returns an error unless one of the verified chains, each
+// running from the leaf to the root, has at most maxPathLen intermediate
+// certificates. A negative maxPathLen allows any.
+func checkPathLength(leafCert *x509.Certificate, chains [][]*x509.Certificate, maxPathLen int) error {
+	if maxPathLen < 0 {
+		return nil
+	}
+	for _, chain := range chains {
+		if len(chain)-2 <= maxPathLen {
 			return nil
 		}
 	}
+	return x509.CertificateInvalidError{
+		Cert:   leafCert,
+		Reason: x509.TooManyIntermediates,
+		Detail: fmt.Sprintf("more than %d intermediate certificates", maxPathLen),
+	}
+}
+
+// ChainFailureReason is the reason a certificate authority did not verify a
+// leaf certificate's chain.
+type ChainFailureReason string
+
+const (
+	// ChainFailureCANotTrusted means the trusted material did not trust the
+	// certificate authority at the signing time.
+	ChainFailureCANotTrusted ChainFailureReason = "certificate authority not trusted at signing time"
+	// ChainFailureUnknownAuthority means the chain does not lead to the
+	// certificate authority's root.
+	ChainFailureUnknownAuthority ChainFailureReason = "certificate signed by unknown authority"
+	// ChainFailureExpired means a certificate in the chain was not valid at
+	// the signing time.
+	ChainFailureExpired ChainFailureReason = "certificate expired or not yet valid at signing time"
+	// ChainFailureKeyUsage means a certificate in the chain is not valid for
+	// code signing.
+	ChainFailureKeyUsage ChainFailureReason = "certificate not valid for code signing"
+	// ChainFailureIssuedBeforeCA means the leaf certificate was issued before
+	// the certificate authority's validity period began.
+	ChainFailureIssuedBeforeCA ChainFailureReason = "leaf certificate issued before certificate authority validity period"
+	// ChainFailurePathLength means the chain has more intermediate
+	// certificates than a CA certificate's path length constraint, or the
+	// verifier's maximum, allows.
+	ChainFailurePathLength ChainFailureReason = "too many intermediate certificates in chain"
+	// ChainFailureOther is any other chain verification failure; see the
+	// candidate's Err for details.
+	ChainFailureOther ChainFailureReason = "chain verification failed"
+)
+
+// ChainCandidateFailure is why one certificate authority from the trusted
+// material did not verify the chain.
+type ChainCandidateFailure struct {
+	// Subject is the subject of the certificate authority's root certificate.
+	Subject string
+	Reason  ChainFailureReason
+	// Err is the error from chain building, if it got that far.
+	Err error
+}
+
+// ChainVerificationError is returned when no certificate authority in the
+// trusted material verifies a leaf certificate's chain.
+type ChainVerificationError struct {
+	// Candidates has an entry for every certificate authority that was
+	// tried, in the order of the trusted material.
+	Candidates []ChainCandidateFailure
+	// BundleIntermediates is the number of intermediate certificates the
+	// entity carried. Chains are only built from the trusted material's
+	// intermediates, so these were not used.
+	BundleIntermediates int
+}
+
+func (e *ChainVerificationError) Error() string {
+	if len(e.Candidates) == 0 {
+		return "leaf certificate verification failed: no certificate authorities in trusted material"
+	}
+
+	reasons := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		reasons[i] = fmt.Sprintf("%q: %s", candidate.Subject, candidate.Reason)
+	}
+	msg := fmt.Sprintf("leaf certificate verification failed: %s", strings.Join(reasons, "; "))
+	if e.BundleIntermediates > 0 {
+		msg += fmt.Sprintf(" (%d intermediate certificates in the bundle were not used)", e.BundleIntermediates)
+	}
+	return msg
+}
+
+func (e *ChainVerificationError) Unwrap() []error {
+	var errs []error
+	for _, candidate := range e.Candidates {
+		if candidate.Err != nil {
+			errs = append(errs, candidate.Err)
+		}
+	}
+	return errs
+}
+
+// chainFailureReason classifies an error from x509.Certificate.Verify.
+func chainFailureReason(err error) ChainFailureReason {
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return ChainFailureUnknownAuthority
+	}
+
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		switch invalid.Reason {
+		case x509.Expired:
+			return ChainFailureExpired
+		case x509.IncompatibleUsage:
+			return ChainFailureKeyUsage
+		case x509.TooManyIntermediates:
+			return ChainFailurePathLength
+		}
+	}
+
+	return ChainFailureOther
+}
+
+// bundleIntermediates returns the number of intermediate certificates the
+// entity carries after its leaf, leaving out any self-signed root.
+func bundleIntermediates(entity SignedEntity) int {
+	chainProvider, ok := entity.(interface {
+		CertificateChain() ([]*x509.Certificate, error)
+	})
+	if !ok {
+		return 0
+	}
+	chain, err := chainProvider.CertificateChain()
+	if err != nil {
+		return 0
+	}
 
-	return errors.New("leaf certificate verification failed")
+	var n int
+	for i, cert := range chain {
+		if i > 0 && !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+			n++
+		}
+	}
+	return n
+}
+
+var (
+	ErrFulcioLeafLifetime = errors.New("leaf certificate is valid for longer than Fulcio issues certificates for")
+	ErrFulcioLeafSAN      = errors.New("leaf certificate does not have exactly one Subject Alternative Name")
+	ErrFulcioLeafIsCA     = errors.New("leaf certificate is a CA certificate")
+	ErrFulcioLeafEKU      = errors.New("leaf certificate is not valid for code signing")
+)
+
+// DefaultFulcioLeafMaxLifetime is the longest validity period suggested for
+// WithFulcioLeafProfile. Fulcio issues certificates valid for 10 minutes.
+const DefaultFulcioLeafMaxLifetime = 15 * time.Minute
+
+var (
+	oidFulcioExtensionArc     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1}
+	oidSubjectAlternativeName = asn1.ObjectIdentifier{2, 5, 29, 17}
+)
+
+// VerifyFulcioLeafProfile checks that the leaf certificate looks like one
+// Fulcio issues: it has exactly one Subject Alternative Name, is not a CA,
+// and has the code signing extended key usage. If it claims any Fulcio
+// extension, it must also be valid for no longer than maxLifetime.
+func VerifyFulcioLeafProfile(leafCert *x509.Certificate, maxLifetime time.Duration) error { // nolint: revive
+	if hasFulcioExtension(leafCert) {
+		if lifetime := leafCert.NotAfter.Sub(leafCert.NotBefore); lifetime > maxLifetime {
+			return fmt.Errorf("%w: valid for %s, maximum is %s", ErrFulcioLeafLifetime, lifetime, maxLifetime)
+		}
+	}
+
+	sanCount, err := countSubjectAlternativeNames(leafCert)
+	if err != nil {
+		return err
+	}
+	if sanCount != 1 {
+		return fmt.Errorf("%w: found %d", ErrFulcioLeafSAN, sanCount)
+	}
+
+	if leafCert.IsCA {
+		return ErrFulcioLeafIsCA
+	}
+
+	for _, usage := range leafCert.ExtKeyUsage {
+		if usage == x509.ExtKeyUsageCodeSigning {
+			return nil
+		}
+	}
+	return ErrFulcioLeafEKU
+}
+
+func hasFulcioExtension(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if len(ext.Id) > len(oidFulcioExtensionArc) && ext.Id[:len(oidFulcioExtensionArc)].Equal(oidFulcioExtensionArc) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKeylessEntity reports whether the entity was signed keyless, with a
+// Fulcio certificate: one carrying a Fulcio OIDC issuer extension and valid
+// for no longer than DefaultFulcioLeafMaxLifetime. Entities signed with a
+// public key or a long-lived CA certificate are not keyless.
+//
+// It only inspects the certificate and verifies nothing; use a
+// SignedEntityVerifier to establish that the certificate is trusted.
+func IsKeylessEntity(entity SignedEntity) (bool, error) {
+	verificationContent, err := entity.VerificationContent()
+	if err != nil {
+		return false, err
+	}
+	leafCert, ok := verificationContent.HasCertificate()
+	if !ok {
+		return false, nil
+	}
+	return isKeylessCertificate(&leafCert), nil
+}
+
+func isKeylessCertificate(cert *x509.Certificate) bool {
+	if cert.NotAfter.Sub(cert.NotBefore) > DefaultFulcioLeafMaxLifetime {
+		return false
+	}
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(certificate.OIDIssuer) || ext.Id.Equal(certificate.OIDIssuerV2) { //nolint:staticcheck
+			return true
+		}
+	}
+	return false
+}
+
+// countSubjectAlternativeNames counts every name in the certificate's
+// Subject Alternative Name extension, including types that
+// certificate.ParseSubjectAlternativeNames leaves out.
+func countSubjectAlternativeNames(cert *x509.Certificate) (int, error) {
+	var n int
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSubjectAlternativeName) {
+			continue
+		}
+
+		var names []asn1.RawValue
+		rest, err := asn1.Unmarshal(ext.Value, &names)
+		if err != nil || len(rest) != 0 {
+			return 0, errors.New("unable to parse Subject Alternative Name")
+		}
+		n += len(names)
+	}
+	return n, nil
+}
+
+// providedCertificate is a certificate supplied separately from the entity it
+// signed, used as the entity's verification content.
+type providedCertificate struct {
+	*x509.Certificate
+}
+
+func (c *providedCertificate) CompareKey(key any, _ root.TrustedMaterial) bool {
+	switch k := key.(type) {
+	case *x509.Certificate:
+		return c.Certificate.Equal(k)
+	case interface{ Equal(x crypto.PublicKey) bool }:
+		// A logged public key must belong to the provided certificate.
+		return k.Equal(c.Certificate.PublicKey)
+	}
+
+	return false
+}
+
+func (c *providedCertificate) ValidAtTime(t time.Time, _ root.TrustedMaterial) bool {
+	return !(c.Certificate.NotAfter.Before(t) || c.Certificate.NotBefore.After(t))
+}
+
+func (c *providedCertificate) HasCertificate() (x509.Certificate, bool) {
+	return *c.Certificate, true
+}
+
+func (c *providedCertificate) HasPublicKey() (PublicKeyProvider, bool) {
+	return nil, false
+}
+
+// providedCertificateEntity is a SignedEntity verified with a provided
+// certificate in place of its own verification content.
+type providedCertificateEntity struct {
+	SignedEntity
+	certificate *providedCertificate
+}
+
+func (e *providedCertificateEntity) VerificationContent() (VerificationContent, error) {
+	return e.certificate, nil
+}
+
+// withProvidedCertificate returns the entity with the given certificate as
+// its verification content. It is an error for the entity to carry a
+// different certificate.
+func withProvidedCertificate(entity SignedEntity, cert *x509.Certificate) (SignedEntity, error) {
+	if verificationContent, err := entity.VerificationContent(); err == nil {
+		if entityCert, ok := verificationContent.HasCertificate(); ok && !entityCert.Equal(cert) {
+			return nil, errors.New("entity carries a certificate that conflicts with the provided certificate")
+		}
+	}
+
+	return &providedCertificateEntity{SignedEntity: entity, certificate: &providedCertificate{cert}}, nil
+}
+
+// clampToNotBefore returns the leaf certificate's NotBefore if t is before it
+// by no more than grace, and t otherwise. NotAfter is left alone.
+func clampToNotBefore(t time.Time, leafCert *x509.Certificate, grace time.Duration) time.Time {
+	if grace > 0 && t.Before(leafCert.NotBefore) && leafCert.NotBefore.Sub(t) <= grace {
+		return leafCert.NotBefore
+	}
+	return t
+}
+
+// notBeforeGraceCertificate is certificate verification content that is
+// also valid for a grace period before the certificate's NotBefore.
+type notBeforeGraceCertificate struct {
+	VerificationContent
+	leafCert x509.Certificate
+	grace    time.Duration
+}
+
+func (c *notBeforeGraceCertificate) ValidAtTime(t time.Time, trustedMaterial root.TrustedMaterial) bool {
+	return c.VerificationContent.ValidAtTime(clampToNotBefore(t, &c.leafCert, c.grace), trustedMaterial)
+}
+
+// notBeforeGraceEntity is a SignedEntity whose certificate is accepted for a
+// grace period before its NotBefore.
+type notBeforeGraceEntity struct {
+	SignedEntity
+	grace time.Duration
+}
+
+func (e *notBeforeGraceEntity) VerificationContent() (VerificationContent, error) {
+	verificationContent, err := e.SignedEntity.VerificationContent()
+	if err != nil {
+		return nil, err
+	}
+	leafCert, ok := verificationContent.HasCertificate()
+	if !ok {
+		return verificationContent, nil
+	}
+	return &notBeforeGraceCertificate{VerificationContent: verificationContent, leafCert: leafCert, grace: e.grace}, nil
 }
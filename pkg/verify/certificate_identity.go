@@ -15,6 +15,7 @@
 package verify
 
 import (
+	"encoding/asn1"
 	"encoding/json"
 	"errors"
 	"regexp"
@@ -29,6 +30,10 @@ type SubjectAlternativeNameMatcher struct {
 
 type CertificateIdentity struct {
 	SubjectAlternativeName SubjectAlternativeNameMatcher `json:"subjectAlternativeName"`
+	// SubjectCommonName, if set, must equal the certificate's Subject CN.
+	// It is meant for certificates from CAs other than Fulcio, see
+	// NewBYOCertificateIdentity
+	SubjectCommonName string `json:"subjectCommonName,omitempty"`
 	certificate.Extensions
 }
 
@@ -61,33 +66,77 @@ func (s *SubjectAlternativeNameMatcher) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// NewOtherNameSANMatcher creates a SubjectAlternativeNameMatcher for an
+// otherName Subject Alternative Name with the given type-id, such as the
+// username identities Fulcio issues under certificate.OIDOtherName.
+func NewOtherNameSANMatcher(typeID asn1.ObjectIdentifier, sanValue string, regexpStr string) (SubjectAlternativeNameMatcher, error) {
+	sanMatcher, err := NewSANMatcher(sanValue, string(certificate.SubjectAlternativeNameTypeOther), regexpStr)
+	if err != nil {
+		return SubjectAlternativeNameMatcher{}, err
+	}
+	sanMatcher.OID = typeID.String()
+	return sanMatcher, nil
+}
+
 // Verify checks if the actualCert matches the SANMatcher's Type, Value, and
 // Regexp – if those values have been provided.
+//
+// A matcher for otherName SANs is checked against every otherName SAN of the
+// certificate, and only matches those with its OID, which defaults to the
+// Fulcio username OID.
 func (s SubjectAlternativeNameMatcher) Verify(actualCert certificate.Summary) bool {
+	candidates := []certificate.SubjectAlternativeName{actualCert.SubjectAlternativeName}
+	if s.Type == certificate.SubjectAlternativeNameTypeOther {
+		for _, san := range actualCert.SubjectAlternativeNames {
+			if san.Type == certificate.SubjectAlternativeNameTypeOther {
+				candidates = append(candidates, san)
+			}
+		}
+	}
+
+	for _, san := range candidates {
+		if s.matches(san) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s SubjectAlternativeNameMatcher) matches(san certificate.SubjectAlternativeName) bool {
 	var typeMatches bool
 	var valueMatches bool
 	var regexMatches bool
+	var oidMatches bool
 
 	// if a {SAN Type, Value, Regexp} was not specified, default to true
 	if s.SubjectAlternativeName.Type != "" {
-		typeMatches = s.Type == actualCert.SubjectAlternativeName.Type
+		typeMatches = s.Type == san.Type
 	} else {
 		typeMatches = true
 	}
 
 	if s.SubjectAlternativeName.Value != "" {
-		valueMatches = s.Value == actualCert.SubjectAlternativeName.Value
+		valueMatches = s.Value == san.Value
 	} else {
 		valueMatches = true
 	}
 
 	if s.Regexp.String() != "" {
-		regexMatches = s.Regexp.MatchString(actualCert.SubjectAlternativeName.Value)
+		regexMatches = s.Regexp.MatchString(san.Value)
 	} else {
 		regexMatches = true
 	}
 
-	return typeMatches && valueMatches && regexMatches
+	switch {
+	case s.OID != "":
+		oidMatches = s.OID == san.OID
+	case san.Type == certificate.SubjectAlternativeNameTypeOther && san.OID != "":
+		oidMatches = san.OID == certificate.OIDOtherName.String()
+	default:
+		oidMatches = true
+	}
+
+	return typeMatches && valueMatches && regexMatches && oidMatches
 }
 
 func NewCertificateIdentity(sanMatcher SubjectAlternativeNameMatcher, extensions certificate.Extensions) (CertificateIdentity, error) {
@@ -116,6 +165,32 @@ func NewShortCertificateIdentity(issuer, sanValue, sanType, sanRegex string) (Ce
 	return NewCertificateIdentity(sanMatcher, certificate.Extensions{Issuer: issuer})
 }
 
+// NewBYOCertificateIdentity creates a CertificateIdentity for a certificate
+// issued by a CA other than Fulcio, which has no Fulcio extensions such as the
+// OIDC Issuer. The certificate is matched by its Subject CN, its email Subject
+// Alternative Name, or both; at least one must be given, and every one given
+// must match.
+//
+// Use it with a SignedEntityVerifier configured with
+// WithBYOCertificateVerification, so that the trusted material's certificate
+// authorities are the only issuers a matching certificate can come from.
+func NewBYOCertificateIdentity(commonName, email string) (CertificateIdentity, error) {
+	if commonName == "" && email == "" {
+		return CertificateIdentity{}, errors.New("when verifying a BYO certificate identity, a Subject CN or email must be provided")
+	}
+
+	certID := CertificateIdentity{SubjectCommonName: commonName}
+	if email != "" {
+		sanMatcher, err := NewSANMatcher(email, string(certificate.SubjectAlternativeNameTypeEmail), "")
+		if err != nil {
+			return CertificateIdentity{}, err
+		}
+		certID.SubjectAlternativeName = sanMatcher
+	}
+
+	return certID, nil
+}
+
 func (i CertificateIdentities) Verify(cert certificate.Summary) (*CertificateIdentity, error) {
 	for _, ci := range i {
 		if ci.Verify(cert) {
@@ -123,14 +198,16 @@ func (i CertificateIdentities) Verify(cert certificate.Summary) (*CertificateIde
 		}
 	}
 
-	return nil, errors.New("no matching certificate identity found")
+	return nil, ErrIdentityMismatch
 }
 
-// Verify checks if the actualCert matches the CertificateIdentity's SAN and
-// any of the provided OID extension values. Any empty values are ignored.
+// Verify checks if the actualCert matches the CertificateIdentity's SAN, any
+// of the provided OID extension values and, if provided, the Subject CN. Any
+// empty values are ignored.
 func (c CertificateIdentity) Verify(actualCert certificate.Summary) bool {
 	sanMatches := c.SubjectAlternativeName.Verify(actualCert)
 	extensionsMatch := certificate.CompareExtensions(c.Extensions, actualCert.Extensions)
+	commonNameMatches := c.SubjectCommonName == "" || c.SubjectCommonName == actualCert.SubjectCommonName
 
-	return sanMatches && extensionsMatch
+	return sanMatches && extensionsMatch && commonNameMatches
 }
@@ -15,6 +15,7 @@
 package verify
 
 import (
+	"encoding/asn1"
 	"testing"
 
 	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
@@ -90,6 +91,46 @@ func TestCertificateIdentityVerify(t *testing.T) {
 	assert.Nil(t, ci)
 }
 
+func TestOtherNameIdentityVerify(t *testing.T) {
+	// a username identity, alongside an otherName of a different type
+	actualCert := certificate.Summary{
+		SubjectAlternativeName: certificate.SubjectAlternativeName{Type: "Other", Value: "foo!example.com", OID: certificate.OIDOtherName.String()},
+		SubjectAlternativeNames: []certificate.SubjectAlternativeName{
+			{Type: "Other", Value: "foo@corp.example.com", OID: "1.3.6.1.4.1.311.20.2.3"},
+			{Type: "Other", Value: "foo!example.com", OID: certificate.OIDOtherName.String()},
+		},
+		Extensions: certificate.Extensions{Issuer: "https://example.com"},
+	}
+
+	username, err := certIDForTesting("foo!example.com", "Other", "", "https://example.com", "")
+	assert.NoError(t, err)
+	assert.True(t, username.Verify(actualCert))
+
+	usernameRegex, err := certIDForTesting("", "Other", "^foo!", "https://example.com", "")
+	assert.NoError(t, err)
+	assert.True(t, usernameRegex.Verify(actualCert))
+
+	// otherNames of other types are only matched by their OID
+	principalName, err := certIDForTesting("foo@corp.example.com", "Other", "", "https://example.com", "")
+	assert.NoError(t, err)
+	assert.False(t, principalName.Verify(actualCert))
+
+	san, err := NewOtherNameSANMatcher(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}, "foo@corp.example.com", "")
+	assert.NoError(t, err)
+	principalName.SubjectAlternativeName = san
+	assert.True(t, principalName.Verify(actualCert))
+
+	san, err = NewOtherNameSANMatcher(asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 20, 2, 3}, "foo!example.com", "")
+	assert.NoError(t, err)
+	principalName.SubjectAlternativeName = san
+	assert.False(t, principalName.Verify(actualCert))
+
+	// otherNames are not matched by matchers of other types
+	email, err := certIDForTesting("foo!example.com", "Email", "", "https://example.com", "")
+	assert.NoError(t, err)
+	assert.False(t, email.Verify(actualCert))
+}
+
 func TestThatCertIDsAreFullySpecified(t *testing.T) {
 	_, err := NewShortCertificateIdentity("", "", "", "")
 	assert.Error(t, err)
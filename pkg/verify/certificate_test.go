@@ -15,12 +15,28 @@
 package verify_test
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestVerifyValidityPeriod(t *testing.T) {
@@ -59,3 +75,862 @@ func TestVerifyValidityPeriod(t *testing.T) {
 		})
 	}
 }
+
+type shiftedCAValidity struct {
+	*ca.VirtualSigstore
+	validityPeriodStart time.Time
+}
+
+func (s *shiftedCAValidity) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	authorities := s.VirtualSigstore.FulcioCertificateAuthorities()
+	for i := range authorities {
+		authorities[i].ValidityPeriodStart = s.validityPeriodStart
+	}
+	return authorities
+}
+
+func TestVerifyLeafNotBeforeCAValidityStart(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	leaf, _, err := virtualSigstore.GenerateLeafCert("example@example.com", "issuer")
+	assert.NoError(t, err)
+
+	observerTimestamp := leaf.NotBefore.Add(5 * time.Minute)
+
+	// leaf issued after the CA's validity period began
+	trustedMaterial := &shiftedCAValidity{virtualSigstore, leaf.NotBefore.Add(-time.Minute)}
+	assert.NoError(t, verify.VerifyLeafCertificate(observerTimestamp, *leaf, trustedMaterial))
+
+	// leaf backdated before the CA's validity period began, even though the
+	// observer timestamp falls within it
+	trustedMaterial = &shiftedCAValidity{virtualSigstore, leaf.NotBefore.Add(time.Minute)}
+	assert.Error(t, verify.VerifyLeafCertificate(observerTimestamp, *leaf, trustedMaterial))
+}
+
+func TestVerifyCAWindowAtSigningTime(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "artifact"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leaf, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	verifyAt := func(trustedMaterial root.TrustedMaterial, signingTime time.Time, opts ...verify.VerifierOption) error {
+		ts, err := virtualSigstore.TimestampResponseAtTime(sigContent.Signature(), signingTime)
+		assert.NoError(t, err)
+		v, err := verify.NewSignedEntityVerifier(trustedMaterial, append([]verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog()}, opts...)...)
+		assert.NoError(t, err)
+		_, err = v.Verify(&multiTimestampEntity{entity, [][]byte{ts}}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	signingTime := leaf.NotBefore.Add(time.Minute)
+	assert.NoError(t, verifyAt(virtualSigstore, signingTime))
+
+	// The certificates are all valid at the signing time, but the trusted
+	// root only trusts the CA from after it
+	notYetTrusted := &shiftedCAValidity{virtualSigstore, signingTime.Add(time.Second)}
+	assert.Error(t, verifyAt(notYetTrusted, signingTime))
+	assert.Error(t, verify.VerifyLeafCertificate(signingTime, leaf, notYetTrusted))
+
+	// Clamping the signing time to the leaf's NotBefore doesn't extend the
+	// CA's validity window back to the signing time
+	signingTime = leaf.NotBefore.Add(-30 * time.Second)
+	assert.NoError(t, verifyAt(virtualSigstore, signingTime, verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace)))
+	notYetTrusted = &shiftedCAValidity{virtualSigstore, leaf.NotBefore}
+	assert.Error(t, verifyAt(notYetTrusted, signingTime, verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace)))
+}
+
+type singleRootTrustedMaterial struct {
+	root.BaseTrustedMaterial
+	fulcioCA root.CertificateAuthority
+}
+
+func (m *singleRootTrustedMaterial) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return []root.CertificateAuthority{m.fulcioCA}
+}
+
+func TestVerifyLeafUnderSingleRootCA(t *testing.T) {
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+
+	certAuthority, err := root.ParseCertificateAuthority(&prototrustroot.CertificateAuthority{
+		CertChain: &protocommon.X509CertificateChain{
+			Certificates: []*protocommon.X509Certificate{{RawBytes: rootCert.Raw}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, rootCert.Raw, certAuthority.Root.Raw)
+	assert.Nil(t, certAuthority.Intermediates)
+	assert.Nil(t, certAuthority.Leaf)
+
+	trustedMaterial := &singleRootTrustedMaterial{fulcioCA: *certAuthority}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leaf, err := ca.GenerateLeafCert("example@example.com", "issuer", time.Now(), leafKey, rootCert, rootKey)
+	assert.NoError(t, err)
+
+	assert.NoError(t, verify.VerifyLeafCertificate(time.Now().Add(time.Minute), *leaf, trustedMaterial))
+
+	// a leaf issued by a different root with the same subject
+	otherRootCert, otherRootKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	otherLeaf, err := ca.GenerateLeafCert("example@example.com", "issuer", time.Now(), leafKey, otherRootCert, otherRootKey)
+	assert.NoError(t, err)
+
+	assert.Error(t, verify.VerifyLeafCertificate(time.Now().Add(time.Minute), *otherLeaf, trustedMaterial))
+}
+
+// detachedCertificateEntity is an entity distributed without its
+// certificate.
+type detachedCertificateEntity struct {
+	*ca.TestEntity
+}
+
+func (e *detachedCertificateEntity) VerificationContent() (verify.VerificationContent, error) {
+	return nil, errors.New("no verification material")
+}
+
+func TestVerifyWithProvidedCertificate(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	cert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+
+	otherCert, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(t, err)
+
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		entity   verify.SignedEntity
+		provided *x509.Certificate
+		wantErr  bool
+	}{
+		{
+			name:     "detached certificate",
+			entity:   &detachedCertificateEntity{entity},
+			provided: &cert,
+		},
+		{
+			name:    "detached certificate not provided",
+			entity:  &detachedCertificateEntity{entity},
+			wantErr: true,
+		},
+		{
+			name:     "wrong certificate provided",
+			entity:   &detachedCertificateEntity{entity},
+			provided: otherCert,
+			wantErr:  true,
+		},
+		{
+			name:     "entity carries the provided certificate",
+			entity:   entity,
+			provided: &cert,
+		},
+		{
+			name:     "entity carries a conflicting certificate",
+			entity:   entity,
+			provided: otherCert,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policyOptions := []verify.PolicyOption{verify.WithCertificateIdentity(identity)}
+			if tt.provided != nil {
+				policyOptions = append(policyOptions, verify.WithProvidedCertificate(tt.provided))
+			}
+
+			res, err := verifier.Verify(tt.entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), policyOptions...))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, res.VerifiedIdentity)
+		})
+	}
+}
+
+func TestCertificateNotBeforeGrace(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "artifact"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leaf, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		grace   time.Duration
+		genTime time.Time
+		wantErr bool
+	}{
+		{
+			name:    "shortly before NotBefore without grace",
+			grace:   0,
+			genTime: leaf.NotBefore.Add(-30 * time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "shortly before NotBefore",
+			grace:   verify.DefaultCertificateNotBeforeGrace,
+			genTime: leaf.NotBefore.Add(-30 * time.Second),
+		},
+		{
+			name:    "at the start of the grace",
+			grace:   verify.DefaultCertificateNotBeforeGrace,
+			genTime: leaf.NotBefore.Add(-verify.DefaultCertificateNotBeforeGrace),
+		},
+		{
+			name:    "before the start of the grace",
+			grace:   verify.DefaultCertificateNotBeforeGrace,
+			genTime: leaf.NotBefore.Add(-verify.DefaultCertificateNotBeforeGrace - time.Second),
+			wantErr: true,
+		},
+		{
+			name:    "shortly after NotAfter",
+			grace:   verify.DefaultCertificateNotBeforeGrace,
+			genTime: leaf.NotAfter.Add(30 * time.Second),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := virtualSigstore.TimestampResponseAtTime(sigContent.Signature(), tt.genTime)
+			assert.NoError(t, err)
+
+			v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog(), verify.WithCertificateNotBeforeGrace(tt.grace))
+			assert.NoError(t, err)
+
+			_, err = v.Verify(&multiTimestampEntity{entity, [][]byte{ts}}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// The grace also applies to log entry integrated timestamps
+	entity, err = virtualSigstore.SignAtTime("foo@example.com", "issuer", []byte(artifact), time.Now().Add(-30*time.Second))
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// Error: negative grace
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCertificateNotBeforeGrace(-time.Second))
+	assert.Error(t, err)
+}
+
+func BenchmarkVerifyLeafCertificate(b *testing.B) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(b, err)
+
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(b, err)
+	observerTimestamp := leaf.NotBefore.Add(time.Second)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := verify.VerifyLeafCertificate(observerTimestamp, *leaf, virtualSigstore); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestTimestampWithinCertValidity(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "artifact"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leaf, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256([]byte(artifact))
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithoutIdentitiesUnsafe())
+
+	tests := []struct {
+		name    string
+		genTime time.Time
+		wantErr bool
+	}{
+		{
+			name:    "within validity",
+			genTime: leaf.NotBefore.Add(time.Second),
+		},
+		{
+			name:    "within NotBefore grace",
+			genTime: leaf.NotBefore.Add(-30 * time.Second),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts, err := virtualSigstore.TimestampResponseAtTime(sigContent.Signature(), tt.genTime)
+			require.NoError(t, err)
+			timestamped := &multiTimestampEntity{entity, [][]byte{ts}}
+
+			// the grace accepts the timestamp unless the option is set
+			v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog(), verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace))
+			require.NoError(t, err)
+			_, err = v.Verify(timestamped, policy)
+			assert.NoError(t, err)
+
+			v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog(), verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace), verify.WithTimestampWithinCertValidity())
+			require.NoError(t, err)
+			_, err = v.Verify(timestamped, policy)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, verify.ErrTimestampOutsideCertValidity)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// the certificate's own validity period isn't a verified timestamp
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure(), verify.WithTimestampWithinCertValidity())
+	assert.Error(t, err)
+}
+func TestBYOCertificateVerification(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.SignWithBYOCertificate("Example Code Signing", "", artifact)
+	assert.NoError(t, err)
+	entityWithEmail, err := virtualSigstore.SignWithBYOCertificate("Example Code Signing", "release@example.com", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	commonName, err := verify.NewBYOCertificateIdentity("Example Code Signing", "")
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(commonName)))
+	assert.NoError(t, err)
+	assert.Equal(t, "Example Code Signing", result.Signature.Certificate.SubjectCommonName)
+	assert.Empty(t, result.Signature.Certificate.SubjectAlternativeName.Value)
+
+	email, err := verify.NewBYOCertificateIdentity("", "release@example.com")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entityWithEmail, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(email)))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(email)))
+	assert.Error(t, err)
+
+	commonNameAndEmail, err := verify.NewBYOCertificateIdentity("Example Code Signing", "release@example.com")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entityWithEmail, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(commonNameAndEmail)))
+	assert.NoError(t, err)
+
+	otherCommonName, err := verify.NewBYOCertificateIdentity("Other Code Signing", "")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(otherCommonName)))
+	assert.Error(t, err)
+
+	// a Fulcio identity requires an OIDC issuer the certificate doesn't have
+	fulcioIdentity, err := verify.NewShortCertificateIdentity("https://example.com", "", "", ".*")
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(fulcioIdentity)))
+	assert.Error(t, err)
+
+	// the leaf must still chain to the trusted CA
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrustedEntity, err := otherSigstore.SignWithBYOCertificate("Example Code Signing", "", artifact)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(untrustedEntity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(commonName)))
+	assert.Error(t, err)
+
+	// without the preset, the certificate has no SCT
+	fulcioVerifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = fulcioVerifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(commonName)))
+	assert.Error(t, err)
+
+	// and no Subject Alternative Name
+	noSCTVerifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutSCTVerification(), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = noSCTVerifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(commonName)))
+	assert.Error(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithSignedCertificateTimestamps(1), verify.WithSignedTimestamps(1))
+	assert.Error(t, err)
+
+	_, err = verify.NewBYOCertificateIdentity("", "")
+	assert.Error(t, err)
+}
+
+func TestAdditionalRoots(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	// a corporate CA issuing certificates for hardware tokens, which the
+	// trusted material doesn't know about
+	corporateRoot, corporateKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.SignWithExternalCertificate("Jane Doe (PIV Signature)", "jane@example.com", corporateRoot, corporateKey, artifact)
+	assert.NoError(t, err)
+
+	identity, err := verify.NewBYOCertificateIdentity("Jane Doe (PIV Signature)", "jane@example.com")
+	assert.NoError(t, err)
+	policy := func() verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity))
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(corporateRoot)
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithAdditionalRoots(roots), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane Doe (PIV Signature)", result.Signature.Certificate.SubjectCommonName)
+
+	// Fulcio-issued certificates are still accepted
+	byoEntity, err := virtualSigstore.SignWithBYOCertificate("Jane Doe (PIV Signature)", "jane@example.com", artifact)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(byoEntity, policy())
+	assert.NoError(t, err)
+
+	// without the additional roots the chain doesn't verify
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	assert.Error(t, err)
+
+	// nor with other roots, which are reported as a candidate
+	otherRoot, _, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherRoot)
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithAdditionalRoots(otherRoots), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	var chainErr *verify.ChainVerificationError
+	if assert.ErrorAs(t, err, &chainErr) && assert.Len(t, chainErr.Candidates, 2) {
+		assert.Equal(t, verify.AdditionalRootsSubject, chainErr.Candidates[1].Subject)
+		assert.Equal(t, verify.ChainFailureUnknownAuthority, chainErr.Candidates[1].Reason)
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithAdditionalRoots(nil), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+}
+
+func TestIsKeylessEntity(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	fulcioEntity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	keyless, err := verify.IsKeylessEntity(fulcioEntity)
+	assert.NoError(t, err)
+	assert.True(t, keyless)
+
+	byoEntity, err := virtualSigstore.SignWithBYOCertificate("Example Code Signing", "", artifact)
+	assert.NoError(t, err)
+	keyless, err = verify.IsKeylessEntity(byoEntity)
+	assert.NoError(t, err)
+	assert.False(t, keyless)
+
+	_, err = verify.IsKeylessEntity(&detachedCertificateEntity{fulcioEntity})
+	assert.Error(t, err)
+}
+
+func TestChainVerificationError(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	leaf, _, err := virtualSigstore.GenerateLeafCert("example@example.com", "issuer")
+	assert.NoError(t, err)
+	caSubject := virtualSigstore.FulcioCertificateAuthorities()[0].Root.Subject.String()
+	signingTime := leaf.NotBefore.Add(time.Minute)
+
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherLeaf, _, err := otherSigstore.GenerateLeafCert("example@example.com", "issuer")
+	assert.NoError(t, err)
+
+	// a leaf chaining through an intermediate restricted to timestamping
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	tsaIntermediate, tsaIntermediateKey, err := ca.GenerateTSAIntermediate(rootCert, rootKey)
+	assert.NoError(t, err)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	wrongUsageLeaf, err := ca.GenerateLeafCert("example@example.com", "issuer", time.Now(), leafKey, tsaIntermediate, tsaIntermediateKey)
+	assert.NoError(t, err)
+	wrongUsageCA := &singleRootTrustedMaterial{fulcioCA: root.CertificateAuthority{Root: rootCert, Intermediates: []*x509.Certificate{tsaIntermediate}}}
+
+	tests := []struct {
+		name            string
+		leaf            *x509.Certificate
+		signingTime     time.Time
+		trustedMaterial root.TrustedMaterial
+		subject         string
+		reason          verify.ChainFailureReason
+	}{
+		{
+			name:            "unknown authority",
+			leaf:            otherLeaf,
+			signingTime:     signingTime,
+			trustedMaterial: virtualSigstore,
+			subject:         caSubject,
+			reason:          verify.ChainFailureUnknownAuthority,
+		},
+		{
+			name:            "expired at signing time",
+			leaf:            leaf,
+			signingTime:     leaf.NotAfter.Add(time.Minute),
+			trustedMaterial: virtualSigstore,
+			subject:         caSubject,
+			reason:          verify.ChainFailureExpired,
+		},
+		{
+			name:            "wrong key usage",
+			leaf:            wrongUsageLeaf,
+			signingTime:     time.Now().Add(time.Minute),
+			trustedMaterial: wrongUsageCA,
+			subject:         rootCert.Subject.String(),
+			reason:          verify.ChainFailureKeyUsage,
+		},
+		{
+			name:            "CA not trusted at signing time",
+			leaf:            leaf,
+			signingTime:     signingTime,
+			trustedMaterial: &shiftedCAValidity{virtualSigstore, signingTime.Add(time.Second)},
+			subject:         caSubject,
+			reason:          verify.ChainFailureCANotTrusted,
+		},
+		{
+			name:            "leaf issued before CA validity period",
+			leaf:            leaf,
+			signingTime:     signingTime,
+			trustedMaterial: &shiftedCAValidity{virtualSigstore, leaf.NotBefore.Add(time.Second)},
+			subject:         caSubject,
+			reason:          verify.ChainFailureIssuedBeforeCA,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify.VerifyLeafCertificate(tt.signingTime, *tt.leaf, tt.trustedMaterial)
+			var chainErr *verify.ChainVerificationError
+			if assert.ErrorAs(t, err, &chainErr) && assert.Len(t, chainErr.Candidates, 1) {
+				assert.Equal(t, tt.subject, chainErr.Candidates[0].Subject)
+				assert.Equal(t, tt.reason, chainErr.Candidates[0].Reason)
+				assert.Zero(t, chainErr.BundleIntermediates)
+			}
+		})
+	}
+
+	// every candidate is reported
+	err = verify.VerifyLeafCertificate(signingTime, *otherLeaf, root.TrustedMaterialCollection{virtualSigstore, &shiftedCAValidity{virtualSigstore, signingTime.Add(time.Second)}})
+	var chainErr *verify.ChainVerificationError
+	if assert.ErrorAs(t, err, &chainErr) && assert.Len(t, chainErr.Candidates, 2) {
+		assert.Equal(t, verify.ChainFailureUnknownAuthority, chainErr.Candidates[0].Reason)
+		var unknownAuthority x509.UnknownAuthorityError
+		assert.ErrorAs(t, err, &unknownAuthority)
+		assert.Equal(t, verify.ChainFailureCANotTrusted, chainErr.Candidates[1].Reason)
+	}
+
+	// the bundle's intermediates are reported as unused
+	entity, err := otherSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	v, err := verify.NewSignedEntityVerifier(&otherFulcioCA{otherSigstore, virtualSigstore}, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()))
+	if assert.ErrorAs(t, err, &chainErr) {
+		assert.Equal(t, 1, chainErr.BundleIntermediates)
+		assert.Contains(t, err.Error(), "1 intermediate certificates in the bundle were not used")
+	}
+}
+
+// otherFulcioCA is a virtual Sigstore that trusts another's Fulcio CA in
+// place of its own.
+type otherFulcioCA struct {
+	*ca.VirtualSigstore
+	fulcio root.TrustedMaterial
+}
+
+func (m *otherFulcioCA) FulcioCertificateAuthorities() []root.CertificateAuthority {
+	return m.fulcio.FulcioCertificateAuthorities()
+}
+
+// generatePathLenIntermediate issues a code signing intermediate under
+// parent with the given BasicConstraints path length, or none if negative.
+func generatePathLenIntermediate(t *testing.T, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, maxPathLen int) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"sigstore.dev"}},
+		NotBefore:             time.Now().Add(-2 * time.Minute),
+		NotAfter:              time.Now().Add(2 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLen:            maxPathLen,
+		MaxPathLenZero:        maxPathLen == 0,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestPathLengthConstraint(t *testing.T) {
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name       string
+		maxPathLen int
+		wantErr    bool
+	}{
+		{name: "unconstrained", maxPathLen: -1},
+		{name: "allows one intermediate below", maxPathLen: 1},
+		{name: "allows none below", maxPathLen: 0, wantErr: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			outer, outerKey := generatePathLenIntermediate(t, "outer-intermediate", rootCert, rootKey, tt.maxPathLen)
+			inner, innerKey := generatePathLenIntermediate(t, "inner-intermediate", outer, outerKey, -1)
+			leaf, err := ca.GenerateLeafCert("example@example.com", "issuer", time.Now(), leafKey, inner, innerKey)
+			assert.NoError(t, err)
+			trustedMaterial := &singleRootTrustedMaterial{fulcioCA: root.CertificateAuthority{Root: rootCert, Intermediates: []*x509.Certificate{outer, inner}}}
+
+			err = verify.VerifyLeafCertificate(time.Now().Add(time.Minute), *leaf, trustedMaterial)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var chainErr *verify.ChainVerificationError
+			if assert.ErrorAs(t, err, &chainErr) && assert.Len(t, chainErr.Candidates, 1) {
+				assert.Equal(t, verify.ChainFailurePathLength, chainErr.Candidates[0].Reason)
+			}
+		})
+	}
+}
+
+func TestMaxPathLength(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+	// the virtual Sigstore's chain has one intermediate
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithMaxPathLength(1), verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithMaxPathLength(0), verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	policy = verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	_, err = verifier.Verify(entity, policy)
+	var chainErr *verify.ChainVerificationError
+	if assert.ErrorAs(t, err, &chainErr) && assert.Len(t, chainErr.Candidates, 1) {
+		assert.Equal(t, verify.ChainFailurePathLength, chainErr.Candidates[0].Reason)
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithMaxPathLength(-1), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+}
+
+func TestMaxCertLifetime(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	longLivedEntity, err := virtualSigstore.SignWithBYOCertificate("Example Code Signing", "", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithMaxCertLifetime(time.Hour), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	policy := func() verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	}
+
+	_, err = verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+
+	// the year-long certificate is rejected
+	_, err = verifier.Verify(longLivedEntity, policy())
+	assert.ErrorContains(t, err, "exceeds maximum")
+
+	// but accepted by default
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(longLivedEntity, policy())
+	assert.NoError(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithMaxCertLifetime(0), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+}
+
+func TestVerifyFulcioLeafProfile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	issuerExtension := pkix.Extension{Id: certificate.OIDIssuer, Value: []byte("https://example.com")}
+
+	tests := []struct {
+		name    string
+		modify  func(*x509.Certificate)
+		wantErr error
+	}{
+		{
+			name:   "Fulcio profile",
+			modify: func(*x509.Certificate) {},
+		},
+		{
+			name: "long-lived with Fulcio extensions",
+			modify: func(c *x509.Certificate) {
+				c.NotAfter = c.NotBefore.AddDate(1, 0, 0)
+			},
+			wantErr: verify.ErrFulcioLeafLifetime,
+		},
+		{
+			name: "long-lived without Fulcio extensions",
+			modify: func(c *x509.Certificate) {
+				c.NotAfter = c.NotBefore.AddDate(1, 0, 0)
+				c.ExtraExtensions = nil
+			},
+		},
+		{
+			name: "no SAN",
+			modify: func(c *x509.Certificate) {
+				c.EmailAddresses = nil
+			},
+			wantErr: verify.ErrFulcioLeafSAN,
+		},
+		{
+			name: "two SANs",
+			modify: func(c *x509.Certificate) {
+				c.URIs = []*url.URL{{Scheme: "https", Host: "example.com"}}
+			},
+			wantErr: verify.ErrFulcioLeafSAN,
+		},
+		{
+			name: "CA certificate",
+			modify: func(c *x509.Certificate) {
+				c.BasicConstraintsValid = true
+				c.IsCA = true
+			},
+			wantErr: verify.ErrFulcioLeafIsCA,
+		},
+		{
+			name: "no EKU",
+			modify: func(c *x509.Certificate) {
+				c.ExtKeyUsage = nil
+			},
+			wantErr: verify.ErrFulcioLeafEKU,
+		},
+		{
+			name: "wrong EKU",
+			modify: func(c *x509.Certificate) {
+				c.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+			},
+			wantErr: verify.ErrFulcioLeafEKU,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := &x509.Certificate{
+				SerialNumber:    big.NewInt(1),
+				EmailAddresses:  []string{"foo@example.com"},
+				NotBefore:       time.Now(),
+				NotAfter:        time.Now().Add(10 * time.Minute),
+				KeyUsage:        x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				ExtraExtensions: []pkix.Extension{issuerExtension},
+			}
+			tt.modify(template)
+			der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+			assert.NoError(t, err)
+			leaf, err := x509.ParseCertificate(der)
+			assert.NoError(t, err)
+
+			err = verify.VerifyFulcioLeafProfile(leaf, verify.DefaultFulcioLeafMaxLifetime)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFulcioLeafProfileVerification(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifyWithMaxLifetime := func(maxLifetime time.Duration) error {
+		verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithFulcioLeafProfile(maxLifetime), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+		assert.NoError(t, err)
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+		return err
+	}
+
+	assert.NoError(t, verifyWithMaxLifetime(verify.DefaultFulcioLeafMaxLifetime))
+	// the virtual Fulcio issues certificates valid for 10 minutes
+	assert.ErrorIs(t, verifyWithMaxLifetime(5*time.Minute), verify.ErrFulcioLeafLifetime)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithBYOCertificateVerification(), verify.WithFulcioLeafProfile(verify.DefaultFulcioLeafMaxLifetime), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+}
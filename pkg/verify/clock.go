@@ -0,0 +1,52 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock is the source of the current time for a SignedEntityVerifier.
+// Entities are verified as of the times they were observed to be signed, so
+// the current time is only used where verification is relative to the
+// present: the checkpoint age allowed by WithCheckpointFreshness, and the
+// signing time of an entity verified with
+// WithoutAnyObserverTimestampsInsecure that has no certificate.
+//
+// Implementations must be safe for concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the Clock that reads the system time. It is the default.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// WithClock configures the SignedEntityVerifier to read the current time
+// from clock, e.g. to verify an entity as of some time in the past or to
+// make tests deterministic.
+func WithClock(clock Clock) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if clock == nil {
+			return errors.New("clock must not be nil")
+		}
+		c.clock = clock
+		return nil
+	}
+}
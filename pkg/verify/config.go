@@ -0,0 +1,130 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Config returns a snapshot of the verifier's configuration. It marshals
+// to JSON describing every option in effect, so that services can record
+// exactly how verification was configured.
+func (v *SignedEntityVerifier) Config() VerifierConfig {
+	return v.config
+}
+
+// verifierConfigJSON is the JSON form of a VerifierConfig. Options that
+// aren't in effect are left out.
+type verifierConfigJSON struct {
+	Online                       bool         `json:"online"`
+	SignedTimestampThreshold     int          `json:"signedTimestampThreshold,omitempty"`
+	DistinctTimestampAuthorities bool         `json:"distinctTimestampAuthorities,omitempty"`
+	AlternateTimestampedContents int          `json:"alternateTimestampedContents,omitempty"`
+	IntegratedTimestampThreshold int          `json:"integratedTimestampThreshold,omitempty"`
+	ObserverTimestampThreshold   int          `json:"observerTimestampThreshold,omitempty"`
+	WithoutObserverTimestamps    bool         `json:"withoutObserverTimestamps,omitempty"`
+	TimestampCrossCheckMaxSkew   string       `json:"timestampCrossCheckMaxSkew,omitempty"`
+	TimestampWithinCertValidity  bool         `json:"timestampWithinCertValidity,omitempty"`
+	TransparencyLogThreshold     int          `json:"transparencyLogThreshold,omitempty"`
+	AdditionalTlogs              int          `json:"additionalTlogs,omitempty"`
+	WithoutTransparencyLog       bool         `json:"withoutTransparencyLog,omitempty"`
+	CheckpointMaxAge             string       `json:"checkpointMaxAge,omitempty"`
+	CheckpointTimestamps         bool         `json:"checkpointTimestamps,omitempty"`
+	ProvidedInclusionProofs      int          `json:"providedInclusionProofs,omitempty"`
+	SCTThreshold                 int          `json:"sctThreshold,omitempty"`
+	WithoutSCTs                  bool         `json:"withoutSCTs,omitempty"`
+	CTInclusionProof             bool         `json:"ctInclusionProof,omitempty"`
+	BYOCertificates              bool         `json:"byoCertificates,omitempty"`
+	AdditionalRoots              bool         `json:"additionalRoots,omitempty"`
+	NotBeforeGrace               string       `json:"notBeforeGrace,omitempty"`
+	MaxCertLifetime              string       `json:"maxCertLifetime,omitempty"`
+	MaxPathLength                *int         `json:"maxPathLength,omitempty"`
+	MinKeyStrength               *KeyStrength `json:"minKeyStrength,omitempty"`
+	FulcioLeafMaxLifetime        string       `json:"fulcioLeafMaxLifetime,omitempty"`
+	PayloadTypes                 []string     `json:"payloadTypes,omitempty"`
+	FIPSMode                     bool         `json:"fipsMode,omitempty"`
+	TrustedRoots                 int          `json:"trustedRoots,omitempty"`
+	Logger                       bool         `json:"logger,omitempty"`
+	Clock                        string       `json:"clock,omitempty"`
+}
+
+// MarshalJSON describes the options in effect. Durations are given in the
+// format of time.Duration.String, and options taking a value that can't be
+// serialized, such as the roots of WithAdditionalRoots, are only reported
+// as set. A Clock other than SystemClock is reported by its type.
+func (c VerifierConfig) MarshalJSON() ([]byte, error) {
+	out := verifierConfigJSON{
+		Online:                       c.performOnlineVerification,
+		DistinctTimestampAuthorities: c.requireDistinctTimestampAuthorities,
+		AlternateTimestampedContents: len(c.alternateTimestampedContents),
+		WithoutObserverTimestamps:    c.weDoNotExpectAnyObserverTimestamps,
+		TimestampWithinCertValidity:  c.requireTimestampWithinCertValidity,
+		AdditionalTlogs:              len(c.additionalTlogs),
+		WithoutTransparencyLog:       c.weDoNotExpectTlogEntries,
+		CheckpointMaxAge:             durationString(c.checkpointMaxAge),
+		CheckpointTimestamps:         c.trustCheckpointTimestamps,
+		ProvidedInclusionProofs:      len(c.providedInclusionProofs),
+		WithoutSCTs:                  c.weDoNotExpectSCTs,
+		CTInclusionProof:             c.requireCTInclusionProof,
+		BYOCertificates:              c.byoCertificates,
+		AdditionalRoots:              c.additionalRoots != nil,
+		NotBeforeGrace:               durationString(c.notBeforeGrace),
+		MaxCertLifetime:              durationString(c.maxCertLifetime),
+		MinKeyStrength:               c.minKeyStrength,
+		PayloadTypes:                 c.payloadTypes,
+		FIPSMode:                     c.fipsMode,
+		TrustedRoots:                 len(c.trustedRoots),
+		Logger:                       c.logger != nil,
+	}
+	if c.weExpectSignedTimestamps {
+		out.SignedTimestampThreshold = c.signedTimestampThreshold
+	}
+	if c.requireIntegratedTimestamps {
+		out.IntegratedTimestampThreshold = c.integratedTimeThreshold
+	}
+	if c.requireObserverTimestamps {
+		out.ObserverTimestampThreshold = c.observerTimestampThreshold
+	}
+	if c.crossCheckTimestamps {
+		out.TimestampCrossCheckMaxSkew = c.maxTimestampSkew.String()
+	}
+	if c.weExpectTlogEntries {
+		out.TransparencyLogThreshold = c.tlogEntriesThreshold
+	}
+	if c.weExpectSCTs {
+		out.SCTThreshold = c.ctlogEntriesThreshold
+	}
+	if c.maxPathLenSet {
+		maxPathLen := c.maxPathLen
+		out.MaxPathLength = &maxPathLen
+	}
+	if _, ok := c.clock.(SystemClock); !ok && c.clock != nil {
+		out.Clock = fmt.Sprintf("%T", c.clock)
+	}
+	if c.fulcioLeafProfile {
+		out.FulcioLeafMaxLifetime = c.fulcioLeafMaxLifetime.String()
+	}
+	return json.Marshal(out)
+}
+
+// durationString formats d, or returns an empty string if it is zero.
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifierConfigSnapshot(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	snapshot := func(t *testing.T, options ...verify.VerifierOption) map[string]any {
+		t.Helper()
+		verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, options...)
+		assert.NoError(t, err)
+		config, err := json.Marshal(verifier.Config())
+		assert.NoError(t, err)
+		var out map[string]any
+		assert.NoError(t, json.Unmarshal(config, &out))
+		return out
+	}
+
+	// SCTs are verified by default
+	assert.Equal(t, map[string]any{
+		"online":                   false,
+		"transparencyLogThreshold": float64(1),
+		"signedTimestampThreshold": float64(1),
+		"sctThreshold":             float64(1),
+	}, snapshot(t, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1)))
+
+	assert.Equal(t, map[string]any{
+		"online":                       true,
+		"transparencyLogThreshold":     float64(2),
+		"checkpointMaxAge":             "24h0m0s",
+		"signedTimestampThreshold":     float64(1),
+		"integratedTimestampThreshold": float64(1),
+		"timestampCrossCheckMaxSkew":   "1m0s",
+		"withoutSCTs":                  true,
+		"byoCertificates":              true,
+		"maxPathLength":                float64(0),
+		"maxCertLifetime":              "1h0m0s",
+		"fipsMode":                     true,
+	}, snapshot(t,
+		verify.WithOnlineVerification(),
+		verify.WithTransparencyLog(2),
+		verify.WithCheckpointFreshness(24*time.Hour),
+		verify.WithIntegratedTimestamps(1),
+		verify.WithSignedTimestamps(1),
+		verify.WithTimestampCrossCheck(verify.DefaultTimestampClockSkew),
+		verify.WithBYOCertificateVerification(),
+		verify.WithMaxPathLength(0),
+		verify.WithMaxCertLifetime(time.Hour),
+		verify.WithFIPSMode(),
+	))
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// CoSignerPredicate decides whether the verified signers of a co-signed
+// attestation are enough, given the certificate summary of each signer. It
+// returns an error describing what is missing if they aren't.
+type CoSignerPredicate func(signers []certificate.Summary) error
+
+// CoSignedResult is the outcome of VerifyCoSigned.
+type CoSignedResult struct {
+	// Results are the verification results of the entities, in order
+	Results []*VerificationResult `json:"results"`
+	// Signers are the certificate summaries of the verified signers, in the
+	// order of the entities
+	Signers []certificate.Summary `json:"signers"`
+}
+
+// RequireCoSigners returns a CoSignerPredicate satisfied when each of the
+// identities is matched by a different signer, e.g. a human's identity and
+// a CI workflow's identity. Signers that match none of the identities are
+// allowed.
+func RequireCoSigners(identities ...CertificateIdentity) CoSignerPredicate {
+	return func(signers []certificate.Summary) error {
+		if len(identities) == 0 {
+			return errors.New("no co-signer identities required")
+		}
+
+		// matchedBy[i] is the index of the signer assigned to identity i
+		matchedBy := make([]int, len(identities))
+		for i := range matchedBy {
+			matchedBy[i] = -1
+		}
+		for i := range identities {
+			if !assignCoSigner(identities, signers, i, matchedBy, make([]bool, len(signers))) {
+				return fmt.Errorf("no distinct signer matches co-signer identity %d", i)
+			}
+		}
+		return nil
+	}
+}
+
+// assignCoSigner finds a signer for identity i, reassigning signers of
+// other identities where needed so that no signer fills two of them.
+func assignCoSigner(identities []CertificateIdentity, signers []certificate.Summary, i int, matchedBy []int, seen []bool) bool {
+	for s := range signers {
+		if seen[s] || !identities[i].Verify(signers[s]) {
+			continue
+		}
+		seen[s] = true
+
+		other := -1
+		for j, m := range matchedBy {
+			if m == s {
+				other = j
+			}
+		}
+		if other == -1 || assignCoSigner(identities, signers, other, matchedBy, seen) {
+			matchedBy[i] = s
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyCoSigned verifies an attestation signed by several identities, each
+// signature carried by its own entity. Every entity is verified
+// independently with Verify and the policy, and must carry a DSSE envelope
+// with the same payload type and payload as the others, signed with a
+// certificate. The signers' certificate summaries must then satisfy the
+// predicate, such as one returned by RequireCoSigners.
+//
+// The policy applies to each signer, so it typically uses
+// WithoutIdentitiesUnsafe, leaving identities to the predicate.
+func (v *SignedEntityVerifier) VerifyCoSigned(entities []SignedEntity, pb PolicyBuilder, predicate CoSignerPredicate) (*CoSignedResult, error) {
+	if len(entities) == 0 {
+		return nil, errors.New("no co-signed entities to verify")
+	}
+	if predicate == nil {
+		return nil, errors.New("co-signer predicate must not be nil")
+	}
+
+	var payloadType string
+	var payload []byte
+	out := &CoSignedResult{}
+	for i, entity := range entities {
+		sigContent, err := entity.SignatureContent()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch signature content of entity %d: %w", i, err)
+		}
+		envelope := sigContent.EnvelopeContent()
+		if envelope == nil || envelope.RawEnvelope() == nil {
+			return nil, fmt.Errorf("entity %d has no DSSE envelope", i)
+		}
+		entityPayload, err := envelope.RawEnvelope().DecodeB64Payload()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode payload of entity %d: %w", i, err)
+		}
+		if i == 0 {
+			payloadType, payload = envelope.RawEnvelope().PayloadType, entityPayload
+		} else if envelope.RawEnvelope().PayloadType != payloadType || !bytes.Equal(entityPayload, payload) {
+			return nil, fmt.Errorf("entity %d signs a different payload than entity 0", i)
+		}
+
+		result, err := v.Verify(entity, pb)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify entity %d: %w", i, err)
+		}
+		if result.Signature == nil || result.Signature.Certificate == nil {
+			return nil, fmt.Errorf("entity %d was not signed with a certificate", i)
+		}
+		out.Results = append(out.Results, result)
+		out.Signers = append(out.Signers, *result.Signature.Certificate)
+	}
+
+	if err := predicate(out.Signers); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCoSignersUnsatisfied, err)
+	}
+	return out, nil
+}
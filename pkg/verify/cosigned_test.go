@@ -0,0 +1,93 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCoSigned(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	humanIssuer := "https://accounts.example.com"
+	ciIssuer := "https://token.actions.githubusercontent.com"
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	human, err := virtualSigstore.Attest("alice@example.com", humanIssuer, statement)
+	assert.NoError(t, err)
+	ci, err := virtualSigstore.Attest("release@example.com", ciIssuer, statement)
+	assert.NoError(t, err)
+	otherHuman, err := virtualSigstore.Attest("bob@example.com", humanIssuer, statement)
+	assert.NoError(t, err)
+	otherStatement, err := virtualSigstore.Attest("release@example.com", ciIssuer, []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customBar","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`))
+	assert.NoError(t, err)
+	messageSignature, err := virtualSigstore.Sign("release@example.com", ciIssuer, []byte("artifact"))
+	assert.NoError(t, err)
+
+	humanIdentity, err := verify.NewShortCertificateIdentity(humanIssuer, "", "", ".*@example.com")
+	assert.NoError(t, err)
+	ciIdentity, err := verify.NewShortCertificateIdentity(ciIssuer, "release@example.com", "", "")
+	assert.NoError(t, err)
+	humanAndCI := verify.RequireCoSigners(humanIdentity, ciIdentity)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	result, err := verifier.VerifyCoSigned([]verify.SignedEntity{human, ci}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.NoError(t, err)
+	assert.Len(t, result.Results, 2)
+	if assert.Len(t, result.Signers, 2) {
+		assert.Equal(t, "alice@example.com", result.Signers[0].SubjectAlternativeName.Value)
+		assert.Equal(t, humanIssuer, result.Signers[0].Extensions.Issuer)
+		assert.Equal(t, "release@example.com", result.Signers[1].SubjectAlternativeName.Value)
+		assert.Equal(t, ciIssuer, result.Signers[1].Extensions.Issuer)
+	}
+
+	// order doesn't matter, nor do extra signers
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{otherHuman, ci, human}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.NoError(t, err)
+
+	// two humans aren't a human and CI
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{human, otherHuman}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.ErrorIs(t, err, verify.ErrCoSignersUnsatisfied)
+
+	// a single signer can't fill both roles, even if it matches both
+	anyoneAtExample, err := verify.NewShortCertificateIdentity(ciIssuer, "", "", ".*@example.com")
+	assert.NoError(t, err)
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{ci}, SkipArtifactAndIdentitiesPolicy, verify.RequireCoSigners(ciIdentity, anyoneAtExample))
+	assert.ErrorIs(t, err, verify.ErrCoSignersUnsatisfied)
+
+	// signers must sign the same statement
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{human, otherStatement}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.ErrorContains(t, err, "different payload")
+
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{human, messageSignature}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.ErrorContains(t, err, "no DSSE envelope")
+
+	// each signature is verified against the trusted material
+	untrustedSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrusted, err := untrustedSigstore.Attest("release@example.com", ciIssuer, statement)
+	assert.NoError(t, err)
+	_, err = verifier.VerifyCoSigned([]verify.SignedEntity{human, untrusted}, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.ErrorContains(t, err, "failed to verify entity 1")
+
+	_, err = verifier.VerifyCoSigned(nil, SkipArtifactAndIdentitiesPolicy, humanAndCI)
+	assert.Error(t, err)
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency-go"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/google/certificate-transparency-go/x509util"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/transparency-dev/merkle/proof"
+	"github.com/transparency-dev/merkle/rfc6962"
+)
+
+// CTInclusionProof proves that a certificate transparency log included the
+// entry it promised with an SCT: that the entry is in the tree of one of the
+// log's signed tree heads.
+type CTInclusionProof struct {
+	// LogID is the ID of the log, as in its SCTs: the SHA-256 digest of the
+	// log's public key.
+	LogID []byte
+	// LeafIndex is the index of the entry in the log.
+	LeafIndex int64
+	// Hashes is the audit path from the entry to the root of the tree.
+	Hashes [][]byte
+	// SignedTreeHead is the signed tree head of the tree the audit path
+	// leads to.
+	SignedTreeHead ct.SignedTreeHead
+}
+
+// CTInclusionProofProvider is implemented by a SignedEntity that carries
+// proofs that its signing certificate was included in certificate
+// transparency logs, which WithCTInclusionProof verifies offline. Bundles
+// don't carry them.
+type CTInclusionProofProvider interface {
+	CTInclusionProofs() ([]*CTInclusionProof, error)
+}
+
+// VerifyCTInclusionProof verifies that the certificate transparency logs that
+// issued the leaf certificate's SCTs included it, for at least threshold of
+// the SCTs from logs in the TrustedMaterial's CTLogs(). If online is true,
+// the proofs are fetched from each log's BaseURL, for its latest signed tree
+// head. Otherwise they are taken from proofs. WebAssembly builds don't make
+// network requests, so there online verification fails.
+//
+// This doesn't verify the SCTs themselves; see
+// VerifySignedCertificateTimestamp.
+func VerifyCTInclusionProof(leafCert *x509.Certificate, proofs []*CTInclusionProof, threshold int, trustedMaterial root.TrustedMaterial, online bool) error { // nolint: revive
+	ctlogs := trustedMaterial.CTLogs()
+
+	scts, err := x509util.ParseSCTsFromCertificate(leafCert.Raw)
+	if err != nil {
+		return err
+	}
+	if len(scts) == 0 {
+		return ErrMissingSCT
+	}
+
+	leafCTCert, err := ctx509.ParseCertificates(leafCert.Raw)
+	if err != nil {
+		return err
+	}
+	fulcioChains := fulcioIssuerChains(leafCTCert, trustedMaterial.FulcioCertificateAuthorities())
+
+	verified := 0
+	// reasons SCTs weren't counted, reported if the threshold isn't met
+	var rejected []error
+	for _, sct := range scts {
+		encodedKeyID := hex.EncodeToString(sct.LogID.KeyID[:])
+		ctlog, ok := ctlogs[encodedKeyID]
+		if !ok {
+			// skip entries the trust root cannot verify
+			continue
+		}
+
+		err := verifySCTInclusion(sct, fulcioChains, ctlog, proofs, online)
+		if err != nil {
+			rejected = append(rejected, fmt.Errorf("ct log %s: %w", encodedKeyID, err))
+			continue
+		}
+		verified++
+	}
+
+	if verified < threshold {
+		thresholdErr := fmt.Errorf("%w: %w: only able to verify inclusion for %d SCT entries; unable to meet threshold of %d", ErrCTInclusionProof, ErrThresholdNotMet, verified, threshold)
+		return errors.Join(append([]error{thresholdErr}, rejected...)...)
+	}
+
+	return nil
+}
+
+// verifySCTInclusion verifies that the log included the entry of the SCT,
+// for the certificate issued by any of the Fulcio CAs.
+func verifySCTInclusion(sct *ct.SignedCertificateTimestamp, fulcioChains [][]*ctx509.Certificate, ctlog *root.TransparencyLog, proofs []*CTInclusionProof, online bool) error {
+	if len(fulcioChains) == 0 {
+		return errors.New("no Fulcio CA to compute the log entry with")
+	}
+
+	var errs []error
+	for _, fulcioChain := range fulcioChains {
+		leaf, err := ct.MerkleTreeLeafForEmbeddedSCT(fulcioChain, sct.Timestamp)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		leafHash, err := ct.LeafHashForLeaf(leaf)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		candidates := proofs
+		if online {
+			fetched, err := fetchCTInclusionProof(ctlog.BaseURL, sct.LogID.KeyID[:], leafHash[:])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			candidates = []*CTInclusionProof{fetched}
+		}
+
+		for _, p := range candidates {
+			if p == nil || !bytes.Equal(p.LogID, sct.LogID.KeyID[:]) {
+				continue
+			}
+			err = verifyCTInclusion(p, leafHash[:], ctlog.PublicKey)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return errors.New("no inclusion proof for the log")
+	}
+	return errors.Join(errs...)
+}
+
+// verifyCTInclusion verifies the signature of the proof's signed tree head
+// with the log's key, and that the audit path leads from leafHash to the
+// root of its tree.
+func verifyCTInclusion(p *CTInclusionProof, leafHash []byte, logKey crypto.PublicKey) error {
+	sigVerifier, err := ct.NewSignatureVerifier(logKey)
+	if err != nil {
+		return err
+	}
+	err = sigVerifier.VerifySTHSignature(p.SignedTreeHead)
+	if err != nil {
+		return fmt.Errorf("invalid signed tree head: %w", err)
+	}
+	if p.LeafIndex < 0 {
+		return fmt.Errorf("invalid leaf index %d", p.LeafIndex)
+	}
+	err = proof.VerifyInclusion(rfc6962.DefaultHasher, uint64(p.LeafIndex), p.SignedTreeHead.TreeSize, leafHash, p.Hashes, p.SignedTreeHead.SHA256RootHash[:])
+	if err != nil {
+		return fmt.Errorf("invalid inclusion proof: %w", err)
+	}
+	return nil
+}
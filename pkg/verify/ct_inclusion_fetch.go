@@ -0,0 +1,81 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package verify
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+)
+
+// ctLogHTTPClient fetches inclusion proofs from certificate transparency logs
+// for online verification.
+var ctLogHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchCTInclusionProof fetches the log's latest signed tree head, and the
+// inclusion proof of the entry with leafHash in its tree, with the RFC 6962
+// API.
+func fetchCTInclusionProof(baseURL string, logID, leafHash []byte) (*CTInclusionProof, error) {
+	var sthResponse ct.GetSTHResponse
+	err := getCTLogJSON(baseURL, "get-sth", nil, &sthResponse)
+	if err != nil {
+		return nil, err
+	}
+	sth, err := sthResponse.ToSignedTreeHead()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("hash", base64.StdEncoding.EncodeToString(leafHash))
+	params.Set("tree_size", strconv.FormatUint(sth.TreeSize, 10))
+	var proofResponse ct.GetProofByHashResponse
+	err = getCTLogJSON(baseURL, "get-proof-by-hash", params, &proofResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CTInclusionProof{
+		LogID:          logID,
+		LeafIndex:      proofResponse.LeafIndex,
+		Hashes:         proofResponse.AuditPath,
+		SignedTreeHead: *sth,
+	}, nil
+}
+
+func getCTLogJSON(baseURL, endpoint string, params url.Values, v any) error {
+	u := strings.TrimSuffix(baseURL, "/") + "/ct/v1/" + endpoint
+	if params != nil {
+		u += "?" + params.Encode()
+	}
+	resp, err := ctLogHTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ct log %s returned %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
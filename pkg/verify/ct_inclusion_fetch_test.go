@@ -0,0 +1,113 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package verify_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// onlineCTLog is a virtual Sigstore whose CT log serves an inclusion proof
+// over the RFC 6962 API.
+type onlineCTLog struct {
+	*ca.VirtualSigstore
+	baseURL string
+}
+
+func (o *onlineCTLog) CTLogs() map[string]*root.TransparencyLog {
+	logs := o.VirtualSigstore.CTLogs()
+	for _, log := range logs {
+		log.BaseURL = o.baseURL
+	}
+	return logs
+}
+
+func TestCTInclusionProofOnline(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	leafCert, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name    string
+		tamper  func(*verify.CTInclusionProof)
+		wantErr bool
+	}{
+		{
+			name:   "valid proof",
+			tamper: func(*verify.CTInclusionProof) {},
+		},
+		{
+			name:    "tampered audit path",
+			tamper:  func(p *verify.CTInclusionProof) { p.Hashes[1][0] ^= 1 },
+			wantErr: true,
+		},
+		{
+			name:    "tampered tree size",
+			tamper:  func(p *verify.CTInclusionProof) { p.SignedTreeHead.TreeSize++ },
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := virtualSigstore.CTInclusionProof(leafCert)
+			assert.NoError(t, err)
+			tt.tamper(p)
+
+			var requestedHash string
+			mux := http.NewServeMux()
+			mux.HandleFunc("/ct/v1/get-sth", func(w http.ResponseWriter, _ *http.Request) {
+				signature, err := cttls.Marshal(p.SignedTreeHead.TreeHeadSignature)
+				assert.NoError(t, err)
+				assert.NoError(t, json.NewEncoder(w).Encode(ct.GetSTHResponse{
+					TreeSize:          p.SignedTreeHead.TreeSize,
+					Timestamp:         p.SignedTreeHead.Timestamp,
+					SHA256RootHash:    p.SignedTreeHead.SHA256RootHash[:],
+					TreeHeadSignature: signature,
+				}))
+			})
+			mux.HandleFunc("/ct/v1/get-proof-by-hash", func(w http.ResponseWriter, r *http.Request) {
+				requestedHash = r.URL.Query().Get("hash")
+				assert.NoError(t, json.NewEncoder(w).Encode(ct.GetProofByHashResponse{LeafIndex: p.LeafIndex, AuditPath: p.Hashes}))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			err = verify.VerifyCTInclusionProof(leafCert, nil, 1, &onlineCTLog{virtualSigstore, server.URL}, true)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, verify.ErrCTInclusionProof)
+			} else {
+				assert.NoError(t, err)
+			}
+			_, err = base64.StdEncoding.DecodeString(requestedHash)
+			assert.NoError(t, err)
+		})
+	}
+
+	// an unreachable log can't prove anything
+	err = verify.VerifyCTInclusionProof(leafCert, nil, 1, &onlineCTLog{virtualSigstore, "http://127.0.0.1:0"}, true)
+	assert.ErrorIs(t, err, verify.ErrCTInclusionProof)
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// ctInclusionProofEntity carries proofs of its certificate's inclusion in
+// CT logs.
+type ctInclusionProofEntity struct {
+	*ca.TestEntity
+	proofs []*verify.CTInclusionProof
+}
+
+func (e *ctInclusionProofEntity) CTInclusionProofs() ([]*verify.CTInclusionProof, error) {
+	return e.proofs, nil
+}
+
+func TestCTInclusionProof(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	digest := sha256.Sum256([]byte("artifact"))
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithoutIdentitiesUnsafe())
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+
+	validProof := func() *verify.CTInclusionProof {
+		p, err := virtualSigstore.CTInclusionProof(&leafCert)
+		assert.NoError(t, err)
+		return p
+	}
+
+	tamperedHash := validProof()
+	tamperedHash.Hashes[0][0] ^= 1
+
+	tamperedRoot := validProof()
+	tamperedRoot.SignedTreeHead.SHA256RootHash[0] ^= 1
+
+	wrongIndex := validProof()
+	wrongIndex.LeafIndex--
+
+	// a tree head signed by a log the trusted material doesn't know
+	otherLeaf, _, err := otherSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(t, err)
+	otherLogProof, err := otherSigstore.CTInclusionProof(otherLeaf)
+	assert.NoError(t, err)
+	wrongSigner := validProof()
+	wrongSigner.SignedTreeHead.TreeHeadSignature = otherLogProof.SignedTreeHead.TreeHeadSignature
+
+	wrongLog := validProof()
+	wrongLog.LogID = otherLogProof.LogID
+
+	// the inclusion of another certificate in the same log
+	anotherLeaf, _, err := virtualSigstore.GenerateLeafCert("bar@example.com", "issuer")
+	assert.NoError(t, err)
+	anotherCertificateProof, err := virtualSigstore.CTInclusionProof(anotherLeaf)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		proofs  []*verify.CTInclusionProof
+		wantErr bool
+	}{
+		{
+			name:   "valid proof",
+			proofs: []*verify.CTInclusionProof{validProof()},
+		},
+		{
+			name:   "valid proof among invalid ones",
+			proofs: []*verify.CTInclusionProof{tamperedHash, nil, validProof()},
+		},
+		{
+			name:    "no proof",
+			wantErr: true,
+		},
+		{
+			name:    "tampered audit path",
+			proofs:  []*verify.CTInclusionProof{tamperedHash},
+			wantErr: true,
+		},
+		{
+			name:    "tampered root hash",
+			proofs:  []*verify.CTInclusionProof{tamperedRoot},
+			wantErr: true,
+		},
+		{
+			name:    "wrong leaf index",
+			proofs:  []*verify.CTInclusionProof{wrongIndex},
+			wantErr: true,
+		},
+		{
+			name:    "tree head signed by another log",
+			proofs:  []*verify.CTInclusionProof{wrongSigner},
+			wantErr: true,
+		},
+		{
+			name:    "proof for another log",
+			proofs:  []*verify.CTInclusionProof{wrongLog},
+			wantErr: true,
+		},
+		{
+			name:    "proof for another certificate",
+			proofs:  []*verify.CTInclusionProof{anotherCertificateProof},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify.VerifyCTInclusionProof(&leafCert, tt.proofs, 1, virtualSigstore, false)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, verify.ErrCTInclusionProof)
+				assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithCTInclusionProof())
+			assert.NoError(t, err)
+			_, err = verifier.Verify(&ctInclusionProofEntity{entity, tt.proofs}, policy)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, verify.ErrCTInclusionProof)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// the proof is only required with WithCTInclusionProof
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithCTInclusionProof(), verify.WithoutSCTVerification())
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CycloneDXPredicateType is the in-toto predicate type of a CycloneDX SBOM.
+// Versioned forms such as "https://cyclonedx.org/bom/v1.5" are also
+// accepted by ParseCycloneDXBOM.
+const CycloneDXPredicateType = "https://cyclonedx.org/bom"
+
+// CycloneDXBOM is the subset of a CycloneDX SBOM needed to check which
+// packages it includes.
+type CycloneDXBOM struct {
+	BOMFormat    string               `json:"bomFormat"`
+	SpecVersion  string               `json:"specVersion"`
+	SerialNumber string               `json:"serialNumber,omitempty"`
+	Version      int                  `json:"version,omitempty"`
+	Components   []CycloneDXComponent `json:"components,omitempty"`
+}
+
+// CycloneDXComponent is a component of a CycloneDX SBOM, such as a library
+// or container.
+type CycloneDXComponent struct {
+	Type    string `json:"type"`
+	BOMRef  string `json:"bom-ref,omitempty"`
+	Group   string `json:"group,omitempty"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+	// Components are the component's own subcomponents.
+	Components []CycloneDXComponent `json:"components,omitempty"`
+}
+
+// AllComponents returns the SBOM's components along with all of their
+// subcomponents, depth first.
+func (b *CycloneDXBOM) AllComponents() []CycloneDXComponent {
+	var all []CycloneDXComponent
+	var walk func([]CycloneDXComponent)
+	walk = func(components []CycloneDXComponent) {
+		for _, component := range components {
+			all = append(all, component)
+			walk(component.Components)
+		}
+	}
+	walk(b.Components)
+	return all
+}
+
+// ParseCycloneDXBOM decodes the CycloneDX SBOM predicate of the entity's
+// in-toto statement. Fields that CycloneDXBOM doesn't have are ignored.
+//
+// It does not verify the entity; only call it once Verify has succeeded.
+func ParseCycloneDXBOM(entity SignedEntity) (*CycloneDXBOM, error) {
+	sigContent, err := entity.SignatureContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signature content: %w", err)
+	}
+	envelope := sigContent.EnvelopeContent()
+	if envelope == nil {
+		return nil, errors.New("entity has no in-toto statement")
+	}
+	statement, err := envelope.Statement()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch envelope statement: %w", err)
+	}
+
+	if statement.PredicateType != CycloneDXPredicateType && !strings.HasPrefix(statement.PredicateType, CycloneDXPredicateType+"/") {
+		return nil, fmt.Errorf("predicate type %q is not a CycloneDX SBOM", statement.PredicateType)
+	}
+
+	predicate, err := json.Marshal(statement.GetPredicate().AsMap())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode predicate: %w", err)
+	}
+	var bom CycloneDXBOM
+	if err := json.Unmarshal(predicate, &bom); err != nil {
+		return nil, fmt.Errorf("failed to decode CycloneDX SBOM: %w", err)
+	}
+	return &bom, nil
+}
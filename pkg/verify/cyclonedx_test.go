@@ -0,0 +1,96 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCycloneDXBOM(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	attest := func(predicateType, predicate string) verify.SignedEntity {
+		statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"%s","subject":[{"name":"artifact","digest":{"sha256":"%s"}}],"predicate":%s}`,
+			predicateType, hex.EncodeToString(artifactDigest[:]), predicate))
+		entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+		assert.NoError(t, err)
+		return entity
+	}
+
+	// unknown fields such as metadata and dependencies are ignored
+	sbom := attest("https://cyclonedx.org/bom", `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"serialNumber": "urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79",
+		"version": 1,
+		"metadata": {"timestamp": "2024-01-01T00:00:00Z"},
+		"components": [
+			{"type": "library", "bom-ref": "golang.org/x/crypto", "name": "golang.org/x/crypto", "version": "v0.21.0", "purl": "pkg:golang/golang.org/x/crypto@v0.21.0"},
+			{"type": "container", "name": "app", "components": [{"type": "library", "name": "openssl", "version": "3.0.13", "purl": "pkg:deb/debian/openssl@3.0.13"}]}
+		],
+		"dependencies": [{"ref": "golang.org/x/crypto"}]
+	}`)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(sbom, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	bom, err := verify.ParseCycloneDXBOM(sbom)
+	assert.NoError(t, err)
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Equal(t, "1.5", bom.SpecVersion)
+	assert.Equal(t, 1, bom.Version)
+	if assert.Len(t, bom.Components, 2) {
+		assert.Equal(t, verify.CycloneDXComponent{
+			Type:    "library",
+			BOMRef:  "golang.org/x/crypto",
+			Name:    "golang.org/x/crypto",
+			Version: "v0.21.0",
+			PURL:    "pkg:golang/golang.org/x/crypto@v0.21.0",
+		}, bom.Components[0])
+	}
+
+	var purls []string
+	for _, component := range bom.AllComponents() {
+		purls = append(purls, component.PURL)
+	}
+	assert.Equal(t, []string{"pkg:golang/golang.org/x/crypto@v0.21.0", "", "pkg:deb/debian/openssl@3.0.13"}, purls)
+
+	// versioned predicate types are accepted
+	_, err = verify.ParseCycloneDXBOM(attest("https://cyclonedx.org/bom/v1.5", `{"bomFormat":"CycloneDX","specVersion":"1.5"}`))
+	assert.NoError(t, err)
+
+	_, err = verify.ParseCycloneDXBOM(attest("https://spdx.dev/Document", `{"spdxVersion":"SPDX-2.3"}`))
+	assert.Error(t, err)
+
+	_, err = verify.ParseCycloneDXBOM(attest("https://cyclonedx.org/bom", `{"components":"not a list"}`))
+	assert.Error(t, err)
+
+	messageSignature, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	_, err = verify.ParseCycloneDXBOM(messageSignature)
+	assert.Error(t, err)
+}
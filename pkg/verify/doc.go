@@ -0,0 +1,35 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify verifies Sigstore signed entities, such as bundles, against
+// trusted material and a policy.
+//
+// # Concurrency
+//
+// A constructed SignedEntityVerifier is safe for concurrent use: Verify may
+// be called from many goroutines at once, with the same or different
+// entities and policies. Construction is not; the options passed to
+// NewSignedEntityVerifier must not be modified while it runs.
+//
+// A PolicyBuilder is built into a fresh policy on every call to Verify, so a
+// PolicyBuilder may be shared between goroutines, with one exception: a
+// policy created with WithArtifact reads the artifact from its io.Reader,
+// which can only be consumed once. Create that policy for each call instead.
+//
+// The trusted material is only read during verification. TrustedRoot is
+// immutable once parsed, and LiveTrustedRoot guards its refreshes with a
+// lock; custom TrustedMaterial implementations must be safe for concurrent
+// reads. Entities are likewise only read, so a bundle.ProtobufBundle may be
+// verified by several goroutines at once.
+package verify
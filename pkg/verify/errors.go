@@ -15,9 +15,63 @@
 package verify
 
 import (
+	"errors"
 	"fmt"
 )
 
+// These errors are returned, wrapped, by Verify and the other verification
+// functions of this package, alongside ErrNoTrustedTimeSource, ErrMissingSCT,
+// ErrInvalidSCT, ErrUnexpectedMessageHash, the ErrTSA* and ErrFulcioLeaf*
+// errors, root.ErrUnknownLog and root.ErrExpiredTrustMaterial. Check for them
+// with errors.Is. They won't be removed or change meaning within a major
+// version; the text of the errors wrapping them may change at any time.
+var (
+	// ErrThresholdNotMet is returned when fewer log entries, timestamps or
+	// SCTs verified than required. It is joined with the reasons the
+	// others were rejected, where known.
+	ErrThresholdNotMet = errors.New("verification threshold not met")
+	// ErrIdentityMismatch is returned when the certificate matches none of
+	// the policy's certificate identities.
+	ErrIdentityMismatch = errors.New("no matching certificate identity found")
+	// ErrTimestampOutsideCertValidity is returned with
+	// WithTimestampWithinCertValidity when no verified timestamp is within
+	// the signing certificate's validity period.
+	ErrTimestampOutsideCertValidity = errors.New("no timestamp within certificate validity")
+	// ErrBuilderIDNotAllowed is returned with NewBuilderIDPolicy when the
+	// SLSA provenance names a builder that isn't in the allowlist.
+	ErrBuilderIDNotAllowed = errors.New("builder ID not allowed")
+	// ErrSubjectNameMismatch is returned with NewSubjectGlobPolicy when no
+	// subject of the in-toto statement has a name matching the pattern.
+	ErrSubjectNameMismatch = errors.New("no subject name matches pattern")
+	// ErrStatementPolicyFailed is returned with WithStatementPredicate when
+	// the in-toto statement doesn't satisfy a predicate.
+	ErrStatementPolicyFailed = errors.New("statement does not satisfy policy")
+	// ErrUnexpectedPayloadType is returned when a DSSE envelope's payload
+	// type isn't one of those expected, see WithExpectedPayloadTypes.
+	ErrUnexpectedPayloadType = errors.New("unexpected DSSE payload type")
+	// ErrTlogPayloadMismatch is returned when a dsse or intoto log entry
+	// records a payload hash that isn't the digest of the bundle's DSSE
+	// envelope payload.
+	ErrTlogPayloadMismatch = errors.New("transparency log payload hash does not match")
+	// ErrCoSignersUnsatisfied is returned by VerifyCoSigned when the
+	// verified signers don't satisfy the co-signer predicate.
+	ErrCoSignersUnsatisfied = errors.New("co-signers do not satisfy policy")
+	// ErrStaleCheckpoint is returned with WithCheckpointFreshness when the
+	// checkpoint of an inclusion proof is outside the freshness window.
+	ErrStaleCheckpoint = errors.New("checkpoint outside freshness window")
+	// ErrNotFIPSApproved is returned with WithFIPSMode when a key,
+	// signature or digest that verification relies on uses an algorithm
+	// that isn't FIPS-approved.
+	ErrNotFIPSApproved = errors.New("algorithm not FIPS-approved")
+	// ErrKeyTooWeak is returned with WithMinKeyStrength when a certificate's
+	// public key is weaker than the minimum.
+	ErrKeyTooWeak = errors.New("key weaker than minimum strength")
+	// ErrCTInclusionProof is returned with WithCTInclusionProof when the
+	// certificate's inclusion in certificate transparency logs couldn't be
+	// proven for enough of its SCTs.
+	ErrCTInclusionProof = errors.New("certificate's inclusion in certificate transparency log could not be verified")
+)
+
 type ErrVerification struct {
 	err error
 }
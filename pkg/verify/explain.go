@@ -0,0 +1,139 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// ExplainReport is a step-by-step account of a verification, for showing to
+// people, such as in a CLI's verbose output. Use the VerificationResult for
+// decisions; the wording of the steps may change at any time.
+type ExplainReport struct {
+	// Steps are what was checked, in order, ending with the outcome
+	Steps []ExplainStep
+	// Result is the result of the verification, nil if it failed
+	Result *VerificationResult
+	// Err is why the verification failed, nil if it succeeded
+	Err error
+}
+
+// ExplainStep is one check made during verification and what it found.
+type ExplainStep struct {
+	// Description says what was checked, e.g. "certificate chain verified"
+	Description string
+	// Details are the particulars of the check, as "key=value" pairs
+	Details []string
+}
+
+// String renders the step as a single line.
+func (s ExplainStep) String() string {
+	if len(s.Details) == 0 {
+		return s.Description
+	}
+	return fmt.Sprintf("%s (%s)", s.Description, strings.Join(s.Details, ", "))
+}
+
+// String renders the report as numbered lines, one per step.
+func (r *ExplainReport) String() string {
+	var b strings.Builder
+	for i, step := range r.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	return b.String()
+}
+
+// Explain verifies the entity like Verify, and reports each check it made
+// and its outcome, whether verification succeeded or not. The steps are
+// the events the verifier would emit with WithLogger; they are recorded for
+// this call only, and not sent to the verifier's logger.
+func (v *SignedEntityVerifier) Explain(entity SignedEntity, pb PolicyBuilder) *ExplainReport {
+	recorder := newExplainRecorder()
+	result, err := v.withLogger(slog.New(recorder)).Verify(entity, pb)
+	return &ExplainReport{Steps: recorder.steps(), Result: result, Err: err}
+}
+
+// withLogger returns a copy of the verifier, and of any verifiers of
+// WithTrustedRoots, that logs to logger.
+func (v *SignedEntityVerifier) withLogger(logger *slog.Logger) *SignedEntityVerifier {
+	logged := *v
+	logged.config.logger = logger
+	logged.rootVerifiers = make([]*SignedEntityVerifier, len(v.rootVerifiers))
+	for i, rootVerifier := range v.rootVerifiers {
+		logged.rootVerifiers[i] = rootVerifier.withLogger(logger)
+	}
+	return &logged
+}
+
+// explainRecorder is a slog.Handler that records every event as an
+// ExplainStep. Handlers derived with WithAttrs and WithGroup record into the
+// same steps.
+type explainRecorder struct {
+	mu      *sync.Mutex
+	records *[]ExplainStep
+	attrs   []string
+	group   string
+}
+
+func newExplainRecorder() *explainRecorder {
+	return &explainRecorder{mu: &sync.Mutex{}, records: &[]ExplainStep{}}
+}
+
+func (h *explainRecorder) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *explainRecorder) Handle(_ context.Context, record slog.Record) error {
+	step := ExplainStep{Description: record.Message, Details: append([]string(nil), h.attrs...)}
+	record.Attrs(func(a slog.Attr) bool {
+		step.Details = append(step.Details, h.detail(a))
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.records = append(*h.records, step)
+	return nil
+}
+
+func (h *explainRecorder) WithAttrs(attrs []slog.Attr) slog.Handler {
+	with := *h
+	with.attrs = append([]string(nil), h.attrs...)
+	for _, a := range attrs {
+		with.attrs = append(with.attrs, h.detail(a))
+	}
+	return &with
+}
+
+func (h *explainRecorder) WithGroup(name string) slog.Handler {
+	with := *h
+	with.group = h.group + name + "."
+	return &with
+}
+
+// detail formats an attribute as "key=value".
+func (h *explainRecorder) detail(a slog.Attr) string {
+	return fmt.Sprintf("%s%s=%s", h.group, a.Key, a.Value.Resolve().String())
+}
+
+func (h *explainRecorder) steps() []ExplainStep {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]ExplainStep(nil), *h.records...)
+}
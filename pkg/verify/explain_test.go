@@ -0,0 +1,77 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithLogger(logger))
+	assert.NoError(t, err)
+
+	policy := func(san string) verify.PolicyBuilder {
+		identity, err := verify.NewShortCertificateIdentity("issuer", san, "", "")
+		assert.NoError(t, err)
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity))
+	}
+
+	// a successful verification is explained step by step
+	report := verifier.Explain(entity, policy("foo@example.com"))
+	assert.NoError(t, report.Err)
+	assert.NotNil(t, report.Result)
+	narrative := report.String()
+	assert.Contains(t, narrative, "1. trusted material selected (type=*ca.VirtualSigstore")
+	assert.Contains(t, narrative, "transparency log entry verified (log_id=")
+	assert.Contains(t, narrative, "log_index=1000")
+	assert.Contains(t, narrative, "certificate chain verified")
+	assert.Contains(t, narrative, "signature verified (artifact=true)")
+	assert.Contains(t, narrative, "certificate identity verified (san_type=Email, san=foo@example.com")
+	assert.Equal(t, "verification succeeded", report.Steps[len(report.Steps)-1].Description)
+
+	// the steps are only recorded in the report
+	assert.Zero(t, buf.Len())
+
+	// a failure is explained up to where it happened
+	report = verifier.Explain(entity, policy("bar@example.com"))
+	assert.ErrorIs(t, report.Err, verify.ErrIdentityMismatch)
+	assert.Nil(t, report.Result)
+	narrative = report.String()
+	assert.Contains(t, narrative, "signature verified")
+	assert.NotContains(t, narrative, "certificate identity verified")
+	last := report.Steps[len(report.Steps)-1]
+	assert.Equal(t, "verification failed", last.Description)
+	assert.Contains(t, last.String(), "failed to verify certificate identity")
+
+	// the verifier's logger still gets the events of Verify
+	_, err = verifier.Verify(entity, policy("foo@example.com"))
+	assert.NoError(t, err)
+	assert.NotZero(t, buf.Len())
+}
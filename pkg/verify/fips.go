@@ -0,0 +1,310 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/digitorus/timestamp"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	"github.com/google/certificate-transparency-go/x509util"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// WithFIPSMode configures the SignedEntityVerifier to only accept
+// FIPS-approved algorithms: ECDSA on P-256 or P-384, or RSA with a modulus of
+// at least 2048 bits, each with SHA-256, SHA-384 or SHA-512. Ed25519 and
+// SHA-1 are rejected wherever they appear.
+//
+// The constraint covers every key, signature and digest that verification
+// relies on: the entity's signing key and message digest, the signing
+// certificate and the chain it is issued by, the keys and tree hash of the
+// transparency logs, the digests in log entry bodies, the SCTs and the keys
+// of the CT logs that issued them, and the message imprints and certificates
+// of signed timestamps. A violation fails verification with an error
+// wrapping ErrNotFIPSApproved that names the component and its algorithm.
+func WithFIPSMode() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.fipsMode = true
+		return nil
+	}
+}
+
+func notFIPSApproved(component, algorithm string) error {
+	return fmt.Errorf("%w: %s uses %s", ErrNotFIPSApproved, component, algorithm)
+}
+
+func checkFIPSPublicKey(component string, pub crypto.PublicKey) error {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if key.Curve == elliptic.P256() || key.Curve == elliptic.P384() {
+			return nil
+		}
+		return notFIPSApproved(component, "ECDSA "+key.Curve.Params().Name)
+	case *rsa.PublicKey:
+		if key.N.BitLen() >= 2048 {
+			return nil
+		}
+		return notFIPSApproved(component, fmt.Sprintf("RSA-%d", key.N.BitLen()))
+	case ed25519.PublicKey:
+		return notFIPSApproved(component, "Ed25519")
+	default:
+		return notFIPSApproved(component, fmt.Sprintf("%T", pub))
+	}
+}
+
+func checkFIPSHash(component string, hash crypto.Hash) error {
+	switch hash {
+	case crypto.SHA256, crypto.SHA384, crypto.SHA512:
+		return nil
+	default:
+		return notFIPSApproved(component, hash.String())
+	}
+}
+
+// checkFIPSCertificate checks both the key a certificate certifies and the
+// algorithm its issuer signed it with.
+func checkFIPSCertificate(component string, cert *x509.Certificate) error {
+	switch cert.SignatureAlgorithm {
+	case x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512,
+		x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA,
+		x509.SHA256WithRSAPSS, x509.SHA384WithRSAPSS, x509.SHA512WithRSAPSS:
+	default:
+		return notFIPSApproved(component+" signature", cert.SignatureAlgorithm.String())
+	}
+	return checkFIPSPublicKey(component+" key", cert.PublicKey)
+}
+
+// checkFIPSLog checks the keys and hash functions of a transparency or CT
+// log. An unset hash function is the log's default of SHA-256.
+func checkFIPSLog(component string, log *root.TransparencyLog) error {
+	if err := checkFIPSPublicKey(component+" key", log.PublicKey); err != nil {
+		return err
+	}
+	if log.HashFunc != 0 {
+		if err := checkFIPSHash(component+" tree hash", log.HashFunc); err != nil {
+			return err
+		}
+	}
+	if log.SignatureHashFunc != 0 {
+		if err := checkFIPSHash(component+" signature hash", log.SignatureHashFunc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFIPSTransparencyLog checks the digests in the entity's log entries,
+// and the logs that integrated them. Entries from logs the trusted material
+// doesn't know are left to log verification to reject.
+func checkFIPSTransparencyLog(entity SignedEntity, trustedMaterial root.TrustedMaterial) error {
+	entries, err := entity.TlogEntries()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		for _, algorithm := range entry.HashAlgorithms() {
+			switch algorithm {
+			case "sha256", "sha384", "sha512":
+			default:
+				return notFIPSApproved(fmt.Sprintf("log entry %d body digest", entry.LogIndex()), algorithm)
+			}
+		}
+		if tlog, ok := trustedMaterial.RekorLogs()[hex.EncodeToString([]byte(entry.LogKeyID()))]; ok {
+			if err := checkFIPSLog("transparency log", tlog); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkFIPSTimestamps checks the entity's signed timestamps, and the
+// timestamp authorities they are verified against.
+func checkFIPSTimestamps(entity SignedEntity, trustedMaterial root.TrustedMaterial) error {
+	signedTimestamps, err := entity.Timestamps()
+	if err != nil {
+		return err
+	}
+	if len(signedTimestamps) == 0 {
+		return nil
+	}
+
+	for _, signedTimestamp := range signedTimestamps {
+		ts, err := timestamp.ParseResponse(signedTimestamp)
+		if err != nil {
+			// left to timestamp verification to reject
+			continue
+		}
+		if err := checkFIPSHash("signed timestamp message imprint", ts.HashAlgorithm); err != nil {
+			return err
+		}
+		for _, cert := range ts.Certificates {
+			if err := checkFIPSCertificate("signed timestamp certificate", cert); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, ca := range trustedMaterial.TimestampingAuthorities() {
+		if err := checkFIPSCertificateAuthority("timestamp authority", ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFIPSCertificateAuthority(component string, ca root.CertificateAuthority) error {
+	if ca.Leaf != nil {
+		if err := checkFIPSCertificate(component+" leaf certificate", ca.Leaf); err != nil {
+			return err
+		}
+	}
+	for _, intermediate := range ca.Intermediates {
+		if err := checkFIPSCertificate(component+" intermediate certificate", intermediate); err != nil {
+			return err
+		}
+	}
+	if ca.Root != nil {
+		if err := checkFIPSCertificate(component+" root certificate", ca.Root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFIPSCertificateChain checks the signing certificate, and the
+// certificates of the Fulcio CAs that issued it, up to a root.
+func checkFIPSCertificateChain(leafCert *x509.Certificate, trustedMaterial root.TrustedMaterial) error {
+	if err := checkFIPSCertificate("signing certificate", leafCert); err != nil {
+		return err
+	}
+
+	// a chain that doesn't reach a root is left to chain verification to
+	// reject
+	for _, issuer := range trustedIssuers(leafCert, trustedMaterial) {
+		if err := checkFIPSCertificate(fmt.Sprintf("certificate chain certificate %q", issuer.Subject.String()), issuer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trustedIssuers returns the certificates of the trusted material's
+// certificate authorities that the leaf certificate chains to, from its
+// issuer up to the root, stopping at the first certificate whose issuer
+// isn't found. The chain isn't otherwise verified.
+func trustedIssuers(leafCert *x509.Certificate, trustedMaterial root.TrustedMaterial) []*x509.Certificate {
+	var pool []*x509.Certificate
+	for _, ca := range trustedMaterial.FulcioCertificateAuthorities() {
+		pool = append(pool, ca.Intermediates...)
+		if ca.Root != nil {
+			pool = append(pool, ca.Root)
+		}
+	}
+
+	var issuers []*x509.Certificate
+	cert := leafCert
+	// each certificate of the pool is in the chain at most once
+	for range pool {
+		issuer := findIssuer(cert, pool)
+		if issuer == nil {
+			break
+		}
+		issuers = append(issuers, issuer)
+		if bytes.Equal(issuer.Raw, cert.Raw) {
+			// a self-signed root
+			break
+		}
+		cert = issuer
+	}
+	return issuers
+}
+
+func findIssuer(cert *x509.Certificate, pool []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range pool {
+		if bytes.Equal(candidate.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// checkFIPSSCTs checks the SCTs embedded in the signing certificate, and the
+// CT logs that issued them.
+func checkFIPSSCTs(leafCert *x509.Certificate, trustedMaterial root.TrustedMaterial) error {
+	scts, err := x509util.ParseSCTsFromCertificate(leafCert.Raw)
+	if err != nil {
+		// left to SCT verification to reject
+		return nil
+	}
+	for _, sct := range scts {
+		switch sct.Signature.Algorithm.Hash {
+		case cttls.SHA256, cttls.SHA384, cttls.SHA512:
+		default:
+			return notFIPSApproved("SCT signature hash", sct.Signature.Algorithm.Hash.String())
+		}
+		switch sct.Signature.Algorithm.Signature {
+		case cttls.ECDSA, cttls.RSA:
+		default:
+			return notFIPSApproved("SCT signature", sct.Signature.Algorithm.Signature.String())
+		}
+		if ctlog, ok := trustedMaterial.CTLogs()[hex.EncodeToString(sct.LogID.KeyID[:])]; ok {
+			if err := checkFIPSLog("CT log", ctlog); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkFIPSSignature checks the key the entity was signed with, and the
+// digest algorithm of a message signature. The key of a signing certificate
+// is checked with its chain.
+func checkFIPSSignature(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial) error {
+	if pk, ok := verificationContent.HasPublicKey(); ok {
+		verifier, err := trustedMaterial.PublicKeyVerifier(pk.Hint())
+		if err != nil {
+			// left to signature verification to reject
+			return nil
+		}
+		pub, err := verifier.PublicKey()
+		if err != nil {
+			return err
+		}
+		if err := checkFIPSPublicKey("signing key", pub); err != nil {
+			return err
+		}
+	}
+
+	if msg := sigContent.MessageSignatureContent(); msg != nil && len(msg.Digest()) > 0 {
+		switch msg.DigestAlgorithm() {
+		case protocommon.HashAlgorithm_SHA2_256.String(), protocommon.HashAlgorithm_SHA2_384.String(), protocommon.HashAlgorithm_SHA2_512.String():
+		default:
+			return notFIPSApproved("message signature digest", msg.DigestAlgorithm())
+		}
+	}
+	return nil
+}
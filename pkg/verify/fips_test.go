@@ -0,0 +1,153 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// ed25519CTLogTrustedMaterial reports an Ed25519 key for each CT log.
+type ed25519CTLogTrustedMaterial struct {
+	*ca.VirtualSigstore
+}
+
+func (m *ed25519CTLogTrustedMaterial) CTLogs() map[string]*root.TransparencyLog {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	logs := m.VirtualSigstore.CTLogs()
+	for _, log := range logs {
+		log.PublicKey = pub
+	}
+	return logs
+}
+
+// sha1MessageEntity declares a SHA-1 digest for its message signature.
+type sha1MessageEntity struct {
+	*ca.TestEntity
+	artifact []byte
+}
+
+func (e *sha1MessageEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	digest := sha1.Sum(e.artifact) //nolint:gosec
+	return bundle.NewMessageSignature(digest[:], "SHA1", sigContent.Signature()), nil
+}
+
+func TestFIPSMode(t *testing.T) {
+	artifact := []byte("artifact")
+
+	sign := func(t *testing.T, opts ...ca.VirtualSigstoreOption) (*ca.VirtualSigstore, *ca.TestEntity) {
+		virtualSigstore, err := ca.NewVirtualSigstoreWithOptions(opts...)
+		assert.NoError(t, err)
+		entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+		assert.NoError(t, err)
+		return virtualSigstore, entity
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity)
+		wantErr string
+	}{
+		{
+			name: "P-256",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				return sign(t)
+			},
+		},
+		{
+			name: "P-384",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				return sign(t, ca.WithLeafKeyCurve(elliptic.P384()), ca.WithTSALeafKeyCurve(elliptic.P384()), ca.WithRekorKeyCurve(elliptic.P384()))
+			},
+		},
+		{
+			name: "P-521 signing certificate",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				return sign(t, ca.WithLeafKeyCurve(elliptic.P521()))
+			},
+			wantErr: "signing certificate key uses ECDSA P-521",
+		},
+		{
+			name: "P-521 transparency log",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				return sign(t, ca.WithRekorKeyCurve(elliptic.P521()))
+			},
+			wantErr: "transparency log key uses ECDSA P-521",
+		},
+		{
+			name: "P-521 timestamp authority",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				return sign(t, ca.WithTSALeafKeyCurve(elliptic.P521()))
+			},
+			wantErr: "uses ECDSA P-521",
+		},
+		{
+			name: "Ed25519 CT log",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				virtualSigstore, entity := sign(t)
+				return &ed25519CTLogTrustedMaterial{virtualSigstore}, entity
+			},
+			wantErr: "CT log key uses Ed25519",
+		},
+		{
+			name: "SHA-1 message digest",
+			setup: func(t *testing.T) (root.TrustedMaterial, verify.SignedEntity) {
+				virtualSigstore, entity := sign(t)
+				return virtualSigstore, &sha1MessageEntity{entity, artifact}
+			},
+			wantErr: "message signature digest uses SHA1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedMaterial, entity := tt.setup(t)
+			policy := verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+			verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithSignedCertificateTimestamps(1), verify.WithFIPSMode())
+			assert.NoError(t, err)
+			_, err = verifier.Verify(entity, policy)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, verify.ErrNotFIPSApproved)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	// the non-compliant algorithms verify without FIPS mode
+	virtualSigstore, entity := sign(t, ca.WithLeafKeyCurve(elliptic.P521()), ca.WithTSALeafKeyCurve(elliptic.P521()), ca.WithRekorKeyCurve(elliptic.P521()))
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithSignedCertificateTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+}
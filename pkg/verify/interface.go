@@ -19,7 +19,7 @@ import (
 	"errors"
 	"time"
 
-	"github.com/in-toto/in-toto-golang/in_toto"
+	in_toto "github.com/in-toto/attestation/go/v1"
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	"github.com/sigstore/sigstore-go/pkg/root"
@@ -89,6 +89,13 @@ type EnvelopeContent interface {
 	Statement() (*in_toto.Statement, error)
 }
 
+// EnvelopeJSONProvider is implemented by an EnvelopeContent that keeps the
+// JSON it was read from, which timestamps over the envelope are checked
+// against. See TimestampedEnvelope.
+type EnvelopeJSONProvider interface {
+	EnvelopeJSON() []byte
+}
+
 // BaseSignedEntity is a helper struct that implements all the interfaces
 // of SignedEntity. It can be embedded in a struct to implement the SignedEntity
 // interface. This may be useful for testing, or for implementing a SignedEntity
@@ -0,0 +1,97 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// KeyStrength is the minimum strength required of certificate public keys
+// by WithMinKeyStrength.
+type KeyStrength struct {
+	// RSABits is the smallest RSA modulus allowed, in bits. Zero allows
+	// any.
+	RSABits int `json:"rsaBits,omitempty"`
+	// ECDSABits is the smallest ECDSA curve allowed, by the size of its
+	// order in bits, e.g. 256 for P-256. Ed25519 keys count as 256 bits.
+	// Zero allows any.
+	ECDSABits int `json:"ecdsaBits,omitempty"`
+	// Chain applies the minimum to the keys of the certificate authorities
+	// in the trusted material that the leaf certificate chains to, as well
+	// as to the leaf's.
+	Chain bool `json:"chain,omitempty"`
+}
+
+// WithMinKeyStrength configures the SignedEntityVerifier to reject a leaf
+// certificate whose public key is weaker than strength, for security
+// policies such as requiring RSA keys of at least 3072 bits. Keys of other
+// types are rejected.
+func WithMinKeyStrength(strength KeyStrength) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if strength.RSABits < 0 || strength.ECDSABits < 0 {
+			return errors.New("minimum key strength must not be negative")
+		}
+		if strength.RSABits == 0 && strength.ECDSABits == 0 {
+			return errors.New("minimum key strength must set RSABits or ECDSABits")
+		}
+		c.minKeyStrength = &strength
+		return nil
+	}
+}
+
+// verifyKeyStrength checks the leaf certificate's key, and with
+// strength.Chain those of its issuers, against strength.
+func verifyKeyStrength(leafCert *x509.Certificate, trustedMaterial root.TrustedMaterial, strength KeyStrength) error {
+	if err := checkKeyStrength("signing certificate", leafCert.PublicKey, strength); err != nil {
+		return err
+	}
+	if !strength.Chain {
+		return nil
+	}
+	for _, issuer := range trustedIssuers(leafCert, trustedMaterial) {
+		if err := checkKeyStrength(fmt.Sprintf("certificate chain certificate %q", issuer.Subject.String()), issuer.PublicKey, strength); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkKeyStrength(component string, pub crypto.PublicKey, strength KeyStrength) error {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		if bits := key.N.BitLen(); bits < strength.RSABits {
+			return fmt.Errorf("%w: %s key is RSA-%d, below the minimum of %d bits", ErrKeyTooWeak, component, bits, strength.RSABits)
+		}
+	case *ecdsa.PublicKey:
+		if bits := key.Curve.Params().BitSize; bits < strength.ECDSABits {
+			return fmt.Errorf("%w: %s key is ECDSA %s, below the minimum of %d bits", ErrKeyTooWeak, component, key.Curve.Params().Name, strength.ECDSABits)
+		}
+	case ed25519.PublicKey:
+		if strength.ECDSABits > 256 {
+			return fmt.Errorf("%w: %s key is Ed25519, below the minimum of %d bits", ErrKeyTooWeak, component, strength.ECDSABits)
+		}
+	default:
+		return fmt.Errorf("%w: %s key type %T", ErrKeyTooWeak, component, pub)
+	}
+	return nil
+}
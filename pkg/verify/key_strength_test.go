@@ -0,0 +1,105 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// reissuedCertificateEntity is a signed entity whose signing certificate
+// has been swapped for another.
+type reissuedCertificateEntity struct {
+	*ca.TestEntity
+	cert *x509.Certificate
+}
+
+func (e *reissuedCertificateEntity) VerificationContent() (verify.VerificationContent, error) {
+	return &bundle.Certificate{Certificate: e.cert}, nil
+}
+
+func TestMinKeyStrength(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	// issue the RSA certificate first, so that it is already valid when
+	// the entity is timestamped
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	rsaCert, err := virtualSigstore.IssueLeafCert("foo@example.com", "issuer", rsaKey.Public())
+	assert.NoError(t, err)
+
+	// the virtual Sigstore's keys are all ECDSA P-256
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	digest := sha256.Sum256([]byte("artifact"))
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithoutIdentitiesUnsafe())
+
+	rsaEntity := &reissuedCertificateEntity{TestEntity: entity, cert: rsaCert}
+
+	for _, tt := range []struct {
+		name     string
+		entity   verify.SignedEntity
+		strength verify.KeyStrength
+		wantErr  string
+	}{
+		{
+			name:     "P-256 leaf",
+			entity:   entity,
+			strength: verify.KeyStrength{RSABits: 3072, ECDSABits: 256},
+		},
+		{
+			name:     "P-256 chain",
+			entity:   entity,
+			strength: verify.KeyStrength{ECDSABits: 256, Chain: true},
+		},
+		{
+			name:     "P-256 leaf below P-384",
+			entity:   entity,
+			strength: verify.KeyStrength{ECDSABits: 384},
+			wantErr:  "signing certificate key is ECDSA P-256, below the minimum of 384 bits",
+		},
+		{
+			name:     "RSA-2048 leaf below 3072 bits",
+			entity:   rsaEntity,
+			strength: verify.KeyStrength{RSABits: 3072, ECDSABits: 256},
+			wantErr:  "signing certificate key is RSA-2048, below the minimum of 3072 bits",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithMinKeyStrength(tt.strength))
+			assert.NoError(t, err)
+			_, err = verifier.Verify(tt.entity, policy)
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, err, verify.ErrKeyTooWeak)
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithMinKeyStrength(verify.KeyStrength{}))
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithMinKeyStrength(verify.KeyStrength{RSABits: -1}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// MetricsHook receives the outcome and latency of each call to
+// SignedEntityVerifier.Verify, the latency of its phases, and the kind of
+// error it failed with, so that they can be exported to a metrics system
+// such as Prometheus or OpenTelemetry. The labels passed are the Outcome*,
+// Phase* and ErrorKind* constants, which keep metric cardinality bounded.
+//
+// A SignedEntityVerifier calls its hook from every goroutine Verify is
+// called from, so implementations must be safe for concurrent use. They are
+// called synchronously and should return quickly.
+type MetricsHook interface {
+	// ObserveVerification is called once per verification, with its
+	// outcome and how long it took end to end.
+	ObserveVerification(outcome string, d time.Duration)
+	// ObservePhase is called after each verification phase that runs,
+	// whether it succeeded or not, with how long it took.
+	ObservePhase(name string, d time.Duration)
+	// IncError is called once per failed verification, with the kind of
+	// error it failed with.
+	IncError(kind string)
+}
+
+// CacheMetricsHook can also be implemented by a MetricsHook to learn whether
+// verifications reuse the state the verifier derived from its trusted
+// material, such as the parsed certificate pools of its timestamp
+// authorities. That state is only rebuilt when the trusted material changes;
+// a trusted root reloaded with the same fingerprint is a hit.
+type CacheMetricsHook interface {
+	// ObserveCacheLookup is called each time a verification looks up derived
+	// state in the named cache, with whether it was found.
+	ObserveCacheLookup(cache string, hit bool)
+}
+
+// Caches of a SignedEntityVerifier, passed to
+// CacheMetricsHook.ObserveCacheLookup.
+const (
+	CacheTimestampAuthorities = "timestamp_authorities"
+)
+
+// Outcomes of a verification, passed to MetricsHook.ObserveVerification.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Verification phases, passed to MetricsHook.ObservePhase. A phase that
+// doesn't apply, such as the certificate chain of an entity signed with a
+// key, isn't observed.
+const (
+	PhaseTransparencyLog             = "transparency_log"
+	PhaseObserverTimestamps          = "observer_timestamps"
+	PhaseCertificateChain            = "certificate_chain"
+	PhaseSignedCertificateTimestamps = "signed_certificate_timestamps"
+	PhaseSignature                   = "signature"
+	// PhasePolicy covers the checks of the certificate identities and
+	// predicate digests, after the entity's cryptographic material has
+	// been verified.
+	PhasePolicy = "policy"
+)
+
+// Kinds of verification error, passed to MetricsHook.IncError.
+const (
+	ErrorKindNoTrustedTimeSource  = "no_trusted_time_source"
+	ErrorKindIdentityMismatch     = "identity_mismatch"
+	ErrorKindCertificate          = "certificate"
+	ErrorKindSCT                  = "sct"
+	ErrorKindTimestampAuthority   = "timestamp_authority"
+	ErrorKindUnknownLog           = "unknown_log"
+	ErrorKindExpiredTrustMaterial = "expired_trust_material"
+	ErrorKindThresholdNotMet      = "threshold_not_met"
+	ErrorKindOther                = "other"
+)
+
+// NoopMetricsHook discards all metrics. It is the default MetricsHook, and
+// can be embedded by hooks that only implement some of its methods.
+type NoopMetricsHook struct{}
+
+func (NoopMetricsHook) ObserveVerification(string, time.Duration) {}
+
+func (NoopMetricsHook) ObservePhase(string, time.Duration) {}
+
+func (NoopMetricsHook) IncError(string) {}
+
+func (NoopMetricsHook) ObserveCacheLookup(string, bool) {}
+
+// WithMetricsHook configures the SignedEntityVerifier to report metrics to
+// hook as it verifies entities.
+func WithMetricsHook(hook MetricsHook) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if hook == nil {
+			return errors.New("metrics hook must not be nil")
+		}
+		c.metrics = hook
+		return nil
+	}
+}
+
+// errorKind classifies a verification error for MetricsHook.IncError. A
+// threshold failure is joined with the reasons each candidate was rejected,
+// so the more specific kinds are checked first.
+func errorKind(err error) string {
+	var chainErr *ChainVerificationError
+	switch {
+	case errors.Is(err, ErrNoTrustedTimeSource):
+		return ErrorKindNoTrustedTimeSource
+	case errors.Is(err, ErrIdentityMismatch):
+		return ErrorKindIdentityMismatch
+	case errors.As(err, &chainErr),
+		errors.Is(err, ErrFulcioLeafLifetime),
+		errors.Is(err, ErrFulcioLeafSAN),
+		errors.Is(err, ErrFulcioLeafIsCA),
+		errors.Is(err, ErrFulcioLeafEKU),
+		errors.Is(err, ErrTimestampOutsideCertValidity):
+		return ErrorKindCertificate
+	case errors.Is(err, ErrMissingSCT), errors.Is(err, ErrInvalidSCT), errors.Is(err, ErrCTInclusionProof):
+		return ErrorKindSCT
+	case errors.Is(err, ErrTSALeafIsCA),
+		errors.Is(err, ErrTSALeafMissingEKU),
+		errors.Is(err, ErrTSALeafEKUNotCritical),
+		errors.Is(err, ErrTSALeafInvalidEKU),
+		errors.Is(err, ErrTSAChainInvalidAtGenTime),
+		errors.Is(err, ErrTSAUnsupportedHash),
+		errors.Is(err, ErrTSAImprintMismatch),
+		errors.Is(err, ErrTSAOutsideValidityPeriod):
+		return ErrorKindTimestampAuthority
+	case errors.Is(err, root.ErrUnknownLog):
+		return ErrorKindUnknownLog
+	case errors.Is(err, root.ErrExpiredTrustMaterial):
+		return ErrorKindExpiredTrustMaterial
+	case errors.Is(err, ErrThresholdNotMet):
+		return ErrorKindThresholdNotMet
+	default:
+		return ErrorKindOther
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// multihashAlgorithms are the digest algorithms of the multihash codes an
+// artifact digest can be given with.
+var multihashAlgorithms = map[uint64]string{
+	0x12: "sha256",
+	0x13: "sha512",
+	0x20: "sha384",
+}
+
+// DecodeMultihash decodes a multihash, a varint hash function code and a
+// varint digest length followed by the digest, into the algorithm and raw
+// digest accepted by WithArtifactDigest. Only the sha2-256, sha2-384 and
+// sha2-512 codes are supported.
+func DecodeMultihash(multihash []byte) (string, []byte, error) {
+	code, n := binary.Uvarint(multihash)
+	if n <= 0 {
+		return "", nil, errors.New("multihash has no valid hash function code")
+	}
+	length, m := binary.Uvarint(multihash[n:])
+	if m <= 0 {
+		return "", nil, errors.New("multihash has no valid digest length")
+	}
+	digest := multihash[n+m:]
+	if uint64(len(digest)) != length {
+		return "", nil, fmt.Errorf("multihash digest is %d bytes, but declares %d", len(digest), length)
+	}
+
+	algorithm, ok := multihashAlgorithms[code]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported multihash hash function code 0x%x", code)
+	}
+	if want := artifactDigestHashes[algorithm].Size(); len(digest) != want {
+		return "", nil, fmt.Errorf("multihash %s digest is %d bytes, expected %d", algorithm, len(digest), want)
+	}
+	return algorithm, digest, nil
+}
+
+// WithArtifactMultihash is like WithArtifactDigest, but takes the digest as
+// a multihash, as some ecosystems encode them, rather than as an algorithm
+// and raw digest. See DecodeMultihash for the hash functions supported.
+func WithArtifactMultihash(multihash []byte) ArtifactPolicyOption {
+	return func(p *PolicyConfig) error {
+		algorithm, digest, err := DecodeMultihash(multihash)
+		if err != nil {
+			return fmt.Errorf("invalid artifact multihash: %w", err)
+		}
+		return WithArtifactDigest(algorithm, digest)(p)
+	}
+}
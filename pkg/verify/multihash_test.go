@@ -0,0 +1,125 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeMultihash(t *testing.T) {
+	sha256Digest := sha256.Sum256([]byte("artifact"))
+	sha512Digest := sha512.Sum512([]byte("artifact"))
+
+	tests := []struct {
+		name          string
+		multihash     []byte
+		wantAlgorithm string
+		wantDigest    []byte
+		wantErr       bool
+	}{
+		{
+			name:          "sha2-256",
+			multihash:     append([]byte{0x12, 0x20}, sha256Digest[:]...),
+			wantAlgorithm: "sha256",
+			wantDigest:    sha256Digest[:],
+		},
+		{
+			name:          "sha2-512",
+			multihash:     append([]byte{0x13, 0x40}, sha512Digest[:]...),
+			wantAlgorithm: "sha512",
+			wantDigest:    sha512Digest[:],
+		},
+		{
+			name:      "sha1 unsupported",
+			multihash: append([]byte{0x11, 0x14}, sha256Digest[:20]...),
+			wantErr:   true,
+		},
+		{
+			name:      "truncated",
+			multihash: append([]byte{0x12, 0x20}, sha256Digest[:16]...),
+			wantErr:   true,
+		},
+		{
+			name:      "trailing bytes",
+			multihash: append(append([]byte{0x12, 0x20}, sha256Digest[:]...), 0x00),
+			wantErr:   true,
+		},
+		{
+			name:      "wrong length for algorithm",
+			multihash: append([]byte{0x12, 0x10}, sha256Digest[:16]...),
+			wantErr:   true,
+		},
+		{
+			name:    "empty",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, digest, err := verify.DecodeMultihash(tt.multihash)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAlgorithm, algorithm)
+			assert.Equal(t, tt.wantDigest, digest)
+		})
+	}
+}
+
+func TestArtifactMultihash(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"%s"}}],"predicate":{}}`,
+		hex.EncodeToString(artifactDigest[:])))
+	attestation, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	messageSignature, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	multihash := append([]byte{0x12, 0x20}, artifactDigest[:]...)
+	otherDigest := sha256.Sum256([]byte("other artifact"))
+	otherMultihash := append([]byte{0x12, 0x20}, otherDigest[:]...)
+
+	for _, entity := range []verify.SignedEntity{attestation, messageSignature} {
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactMultihash(multihash), verify.WithoutIdentitiesUnsafe()))
+		assert.NoError(t, err)
+
+		// bare digests are still accepted
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe()))
+		assert.NoError(t, err)
+
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactMultihash(otherMultihash), verify.WithoutIdentitiesUnsafe()))
+		assert.Error(t, err)
+	}
+
+	_, err = verify.NewPolicy(verify.WithArtifactMultihash(multihash[:10]), verify.WithoutIdentitiesUnsafe()).BuildConfig()
+	assert.ErrorContains(t, err, "invalid artifact multihash")
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// IntotoPayloadType is the DSSE payload type of in-toto statements, which is
+// the only payload type expected unless WithExpectedPayloadTypes is used.
+const IntotoPayloadType = "application/vnd.in-toto+json"
+
+// WithExpectedPayloadTypes configures the SignedEntityVerifier to accept DSSE
+// envelopes with any of the given payload types, instead of only
+// IntotoPayloadType. Include IntotoPayloadType to keep accepting in-toto
+// statements. Each call replaces the types of the previous one.
+// VerificationResult.Statement is only set for in-toto statements, and
+// policies that read the statement, such as those checking artifacts or
+// predicates, fail for other payload types.
+//
+// The payload type is part of the DSSE pre-authentication encoding, so the
+// type checked is the one the signature covers, not just the envelope's
+// label: an envelope relabelled with an expected type fails signature
+// verification.
+func WithExpectedPayloadTypes(payloadTypes ...string) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if len(payloadTypes) == 0 {
+			return errors.New("at least one payload type is required")
+		}
+		for _, payloadType := range payloadTypes {
+			if payloadType == "" {
+				return errors.New("payload type must not be empty")
+			}
+		}
+		c.payloadTypes = slices.Clone(payloadTypes)
+		return nil
+	}
+}
+
+// expectedPayloadTypes returns the DSSE payload types the verifier accepts.
+func (c *VerifierConfig) expectedPayloadTypes() []string {
+	if len(c.payloadTypes) == 0 {
+		return []string{IntotoPayloadType}
+	}
+	return c.payloadTypes
+}
+
+// verifyPayloadType checks that the envelope's payload type is expected. The
+// signature is verified over the pre-authentication encoding of this same
+// type, which binds it to what was signed.
+func verifyPayloadType(envelope EnvelopeContent, payloadTypes []string) error {
+	rawEnvelope := envelope.RawEnvelope()
+	if rawEnvelope == nil {
+		return errors.New("could not verify envelope: no envelope")
+	}
+	if !slices.Contains(payloadTypes, rawEnvelope.PayloadType) {
+		return fmt.Errorf("%w: %q", ErrUnexpectedPayloadType, rawEnvelope.PayloadType)
+	}
+	return nil
+}
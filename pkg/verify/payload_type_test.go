@@ -0,0 +1,130 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// relabelledEntity presents its envelope with a different payload type than
+// the one that was signed.
+type relabelledEntity struct {
+	*ca.TestEntity
+	payloadType string
+}
+
+func (e *relabelledEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := *sigContent.EnvelopeContent().RawEnvelope()
+	envelope.PayloadType = e.payloadType
+	return &bundle.Envelope{Envelope: &envelope}, nil
+}
+
+func TestExpectedPayloadTypes(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	customType := "application/vnd.example.custom+json"
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	attestation, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	custom, err := virtualSigstore.AttestWithPayloadType("foo@example.com", "issuer", customType, []byte(`{"custom":true}`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		entity       verify.SignedEntity
+		payloadTypes []string
+		wantErr      bool
+		wantErrIs    error
+	}{
+		{
+			name:   "in-toto by default",
+			entity: attestation,
+		},
+		{
+			name:      "custom type rejected by default",
+			entity:    custom,
+			wantErr:   true,
+			wantErrIs: verify.ErrUnexpectedPayloadType,
+		},
+		{
+			name:         "custom type expected",
+			entity:       custom,
+			payloadTypes: []string{customType},
+		},
+		{
+			name:         "in-toto no longer expected",
+			entity:       attestation,
+			payloadTypes: []string{customType},
+			wantErr:      true,
+			wantErrIs:    verify.ErrUnexpectedPayloadType,
+		},
+		{
+			name:         "both expected",
+			entity:       attestation,
+			payloadTypes: []string{verify.IntotoPayloadType, customType},
+		},
+		{
+			// the envelope claims the in-toto type, but the signed PAE has
+			// the custom type
+			name:    "relabelled to in-toto",
+			entity:  &relabelledEntity{custom, verify.IntotoPayloadType},
+			wantErr: true,
+		},
+		{
+			name:         "relabelled to expected type",
+			entity:       &relabelledEntity{attestation, customType},
+			payloadTypes: []string{verify.IntotoPayloadType, customType},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1)}
+			if tt.payloadTypes != nil {
+				opts = append(opts, verify.WithExpectedPayloadTypes(tt.payloadTypes...))
+			}
+			verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, opts...)
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(tt.entity, SkipArtifactAndIdentitiesPolicy)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			if tt.wantErrIs != nil {
+				assert.ErrorIs(t, err, tt.wantErrIs)
+			} else {
+				assert.ErrorContains(t, err, "failed to verify signature")
+			}
+		})
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithExpectedPayloadTypes())
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithExpectedPayloadTypes(""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+)
+
+// PredicateDigest is a digest that must appear within an in-toto statement's
+// predicate, such as an artifact digest restated in a SLSA provenance
+// predicate's materials or resolvedDependencies.
+type PredicateDigest struct {
+	path      string
+	segments  []predicatePathSegment
+	algorithm string
+	digest    []byte
+}
+
+// predicatePathSegment selects an object member by key, followed by zero or
+// more array selectors. A selector of -1 selects every element.
+type predicatePathSegment struct {
+	key       string
+	selectors []int
+}
+
+// NewPredicateDigest returns a PredicateDigest that is found at the given
+// location in the predicate.
+//
+// The location is a JSONPath-like expression made up of object keys separated
+// by dots, each optionally followed by array selectors: "[n]" selects the n-th
+// element and "[*]" selects every element. A leading "$." is allowed. For
+// example, "buildDefinition.resolvedDependencies[*].digest".
+//
+// The location must refer to a digest set, i.e. an object mapping algorithm
+// names to hex encoded digests. The digest is found if any digest set at the
+// location maps algorithm to it.
+func NewPredicateDigest(path string, algorithm string, digest []byte) (PredicateDigest, error) {
+	segments, err := parsePredicatePath(path)
+	if err != nil {
+		return PredicateDigest{}, err
+	}
+	if algorithm == "" {
+		return PredicateDigest{}, errors.New("predicate digest algorithm must not be empty")
+	}
+	if len(digest) == 0 {
+		return PredicateDigest{}, errors.New("predicate digest must not be empty")
+	}
+	return PredicateDigest{path: path, segments: segments, algorithm: algorithm, digest: digest}, nil
+}
+
+func parsePredicatePath(path string) ([]predicatePathSegment, error) {
+	trimmed := strings.TrimPrefix(path, "$.")
+	if trimmed == "" {
+		return nil, errors.New("predicate path must not be empty")
+	}
+
+	var segments []predicatePathSegment
+	for _, part := range strings.Split(trimmed, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key == "" {
+			return nil, fmt.Errorf("predicate path %q has an empty key", path)
+		}
+		segment := predicatePathSegment{key: key}
+		if rest != "" {
+			rest = "[" + rest
+		}
+		for rest != "" {
+			if !strings.HasPrefix(rest, "[") {
+				return nil, fmt.Errorf("predicate path %q has a malformed array selector", path)
+			}
+			selector, remainder, ok := strings.Cut(rest[1:], "]")
+			if !ok {
+				return nil, fmt.Errorf("predicate path %q has an unterminated array selector", path)
+			}
+			if selector == "*" {
+				segment.selectors = append(segment.selectors, -1)
+			} else {
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("predicate path %q has an invalid array index %q", path, selector)
+				}
+				segment.selectors = append(segment.selectors, index)
+			}
+			rest = remainder
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// Verify checks that the digest appears at the expected location in the
+// statement's predicate.
+func (d PredicateDigest) Verify(statement *in_toto.Statement) error {
+	if statement == nil {
+		return errors.New("no in-toto statement to check the predicate of")
+	}
+
+	nodes := []interface{}{statement.GetPredicate().AsMap()}
+	for _, segment := range d.segments {
+		var next []interface{}
+		for _, node := range nodes {
+			object, ok := node.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			value, ok := object[segment.key]
+			if !ok {
+				continue
+			}
+			next = append(next, selectPredicateElements(value, segment.selectors)...)
+		}
+		nodes = next
+	}
+
+	if len(nodes) == 0 {
+		return fmt.Errorf("predicate has no value at %s", d.path)
+	}
+
+	for _, node := range nodes {
+		digestSet, ok := node.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		hexDigest, ok := digestSet[d.algorithm].(string)
+		if !ok {
+			continue
+		}
+		digest, err := hex.DecodeString(hexDigest)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(digest, d.digest) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("predicate does not contain %s digest %x at %s", d.algorithm, d.digest, d.path)
+}
+
+func selectPredicateElements(value interface{}, selectors []int) []interface{} {
+	values := []interface{}{value}
+	for _, selector := range selectors {
+		var next []interface{}
+		for _, v := range values {
+			elements, ok := v.([]interface{})
+			if !ok {
+				continue
+			}
+			switch {
+			case selector < 0:
+				next = append(next, elements...)
+			case selector < len(elements):
+				next = append(next, elements[selector])
+			}
+		}
+		values = next
+	}
+	return values
+}
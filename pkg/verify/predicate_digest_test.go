@@ -0,0 +1,135 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredicateDigest(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifactDigest := sha256.Sum256([]byte("artifact"))
+	sourceDigest := sha256.Sum256([]byte("source"))
+	otherDigest := sha256.Sum256([]byte("something else"))
+
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v1","subject":[{"name":"artifact","digest":{"sha256":"%s"}}],"predicate":{"buildDefinition":{"resolvedDependencies":[{"uri":"git+https://example.com/tool"},{"uri":"git+https://example.com/repo","digest":{"sha256":"%s"}}]}}}`,
+		hex.EncodeToString(artifactDigest[:]), hex.EncodeToString(sourceDigest[:])))
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		path       string
+		algorithm  string
+		digest     []byte
+		wantNewErr bool
+		wantErr    bool
+	}{
+		{
+			name:      "matching digest in any dependency",
+			path:      "buildDefinition.resolvedDependencies[*].digest",
+			algorithm: "sha256",
+			digest:    sourceDigest[:],
+		},
+		{
+			name:      "matching digest at index with root prefix",
+			path:      "$.buildDefinition.resolvedDependencies[1].digest",
+			algorithm: "sha256",
+			digest:    sourceDigest[:],
+		},
+		{
+			name:      "non-matching digest",
+			path:      "buildDefinition.resolvedDependencies[*].digest",
+			algorithm: "sha256",
+			digest:    otherDigest[:],
+			wantErr:   true,
+		},
+		{
+			name:      "subject digest is not a predicate digest",
+			path:      "buildDefinition.resolvedDependencies[*].digest",
+			algorithm: "sha256",
+			digest:    artifactDigest[:],
+			wantErr:   true,
+		},
+		{
+			name:      "matching digest at a different index",
+			path:      "buildDefinition.resolvedDependencies[0].digest",
+			algorithm: "sha256",
+			digest:    sourceDigest[:],
+			wantErr:   true,
+		},
+		{
+			name:      "different algorithm",
+			path:      "buildDefinition.resolvedDependencies[*].digest",
+			algorithm: "sha512",
+			digest:    sourceDigest[:],
+			wantErr:   true,
+		},
+		{
+			name:      "missing location",
+			path:      "materials[*].digest",
+			algorithm: "sha256",
+			digest:    sourceDigest[:],
+			wantErr:   true,
+		},
+		{
+			name:       "malformed path",
+			path:       "buildDefinition.resolvedDependencies[*.digest",
+			algorithm:  "sha256",
+			digest:     sourceDigest[:],
+			wantNewErr: true,
+		},
+		{
+			name:       "empty path",
+			path:       "",
+			algorithm:  "sha256",
+			digest:     sourceDigest[:],
+			wantNewErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			predicateDigest, err := verify.NewPredicateDigest(tt.path, tt.algorithm, tt.digest)
+			if tt.wantNewErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), verify.WithPredicateDigest(predicateDigest)))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Error: a zero PredicateDigest is rejected
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), verify.WithPredicateDigest(verify.PredicateDigest{})))
+	assert.Error(t, err)
+}
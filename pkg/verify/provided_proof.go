@@ -0,0 +1,100 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+// WithProvidedInclusionProof configures the SignedEntityVerifier to verify
+// the entity's entry in the Rekor log with the ID logID, the SHA-256 digest
+// of the log's public key, with proof, for offline verification of an
+// entity whose bundle only has an inclusion promise, when the proof was
+// fetched from the log separately. The proof is only used for an entry
+// that has none of its own, and is verified as though the entry had it: it
+// must lead to a checkpoint signed by the log. The log must be one of the
+// trusted material's RekorLogs(). It requires WithTransparencyLog, and may
+// be given once for each log.
+func WithProvidedInclusionProof(logID []byte, proof *protorekor.InclusionProof) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if len(logID) == 0 {
+			return errors.New("provided inclusion proof must have a log ID")
+		}
+		if proof == nil {
+			return errors.New("provided inclusion proof must not be nil")
+		}
+		key := hex.EncodeToString(logID)
+		if _, ok := c.providedInclusionProofs[key]; ok {
+			return fmt.Errorf("inclusion proof for rekor log %s provided more than once", key)
+		}
+		if c.providedInclusionProofs == nil {
+			c.providedInclusionProofs = make(map[string]*protorekor.InclusionProof)
+		}
+		c.providedInclusionProofs[key] = proof
+		return nil
+	}
+}
+
+// withProvidedInclusionProofs returns the entity with the proofs of
+// WithProvidedInclusionProof added to its log entries, after checking that
+// each is for a log in the trusted material.
+func (v *SignedEntityVerifier) withProvidedInclusionProofs(entity SignedEntity) (SignedEntity, error) {
+	if len(v.config.providedInclusionProofs) == 0 {
+		return entity, nil
+	}
+	rekorLogs := v.trustedMaterial.RekorLogs()
+	for key := range v.config.providedInclusionProofs {
+		if _, ok := rekorLogs[key]; !ok {
+			return nil, fmt.Errorf("provided inclusion proof: %w: rekor log %s", root.ErrUnknownLog, key)
+		}
+	}
+	return &providedProofEntity{SignedEntity: entity, proofs: v.config.providedInclusionProofs}, nil
+}
+
+// providedProofEntity is a SignedEntity whose log entries without an
+// inclusion proof take the one provided for their log, if any.
+type providedProofEntity struct {
+	SignedEntity
+	proofs map[string]*protorekor.InclusionProof
+}
+
+func (e *providedProofEntity) HasInclusionProof() bool {
+	return true
+}
+
+func (e *providedProofEntity) TlogEntries() ([]*tlog.Entry, error) {
+	entries, err := e.SignedEntity.TlogEntries()
+	if err != nil {
+		return nil, err
+	}
+	withProofs := make([]*tlog.Entry, len(entries))
+	for i, entry := range entries {
+		withProofs[i] = entry
+		if entry.HasInclusionProof() {
+			continue
+		}
+		if proof, ok := e.proofs[hex.EncodeToString([]byte(entry.LogKeyID()))]; ok {
+			withProofs[i] = entry.WithInclusionProof(proof)
+		}
+	}
+	return withProofs, nil
+}
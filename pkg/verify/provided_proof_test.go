@@ -0,0 +1,127 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"testing"
+	"time"
+
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// proofStrippedEntity is a bundle whose log entries have had their
+// inclusion proofs removed, leaving only their inclusion promises.
+type proofStrippedEntity struct {
+	*bundle.ProtobufBundle
+	entries []*tlog.Entry
+}
+
+func (e *proofStrippedEntity) HasInclusionProof() bool {
+	return false
+}
+
+func (e *proofStrippedEntity) TlogEntries() ([]*tlog.Entry, error) {
+	return e.entries, nil
+}
+
+// stripInclusionProofs returns the bundle without its inclusion proofs,
+// along with the proof and log ID of its first log entry.
+func stripInclusionProofs(t *testing.T, b *bundle.ProtobufBundle) (*proofStrippedEntity, []byte, *protorekor.InclusionProof) {
+	t.Helper()
+	stripped := &proofStrippedEntity{ProtobufBundle: b}
+	for _, protoEntry := range b.VerificationMaterial.TlogEntries {
+		withoutProof := proto.Clone(protoEntry).(*protorekor.TransparencyLogEntry)
+		withoutProof.InclusionProof = nil
+		entry, err := tlog.ParseEntry(withoutProof)
+		require.NoError(t, err)
+		stripped.entries = append(stripped.entries, entry)
+	}
+	first := b.VerificationMaterial.TlogEntries[0]
+	return stripped, first.LogId.KeyId, first.InclusionProof
+}
+
+func TestProvidedInclusionProof(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	entity, logID, proof := stripInclusionProofs(t, data.SigstoreJS200ProvenanceBundle(t))
+
+	// the entity still verifies with its inclusion promise alone
+	v, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithProvidedInclusionProof(logID, proof))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	// the provided proof is verified: its checkpoint is from August 2023
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(24*time.Hour), verify.WithProvidedInclusionProof(logID, proof))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, verify.ErrStaleCheckpoint)
+
+	tampered := proto.Clone(proof).(*protorekor.InclusionProof)
+	tampered.RootHash[0] ^= 0xff
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithProvidedInclusionProof(logID, tampered))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.Error(t, err)
+
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithProvidedInclusionProof([]byte("unknown log"), proof))
+	require.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, root.ErrUnknownLog)
+}
+
+func TestProvidedInclusionProofOptions(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	proof := &protorekor.InclusionProof{}
+
+	for _, tt := range []struct {
+		name    string
+		options []verify.VerifierOption
+	}{
+		{
+			name:    "no log ID",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithProvidedInclusionProof(nil, proof)},
+		},
+		{
+			name:    "no proof",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithProvidedInclusionProof([]byte("log"), nil)},
+		},
+		{
+			name:    "same log twice",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithProvidedInclusionProof([]byte("log"), proof), verify.WithProvidedInclusionProof([]byte("log"), proof)},
+		},
+		{
+			name:    "without transparency log",
+			options: []verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithProvidedInclusionProof([]byte("log"), proof)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verify.NewSignedEntityVerifier(tr, tt.options...)
+			assert.Error(t, err)
+		})
+	}
+}
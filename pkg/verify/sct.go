@@ -17,7 +17,9 @@ package verify
 import (
 	"crypto/x509"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/certificate-transparency-go/ctutil"
 	ctx509 "github.com/google/certificate-transparency-go/x509"
@@ -25,62 +27,104 @@ import (
 	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
+var (
+	ErrMissingSCT = errors.New("certificate has no embedded signed certificate timestamp")
+	ErrInvalidSCT = errors.New("certificate's signed certificate timestamps could not be verified")
+)
+
 // VerifySignedCertificateTimestamp, given a threshold, TrustedMaterial, and a
 // leaf certificate, will extract SCTs from the leaf certificate and verify the
 // timestamps using the TrustedMaterial's FulcioCertificateAuthorities() and
-// CTLogs()
-// TODO(issue#46): Add unit tests
+// CTLogs(). An SCT is only verified with a CT log whose validity period
+// includes the SCT's timestamp, as CT logs are sharded by time; trusted
+// material implementing root.CTLogKeyVersions may list several logs under
+// the same log ID.
 func VerifySignedCertificateTimestamp(leafCert *x509.Certificate, threshold int, trustedMaterial root.TrustedMaterial) error { // nolint: revive
-	ctlogs := trustedMaterial.CTLogs()
 	fulcioCerts := trustedMaterial.FulcioCertificateAuthorities()
 
 	scts, err := x509util.ParseSCTsFromCertificate(leafCert.Raw)
 	if err != nil {
 		return err
 	}
+	if len(scts) == 0 {
+		return ErrMissingSCT
+	}
 
 	leafCTCert, err := ctx509.ParseCertificates(leafCert.Raw)
 	if err != nil {
 		return err
 	}
 
+	fulcioChains := fulcioIssuerChains(leafCTCert, fulcioCerts)
+
 	verified := 0
 	for _, sct := range scts {
-		encodedKeyID := hex.EncodeToString(sct.LogID.KeyID[:])
-		key, ok := ctlogs[encodedKeyID]
-		if !ok {
+		ctLogs := ctLogsAt(trustedMaterial, sct.LogID.KeyID[:], time.UnixMilli(int64(sct.Timestamp)))
+		if len(ctLogs) == 0 {
 			// skip entries the trust root cannot verify
 			continue
 		}
 
-		for _, fulcioCa := range fulcioCerts {
-			fulcioChain := make([]*ctx509.Certificate, len(leafCTCert))
-			copy(fulcioChain, leafCTCert)
+		for _, fulcioChain := range fulcioChains {
+			for _, ctLog := range ctLogs {
+				if ctutil.VerifySCT(ctLog.PublicKey, fulcioChain, sct, true) == nil {
+					verified++
+					break
+				}
+			}
+		}
+	}
 
-			var parentCert []byte
+	if verified < threshold {
+		return fmt.Errorf("%w: %w: only able to verify %d SCT entries; unable to meet threshold of %d", ErrInvalidSCT, ErrThresholdNotMet, verified, threshold)
+	}
 
-			if len(fulcioCa.Intermediates) == 0 {
-				parentCert = fulcioCa.Root.Raw
-			} else {
-				parentCert = fulcioCa.Intermediates[0].Raw
-			}
+	return nil
+}
 
-			fulcioIssuer, err := ctx509.ParseCertificates(parentCert)
-			if err != nil {
-				continue
-			}
-			fulcioChain = append(fulcioChain, fulcioIssuer...)
+// fulcioIssuerChains returns the leaf certificate followed by the issuing
+// certificate of each Fulcio CA that could have issued it, as the SCT was
+// computed over. Each issuing certificate is parsed once, rather than for
+// every SCT.
+func fulcioIssuerChains(leafCTCert []*ctx509.Certificate, fulcioCerts []root.CertificateAuthority) [][]*ctx509.Certificate {
+	fulcioChains := make([][]*ctx509.Certificate, 0, len(fulcioCerts))
+	for _, fulcioCa := range fulcioCerts {
+		var parentCert []byte
 
-			err = ctutil.VerifySCT(key.PublicKey, fulcioChain, sct, true)
-			if err == nil {
-				verified++
-			}
+		switch {
+		case len(fulcioCa.Intermediates) > 0:
+			parentCert = fulcioCa.Intermediates[0].Raw
+		case fulcioCa.Root != nil:
+			parentCert = fulcioCa.Root.Raw
+		default:
+			continue
+		}
+
+		fulcioIssuer, err := ctx509.ParseCertificates(parentCert)
+		if err != nil {
+			continue
 		}
+		fulcioChain := make([]*ctx509.Certificate, 0, len(leafCTCert)+len(fulcioIssuer))
+		fulcioChain = append(fulcioChain, leafCTCert...)
+		fulcioChains = append(fulcioChains, append(fulcioChain, fulcioIssuer...))
 	}
+	return fulcioChains
+}
 
-	if verified < threshold {
-		return fmt.Errorf("only able to verify %d SCT entries; unable to meet threshold of %d", verified, threshold)
+// ctLogsAt returns the CT logs with the log ID whose validity period includes
+// time t, the time of an SCT.
+func ctLogsAt(trustedMaterial root.TrustedMaterial, logID []byte, t time.Time) []*root.TransparencyLog {
+	if keyVersions, ok := trustedMaterial.(root.CTLogKeyVersions); ok {
+		ctLogs, err := keyVersions.CTLogVerifiersAt(logID, t)
+		if err != nil {
+			return nil
+		}
+		return ctLogs
 	}
 
-	return nil
+	ctLog, ok := trustedMaterial.CTLogs()[hex.EncodeToString(logID)]
+	if !ok || !ctLog.ValidAtTime(t) {
+		return nil
+	}
+	return []*root.TransparencyLog{ctLog}
 }
@@ -0,0 +1,228 @@
+// Copyright 2023 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestVerifySignedCertificateTimestamp(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherVirtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(t, err)
+
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	assert.NoError(t, err)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leafWithoutSCT, err := ca.GenerateLeafCert("foo@example.com", "issuer", time.Now(), leafKey, rootCert, rootKey)
+	assert.NoError(t, err)
+
+	assert.NoError(t, verify.VerifySignedCertificateTimestamp(leaf, 1, virtualSigstore))
+
+	// the SCT is from a log the trusted material doesn't know
+	assert.ErrorIs(t, verify.VerifySignedCertificateTimestamp(leaf, 1, otherVirtualSigstore), verify.ErrInvalidSCT)
+
+	// only one SCT is embedded
+	assert.ErrorIs(t, verify.VerifySignedCertificateTimestamp(leaf, 2, virtualSigstore), verify.ErrInvalidSCT)
+
+	assert.ErrorIs(t, verify.VerifySignedCertificateTimestamp(leafWithoutSCT, 1, virtualSigstore), verify.ErrMissingSCT)
+}
+
+// noCTLogsTrustedMaterial is the trusted material of a private deployment
+// that doesn't log its certificates.
+type noCTLogsTrustedMaterial struct {
+	*ca.VirtualSigstore
+}
+
+func (m *noCTLogsTrustedMaterial) CTLogs() map[string]*root.TransparencyLog {
+	return map[string]*root.TransparencyLog{}
+}
+
+func TestSCTVerificationByDefault(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	// Error: the SCT is verified without asking for it
+	trustedMaterial := &noCTLogsTrustedMaterial{virtualSigstore}
+	v, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	policy = verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	_, err = v.Verify(entity, policy)
+	assert.ErrorIs(t, err, verify.ErrInvalidSCT)
+
+	v, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithoutSCTVerification())
+	assert.NoError(t, err)
+	policy = verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	_, err = v.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	// Error: SCTs can't be both required and skipped
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithSignedCertificateTimestamps(1), verify.WithoutSCTVerification())
+	assert.Error(t, err)
+}
+
+// ctLogShard returns a CT log instance for a temporal shard, valid from start
+// to end.
+func ctLogShard(t *testing.T, logID []byte, pub crypto.PublicKey, start, end time.Time) *prototrustroot.TransparencyLogInstance {
+	t.Helper()
+	pubBytes, err := x509.MarshalPKIXPublicKey(pub)
+	assert.NoError(t, err)
+	return &prototrustroot.TransparencyLogInstance{
+		BaseUrl:       "https://ctfe.example.com/" + start.Format("2006"),
+		HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+		PublicKey: &protocommon.PublicKey{
+			RawBytes:   pubBytes,
+			KeyDetails: protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+			ValidFor:   &protocommon.TimeRange{Start: timestamppb.New(start), End: timestamppb.New(end)},
+		},
+		LogId: &protocommon.LogId{KeyId: logID},
+	}
+}
+
+func TestSCTTemporalShards(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(t, err)
+
+	var logID []byte
+	var logKey crypto.PublicKey
+	for encodedLogID, ctLog := range virtualSigstore.CTLogs() {
+		logID, err = hex.DecodeString(encodedLogID)
+		assert.NoError(t, err)
+		logKey = ctLog.PublicKey
+	}
+	oldShardKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	lastYear := now.AddDate(-1, 0, 0)
+	nextYear := now.AddDate(1, 0, 0)
+
+	tests := []struct {
+		name    string
+		shards  []*prototrustroot.TransparencyLogInstance
+		wantErr bool
+	}{
+		{
+			// the log ID matches both shards, but only the second was
+			// valid when the SCT was issued
+			name: "SCT from the second shard",
+			shards: []*prototrustroot.TransparencyLogInstance{
+				ctLogShard(t, logID, logKey, now.Add(-time.Hour), nextYear),
+				ctLogShard(t, logID, oldShardKey.Public(), lastYear, now.Add(-time.Hour)),
+			},
+		},
+		{
+			name: "SCT before the second shard",
+			shards: []*prototrustroot.TransparencyLogInstance{
+				ctLogShard(t, logID, oldShardKey.Public(), lastYear, now.Add(time.Hour)),
+				ctLogShard(t, logID, logKey, now.Add(time.Hour), nextYear),
+			},
+			wantErr: true,
+		},
+		{
+			name: "SCT after the second shard",
+			shards: []*prototrustroot.TransparencyLogInstance{
+				ctLogShard(t, logID, logKey, lastYear, now.Add(-time.Hour)),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustedRoot, err := root.NewTrustedRootFromProtobuf(&prototrustroot.TrustedRoot{
+				MediaType: root.TrustedRootMediaType01,
+				Ctlogs:    tt.shards,
+			})
+			assert.NoError(t, err)
+			trustedMaterial := root.TrustedMaterialCollection{&noCTLogsTrustedMaterial{virtualSigstore}, trustedRoot}
+
+			err = verify.VerifySignedCertificateTimestamp(leaf, 1, trustedMaterial)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, verify.ErrInvalidSCT)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+// FuzzVerifySignedCertificateTimestamp checks that verifying the SCTs
+// embedded in an untrusted certificate never panics.
+func FuzzVerifySignedCertificateTimestamp(f *testing.F) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(f, err)
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(f, err)
+	f.Add(leaf.Raw)
+
+	f.Fuzz(func(_ *testing.T, der []byte) {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return
+		}
+
+		_ = verify.VerifySignedCertificateTimestamp(cert, 1, virtualSigstore)
+	})
+}
+
+func BenchmarkVerifySignedCertificateTimestamp(b *testing.B) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(b, err)
+
+	leaf, _, err := virtualSigstore.GenerateLeafCert("foo@example.com", "issuer")
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := verify.VerifySignedCertificateTimestamp(leaf, 1, virtualSigstore); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -18,19 +18,26 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"hash"
 	"io"
+	"sync"
 
 	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore/pkg/signature"
 	sigdsse "github.com/sigstore/sigstore/pkg/signature/dsse"
 	"github.com/sigstore/sigstore/pkg/signature/options"
 )
 
+// ErrUnexpectedMessageHash is returned when a message signature declares a
+// hash algorithm other than the one required with WithRequiredMessageHash.
+var ErrUnexpectedMessageHash = errors.New("unexpected message signature hash algorithm")
+
 func VerifySignature(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial) error { // nolint: revive
 	var verifier signature.Verifier
 	var err error
@@ -88,6 +95,84 @@ func VerifySignatureWithArtifactDigest(sigContent SignatureContent, verification
 	return fmt.Errorf("signature content has neither an envelope or a message")
 }
 
+// VerifySignatureOnly verifies the entity's DSSE envelope, or its message
+// signature over the digest it carries, with the public key of its signing
+// certificate. It is meant for troubleshooting, to tell a bad signature apart
+// from a problem with the trust material.
+//
+// It is NOT sufficient to verify an entity: the certificate isn't chained to
+// a trusted root, and no transparency log entries, timestamps, identities or
+// artifacts are checked, so anyone can produce an entity that passes. Use
+// SignedEntityVerifier.Verify for that.
+func VerifySignatureOnly(entity SignedEntity) error {
+	verificationContent, err := entity.VerificationContent()
+	if err != nil {
+		return fmt.Errorf("failed to fetch verification content: %w", err)
+	}
+	leafCert, ok := verificationContent.HasCertificate()
+	if !ok {
+		return errors.New("entity was not signed with a certificate")
+	}
+	verifier, err := signature.LoadVerifier(leafCert.PublicKey, crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("could not load signature verifier: %w", err)
+	}
+
+	sigContent, err := entity.SignatureContent()
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature content: %w", err)
+	}
+
+	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+		return verifyEnvelope(verifier, envelope)
+	} else if msg := sigContent.MessageSignatureContent(); msg != nil {
+		if len(msg.Digest()) == 0 {
+			return errors.New("message signature has no digest to verify against")
+		}
+		return verifyMessageSignatureWithArtifactDigest(verifier, msg, msg.Digest())
+	}
+
+	// handle an invalid signature content message
+	return fmt.Errorf("signature content has neither an envelope or a message")
+}
+
+// verifySignatureWithSubjectDigests verifies the signature on a DSSE envelope
+// and that its statement's subjects include any of the given digests.
+func verifySignatureWithSubjectDigests(sigContent SignatureContent, verificationContent VerificationContent, trustedMaterial root.TrustedMaterial, digests []subjectDigest) error {
+	envelope := sigContent.EnvelopeContent()
+	if envelope == nil {
+		return errors.New("subject digests can only be verified against a DSSE envelope")
+	}
+
+	verifier, err := getSignatureVerifier(verificationContent, trustedMaterial)
+	if err != nil {
+		return fmt.Errorf("could not load signature verifier: %w", err)
+	}
+
+	err = verifyEnvelope(verifier, envelope)
+	if err != nil {
+		return err
+	}
+	statement, err := envelope.Statement()
+	if err != nil {
+		return fmt.Errorf("could not verify artifact: unable to extract statement from envelope: %w", err)
+	}
+	for _, subject := range statement.Subject {
+		for alg, digest := range subject.Digest {
+			hexdigest, err := hex.DecodeString(digest)
+			if err != nil {
+				return fmt.Errorf("could not verify artifact: unable to decode subject digest: %w", err)
+			}
+			for _, expected := range digests {
+				if alg == expected.algorithm && bytes.Equal(hexdigest, expected.digest) {
+					return nil
+				}
+			}
+		}
+	}
+	return errors.New("none of the provided digests match any digest in statement")
+}
+
 func getSignatureVerifier(verificationContent VerificationContent, tm root.TrustedMaterial) (signature.Verifier, error) {
 	if leafCert, ok := verificationContent.HasCertificate(); ok {
 		// TODO: Inspect certificate's SignatureAlgorithm to determine hash function
@@ -100,6 +185,21 @@ func getSignatureVerifier(verificationContent VerificationContent, tm root.Trust
 }
 
 func verifyEnvelope(verifier signature.Verifier, envelope EnvelopeContent) error {
+	rawEnvelope := envelope.RawEnvelope()
+	if rawEnvelope == nil {
+		return errors.New("could not verify envelope: no envelope")
+	}
+
+	// Signatures without a keyid, as cosign commonly produces, don't name
+	// the key that made them, so they are verified with the signing key
+	// directly rather than relying on how the envelope verifier matches
+	// keyids to keys.
+	for _, sig := range rawEnvelope.Signatures {
+		if sig.KeyID == "" && verifyEnvelopeSignature(verifier, rawEnvelope, sig) == nil {
+			return nil
+		}
+	}
+
 	pub, err := verifier.PublicKey()
 	if err != nil {
 		return fmt.Errorf("could not fetch verifier public key: %w", err)
@@ -113,7 +213,7 @@ func verifyEnvelope(verifier signature.Verifier, envelope EnvelopeContent) error
 		return fmt.Errorf("could not load envelope verifier: %w", err)
 	}
 
-	_, err = envVerifier.Verify(context.TODO(), envelope.RawEnvelope())
+	_, err = envVerifier.Verify(context.TODO(), rawEnvelope)
 	if err != nil {
 		return fmt.Errorf("could not verify envelope: %w", err)
 	}
@@ -121,6 +221,20 @@ func verifyEnvelope(verifier signature.Verifier, envelope EnvelopeContent) error
 	return nil
 }
 
+// verifyEnvelopeSignature verifies one of the envelope's signatures over its
+// pre-authentication encoding.
+func verifyEnvelopeSignature(verifier signature.Verifier, envelope *dsse.Envelope, sig dsse.Signature) error {
+	payload, err := envelope.DecodeB64Payload()
+	if err != nil {
+		return err
+	}
+	rawSig, err := base64.StdEncoding.DecodeString(sig.Sig)
+	if err != nil {
+		return err
+	}
+	return verifier.VerifySignature(bytes.NewReader(rawSig), bytes.NewReader(dsse.PAE(envelope.PayloadType, payload)))
+}
+
 func verifyEnvelopeWithArtifact(verifier signature.Verifier, envelope EnvelopeContent, artifact io.Reader) error {
 	err := verifyEnvelope(verifier, envelope)
 	if err != nil {
@@ -155,20 +269,10 @@ func verifyEnvelopeWithArtifact(verifier signature.Verifier, envelope EnvelopeCo
 	}
 
 	// Compute digest of the artifact.
-	var hasher hash.Hash
-	switch artifactDigestAlgorithm {
-	case "sha512":
-		hasher = crypto.SHA512.New()
-	case "sha384":
-		hasher = crypto.SHA384.New()
-	case "sha256":
-		hasher = crypto.SHA256.New()
-	}
-	_, err = io.Copy(hasher, artifact)
+	artifactDigest, err = digestArtifact(artifactDigestHashes[artifactDigestAlgorithm], artifact)
 	if err != nil {
 		return fmt.Errorf("could not verify artifact: unable to calculate digest: %w", err)
 	}
-	artifactDigest = hasher.Sum(nil)
 
 	// Look for artifact digest in statement
 	for _, subject := range statement.Subject {
@@ -185,6 +289,58 @@ func verifyEnvelopeWithArtifact(verifier signature.Verifier, envelope EnvelopeCo
 	return fmt.Errorf("could not verify artifact: unable to confirm artifact digest is present in subject digests: %w", err)
 }
 
+// artifactDigestHashes are the hash functions of the digest algorithms an
+// artifact can be verified against a statement's subjects with.
+var artifactDigestHashes = map[string]crypto.Hash{
+	"sha512": crypto.SHA512,
+	"sha384": crypto.SHA384,
+	"sha256": crypto.SHA256,
+}
+
+// hasherPools hold reset hash.Hash instances for each of the
+// artifactDigestHashes, which are reused across verifications.
+var hasherPools = func() map[crypto.Hash]*sync.Pool {
+	pools := make(map[crypto.Hash]*sync.Pool, len(artifactDigestHashes))
+	for _, h := range artifactDigestHashes {
+		h := h
+		pools[h] = &sync.Pool{
+			New: func() any {
+				return h.New()
+			},
+		}
+	}
+	return pools
+}()
+
+// copyBufferPool holds the buffers artifacts are read into while they are
+// hashed, as readers such as files would otherwise be copied through a new
+// buffer every time.
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 32*1024)
+		return &buf
+	},
+}
+
+// digestArtifact reads the artifact to its end and returns its digest with
+// hash function h, one of the artifactDigestHashes.
+func digestArtifact(h crypto.Hash, artifact io.Reader) ([]byte, error) {
+	pool := hasherPools[h]
+	hasher := pool.Get().(hash.Hash)
+	defer func() {
+		hasher.Reset()
+		pool.Put(hasher)
+	}()
+
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+
+	if _, err := io.CopyBuffer(hasher, artifact, *buf); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
 func verifyEnvelopeWithArtifactDigest(verifier signature.Verifier, envelope EnvelopeContent, artifactDigest []byte, artifactDigestAlgorithm string) error {
 	err := verifyEnvelope(verifier, envelope)
 	if err != nil {
@@ -219,6 +375,33 @@ func verifyMessageSignature(verifier signature.Verifier, msg MessageSignatureCon
 	return nil
 }
 
+// messageHashAlgorithm returns the name a message signature uses to declare
+// the given hash algorithm.
+func messageHashAlgorithm(hash crypto.Hash) (string, error) {
+	switch hash {
+	case crypto.SHA256:
+		return protocommon.HashAlgorithm_SHA2_256.String(), nil
+	case crypto.SHA384:
+		return protocommon.HashAlgorithm_SHA2_384.String(), nil
+	case crypto.SHA512:
+		return protocommon.HashAlgorithm_SHA2_512.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported message signature hash algorithm %s", hash)
+	}
+}
+
+func verifyMessageSignatureHash(msg MessageSignatureContent, hash crypto.Hash) error {
+	algorithm, err := messageHashAlgorithm(hash)
+	if err != nil {
+		return err
+	}
+	if msg.DigestAlgorithm() != algorithm {
+		return fmt.Errorf("%w: %s, expected %s", ErrUnexpectedMessageHash, msg.DigestAlgorithm(), algorithm)
+	}
+
+	return nil
+}
+
 func verifyMessageSignatureWithArtifactDigest(verifier signature.Verifier, msg MessageSignatureContent, artifactDigest []byte) error {
 	if !bytes.Equal(artifactDigest, msg.Digest()) {
 		return errors.New("artifact does not match digest")
@@ -16,11 +16,17 @@ package verify_test
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"testing"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
@@ -113,3 +119,228 @@ func TestSignatureVerifierMessageSignature(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, result)
 }
+
+// otherCertificateEntity carries the signing certificate of another entity.
+type otherCertificateEntity struct {
+	*ca.TestEntity
+	other *ca.TestEntity
+}
+
+func (e *otherCertificateEntity) VerificationContent() (verify.VerificationContent, error) {
+	return e.other.VerificationContent()
+}
+
+func TestVerifySignatureOnly(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	attestation, err := virtualSigstore.Attest("foofighters@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	assert.NoError(t, verify.VerifySignatureOnly(attestation))
+
+	messageSignature, err := virtualSigstore.Sign("foofighters@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	assert.NoError(t, verify.VerifySignatureOnly(messageSignature))
+
+	// the signature verifies even though nothing chains the certificate to
+	// a trusted root
+	otherVirtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	verifier, err := verify.NewSignedEntityVerifier(otherVirtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(attestation, SkipArtifactAndIdentitiesPolicy)
+	assert.Error(t, err)
+
+	// the signature must be made with the certificate's key
+	assert.Error(t, verify.VerifySignatureOnly(&otherCertificateEntity{attestation, messageSignature}))
+	assert.Error(t, verify.VerifySignatureOnly(&otherCertificateEntity{messageSignature, attestation}))
+}
+
+// rewrittenEnvelopeEntity carries a copy of its DSSE envelope, changed by
+// rewrite.
+type rewrittenEnvelopeEntity struct {
+	*ca.TestEntity
+	rewrite func(*dsse.Envelope)
+}
+
+func (e *rewrittenEnvelopeEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+	envelope := *sigContent.EnvelopeContent().RawEnvelope()
+	envelope.Signatures = append([]dsse.Signature(nil), envelope.Signatures...)
+	e.rewrite(&envelope)
+	return &bundle.Envelope{Envelope: &envelope}, nil
+}
+
+func TestEnvelopeWithEmptyKeyID(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foofighters@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rewrite func(*dsse.Envelope)
+		wantErr bool
+	}{
+		{
+			name: "empty keyid",
+			rewrite: func(e *dsse.Envelope) {
+				e.Signatures[0].KeyID = ""
+			},
+		},
+		{
+			name: "invalid signature before a valid one",
+			rewrite: func(e *dsse.Envelope) {
+				e.Signatures[0].KeyID = ""
+				bogus := dsse.Signature{Sig: base64.StdEncoding.EncodeToString([]byte("bogus"))}
+				e.Signatures = append([]dsse.Signature{bogus}, e.Signatures...)
+			},
+		},
+		{
+			name: "tampered payload",
+			rewrite: func(e *dsse.Envelope) {
+				e.Signatures[0].KeyID = ""
+				e.Payload = base64.StdEncoding.EncodeToString(bytes.Replace(statement, []byte("customFoo"), []byte("customBar"), 1))
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid signature",
+			rewrite: func(e *dsse.Envelope) {
+				e.Signatures[0] = dsse.Signature{Sig: base64.StdEncoding.EncodeToString([]byte("bogus"))}
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigContent, err := (&rewrittenEnvelopeEntity{entity, tt.rewrite}).SignatureContent()
+			assert.NoError(t, err)
+			err = verify.VerifySignature(sigContent, verificationContent, virtualSigstore)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOCIImageSubjectPolicy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	digestOf := func(s string) string {
+		digest := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(digest[:])
+	}
+	indexDigest := digestOf("image index")
+	amd64Digest := digestOf("linux/amd64 manifest")
+	arm64Digest := digestOf("linux/arm64 manifest")
+
+	// the attestation is bound to the arm64 manifest only
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"image","digest":{"sha256":"%s"}}],"predicate":{}}`, arm64Digest[len("sha256:"):]))
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImageSubjects(indexDigest, amd64Digest, arm64Digest), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// the deprecated constructor is equivalent
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.NewOCIImageSubjectPolicy(indexDigest, []string{amd64Digest, arm64Digest}), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	// Error: the attested platform is not part of the image
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImageSubjects(indexDigest, amd64Digest), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+
+	// Error: malformed digest
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithOCIImageSubjects("not-a-digest"), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
+
+// sha512MessageSignatureEntity declares a SHA-512 digest of the artifact for
+// its message signature.
+type sha512MessageSignatureEntity struct {
+	*ca.TestEntity
+	artifact []byte
+}
+
+func (e *sha512MessageSignatureEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha512.Sum512(e.artifact)
+	return bundle.NewMessageSignature(digest[:], "SHA2_512", sigContent.MessageSignatureContent().Signature()), nil
+}
+
+func TestRequiredMessageHash(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("Hi, I am an artifact!")
+	entity, err := virtualSigstore.Sign("foofighters@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe(), verify.WithRequiredMessageHash(crypto.SHA256)))
+	assert.NoError(t, err)
+
+	// Error: the bundle declares SHA-512
+	sha512Entity := &sha512MessageSignatureEntity{TestEntity: entity, artifact: artifact}
+	_, err = verifier.Verify(sha512Entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe(), verify.WithRequiredMessageHash(crypto.SHA256)))
+	assert.ErrorIs(t, err, verify.ErrUnexpectedMessageHash)
+
+	// Error: unsupported hash algorithm
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe(), verify.WithRequiredMessageHash(crypto.MD5)))
+	assert.Error(t, err)
+}
+
+// fileLikeReader hides the WriterTo of the reader it wraps, so that the
+// artifact is copied through a buffer as a file would be.
+type fileLikeReader struct {
+	io.Reader
+}
+
+func TestVerifyArtifactAllocations(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	verifyArtifact := func(artifact []byte) func() {
+		digest := sha256.Sum256(artifact)
+		statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"%x"}}],"predicate":{}}`, digest))
+		entity, err := virtualSigstore.Attest("foofighters@example.com", "issuer", statement)
+		assert.NoError(t, err)
+		sigContent, err := entity.SignatureContent()
+		assert.NoError(t, err)
+		verificationContent, err := entity.VerificationContent()
+		assert.NoError(t, err)
+
+		assert.NoError(t, verify.VerifySignatureWithArtifact(sigContent, verificationContent, virtualSigstore, fileLikeReader{bytes.NewReader(artifact)}))
+		return func() {
+			_ = verify.VerifySignatureWithArtifact(sigContent, verificationContent, virtualSigstore, fileLikeReader{bytes.NewReader(artifact)})
+		}
+	}
+
+	// artifacts are hashed through reused buffers, so that a large artifact
+	// takes no more allocations to verify than a small one
+	smallAllocs := testing.AllocsPerRun(20, verifyArtifact([]byte("artifact")))
+	largeAllocs := testing.AllocsPerRun(20, verifyArtifact(bytes.Repeat([]byte("artifact"), 128*1024)))
+	assert.Equal(t, smallAllocs, largeAllocs)
+}
@@ -15,14 +15,24 @@
 package verify
 
 import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"strings"
 	"time"
 
-	"github.com/in-toto/in-toto-golang/in_toto"
+	in_toto "github.com/in-toto/attestation/go/v1"
+	protorekor "github.com/sigstore/protobuf-specs/gen/pb-go/rekor/v1"
 	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
 	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"google.golang.org/protobuf/encoding/protojson"
 )
 
 const (
@@ -32,6 +42,12 @@ const (
 type SignedEntityVerifier struct {
 	trustedMaterial root.TrustedMaterial
 	config          VerifierConfig
+	// timestampAuthorities holds the trusted material's timestamp
+	// authorities, prepared for verification
+	timestampAuthorities *timestampAuthorityCache
+	// rootVerifiers verify against each of the roots given with
+	// WithTrustedRoots, in order
+	rootVerifiers []*SignedEntityVerifier
 }
 
 type VerifierConfig struct { // nolint: revive
@@ -44,6 +60,12 @@ type VerifierConfig struct { // nolint: revive
 	// signedTimestampThreshold is the minimum number of verified
 	// RFC3161 timestamps in a bundle
 	signedTimestampThreshold int
+	// alternateTimestampedContents are accepted as the content covered by an
+	// RFC3161 timestamp, besides the signature
+	alternateTimestampedContents []TimestampedContent
+	// requireDistinctTimestampAuthorities counts at most one verified
+	// RFC3161 timestamp per timestamp authority towards the thresholds
+	requireDistinctTimestampAuthorities bool
 	// requireIntegratedTimestamps requires log entry integrated timestamps to
 	// verify short-lived certificates
 	requireIntegratedTimestamps bool
@@ -61,19 +83,106 @@ type VerifierConfig struct { // nolint: revive
 	// tlogEntriesThreshold is the minimum number of verified inclusion
 	// proofs in a bundle
 	tlogEntriesThreshold int
-	// weExpectSCTs requires SCTs in Fulcio certificates
+	// additionalTlogs are Rekor logs trusted besides the trusted
+	// material's
+	additionalTlogs []*root.TransparencyLog
+	// weDoNotExpectTlogEntries explicitly skips transparency log
+	// verification, relying on RFC3161 timestamps alone
+	weDoNotExpectTlogEntries bool
+	// checkpointMaxAge is how far the time in the checkpoint of an
+	// inclusion proof may be from the verification time. Zero disables the
+	// check
+	checkpointMaxAge time.Duration
+	// trustCheckpointTimestamps uses the time in the verified checkpoint of
+	// an entry's inclusion proof as a log timestamp, for entries without an
+	// inclusion promise
+	trustCheckpointTimestamps bool
+	// providedInclusionProofs are Rekor inclusion proofs for entries
+	// without one, keyed by the hex-encoded ID of the log
+	providedInclusionProofs map[string]*protorekor.InclusionProof
+	// weExpectSCTs requires SCTs in Fulcio certificates. It is the default
+	// unless weDoNotExpectSCTs is set
 	weExpectSCTs bool
+	// weDoNotExpectSCTs explicitly skips SCT verification, for private
+	// CAs that don't log to a certificate transparency log
+	weDoNotExpectSCTs bool
+	// byoCertificates verifies certificates from CAs other than Fulcio,
+	// which need not carry a Subject Alternative Name or Fulcio extensions
+	byoCertificates bool
+	// additionalRoots are trust anchors for BYO certificates besides the
+	// trusted material's certificate authorities
+	additionalRoots *x509.CertPool
 	// ctlogEntriesTreshold is the minimum number of verified SCTs in
 	// a Fulcio certificate
 	ctlogEntriesThreshold int
+	// requireCTInclusionProof additionally requires proof that the CT logs
+	// included the certificate, for as many SCTs as the threshold
+	requireCTInclusionProof bool
+	// crossCheckTimestamps requires verified RFC3161 timestamps and log
+	// integrated timestamps to agree with each other
+	crossCheckTimestamps bool
+	// maxTimestampSkew is the largest difference allowed between an RFC3161
+	// timestamp and a log integrated timestamp when cross-checking them
+	maxTimestampSkew time.Duration
+	// notBeforeGrace is how long before the leaf certificate's NotBefore an
+	// observer timestamp is still accepted
+	notBeforeGrace time.Duration
+	// requireTimestampWithinCertValidity requires at least one verified
+	// signed or log timestamp to be within the leaf certificate's validity
+	// period, without any NotBefore grace
+	requireTimestampWithinCertValidity bool
+	// maxCertLifetime is the longest validity period allowed for the leaf
+	// certificate. Zero allows any
+	maxCertLifetime time.Duration
+	// maxPathLen is the most intermediate certificates allowed between the
+	// leaf certificate and its root, if maxPathLenSet
+	maxPathLen    int
+	maxPathLenSet bool
+	// minKeyStrength is the minimum strength of the leaf certificate's
+	// key. Nil allows any
+	minKeyStrength *KeyStrength
+	// fulcioLeafProfile requires the leaf certificate to look like one
+	// Fulcio issues
+	fulcioLeafProfile bool
+	// fulcioLeafMaxLifetime is the longest validity period allowed for a
+	// leaf certificate with Fulcio extensions under fulcioLeafProfile
+	fulcioLeafMaxLifetime time.Duration
 	// weDoNotExpectAnyObserverTimestamps uses the certificate's lifetime
 	// rather than a provided signed or log timestamp. Most workflows will
 	// not use this option
 	weDoNotExpectAnyObserverTimestamps bool
+	// payloadTypes are the DSSE payload types accepted. Empty accepts only
+	// IntotoPayloadType
+	payloadTypes []string
+	// fipsMode rejects any key, signature or digest that isn't
+	// FIPS-approved
+	fipsMode bool
+	// trustedRoots are verified against in turn, instead of the trusted
+	// material passed to NewSignedEntityVerifier
+	trustedRoots []root.TrustedMaterial
+	// logger receives debug-level events during verification. Nil logs
+	// nothing
+	logger *slog.Logger
+	// metrics receives the outcome and latency of verifications and their
+	// phases. It is never nil
+	metrics MetricsHook
+	// clock is the source of the current time. It is never nil
+	clock Clock
 }
 
 type VerifierOption func(*VerifierConfig) error
 
+// DefaultTimestampClockSkew is the clock skew tolerated between a timestamp
+// authority and a transparency log by WithTimestampCrossCheck. The two
+// services keep their own clocks, which can legitimately drift apart by a
+// few seconds.
+const DefaultTimestampClockSkew = 1 * time.Minute
+
+// ErrNoTrustedTimeSource is returned when an entity has neither a verified
+// signed timestamp nor a verified log entry integrated timestamp to establish
+// when it was signed.
+var ErrNoTrustedTimeSource = errors.New("no trusted time source")
+
 // NewSignedEntityVerifier creates a new SignedEntityVerifier. It takes a
 // root.TrustedMaterial, which contains a set of trusted public keys and
 // certificates, and a set of VerifierConfigurators, which set the config
@@ -81,10 +190,12 @@ type VerifierOption func(*VerifierConfig) error
 //
 // VerifierConfig's set of options should match the properties of a given
 // Sigstore deployment, i.e. whether to expect SCTs, Tlog entries, or signed
-// timestamps.
+// timestamps. Trusted material without transparency logs, such as a trusted
+// root with only CT logs, can be used as long as Tlog entries aren't expected;
+// SCTs are then still verified.
 func NewSignedEntityVerifier(trustedMaterial root.TrustedMaterial, options ...VerifierOption) (*SignedEntityVerifier, error) {
 	var err error
-	c := VerifierConfig{}
+	c := VerifierConfig{metrics: NoopMetricsHook{}, clock: SystemClock{}}
 
 	for _, opt := range options {
 		err = opt(&c)
@@ -98,10 +209,48 @@ func NewSignedEntityVerifier(trustedMaterial root.TrustedMaterial, options ...Ve
 		return nil, err
 	}
 
+	// SCTs are verified unless explicitly turned off, so that the
+	// certificate transparency guarantee can't be lost by omission
+	if !c.weExpectSCTs && !c.weDoNotExpectSCTs {
+		c.weExpectSCTs = true
+		c.ctlogEntriesThreshold = 1
+	}
+
+	if len(c.trustedRoots) > 0 {
+		if trustedMaterial != nil {
+			return nil, errors.New("WithTrustedRoots() requires nil trusted material")
+		}
+		return newTrustedRootsVerifier(c)
+	}
+	if trustedMaterial == nil {
+		return nil, errors.New("trusted material must not be nil")
+	}
+
+	return newSignedEntityVerifier(trustedMaterial, c)
+}
+
+// newSignedEntityVerifier returns a SignedEntityVerifier for the trusted
+// material, with a validated configuration.
+func newSignedEntityVerifier(trustedMaterial root.TrustedMaterial, c VerifierConfig) (*SignedEntityVerifier, error) {
+	if len(c.additionalTlogs) > 0 {
+		trustedMaterial = root.TrustedMaterialCollection{trustedMaterial, newAdditionalTlogs(c.additionalTlogs)}
+	}
+
+	if c.weDoNotExpectTlogEntries && len(trustedMaterial.TimestampingAuthorities()) == 0 {
+		return nil, errors.New("WithoutTransparencyLog() requires trusted material with at least one timestamp authority")
+	}
+
+	if c.weExpectTlogEntries && len(trustedMaterial.RekorLogs()) == 0 {
+		return nil, errors.New("WithTransparencyLog() requires trusted material with at least one transparency log")
+	}
+
 	v := &SignedEntityVerifier{
 		trustedMaterial: trustedMaterial,
 		config:          c,
 	}
+	if c.weExpectSignedTimestamps || c.requireObserverTimestamps {
+		v.timestampAuthorities = newTimestampAuthorityCache(trustedMaterial)
+	}
 
 	return v, nil
 }
@@ -131,6 +280,44 @@ func WithSignedTimestamps(threshold int) VerifierOption {
 	}
 }
 
+// WithDistinctTimestampAuthorities configures the SignedEntityVerifier to
+// count at most one verified RFC 3161 timestamp per timestamp authority
+// towards the thresholds set by WithSignedTimestamps and
+// WithObserverTimestamps. Timestamp authorities are distinguished by their
+// root certificate.
+//
+// Without this option, a bundle carrying several timestamps from the same
+// timestamp authority meets a threshold just as well as one carrying the same
+// number of timestamps from independent authorities. It requires
+// WithSignedTimestamps or WithObserverTimestamps.
+func WithDistinctTimestampAuthorities() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.requireDistinctTimestampAuthorities = true
+		return nil
+	}
+}
+
+// WithAlternateTimestampedContent configures the SignedEntityVerifier to
+// also accept RFC 3161 timestamps over the given contents, for entities
+// produced by tooling that does not timestamp the signature bytes. The
+// signature is always tried first, and a timestamp covering none of the
+// accepted contents is rejected. Which content matched is recorded in each
+// verified timestamp's TimestampInfo. Like WithDistinctTimestampAuthorities,
+// it requires WithSignedTimestamps or WithObserverTimestamps.
+func WithAlternateTimestampedContent(contents ...TimestampedContent) VerifierOption {
+	return func(c *VerifierConfig) error {
+		for _, content := range contents {
+			switch content {
+			case TimestampedSignature, TimestampedEnvelope, TimestampedDigest:
+			default:
+				return fmt.Errorf("unknown timestamped content: %s", content)
+			}
+		}
+		c.alternateTimestampedContents = append(c.alternateTimestampedContents, contents...)
+		return nil
+	}
+}
+
 // WithObserverTimestamps configures the SignedEntityVerifier to expect
 // timestamps from either an RFC3161 timestamp authority or a log's
 // SignedEntryTimestamp. These are verified using the TrustedMaterial's
@@ -161,11 +348,84 @@ func WithTransparencyLog(threshold int) VerifierOption {
 	}
 }
 
+// WithAdditionalTlogVerifier configures the SignedEntityVerifier to also
+// trust the Rekor log transparencyLog, e.g. a private instance whose key is
+// distributed out-of-band rather than in a trusted root. It augments the
+// trusted material's RekorLogs(), and may be given several times.
+//
+// The log's ID must be the raw log ID that its entries carry, i.e. the
+// SHA-256 digest of its DER-encoded public key for Rekor.
+func WithAdditionalTlogVerifier(transparencyLog *root.TransparencyLog) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if transparencyLog == nil || len(transparencyLog.ID) == 0 || transparencyLog.PublicKey == nil {
+			return errors.New("additional transparency log must have an ID and a public key")
+		}
+		c.additionalTlogs = append(c.additionalTlogs, transparencyLog)
+		return nil
+	}
+}
+
+// WithCheckpointFreshness configures the SignedEntityVerifier to reject a
+// log entry whose inclusion proof is backed by a checkpoint more than maxAge
+// older, or newer, than the time of verification. A stale checkpoint may
+// come from a log that is presenting a split view of its tree to hide
+// entries. Only checkpoints with a "Timestamp:" line, as Rekor writes them,
+// are checked, and only when verifying offline. It requires
+// WithTransparencyLog.
+func WithCheckpointFreshness(maxAge time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxAge <= 0 {
+			return errors.New("checkpoint freshness window must be positive")
+		}
+		c.checkpointMaxAge = maxAge
+		return nil
+	}
+}
+
+// WithoutTransparencyLog configures the SignedEntityVerifier to not expect
+// Transparency Log entries, for deployments that do not run a log. It must be
+// combined with WithSignedTimestamps, so that the signing time, and the time
+// at which the Fulcio certificate is verified, come exclusively from verified
+// RFC 3161 timestamps. All other verification, such as of Signed Certificate
+// Timestamps, still applies.
+func WithoutTransparencyLog() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.weDoNotExpectTlogEntries = true
+		return nil
+	}
+}
+
+// WithCheckpointTimestamps configures the SignedEntityVerifier to use the
+// time in the checkpoint of a log entry's inclusion proof as a log
+// timestamp, for entries with no inclusion promise, such as those from
+// tile-based logs. The timestamp counts towards WithIntegratedTimestamps and
+// WithObserverTimestamps, and is used to check that the certificate was
+// valid when signing, as an integrated time would be. It requires
+// WithTransparencyLog and one of those, and only applies when verifying
+// offline.
+//
+// A checkpoint's time is only as trustworthy as the log that signed it:
+// it is the log's claim of when it committed to a tree containing the
+// entry, authenticated by the checkpoint signature but by no independent
+// party. The entry was logged no later than that, so the certificate must
+// still have been valid when the checkpoint was signed, which only holds
+// for a checkpoint signed soon after the entry was logged. Only
+// checkpoints with a "Timestamp:" line, as Rekor writes them, have a time.
+func WithCheckpointTimestamps() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.trustCheckpointTimestamps = true
+		return nil
+	}
+}
+
 // WithIntegratedTimestamps configures the SignedEntityVerifier to
 // expect log entry integrated timestamps from either SignedEntryTimestamps
 // or live log lookups.
 func WithIntegratedTimestamps(threshold int) VerifierOption {
 	return func(c *VerifierConfig) error {
+		if threshold < 1 {
+			return errors.New("integrated timestamp threshold must be at least 1")
+		}
 		c.requireIntegratedTimestamps = true
 		c.integratedTimeThreshold = threshold
 		return nil
@@ -173,8 +433,12 @@ func WithIntegratedTimestamps(threshold int) VerifierOption {
 }
 
 // WithSignedCertificateTimestamps configures the SignedEntityVerifier to
-// expect the Fulcio certificate to have a SignedCertificateTimestamp, and
-// verify it using the TrustedMaterial's CTLogAuthorities().
+// expect the Fulcio certificate to have at least threshold
+// SignedCertificateTimestamps, and verify them using the TrustedMaterial's
+// CTLogs().
+//
+// A single SCT is verified by default, so this is only needed to require
+// more than one.
 func WithSignedCertificateTimestamps(threshold int) VerifierOption {
 	return func(c *VerifierConfig) error {
 		if threshold < 1 {
@@ -186,6 +450,197 @@ func WithSignedCertificateTimestamps(threshold int) VerifierOption {
 	}
 }
 
+// WithoutSCTVerification configures the SignedEntityVerifier to not verify
+// the SignedCertificateTimestamps embedded in Fulcio certificates. This is
+// only appropriate for private certificate authorities that don't log the
+// certificates they issue to a certificate transparency log.
+func WithoutSCTVerification() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.weDoNotExpectSCTs = true
+		return nil
+	}
+}
+
+// WithCTInclusionProof configures the SignedEntityVerifier to also verify
+// that the certificate transparency logs included the Fulcio certificate,
+// rather than only trusting the promise to include it that an SCT makes.
+// For as many SCTs as the SCT threshold, the log's inclusion proof must
+// lead to one of its signed tree heads.
+//
+// With WithOnlineVerification, the proofs are fetched from the logs. A
+// certificate is only included once the log's maximum merge delay has
+// passed, typically 24 hours, so verification of a recently issued
+// certificate fails. Otherwise the entity must provide the proofs by
+// implementing CTInclusionProofProvider.
+func WithCTInclusionProof() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.requireCTInclusionProof = true
+		return nil
+	}
+}
+
+// WithBYOCertificateVerification configures the SignedEntityVerifier for
+// long-lived certificates issued by the caller's own CA rather than Fulcio,
+// e.g. an enterprise code-signing CA whose signatures are logged to a private
+// Rekor. The CA is configured as one of the trusted material's
+// FulcioCertificateAuthorities(), and the leaf certificate must chain to it.
+//
+// Such certificates have neither SCTs nor Fulcio extensions, so SCTs are not
+// verified, and the certificate need not have a Subject Alternative Name.
+// Fulcio extensions are only checked if a CertificateIdentity names them;
+// use NewBYOCertificateIdentity to match the leaf's Subject CN or email SAN
+// instead. Observer timestamps are required as usual, and the certificate
+// must be valid at each of them.
+//
+// sigstore-go does not check certificate revocation. Callers whose CA
+// revokes certificates must check the leaf in the verification result
+// against the CA's CRL or OCSP responder themselves.
+func WithBYOCertificateVerification() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.byoCertificates = true
+		c.weDoNotExpectSCTs = true
+		return nil
+	}
+}
+
+// WithAdditionalRoots configures the SignedEntityVerifier to also accept
+// leaf certificates that chain to one of roots, e.g. a corporate CA issuing
+// certificates for PIV or PKCS#11 hardware tokens. It implies
+// WithBYOCertificateVerification(), and the trusted material is still used
+// for transparency logs and timestamping authorities.
+//
+// Unlike the trusted material's certificate authorities, the roots have no
+// validity period beyond their certificates' own, and the bundle's
+// intermediates are not used to build chains. Add the CA that issued the
+// leaf certificate to roots if it is not self-signed. The leaf must be valid
+// for code signing, or have no extended key usage at all.
+func WithAdditionalRoots(roots *x509.CertPool) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if roots == nil {
+			return errors.New("additional roots must not be nil")
+		}
+		c.additionalRoots = roots
+		c.byoCertificates = true
+		c.weDoNotExpectSCTs = true
+		return nil
+	}
+}
+
+// WithTimestampCrossCheck configures the SignedEntityVerifier to require
+// every verified RFC 3161 timestamp to be within maxSkew of every verified
+// log entry integrated timestamp, in either direction. Most callers should
+// pass DefaultTimestampClockSkew. There is nothing to cross-check without
+// WithSignedTimestamps or WithObserverTimestamps, or with
+// WithoutTransparencyLog, so those combinations are rejected.
+//
+// The skew only applies to comparing the timestamps with each other. The
+// Fulcio certificate must still be valid at each timestamp exactly as
+// observed, since widening its short validity period would defeat it.
+func WithTimestampCrossCheck(maxSkew time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxSkew < 0 {
+			return errors.New("timestamp clock skew must not be negative")
+		}
+		c.crossCheckTimestamps = true
+		c.maxTimestampSkew = maxSkew
+		return nil
+	}
+}
+
+// DefaultCertificateNotBeforeGrace is the grace period suggested for
+// WithCertificateNotBeforeGrace. It covers the few seconds by which the
+// clocks of Fulcio and of a log or timestamp authority may drift apart.
+const DefaultCertificateNotBeforeGrace = 1 * time.Minute
+
+// WithCertificateNotBeforeGrace configures the SignedEntityVerifier to accept
+// a leaf certificate at observer timestamps up to grace before its
+// NotBefore, as if they were at its NotBefore. This avoids "not yet valid"
+// failures when a signature is verified right after the certificate was
+// issued, and the clock of the log or timestamp authority is behind Fulcio's.
+// A grace of zero disables it. Most callers that need it should pass
+// DefaultCertificateNotBeforeGrace.
+//
+// The grace is never applied to NotAfter, so the certificate's short
+// validity still bounds how long its key can be used. The tradeoff is that
+// a signature observed up to grace before the certificate was issued is
+// accepted, i.e. one made with the key before Fulcio bound it to the
+// identity. Keep the grace small.
+func WithCertificateNotBeforeGrace(grace time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if grace < 0 {
+			return errors.New("certificate NotBefore grace must not be negative")
+		}
+		c.notBeforeGrace = grace
+		return nil
+	}
+}
+
+// WithTimestampWithinCertValidity configures the SignedEntityVerifier to
+// require at least one verified RFC 3161 timestamp or log entry integrated
+// timestamp to be within the leaf certificate's NotBefore and NotAfter, for
+// either source of timestamps. This proves the signature was made while the
+// certificate was valid, even when WithCertificateNotBeforeGrace accepts
+// timestamps shortly before it. The certificate's validity period alone is
+// not enough, so this requires WithSignedTimestamps, WithObserverTimestamps
+// or WithIntegratedTimestamps. It doesn't apply to entities signed with a
+// public key.
+func WithTimestampWithinCertValidity() VerifierOption {
+	return func(c *VerifierConfig) error {
+		c.requireTimestampWithinCertValidity = true
+		return nil
+	}
+}
+
+// WithMaxCertLifetime configures the SignedEntityVerifier to reject leaf
+// certificates valid for longer than maxLifetime, from NotBefore to
+// NotAfter. Fulcio certificates are valid for minutes, so one valid for far
+// longer was likely not issued by Fulcio.
+func WithMaxCertLifetime(maxLifetime time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxLifetime <= 0 {
+			return errors.New("maximum certificate lifetime must be positive")
+		}
+		c.maxCertLifetime = maxLifetime
+		return nil
+	}
+}
+
+// WithMaxPathLength configures the SignedEntityVerifier to reject leaf
+// certificate chains with more than maxPathLen intermediate certificates
+// between the leaf and the root. This applies on top of any BasicConstraints
+// path length in the chain's CA certificates, which is always enforced.
+func WithMaxPathLength(maxPathLen int) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxPathLen < 0 {
+			return errors.New("maximum path length must not be negative")
+		}
+		c.maxPathLen = maxPathLen
+		c.maxPathLenSet = true
+		return nil
+	}
+}
+
+// WithFulcioLeafProfile configures the SignedEntityVerifier to check that
+// the leaf certificate matches what Fulcio issues, with VerifyFulcioLeafProfile.
+// Most callers should pass DefaultFulcioLeafMaxLifetime.
+//
+// This catches a certificate that carries Fulcio extensions without having
+// been issued by Fulcio, e.g. by another CA merged into the trusted root.
+// Leaf certificates are only chained to the trusted material's
+// FulcioCertificateAuthorities(), never to its timestamping authorities.
+// The checks don't apply to other CAs' certificates, so this can't be
+// combined with WithBYOCertificateVerification().
+func WithFulcioLeafProfile(maxLifetime time.Duration) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if maxLifetime <= 0 {
+			return errors.New("maximum Fulcio certificate lifetime must be positive")
+		}
+		c.fulcioLeafProfile = true
+		c.fulcioLeafMaxLifetime = maxLifetime
+		return nil
+	}
+}
+
 // WithoutAnyObserverTimestampsInsecure configures the SignedEntityVerifier to not expect
 // any timestamps from either a Timestamp Authority or a Transparency Log.
 //
@@ -202,12 +657,88 @@ func WithoutAnyObserverTimestampsInsecure() VerifierOption {
 	}
 }
 
+// WithLogger configures the SignedEntityVerifier to emit debug-level events
+// to logger as it verifies an entity: the trusted material it verifies
+// against, the log entries, timestamps and certificate chain it verified,
+// how long each of those took, and the outcome of the policy. Without a
+// logger, nothing is logged and no attributes are built.
+func WithLogger(logger *slog.Logger) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
 func (c *VerifierConfig) Validate() error {
 	if !c.requireObserverTimestamps && !c.weExpectSignedTimestamps && !c.requireIntegratedTimestamps && !c.weDoNotExpectAnyObserverTimestamps {
 		return errors.New("when initializing a new SignedEntityVerifier, you must specify at least one of " +
 			"WithObserverTimestamps(), WithSignedTimestamps(), WithIntegratedTimestamps(), or WithoutAnyObserverTimestampsInsecure()")
 	}
 
+	if c.weExpectSCTs && c.byoCertificates {
+		return errors.New("WithBYOCertificateVerification() can't be combined with WithSignedCertificateTimestamps()")
+	}
+
+	if c.fulcioLeafProfile && c.byoCertificates {
+		return errors.New("WithBYOCertificateVerification() can't be combined with WithFulcioLeafProfile()")
+	}
+
+	if c.weExpectSCTs && c.weDoNotExpectSCTs {
+		return errors.New("WithoutSCTVerification() can't be combined with WithSignedCertificateTimestamps()")
+	}
+
+	if c.weDoNotExpectTlogEntries {
+		if c.weExpectTlogEntries || c.requireIntegratedTimestamps {
+			return errors.New("WithoutTransparencyLog() can't be combined with WithTransparencyLog() or WithIntegratedTimestamps()")
+		}
+		if !c.weExpectSignedTimestamps {
+			return errors.New("WithoutTransparencyLog() requires WithSignedTimestamps()")
+		}
+	}
+
+	if c.requireCTInclusionProof && (c.weDoNotExpectSCTs || c.byoCertificates) {
+		return errors.New("WithCTInclusionProof() can't be combined with WithoutSCTVerification() or WithBYOCertificateVerification()")
+	}
+
+	if c.checkpointMaxAge > 0 && !c.weExpectTlogEntries {
+		return errors.New("WithCheckpointFreshness() requires WithTransparencyLog()")
+	}
+
+	if len(c.providedInclusionProofs) > 0 && !c.weExpectTlogEntries {
+		return errors.New("WithProvidedInclusionProof() requires WithTransparencyLog()")
+	}
+
+	if c.trustCheckpointTimestamps && (!c.weExpectTlogEntries || !(c.requireIntegratedTimestamps || c.requireObserverTimestamps)) {
+		return errors.New("WithCheckpointTimestamps() requires WithTransparencyLog(), and WithIntegratedTimestamps() or WithObserverTimestamps()")
+	}
+
+	// the options below only change how timestamps are verified, so they'd
+	// silently do nothing without any
+	weVerifySignedTimestamps := c.weExpectSignedTimestamps || c.requireObserverTimestamps
+	if c.requireDistinctTimestampAuthorities && !weVerifySignedTimestamps {
+		return errors.New("WithDistinctTimestampAuthorities() requires WithSignedTimestamps() or WithObserverTimestamps()")
+	}
+
+	if len(c.alternateTimestampedContents) > 0 && !weVerifySignedTimestamps {
+		return errors.New("WithAlternateTimestampedContent() requires WithSignedTimestamps() or WithObserverTimestamps()")
+	}
+
+	if c.crossCheckTimestamps {
+		if !weVerifySignedTimestamps {
+			return errors.New("WithTimestampCrossCheck() requires WithSignedTimestamps() or WithObserverTimestamps()")
+		}
+		if c.weDoNotExpectTlogEntries {
+			return errors.New("WithTimestampCrossCheck() can't be combined with WithoutTransparencyLog()")
+		}
+	}
+
+	if c.requireTimestampWithinCertValidity && !weVerifySignedTimestamps && !c.requireIntegratedTimestamps {
+		return errors.New("WithTimestampWithinCertValidity() requires WithSignedTimestamps(), WithObserverTimestamps() or WithIntegratedTimestamps()")
+	}
+
 	return nil
 }
 
@@ -216,7 +747,54 @@ type VerificationResult struct {
 	Statement          *in_toto.Statement            `json:"statement,omitempty"`
 	Signature          *SignatureVerificationResult  `json:"signature,omitempty"`
 	VerifiedTimestamps []TimestampVerificationResult `json:"verifiedTimestamps"`
-	VerifiedIdentity   *CertificateIdentity          `json:"verifiedIdentity,omitempty"`
+	// SigningTime is the earliest of the VerifiedTimestamps, leaving out
+	// those over the message digest (see TimestampedDigest)
+	SigningTime time.Time `json:"signingTime"`
+	// TransparencyLogVerified is false when the verifier was not configured
+	// with WithTransparencyLog, in which case no log entries were checked
+	TransparencyLogVerified bool `json:"transparencyLogVerified"`
+	// VerifiedTlogEntries are the transparency log entries that verified,
+	// recording exactly which entries backed the verification
+	VerifiedTlogEntries []TlogEntryInfo      `json:"verifiedTlogEntries,omitempty"`
+	VerifiedIdentity    *CertificateIdentity `json:"verifiedIdentity,omitempty"`
+	// TrustedRoot is the root the entity verified against, when verifying
+	// against several with WithTrustedRoots
+	TrustedRoot *TrustedRootResult `json:"trustedRoot,omitempty"`
+}
+
+// verificationResultJSON is a VerificationResult with its statement in the
+// in-toto JSON encoding, which encoding/json doesn't produce for the
+// statement's protobuf message.
+type verificationResultJSON struct {
+	*verificationResultAlias
+	Statement json.RawMessage `json:"statement,omitempty"`
+}
+
+type verificationResultAlias VerificationResult
+
+func (r *VerificationResult) MarshalJSON() ([]byte, error) {
+	out := verificationResultJSON{verificationResultAlias: (*verificationResultAlias)(r)}
+	if r.Statement != nil {
+		statement, err := protojson.Marshal(r.Statement)
+		if err != nil {
+			return nil, err
+		}
+		out.Statement = statement
+	}
+	return json.Marshal(out)
+}
+
+func (r *VerificationResult) UnmarshalJSON(data []byte) error {
+	in := verificationResultJSON{verificationResultAlias: (*verificationResultAlias)(r)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	r.Statement = nil
+	if len(in.Statement) > 0 && string(in.Statement) != "null" {
+		r.Statement = &in_toto.Statement{}
+		return protojson.Unmarshal(in.Statement, r.Statement)
+	}
+	return nil
 }
 
 type SignatureVerificationResult struct {
@@ -228,6 +806,24 @@ type TimestampVerificationResult struct {
 	Type      string    `json:"type"`
 	URI       string    `json:"uri"`
 	Timestamp time.Time `json:"timestamp"`
+	// TimestampInfo is only set for RFC 3161 signed timestamps
+	TimestampInfo *TimestampInfo `json:"timestampInfo,omitempty"`
+}
+
+// validityWindow returns the times at which the signing certificate must be
+// valid for the timestamp to be accepted. For signed timestamps that declare
+// an accuracy, this is both ends of genTime ± accuracy.
+func (r TimestampVerificationResult) validityWindow() []time.Time {
+	if r.TimestampInfo == nil || r.TimestampInfo.Accuracy == 0 {
+		return []time.Time{r.Timestamp}
+	}
+	return []time.Time{r.TimestampInfo.Earliest(), r.TimestampInfo.Latest()}
+}
+
+// evidencesSigning reports whether the timestamp shows when the entity was
+// signed, rather than only when the artifact existed. See TimestampedDigest.
+func (r TimestampVerificationResult) evidencesSigning() bool {
+	return r.TimestampInfo == nil || r.TimestampInfo.TimestampedContent != TimestampedDigest
 }
 
 func NewVerificationResult() *VerificationResult {
@@ -253,6 +849,10 @@ func (pc PolicyBuilder) Options() []PolicyOption {
 func (pc PolicyBuilder) BuildConfig() (*PolicyConfig, error) {
 	var err error
 
+	if pc.artifactPolicy == nil {
+		return nil, errors.New("an artifact policy is required; use WithoutArtifactUnsafe to verify without an artifact")
+	}
+
 	policy := &PolicyConfig{}
 	for _, applyOption := range pc.Options() {
 		err = applyOption(policy)
@@ -277,6 +877,21 @@ type PolicyConfig struct {
 	verifyArtifactDigest    bool
 	artifactDigest          []byte
 	artifactDigestAlgorithm string
+	verifySubjectDigests    bool
+	subjectDigests          []subjectDigest
+	predicateDigests        []PredicateDigest
+	providedCertificate     *x509.Certificate
+	requiredMessageHash     crypto.Hash
+	allowedBuilderIDs       []string
+	subjectNamePatterns     []string
+	statementPredicates     []StatementPredicate
+}
+
+// subjectDigest is a digest that may appear in an in-toto statement's
+// subjects.
+type subjectDigest struct {
+	algorithm string
+	digest    []byte
 }
 
 func (p *PolicyConfig) Validate() error {
@@ -371,6 +986,65 @@ func WithCertificateIdentity(identity CertificateIdentity) PolicyOption {
 	}
 }
 
+// WithProvidedCertificate allows the caller of Verify to supply the Fulcio
+// certificate that signed the SignedEntity, for entities that were
+// distributed without it. The certificate is used as the leaf and verified
+// against the trusted material like a certificate carried by the entity.
+//
+// If the SignedEntity does carry a certificate, it must be the provided one.
+func WithProvidedCertificate(cert *x509.Certificate) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if cert == nil {
+			return errors.New("provided certificate must not be nil")
+		}
+		if p.providedCertificate != nil {
+			return errors.New("only one invocation of WithProvidedCertificate is allowed")
+		}
+
+		p.providedCertificate = cert
+		return nil
+	}
+}
+
+// WithPredicateDigest allows the caller of Verify to enforce that the
+// SignedEntity's in-toto statement restates a digest within its predicate,
+// such as the digest of a build's source in a SLSA provenance predicate. See
+// NewPredicateDigest for how the location of the digest is given.
+//
+// Providing this function multiple times requires every one of the digests
+// to be present. If this policy is enabled, but the SignedEntity does not
+// contain an in-toto statement, verification will fail.
+func WithPredicateDigest(digest PredicateDigest) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if len(digest.segments) == 0 {
+			return errors.New("predicate digest must be created with NewPredicateDigest")
+		}
+
+		p.predicateDigests = append(p.predicateDigests, digest)
+		return nil
+	}
+}
+
+// WithRequiredMessageHash allows the caller of Verify to enforce that a
+// SignedEntity's MessageSignature declares the given hash algorithm, one of
+// SHA-256, SHA-384 or SHA-512. By default, any of them is accepted.
+//
+// SignedEntities with a DSSE envelope rather than a MessageSignature are not
+// affected by this policy.
+func WithRequiredMessageHash(hash crypto.Hash) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if _, err := messageHashAlgorithm(hash); err != nil {
+			return err
+		}
+		if p.requiredMessageHash != 0 {
+			return errors.New("only one invocation of WithRequiredMessageHash is allowed")
+		}
+
+		p.requiredMessageHash = hash
+		return nil
+	}
+}
+
 // WithoutArtifactUnsafe allows the caller of Verify to skip checking whether
 // the SignedEntity was created from, or references, an artifact.
 //
@@ -387,8 +1061,8 @@ func WithCertificateIdentity(identity CertificateIdentity) PolicyOption {
 // an artifact.
 func WithoutArtifactUnsafe() ArtifactPolicyOption {
 	return func(p *PolicyConfig) error {
-		if p.verifyArtifact || p.verifyArtifactDigest {
-			return errors.New("can't use WithoutArtifactUnsafe while using WithArtifact or WithArtifactDigest")
+		if p.verifyArtifact || p.verifyArtifactDigest || p.verifySubjectDigests {
+			return errors.New("can't use WithoutArtifactUnsafe while using WithArtifact, WithArtifactDigest or WithOCIImageSubjects")
 		}
 
 		p.weDoNotExpectAnArtifact = true
@@ -404,8 +1078,11 @@ func WithoutArtifactUnsafe() ArtifactPolicyOption {
 // envelope's statement.
 func WithArtifact(artifact io.Reader) ArtifactPolicyOption {
 	return func(p *PolicyConfig) error {
-		if p.verifyArtifact || p.verifyArtifactDigest {
-			return errors.New("only one invocation of WithArtifact/WithArtifactDigest is allowed")
+		if artifact == nil {
+			return errors.New("artifact must not be nil")
+		}
+		if p.verifyArtifact || p.verifyArtifactDigest || p.verifySubjectDigests {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/WithOCIImageSubjects is allowed")
 		}
 
 		if p.weDoNotExpectAnArtifact {
@@ -429,8 +1106,11 @@ func WithArtifact(artifact io.Reader) ArtifactPolicyOption {
 // compared to the digest in the envelope's statement.
 func WithArtifactDigest(algorithm string, artifactDigest []byte) ArtifactPolicyOption {
 	return func(p *PolicyConfig) error {
-		if p.verifyArtifact || p.verifyArtifactDigest {
-			return errors.New("only one invocation of WithArtifact/WithArtifactDigest is allowed")
+		if algorithm == "" || len(artifactDigest) == 0 {
+			return errors.New("artifact digest and its algorithm must not be empty")
+		}
+		if p.verifyArtifact || p.verifyArtifactDigest || p.verifySubjectDigests {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/WithOCIImageSubjects is allowed")
 		}
 
 		if p.weDoNotExpectAnArtifact {
@@ -444,6 +1124,49 @@ func WithArtifactDigest(algorithm string, artifactDigest []byte) ArtifactPolicyO
 	}
 }
 
+// WithOCIImageSubjects allows the caller of Verify to enforce that the
+// SignedEntity being verified was created for an OCI image that may be
+// multi-platform. Verification passes if the statement's subjects include
+// either the image index digest or the digest of any of its per-platform
+// manifests. Digests are given in their "algorithm:hex" form, e.g.
+// "sha256:abc...".
+//
+// The SignedEntity must contain a DSSE envelope with an in-toto statement.
+func WithOCIImageSubjects(indexDigest string, manifestDigests ...string) ArtifactPolicyOption {
+	return func(p *PolicyConfig) error {
+		if p.verifyArtifact || p.verifyArtifactDigest || p.verifySubjectDigests {
+			return errors.New("only one invocation of WithArtifact/WithArtifactDigest/WithOCIImageSubjects is allowed")
+		}
+
+		if p.weDoNotExpectAnArtifact {
+			return errors.New("can't use WithOCIImageSubjects while using WithoutArtifactUnsafe")
+		}
+
+		for _, digest := range append([]string{indexDigest}, manifestDigests...) {
+			algorithm, hexDigest, ok := strings.Cut(digest, ":")
+			if !ok {
+				return fmt.Errorf("OCI image digest %q is not of the form algorithm:hex", digest)
+			}
+			decoded, err := hex.DecodeString(hexDigest)
+			if err != nil {
+				return fmt.Errorf("OCI image digest %q is not hex encoded: %w", digest, err)
+			}
+			p.subjectDigests = append(p.subjectDigests, subjectDigest{algorithm: algorithm, digest: decoded})
+		}
+
+		p.verifySubjectDigests = true
+		return nil
+	}
+}
+
+// NewOCIImageSubjectPolicy is like WithOCIImageSubjects.
+//
+// Deprecated: use WithOCIImageSubjects, which is named like the other
+// artifact policies.
+func NewOCIImageSubjectPolicy(indexDigest string, manifestDigests []string) ArtifactPolicyOption {
+	return WithOCIImageSubjects(indexDigest, manifestDigests...)
+}
+
 // Verify checks the cryptographic integrity of a given SignedEntity according
 // to the options configured in the NewSignedEntityVerifier. Its purpose is to
 // determine whether the SignedEntity was created by a Sigstore deployment we
@@ -465,24 +1188,118 @@ func WithArtifactDigest(algorithm string, artifactDigest []byte) ArtifactPolicyO
 //   - (if the signed entity has a dsse envelope) verify that the envelope's
 //     statement's subject matches the artifact being verified
 func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*VerificationResult, error) {
+	start := time.Now()
+	result, err := v.verify(entity, pb)
+	if err != nil {
+		v.config.metrics.IncError(errorKind(err))
+		v.config.metrics.ObserveVerification(OutcomeFailure, time.Since(start))
+	} else {
+		v.config.metrics.ObserveVerification(OutcomeSuccess, time.Since(start))
+	}
+	if v.config.logger != nil {
+		if err != nil {
+			v.debug("verification failed", slog.Any("error", err), slog.Duration("duration", time.Since(start)))
+		} else {
+			v.debug("verification succeeded", slog.Time("signing_time", result.SigningTime), slog.Duration("duration", time.Since(start)))
+		}
+	}
+	return result, err
+}
+
+// debug emits a debug-level event to the verifier's logger. Callers check
+// that a logger is configured first, so that attributes aren't built for
+// nothing.
+func (v *SignedEntityVerifier) debug(msg string, attrs ...slog.Attr) {
+	v.config.logger.LogAttrs(context.Background(), slog.LevelDebug, msg, attrs...)
+}
+
+func (v *SignedEntityVerifier) verify(entity SignedEntity, pb PolicyBuilder) (*VerificationResult, error) {
 	policy, err := pb.BuildConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build policy: %w", err)
 	}
 
+	if len(v.rootVerifiers) > 0 {
+		return v.verifyWithTrustedRoots(entity, policy)
+	}
+	return v.verifyWithPolicy(entity, policy)
+}
+
+// verifyWithPolicy verifies the entity against the verifier's trusted
+// material.
+func (v *SignedEntityVerifier) verifyWithPolicy(entity SignedEntity, policy *PolicyConfig) (*VerificationResult, error) {
+	var err error
+	if v.config.logger != nil {
+		v.debug("trusted material selected",
+			slog.String("type", fmt.Sprintf("%T", v.trustedMaterial)),
+			slog.Int("fulcio_certificate_authorities", len(v.trustedMaterial.FulcioCertificateAuthorities())),
+			slog.Int("timestamping_authorities", len(v.trustedMaterial.TimestampingAuthorities())),
+			slog.Int("rekor_logs", len(v.trustedMaterial.RekorLogs())),
+			slog.Int("ct_logs", len(v.trustedMaterial.CTLogs())))
+	}
+
+	// entity may be wrapped below, hiding the optional interfaces it
+	// implements; keep it for reporting chain failures and for the CT
+	// inclusion proofs it provides
+	signedEntity := entity
+
+	// each step below fetches the entity's content, which for a bundle means
+	// parsing it again, so it is parsed once for the whole verification
+	entity = &memoizedEntity{SignedEntity: entity}
+
+	if policy.providedCertificate != nil {
+		entity, err = withProvidedCertificate(entity, policy.providedCertificate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if v.config.notBeforeGrace > 0 {
+		entity = &notBeforeGraceEntity{SignedEntity: entity, grace: v.config.notBeforeGrace}
+	}
+
 	// Let's go by the spec: https://docs.google.com/document/d/1kbhK2qyPPk8SLavHzYSDM8-Ueul9_oxIMVFuWMWKz0E/edit#heading=h.g11ovq2s1jxh
 	// > ## Transparency Log Entry
-	verifiedTlogTimestamps, err := v.VerifyTransparencyLogInclusion(entity)
+	phaseStart := time.Now()
+	if v.config.fipsMode && v.config.weExpectTlogEntries {
+		if err := checkFIPSTransparencyLog(entity, v.trustedMaterial); err != nil {
+			return nil, fmt.Errorf("failed to verify log inclusion: %w", err)
+		}
+	}
+	verifiedTlogTimestamps, verifiedTlogEntries, err := v.verifyTransparencyLogInclusion(entity)
+	if v.config.weExpectTlogEntries {
+		v.config.metrics.ObservePhase(PhaseTransparencyLog, time.Since(phaseStart))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify log inclusion: %w", err)
 	}
+	if v.config.logger != nil && v.config.weExpectTlogEntries {
+		for _, entry := range verifiedTlogEntries {
+			v.debug("transparency log entry verified",
+				slog.String("log_id", entry.LogID),
+				slog.Int64("log_index", entry.LogIndex),
+				slog.Time("integrated_time", entry.IntegratedTime),
+				slog.String("uuid", entry.UUID))
+		}
+		v.debug("transparency log inclusion verified", slog.Int("entries", len(verifiedTlogEntries)), slog.Duration("duration", time.Since(phaseStart)))
+	}
 
 	// > ## Establishing a Time for the Signature
 	// > First, establish a time for the signature. This timestamp is required to validate the certificate chain, so this step comes first.
+	phaseStart = time.Now()
+	if v.config.fipsMode && (v.config.weExpectSignedTimestamps || v.config.requireObserverTimestamps) {
+		if err := checkFIPSTimestamps(entity, v.trustedMaterial); err != nil {
+			return nil, fmt.Errorf("failed to verify timestamps: %w", err)
+		}
+	}
 	verifiedTimestamps, err := v.VerifyObserverTimestamps(entity, verifiedTlogTimestamps)
+	v.config.metrics.ObservePhase(PhaseObserverTimestamps, time.Since(phaseStart))
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify timestamps: %w", err)
 	}
+	if v.config.logger != nil {
+		v.debug("observer timestamps verified", slog.Int("timestamps", len(verifiedTimestamps)), slog.Duration("duration", time.Since(phaseStart)))
+	}
 
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
@@ -497,30 +1314,88 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 	if leafCert, ok := verificationContent.HasCertificate(); ok {
 		signedWithCertificate = true
 
+		if v.config.maxCertLifetime > 0 {
+			if lifetime := leafCert.NotAfter.Sub(leafCert.NotBefore); lifetime > v.config.maxCertLifetime {
+				return nil, fmt.Errorf("leaf certificate lifetime %s exceeds maximum of %s", lifetime, v.config.maxCertLifetime)
+			}
+		}
+
+		if v.config.minKeyStrength != nil {
+			if err := verifyKeyStrength(&leafCert, v.trustedMaterial, *v.config.minKeyStrength); err != nil {
+				return nil, fmt.Errorf("failed to verify leaf certificate: %w", err)
+			}
+		}
+
 		// From spec:
 		// > ## Certificate
 		// > …
 		// > The Verifier MUST perform certification path validation (RFC 5280 §6) of the certificate chain with the pre-distributed Fulcio root certificate(s) as a trust anchor, but with a fake “current time.” If a timestamp from the timestamping service is available, the Verifier MUST perform path validation using the timestamp from the Timestamping Service. If a timestamp from the Transparency Service is available, the Verifier MUST perform path validation using the timestamp from the Transparency Service. If both are available, the Verifier performs path validation twice. If either fails, verification fails.
 
-		for _, verifiedTs := range verifiedTimestamps {
-			// verify the leaf certificate against the root
-			err = VerifyLeafCertificate(verifiedTs.Timestamp, leafCert, v.trustedMaterial)
-			if err != nil {
+		phaseStart = time.Now()
+		if v.config.fipsMode {
+			if err := checkFIPSCertificateChain(&leafCert, v.trustedMaterial); err != nil {
 				return nil, fmt.Errorf("failed to verify leaf certificate: %w", err)
 			}
 		}
+		err = v.verifyLeafCertificate(verifiedTimestamps, leafCert)
+		v.config.metrics.ObservePhase(PhaseCertificateChain, time.Since(phaseStart))
+		if err != nil {
+			var chainErr *ChainVerificationError
+			if errors.As(err, &chainErr) {
+				chainErr.BundleIntermediates = bundleIntermediates(signedEntity)
+			}
+			return nil, fmt.Errorf("failed to verify leaf certificate: %w", err)
+		}
+		if v.config.logger != nil {
+			v.debug("certificate chain verified",
+				slog.String("certificate_issuer", leafCert.Issuer.String()),
+				slog.Time("not_before", leafCert.NotBefore),
+				slog.Time("not_after", leafCert.NotAfter),
+				slog.Duration("duration", time.Since(phaseStart)))
+		}
+
+		if v.config.requireTimestampWithinCertValidity {
+			err = verifyTimestampWithinCertValidity(verifiedTimestamps, &leafCert)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if v.config.fulcioLeafProfile {
+			err = VerifyFulcioLeafProfile(&leafCert, v.config.fulcioLeafMaxLifetime)
+			if err != nil {
+				return nil, fmt.Errorf("failed to verify Fulcio leaf certificate profile: %w", err)
+			}
+		}
 
 		// From spec:
 		// > Unless performing online verification (see §Alternative Workflows), the Verifier MUST extract the  SignedCertificateTimestamp embedded in the leaf certificate, and verify it as in RFC 9162 §8.1.3, using the verification key from the Certificate Transparency Log.
 
 		if v.config.weExpectSCTs {
+			phaseStart = time.Now()
+			if v.config.fipsMode {
+				if err := checkFIPSSCTs(&leafCert, v.trustedMaterial); err != nil {
+					return nil, fmt.Errorf("failed to verify signed certificate timestamp: %w", err)
+				}
+			}
 			err = VerifySignedCertificateTimestamp(&leafCert, v.config.ctlogEntriesThreshold, v.trustedMaterial)
+			if err == nil && v.config.requireCTInclusionProof {
+				err = v.verifyCTInclusionProof(signedEntity, &leafCert)
+			}
+			v.config.metrics.ObservePhase(PhaseSignedCertificateTimestamps, time.Since(phaseStart))
 			if err != nil {
 				return nil, fmt.Errorf("failed to verify signed certificate timestamp: %w", err)
 			}
+			if v.config.logger != nil {
+				v.debug("signed certificate timestamps verified", slog.Int("threshold", v.config.ctlogEntriesThreshold), slog.Duration("duration", time.Since(phaseStart)))
+			}
 		}
 
-		certSummary, err = certificate.SummarizeCertificate(&leafCert)
+		if v.config.byoCertificates {
+			certSummary, err = certificate.SummarizeBYOCertificate(&leafCert)
+		} else {
+			certSummary, err = certificate.SummarizeCertificate(&leafCert)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to summarize certificate: %w", err)
 		}
@@ -535,25 +1410,20 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 		return nil, fmt.Errorf("failed to fetch signature content: %w", err)
 	}
 
-	if policy.WeExpectAnArtifact() {
-		switch {
-		case policy.verifyArtifact:
-			err = VerifySignatureWithArtifact(sigContent, verificationContent, v.trustedMaterial, policy.artifact)
-		case policy.verifyArtifactDigest:
-			err = VerifySignatureWithArtifactDigest(sigContent, verificationContent, v.trustedMaterial, policy.artifactDigest, policy.artifactDigestAlgorithm)
-		default:
-			// should never happen, but just in case:
-			err = errors.New("no artifact or artifact digest provided")
+	phaseStart = time.Now()
+	if v.config.fipsMode {
+		if err := checkFIPSSignature(sigContent, verificationContent, v.trustedMaterial); err != nil {
+			return nil, fmt.Errorf("failed to verify signature: %w", err)
 		}
-	} else {
-		// verifying with artifact has been explicitly turned off, so just check
-		// the signature on the dsse envelope:
-		err = VerifySignature(sigContent, verificationContent, v.trustedMaterial)
 	}
-
+	err = v.verifySignature(sigContent, verificationContent, policy)
+	v.config.metrics.ObservePhase(PhaseSignature, time.Since(phaseStart))
 	if err != nil {
 		return nil, fmt.Errorf("failed to verify signature: %w", err)
 	}
+	if v.config.logger != nil {
+		v.debug("signature verified", slog.Bool("artifact", policy.WeExpectAnArtifact()))
+	}
 
 	// Hooray! We've verified all of the entity's constituent parts! 🎉 🥳
 	// Now we can construct the results object accordingly.
@@ -565,8 +1435,9 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 	}
 
 	// SignatureContent can be either an Envelope or a MessageSignature.
-	// If it's an Envelope, let's pop the Statement for our results:
-	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+	// If it's an Envelope of an in-toto statement, let's pop the Statement
+	// for our results:
+	if envelope := sigContent.EnvelopeContent(); envelope != nil && envelope.RawEnvelope().PayloadType == IntotoPayloadType {
 		stmt, err := envelope.Statement()
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch envelope statement: %w", err)
@@ -576,34 +1447,204 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 	}
 
 	result.VerifiedTimestamps = verifiedTimestamps
+	result.SigningTime = earliestTimestamp(verifiedTimestamps)
+	result.TransparencyLogVerified = v.config.weExpectTlogEntries
+	result.VerifiedTlogEntries = verifiedTlogEntries
 
 	// Now that the signed entity's crypto material has been verified, and the
 	// result struct has been constructed, we can optionally enforce some
 	// additional policies:
 	// --------------------
 
+	phaseStart = time.Now()
+	err = v.verifyPolicy(policy, signedWithCertificate, certSummary, result)
+	v.config.metrics.ObservePhase(PhasePolicy, time.Since(phaseStart))
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// maxIntermediates returns the most intermediate certificates allowed in a
+// leaf certificate's chain, or -1 for no limit beyond the chain's own
+// constraints.
+func (c *VerifierConfig) maxIntermediates() int {
+	if !c.maxPathLenSet {
+		return -1
+	}
+	return c.maxPathLen
+}
+
+// verifyLeafCertificate verifies the leaf certificate's chain at each of the
+// verified timestamps.
+func (v *SignedEntityVerifier) verifyLeafCertificate(verifiedTimestamps []TimestampVerificationResult, leafCert x509.Certificate) error {
+	for _, verifiedTs := range verifiedTimestamps {
+		if !verifiedTs.evidencesSigning() {
+			continue
+		}
+		for _, observerTime := range verifiedTs.validityWindow() {
+			// verify the leaf certificate against the root
+			verificationTime := clampToNotBefore(observerTime, &leafCert, v.config.notBeforeGrace)
+			err := verifyLeafCertificateAt(observerTime, verificationTime, leafCert, v.trustedMaterial, v.config.maxIntermediates())
+			var chainErr *ChainVerificationError
+			if v.config.additionalRoots != nil && errors.As(err, &chainErr) {
+				candidate, ok := verifyLeafCertificateWithRoots(verificationTime, leafCert, v.config.additionalRoots, v.config.maxIntermediates())
+				if ok {
+					err = nil
+				} else {
+					chainErr.Candidates = append(chainErr.Candidates, candidate)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// verifySignature verifies the entity's signature, over the artifact or
+// artifact digests of the policy if it has any.
+func (v *SignedEntityVerifier) verifySignature(sigContent SignatureContent, verificationContent VerificationContent, policy *PolicyConfig) error {
+	if msg := sigContent.MessageSignatureContent(); msg != nil && policy.requiredMessageHash != 0 {
+		if err := verifyMessageSignatureHash(msg, policy.requiredMessageHash); err != nil {
+			return err
+		}
+	}
+
+	if envelope := sigContent.EnvelopeContent(); envelope != nil {
+		if err := verifyPayloadType(envelope, v.config.expectedPayloadTypes()); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if policy.WeExpectAnArtifact() {
+		switch {
+		case policy.verifyArtifact:
+			err = VerifySignatureWithArtifact(sigContent, verificationContent, v.trustedMaterial, policy.artifact)
+		case policy.verifyArtifactDigest:
+			err = VerifySignatureWithArtifactDigest(sigContent, verificationContent, v.trustedMaterial, policy.artifactDigest, policy.artifactDigestAlgorithm)
+		case policy.verifySubjectDigests:
+			err = verifySignatureWithSubjectDigests(sigContent, verificationContent, v.trustedMaterial, policy.subjectDigests)
+		default:
+			// should never happen, but just in case:
+			err = errors.New("no artifact or artifact digest provided")
+		}
+	} else {
+		// verifying with artifact has been explicitly turned off, so just check
+		// the signature on the dsse envelope:
+		err = VerifySignature(sigContent, verificationContent, v.trustedMaterial)
+	}
+
+	return err
+}
+
+// verifyPolicy checks the verified certificate and statement against the
+// policy's certificate identities and predicate digests, recording the
+// matching identity in result.
+func (v *SignedEntityVerifier) verifyPolicy(policy *PolicyConfig, signedWithCertificate bool, certSummary certificate.Summary, result *VerificationResult) error {
 	// From ## Certificate section,
 	// >The Verifier MUST then check the certificate against the verification policy. Details on how to do this depend on the verification policy, but the Verifier SHOULD check the Issuer X.509 extension (OID 1.3.6.1.4.1.57264.1.1) at a minimum, and will in most cases check the SubjectAlternativeName as well. See  Spec: Fulcio §TODO for example checks on the certificate.
 	if policy.WeExpectIdentities() {
 		if !signedWithCertificate {
 			// We got asked to verify identities, but the entity was not signed with
 			// a certificate. That's a problem!
-			return nil, errors.New("can't verify certificate identities: entity was not signed with a certificate")
+			return errors.New("can't verify certificate identities: entity was not signed with a certificate")
 		}
 
 		if len(policy.certificateIdentities) == 0 {
-			return nil, errors.New("can't verify certificate identities: no identities provided")
+			return errors.New("can't verify certificate identities: no identities provided")
 		}
 
 		matchingCertID, err := policy.certificateIdentities.Verify(certSummary)
 		if err != nil {
-			return nil, fmt.Errorf("failed to verify certificate identity: %w", err)
+			return fmt.Errorf("failed to verify certificate identity: %w", err)
+		}
+		if v.config.logger != nil {
+			v.debug("certificate identity verified",
+				slog.String("san_type", string(certSummary.SubjectAlternativeName.Type)),
+				slog.String("san", certSummary.SubjectAlternativeName.Value),
+				slog.String("issuer", certSummary.Issuer))
 		}
 
 		result.VerifiedIdentity = matchingCertID
 	}
 
-	return result, nil
+	for _, predicateDigest := range policy.predicateDigests {
+		if err := predicateDigest.Verify(result.Statement); err != nil {
+			return fmt.Errorf("failed to verify predicate digest: %w", err)
+		}
+	}
+	if v.config.logger != nil && len(policy.predicateDigests) > 0 {
+		v.debug("predicate digests verified", slog.Int("digests", len(policy.predicateDigests)))
+	}
+
+	for _, pattern := range policy.subjectNamePatterns {
+		name, err := verifySubjectName(result.Statement, pattern)
+		if err != nil {
+			return fmt.Errorf("failed to verify subject name: %w", err)
+		}
+		if v.config.logger != nil {
+			v.debug("subject name verified", slog.String("pattern", pattern), slog.String("name", name))
+		}
+	}
+
+	if policy.allowedBuilderIDs != nil {
+		builderID, err := verifyBuilderID(result.Statement, policy.allowedBuilderIDs)
+		if err != nil {
+			return fmt.Errorf("failed to verify builder ID: %w", err)
+		}
+		if v.config.logger != nil {
+			v.debug("builder ID verified", slog.String("builder_id", builderID))
+		}
+	}
+
+	if len(policy.statementPredicates) > 0 {
+		if result.Statement == nil {
+			return errors.New("can't verify statement predicates: entity has no in-toto statement")
+		}
+		var signer *certificate.Summary
+		if signedWithCertificate {
+			signer = &certSummary
+		}
+		for _, predicate := range policy.statementPredicates {
+			if err := predicate(result.Statement, signer); err != nil {
+				return fmt.Errorf("failed to verify statement predicate: %w", err)
+			}
+		}
+		if v.config.logger != nil {
+			v.debug("statement predicates verified", slog.Int("predicates", len(policy.statementPredicates)))
+		}
+	}
+
+	return nil
+}
+
+// VerifyAndExtractPredicate verifies the entity like Verify, then decodes the
+// predicate of its in-toto statement into out, which must be a pointer as
+// for json.Unmarshal. It is an error for the entity not to carry a statement.
+func (v *SignedEntityVerifier) VerifyAndExtractPredicate(entity SignedEntity, pb PolicyBuilder, out any) error {
+	result, err := v.Verify(entity, pb)
+	if err != nil {
+		return err
+	}
+
+	if result.Statement == nil {
+		return errors.New("entity has no in-toto statement")
+	}
+
+	predicate, err := json.Marshal(result.Statement.GetPredicate().AsMap())
+	if err != nil {
+		return fmt.Errorf("failed to encode predicate: %w", err)
+	}
+	err = json.Unmarshal(predicate, out)
+	if err != nil {
+		return fmt.Errorf("failed to decode predicate: %w", err)
+	}
+
+	return nil
 }
 
 // VerifyTransparencyLogInclusion verifies TlogEntries if expected. Optionally returns
@@ -611,22 +1652,60 @@ func (v *SignedEntityVerifier) Verify(entity SignedEntity, pb PolicyBuilder) (*V
 // with observer timestamps.
 // TODO: Return a different verification result for logs specifically (also for #48)
 func (v *SignedEntityVerifier) VerifyTransparencyLogInclusion(entity SignedEntity) ([]TimestampVerificationResult, error) {
+	verifiedTimestamps, _, err := v.verifyTransparencyLogInclusion(entity)
+	return verifiedTimestamps, err
+}
+
+// verifyTransparencyLogInclusion is VerifyTransparencyLogInclusion, also
+// returning the log entries that were verified.
+func (v *SignedEntityVerifier) verifyTransparencyLogInclusion(entity SignedEntity) ([]TimestampVerificationResult, []TlogEntryInfo, error) {
 	verifiedTimestamps := []TimestampVerificationResult{}
+	var verifiedEntries []TlogEntryInfo
 
 	if v.config.weExpectTlogEntries {
+		entity, err := v.withProvidedInclusionProofs(entity)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		// log timestamps should be verified if with WithIntegratedTimestamps or WithObserverTimestamps is used
-		verifiedTlogTimestamps, err := VerifyArtifactTransparencyLog(entity, v.trustedMaterial, v.config.tlogEntriesThreshold,
-			v.config.requireIntegratedTimestamps || v.config.requireObserverTimestamps, v.config.performOnlineVerification)
+		verifiedTlogTimestamps, entries, err := verifyArtifactTransparencyLog(entity, v.trustedMaterial, v.config.tlogEntriesThreshold,
+			v.config.requireIntegratedTimestamps || v.config.requireObserverTimestamps, v.config.performOnlineVerification, v.config.trustCheckpointTimestamps, v.config.checkpointMaxAge, v.config.clock)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		verifiedEntries = entries
 
 		for _, vts := range verifiedTlogTimestamps {
 			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "Tlog", URI: "TODO", Timestamp: vts})
 		}
 	}
 
-	return verifiedTimestamps, nil
+	return verifiedTimestamps, verifiedEntries, nil
+}
+
+// verifyCTInclusionProof verifies that the CT logs included the leaf
+// certificate, with the proofs the entity provides unless verifying online.
+func (v *SignedEntityVerifier) verifyCTInclusionProof(entity SignedEntity, leafCert *x509.Certificate) error {
+	var proofs []*CTInclusionProof
+	if provider, ok := entity.(CTInclusionProofProvider); ok && !v.config.performOnlineVerification {
+		var err error
+		proofs, err = provider.CTInclusionProofs()
+		if err != nil {
+			return fmt.Errorf("failed to fetch CT inclusion proofs: %w", err)
+		}
+	}
+	return VerifyCTInclusionProof(leafCert, proofs, v.config.ctlogEntriesThreshold, v.trustedMaterial, v.config.performOnlineVerification)
+}
+
+// verifyTimestampAuthority verifies the entity's signed timestamps against the
+// timestamp authorities prepared when the verifier was created.
+func (v *SignedEntityVerifier) verifyTimestampAuthority(entity SignedEntity) ([]verifiedSignedTimestamp, []error, error) {
+	authorities, hit := v.timestampAuthorities.get(v.trustedMaterial)
+	if hook, ok := v.config.metrics.(CacheMetricsHook); ok {
+		hook.ObserveCacheLookup(CacheTimestampAuthorities, hit)
+	}
+	return verifyTimestampAuthority(entity, authorities, v.trustedMaterial, v.config.alternateTimestampedContents)
 }
 
 // VerifyObserverTimestamps verifies RFC3161 signed timestamps, and verifies
@@ -636,46 +1715,59 @@ func (v *SignedEntityVerifier) VerifyTransparencyLogInclusion(entity SignedEntit
 // logTimestamps may be populated with verified log entry integrated timestamps
 // In order to be verifiable, a SignedEntity must have at least one verified
 // "observer timestamp".
+//
+// When both signed timestamps and log entry integrated timestamps are
+// present, every one of them is returned, and Verify requires all of them to
+// fall within the validity period of the signing certificate. The earliest
+// returned timestamp is used as the entity's signing time. With
+// WithDistinctTimestampAuthorities, signed timestamps are counted once per
+// timestamp authority, while each log timestamp counts once. Signed
+// timestamps over the message digest are returned, but aren't counted and
+// aren't used to verify the certificate; see TimestampedDigest.
 func (v *SignedEntityVerifier) VerifyObserverTimestamps(entity SignedEntity, logTimestamps []TimestampVerificationResult) ([]TimestampVerificationResult, error) {
 	verifiedTimestamps := []TimestampVerificationResult{}
 
 	// From spec:
 	// > … if verification or timestamp parsing fails, the Verifier MUST abort
 	if v.config.weExpectSignedTimestamps {
-		verifiedSignedTimestamps, err := VerifyTimestampAuthorityWithThreshold(entity, v.trustedMaterial, v.config.signedTimestampThreshold)
+		verifiedSignedTimestamps, rejected, err := v.verifyTimestampAuthority(entity)
 		if err != nil {
 			return nil, err
 		}
-		for _, vts := range verifiedSignedTimestamps {
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "TimestampAuthority", URI: "TODO", Timestamp: vts})
+		tsCount := v.countSignedTimestamps(verifiedSignedTimestamps)
+		if tsCount < v.config.signedTimestampThreshold {
+			errs := append([]error{fmt.Errorf("%w for verified signed timestamps: %d < %d", ErrThresholdNotMet, tsCount, v.config.signedTimestampThreshold)}, rejected...)
+			if tsCount == 0 && len(logTimestamps) == 0 {
+				errs = append([]error{ErrNoTrustedTimeSource}, errs...)
+			}
+			return nil, errors.Join(errs...)
 		}
+		verifiedTimestamps = append(verifiedTimestamps, signedTimestampResults(verifiedSignedTimestamps)...)
 	}
 
 	if v.config.requireIntegratedTimestamps {
 		if len(logTimestamps) < v.config.integratedTimeThreshold {
-			return nil, fmt.Errorf("threshold not met for verified log entry integrated timestamps: %d < %d", len(logTimestamps), v.config.integratedTimeThreshold)
+			return nil, fmt.Errorf("%w for verified log entry integrated timestamps: %d < %d", ErrThresholdNotMet, len(logTimestamps), v.config.integratedTimeThreshold)
 		}
 		verifiedTimestamps = append(verifiedTimestamps, logTimestamps...)
 	}
 
 	if v.config.requireObserverTimestamps {
-		verifiedSignedTimestamps, err := VerifyTimestampAuthority(entity, v.trustedMaterial)
+		verifiedSignedTimestamps, rejected, err := v.verifyTimestampAuthority(entity)
 		if err != nil {
 			return nil, err
 		}
 
 		// check threshold for both RFC3161 and log timestamps
-		tsCount := len(verifiedSignedTimestamps) + len(logTimestamps)
+		tsCount := v.countSignedTimestamps(verifiedSignedTimestamps) + len(logTimestamps)
 		if tsCount < v.config.observerTimestampThreshold {
-			return nil, fmt.Errorf("threshold not met for verified signed & log entry integrated timestamps: %d < %d",
-				tsCount, v.config.observerTimestampThreshold)
+			return nil, errors.Join(append([]error{fmt.Errorf("%w for verified signed & log entry integrated timestamps: %d < %d",
+				ErrThresholdNotMet, tsCount, v.config.observerTimestampThreshold)}, rejected...)...)
 		}
 
 		// append all timestamps
 		verifiedTimestamps = append(verifiedTimestamps, logTimestamps...)
-		for _, vts := range verifiedSignedTimestamps {
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "TimestampAuthority", URI: "TODO", Timestamp: vts})
-		}
+		verifiedTimestamps = append(verifiedTimestamps, signedTimestampResults(verifiedSignedTimestamps)...)
 	}
 
 	if v.config.weDoNotExpectAnyObserverTimestamps {
@@ -689,13 +1781,149 @@ func (v *SignedEntityVerifier) VerifyObserverTimestamps(entity SignedEntity, log
 			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "LeafCert.NotBefore", URI: "", Timestamp: leafCert.NotBefore})
 		} else {
 			// no cert? use current time
-			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "CurrentTime", URI: "", Timestamp: time.Now()})
+			verifiedTimestamps = append(verifiedTimestamps, TimestampVerificationResult{Type: "CurrentTime", URI: "", Timestamp: v.config.clock.Now()})
 		}
 	}
 
 	if len(verifiedTimestamps) == 0 {
-		return nil, fmt.Errorf("no valid observer timestamps found")
+		return nil, fmt.Errorf("%w: no valid observer timestamps found", ErrThresholdNotMet)
+	}
+
+	if v.config.crossCheckTimestamps {
+		err := crossCheckTimestamps(verifiedTimestamps, v.config.maxTimestampSkew)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return verifiedTimestamps, nil
 }
+
+// verifyTimestampWithinCertValidity checks that at least one of the verified
+// signed or log timestamps is within the leaf certificate's validity period.
+// A signed timestamp with an accuracy must be within it at both ends of its
+// window.
+func verifyTimestampWithinCertValidity(verifiedTimestamps []TimestampVerificationResult, leafCert *x509.Certificate) error {
+	for _, verifiedTs := range verifiedTimestamps {
+		if (verifiedTs.Type != "TimestampAuthority" && verifiedTs.Type != "Tlog") || !verifiedTs.evidencesSigning() {
+			continue
+		}
+		within := true
+		for _, observerTime := range verifiedTs.validityWindow() {
+			if observerTime.Before(leafCert.NotBefore) || observerTime.After(leafCert.NotAfter) {
+				within = false
+			}
+		}
+		if within {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: no verified timestamp is between %s and %s", ErrTimestampOutsideCertValidity, leafCert.NotBefore, leafCert.NotAfter)
+}
+
+// crossCheckTimestamps checks that every signed timestamp is within maxSkew
+// of every log integrated timestamp.
+func crossCheckTimestamps(verifiedTimestamps []TimestampVerificationResult, maxSkew time.Duration) error {
+	for _, signed := range verifiedTimestamps {
+		if signed.Type != "TimestampAuthority" || !signed.evidencesSigning() {
+			continue
+		}
+		for _, logged := range verifiedTimestamps {
+			if logged.Type != "Tlog" {
+				continue
+			}
+			skew := signed.Timestamp.Sub(logged.Timestamp)
+			if skew < 0 {
+				skew = -skew
+			}
+			if skew > maxSkew {
+				return fmt.Errorf("signed timestamp %s and log integrated timestamp %s differ by %s, more than the allowed %s",
+					signed.Timestamp.Format(time.RFC3339), logged.Timestamp.Format(time.RFC3339), skew, maxSkew)
+			}
+		}
+	}
+	return nil
+}
+
+// countSignedTimestamps returns how many of the verified signed timestamps
+// count towards a timestamp threshold.
+func (v *SignedEntityVerifier) countSignedTimestamps(verifiedSignedTimestamps []verifiedSignedTimestamp) int {
+	counted := make([]verifiedSignedTimestamp, 0, len(verifiedSignedTimestamps))
+	for _, vts := range verifiedSignedTimestamps {
+		if vts.Info.TimestampedContent != TimestampedDigest {
+			counted = append(counted, vts)
+		}
+	}
+	if v.config.requireDistinctTimestampAuthorities {
+		return countDistinctTimestampAuthorities(counted)
+	}
+	return len(counted)
+}
+
+// earliestTimestamp returns the earliest of the given verified timestamps
+// that show when the entity was signed.
+func earliestTimestamp(verifiedTimestamps []TimestampVerificationResult) time.Time {
+	var earliest time.Time
+	for _, vts := range verifiedTimestamps {
+		if !vts.evidencesSigning() {
+			continue
+		}
+		if earliest.IsZero() || vts.Timestamp.Before(earliest) {
+			earliest = vts.Timestamp
+		}
+	}
+	return earliest
+}
+
+func signedTimestampResults(verifiedSignedTimestamps []verifiedSignedTimestamp) []TimestampVerificationResult {
+	results := make([]TimestampVerificationResult, len(verifiedSignedTimestamps))
+	for i, vts := range verifiedSignedTimestamps {
+		info := vts.Info
+		results[i] = TimestampVerificationResult{Type: "TimestampAuthority", URI: "TODO", Timestamp: vts.Time, TimestampInfo: &info}
+	}
+	return results
+}
+
+// memoizedEntity is a SignedEntity whose verification content, signature
+// content and log entries are fetched from the wrapped entity once, and then
+// returned again, errors included. It is used for the duration of a single
+// verification, and isn't safe for concurrent use.
+type memoizedEntity struct {
+	SignedEntity
+
+	verificationContent     VerificationContent
+	verificationContentErr  error
+	haveVerificationContent bool
+
+	sigContent     SignatureContent
+	sigContentErr  error
+	haveSigContent bool
+
+	tlogEntries     []*tlog.Entry
+	tlogEntriesErr  error
+	haveTlogEntries bool
+}
+
+func (e *memoizedEntity) VerificationContent() (VerificationContent, error) {
+	if !e.haveVerificationContent {
+		e.verificationContent, e.verificationContentErr = e.SignedEntity.VerificationContent()
+		e.haveVerificationContent = true
+	}
+	return e.verificationContent, e.verificationContentErr
+}
+
+func (e *memoizedEntity) SignatureContent() (SignatureContent, error) {
+	if !e.haveSigContent {
+		e.sigContent, e.sigContentErr = e.SignedEntity.SignatureContent()
+		e.haveSigContent = true
+	}
+	return e.sigContent, e.sigContentErr
+}
+
+func (e *memoizedEntity) TlogEntries() ([]*tlog.Entry, error) {
+	if !e.haveTlogEntries {
+		e.tlogEntries, e.tlogEntriesErr = e.SignedEntity.TlogEntries()
+		e.haveTlogEntries = true
+	}
+	return e.tlogEntries, e.tlogEntriesErr
+}
@@ -15,16 +15,31 @@
 package verify_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"log/slog"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unicode"
 
 	"encoding/hex"
 	"encoding/json"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/testing/data"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestSignedEntityVerifierInitialization(t *testing.T) {
@@ -52,6 +67,103 @@ func TestSignedEntityVerifierInitialization(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSignedEntityVerifierOptionConflicts(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+
+	for _, tt := range []struct {
+		name    string
+		options []verify.VerifierOption
+		wantErr bool
+	}{
+		{
+			name:    "integrated timestamp threshold of 0",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(0)},
+			wantErr: true,
+		},
+		{
+			name:    "observer timestamp threshold of 0",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithObserverTimestamps(0)},
+			wantErr: true,
+		},
+		{
+			name:    "SCT threshold of 0",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithSignedCertificateTimestamps(0)},
+			wantErr: true,
+		},
+		{
+			name:    "checkpoint freshness without transparency log",
+			options: []verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithCheckpointFreshness(time.Hour)},
+			wantErr: true,
+		},
+		{
+			name:    "checkpoint freshness window of 0",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(0)},
+			wantErr: true,
+		},
+		{
+			name:    "distinct timestamp authorities with signed timestamps",
+			options: []verify.VerifierOption{verify.WithSignedTimestamps(2), verify.WithDistinctTimestampAuthorities()},
+		},
+		{
+			name:    "distinct timestamp authorities without signed timestamps",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithDistinctTimestampAuthorities()},
+			wantErr: true,
+		},
+		{
+			name:    "alternate timestamped content with observer timestamps",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1), verify.WithAlternateTimestampedContent(verify.TimestampedEnvelope)},
+		},
+		{
+			name:    "alternate timestamped content without signed timestamps",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithAlternateTimestampedContent(verify.TimestampedEnvelope)},
+			wantErr: true,
+		},
+		{
+			name:    "timestamp cross check",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithTimestampCrossCheck(verify.DefaultTimestampClockSkew)},
+		},
+		{
+			name:    "timestamp cross check without signed timestamps",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithTimestampCrossCheck(verify.DefaultTimestampClockSkew)},
+			wantErr: true,
+		},
+		{
+			name:    "timestamp cross check without transparency log",
+			options: []verify.VerifierOption{verify.WithoutTransparencyLog(), verify.WithSignedTimestamps(1), verify.WithTimestampCrossCheck(verify.DefaultTimestampClockSkew)},
+			wantErr: true,
+		},
+		{
+			name:    "timestamp within certificate validity without timestamps",
+			options: []verify.VerifierOption{verify.WithoutAnyObserverTimestampsInsecure(), verify.WithTimestampWithinCertValidity()},
+			wantErr: true,
+		},
+		{
+			name:    "BYO certificates with SCTs",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithBYOCertificateVerification(), verify.WithSignedCertificateTimestamps(1)},
+			wantErr: true,
+		},
+		{
+			name:    "without SCT verification with SCTs",
+			options: []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithoutSCTVerification(), verify.WithSignedCertificateTimestamps(1)},
+			wantErr: true,
+		},
+		{
+			name:    "without transparency log with transparency log",
+			options: []verify.VerifierOption{verify.WithoutTransparencyLog(), verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1)},
+			wantErr: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verify.NewSignedEntityVerifier(tr, tt.options...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestSignedEntityVerifierInitRequiresTimestamp(t *testing.T) {
 	tr := data.PublicGoodTrustedMaterialRoot(t)
 
@@ -102,6 +214,84 @@ func TestEntitySignedByPublicGoodWithTlogVerifiesSuccessfully(t *testing.T) {
 	assert.NotNil(t, res)
 }
 
+func TestCheckpointFreshness(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	// the bundle's inclusion proof is backed by a checkpoint from August 2023
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+
+	v, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(24*time.Hour))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, verify.ErrStaleCheckpoint)
+
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(time.Since(time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC))))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+}
+
+// fixedClock is a verify.Clock stopped at a point in time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestClock(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+	entries, err := entity.TlogEntries()
+	assert.NoError(t, err)
+	signedAt := entries[0].IntegratedTime()
+
+	// as of when it was signed, the bundle's checkpoint was fresh
+	v, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(24*time.Hour), verify.WithClock(fixedClock(signedAt)))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	v, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointFreshness(24*time.Hour), verify.WithClock(fixedClock(signedAt.AddDate(0, 0, 2))))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, verify.ErrStaleCheckpoint)
+
+	_, err = verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithClock(nil))
+	assert.Error(t, err)
+}
+
+func TestCheckpointTimestamps(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	// like an entry from a tile-based log, with no SET for an integrated
+	// time, only a checkpoint signed with the time
+	entity, err = virtualSigstore.ProveInclusion(entity)
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithCheckpointTimestamps())
+	assert.NoError(t, err)
+	res, err := v.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+	entries, err := entity.TlogEntries()
+	assert.NoError(t, err)
+	checkpointTime, ok, err := entries[0].CheckpointTime()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	if assert.Len(t, res.VerifiedTimestamps, 1) {
+		assert.True(t, checkpointTime.Equal(res.VerifiedTimestamps[0].Timestamp))
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithCheckpointTimestamps())
+	assert.Error(t, err)
+}
+
 func TestEntitySignedByPublicGoodWithoutTimestampsVerifiesSuccessfully(t *testing.T) {
 	tr := data.PublicGoodTrustedMaterialRoot(t)
 	entity := data.SigstoreJS200ProvenanceBundle(t)
@@ -114,6 +304,49 @@ func TestEntitySignedByPublicGoodWithoutTimestampsVerifiesSuccessfully(t *testin
 	assert.NotNil(t, res)
 }
 
+// minimalEntity carries only a certificate and a signature, with no
+// transparency log entries or signed timestamps.
+type minimalEntity struct {
+	*ca.TestEntity
+}
+
+func (e *minimalEntity) Timestamps() ([][]byte, error) {
+	return nil, nil
+}
+
+func (e *minimalEntity) TlogEntries() ([]*tlog.Entry, error) {
+	return nil, nil
+}
+
+func TestMinimalEntityWithCertificateOnly(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	testEntity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	entity := &minimalEntity{testEntity}
+
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithoutAnyObserverTimestampsInsecure())
+	assert.NoError(t, err)
+	res, err := v.Verify(entity, policy)
+	assert.NoError(t, err)
+	assert.False(t, res.TransparencyLogVerified)
+
+	// the same entity with its log entry does report it
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	res, err = v.Verify(testEntity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+	assert.True(t, res.TransparencyLogVerified)
+
+	// the usual configuration still requires transparency
+	_, err = v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.Error(t, err)
+}
+
 func TestEntitySignedByPublicGoodWithHighTlogThresholdFails(t *testing.T) {
 	tr := data.PublicGoodTrustedMaterialRoot(t)
 	entity := data.SigstoreJS200ProvenanceBundle(t)
@@ -278,6 +511,25 @@ func TestVerifyPolicyOptionErors(t *testing.T) {
 
 	_, err = verifier.Verify(entity, badIdentityPolicyCombo)
 	assert.NotNil(t, err)
+
+	// 5. an artifact policy must be given, even if it's WithoutArtifactUnsafe
+	_, err = verify.NewPolicy(nil, verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
+
+	// 6. artifact policies reject empty arguments
+	_, err = verify.NewPolicy(verify.WithArtifactDigest("sha512", nil), verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
+	_, err = verify.NewPolicy(verify.WithArtifactDigest("", digest), verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
+	_, err = verify.NewPolicy(verify.WithArtifact(nil), verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
+
+	// 7. can't combine the OCI image subjects with other artifact options
+	ociDigest := "sha256:" + strings.Repeat("00", 32)
+	_, err = verify.NewPolicy(verify.WithOCIImageSubjects(ociDigest), verify.PolicyOption(verify.WithArtifactDigest("sha512", digest)), verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
+	_, err = verify.NewPolicy(verify.WithOCIImageSubjects(ociDigest), verify.PolicyOption(verify.WithoutArtifactUnsafe()), verify.WithCertificateIdentity(goodCertID)).BuildConfig()
+	assert.NotNil(t, err)
 }
 
 func TestEntitySignedByPublicGoodWithCertificateIdentityVerifiesSuccessfully(t *testing.T) {
@@ -322,6 +574,47 @@ func TestEntitySignedByPublicGoodWithCertificateIdentityVerifiesSuccessfully(t *
 	assert.Nil(t, res)
 }
 
+func TestUsernameCertificateIdentity(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	// Fulcio issues username identities in a critical otherName SAN
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo!example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	username, err := verify.NewShortCertificateIdentity("issuer", "foo!example.com", "Other", "")
+	assert.NoError(t, err)
+	res, err := verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(username)))
+	assert.NoError(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, certificate.SubjectAlternativeNameTypeOther, res.Signature.Certificate.SubjectAlternativeName.Type)
+		assert.Equal(t, "foo!example.com", res.Signature.Certificate.SubjectAlternativeName.Value)
+		assert.Equal(t, certificate.OIDOtherName.String(), res.Signature.Certificate.SubjectAlternativeName.OID)
+	}
+
+	sanMatcher, err := verify.NewOtherNameSANMatcher(certificate.OIDOtherName, "", "^foo!")
+	assert.NoError(t, err)
+	usernameRegexp, err := verify.NewCertificateIdentity(sanMatcher, certificate.Extensions{Issuer: "issuer"})
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(usernameRegexp)))
+	assert.NoError(t, err)
+
+	// the username isn't an email address, nor another user's name
+	for _, identity := range []struct{ san, sanType string }{
+		{san: "foo!example.com", sanType: "Email"},
+		{san: "bar!example.com", sanType: "Other"},
+	} {
+		certID, err := verify.NewShortCertificateIdentity("issuer", identity.san, identity.sanType, "")
+		assert.NoError(t, err)
+		_, err = verifier.Verify(entity, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(certID)))
+		assert.ErrorIs(t, err, verify.ErrIdentityMismatch)
+	}
+}
+
 // TODO test bundles:
 // - signed with a key, not a fulcio cert, i.e. npm
 // - with duplicate tlog entries
@@ -352,6 +645,35 @@ func TestThatAllTheJSONKeysStartWithALowerCase(t *testing.T) {
 	ensureKeysBeginWithLowercase(t, unmarshaledJSON)
 }
 
+func TestVerificationResultStatementJSON(t *testing.T) {
+	tr := data.PublicGoodTrustedMaterialRoot(t)
+	entity := data.SigstoreJS200ProvenanceBundle(t)
+
+	verifier, err := verify.NewSignedEntityVerifier(tr, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+
+	res, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	rawJSON, err := json.Marshal(res)
+	assert.NoError(t, err)
+
+	// the statement is encoded as the in-toto statement the envelope carries
+	var encoded struct {
+		Statement map[string]any `json:"statement"`
+	}
+	assert.NoError(t, json.Unmarshal(rawJSON, &encoded))
+	assert.Equal(t, "https://in-toto.io/Statement/v1", encoded.Statement["_type"])
+	assert.Equal(t, "https://slsa.dev/provenance/v1", encoded.Statement["predicateType"])
+	assert.NotEmpty(t, encoded.Statement["subject"])
+	assert.NotEmpty(t, encoded.Statement["predicate"])
+
+	var decoded verify.VerificationResult
+	assert.NoError(t, json.Unmarshal(rawJSON, &decoded))
+	assert.True(t, proto.Equal(res.Statement, decoded.Statement))
+	assert.Equal(t, res.MediaType, decoded.MediaType)
+}
+
 func ensureKeysBeginWithLowercase(t *testing.T, obj interface{}) {
 	switch v := obj.(type) {
 	case map[string]interface{}:
@@ -367,3 +689,689 @@ func ensureKeysBeginWithLowercase(t *testing.T, obj interface{}) {
 		}
 	}
 }
+
+func TestVerifyAndExtractPredicate(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{"foo":"bar","count":3,"tags":["a","b"]}}`)
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+
+	type customFoo struct {
+		Foo   string   `json:"foo"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+	var predicate customFoo
+	err = verifier.VerifyAndExtractPredicate(entity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()), &predicate)
+	assert.NoError(t, err)
+	assert.Equal(t, customFoo{Foo: "bar", Count: 3, Tags: []string{"a", "b"}}, predicate)
+
+	// out must be a pointer
+	err = verifier.VerifyAndExtractPredicate(entity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()), predicate)
+	assert.Error(t, err)
+
+	// the predicate must decode into out
+	var wrongType struct {
+		Foo int `json:"foo"`
+	}
+	err = verifier.VerifyAndExtractPredicate(entity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()), &wrongType)
+	assert.Error(t, err)
+
+	// a message signature has no statement
+	messageSignatureEntity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	err = verifier.VerifyAndExtractPredicate(messageSignatureEntity, verify.NewPolicy(verify.WithArtifact(strings.NewReader("artifact")), verify.WithoutIdentitiesUnsafe()), &predicate)
+	assert.ErrorContains(t, err, "no in-toto statement")
+
+	// verification failures are returned before decoding
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrustedEntity, err := otherSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	err = verifier.VerifyAndExtractPredicate(untrustedEntity, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()), &predicate)
+	assert.Error(t, err)
+}
+
+// expiredRekorLog is a virtual Sigstore whose log key expired before the
+// entries it logged were integrated.
+type expiredRekorLog struct {
+	*ca.VirtualSigstore
+}
+
+func (e *expiredRekorLog) RekorLogs() map[string]*root.TransparencyLog {
+	logs := e.VirtualSigstore.RekorLogs()
+	for _, transparencyLog := range logs {
+		transparencyLog.ValidityPeriodEnd = time.Now()
+	}
+	return logs
+}
+
+func TestVerifyErrorTaxonomy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "artifact"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	otherLogEntity, err := otherSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	otherIdentity, err := verify.NewShortCertificateIdentity("issuer", "bar@example.com", "", "")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		entity          verify.SignedEntity
+		trustedMaterial root.TrustedMaterial
+		options         []verify.VerifierOption
+		policyOptions   []verify.PolicyOption
+		wantErrs        []error
+		wantKind        string
+	}{
+		{
+			name:            "unknown log",
+			entity:          otherLogEntity,
+			trustedMaterial: virtualSigstore,
+			wantErrs:        []error{verify.ErrThresholdNotMet, root.ErrUnknownLog},
+			wantKind:        verify.ErrorKindUnknownLog,
+		},
+		{
+			name:            "expired log key",
+			entity:          entity,
+			trustedMaterial: &expiredRekorLog{virtualSigstore},
+			wantErrs:        []error{verify.ErrThresholdNotMet, root.ErrExpiredTrustMaterial},
+			wantKind:        verify.ErrorKindExpiredTrustMaterial,
+		},
+		{
+			name:            "log threshold not met",
+			entity:          entity,
+			trustedMaterial: virtualSigstore,
+			options:         []verify.VerifierOption{verify.WithTransparencyLog(2), verify.WithIntegratedTimestamps(1)},
+			wantErrs:        []error{verify.ErrThresholdNotMet},
+			wantKind:        verify.ErrorKindThresholdNotMet,
+		},
+		{
+			name:            "SCT threshold not met",
+			entity:          entity,
+			trustedMaterial: virtualSigstore,
+			options:         []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithSignedCertificateTimestamps(2)},
+			wantErrs:        []error{verify.ErrThresholdNotMet, verify.ErrInvalidSCT},
+			wantKind:        verify.ErrorKindSCT,
+		},
+		{
+			name:            "identity mismatch",
+			entity:          entity,
+			trustedMaterial: virtualSigstore,
+			policyOptions:   []verify.PolicyOption{verify.WithCertificateIdentity(otherIdentity)},
+			wantErrs:        []error{verify.ErrIdentityMismatch},
+			wantKind:        verify.ErrorKindIdentityMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			if options == nil {
+				options = []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1)}
+			}
+			metrics := &recordingMetricsHook{}
+			verifier, err := verify.NewSignedEntityVerifier(tt.trustedMaterial, append(options, verify.WithMetricsHook(metrics))...)
+			assert.NoError(t, err)
+
+			policyOptions := tt.policyOptions
+			if policyOptions == nil {
+				policyOptions = []verify.PolicyOption{verify.WithoutIdentitiesUnsafe()}
+			}
+			_, err = verifier.Verify(tt.entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), policyOptions...))
+			for _, wantErr := range tt.wantErrs {
+				assert.ErrorIs(t, err, wantErr)
+			}
+			assert.Equal(t, []string{tt.wantKind}, metrics.errors)
+		})
+	}
+}
+
+// ctOnlyTrustedRoot returns a trusted root with the virtual Sigstore's
+// Fulcio and timestamp authorities and the CT log of ctlogSigstore, but no
+// transparency logs.
+func ctOnlyTrustedRoot(t *testing.T, virtualSigstore, ctlogSigstore *ca.VirtualSigstore) *root.TrustedRoot {
+	certChain := func(certs ...*x509.Certificate) *protocommon.X509CertificateChain {
+		chain := &protocommon.X509CertificateChain{}
+		for _, cert := range certs {
+			if cert != nil {
+				chain.Certificates = append(chain.Certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+			}
+		}
+		return chain
+	}
+
+	fulcioCA := virtualSigstore.FulcioCertificateAuthorities()[0]
+	tsaCA := virtualSigstore.TimestampingAuthorities()[0]
+	pbTrustedRoot := &prototrustroot.TrustedRoot{
+		MediaType: root.TrustedRootMediaType01,
+		CertificateAuthorities: []*prototrustroot.CertificateAuthority{{
+			CertChain: certChain(append([]*x509.Certificate{fulcioCA.Root}, fulcioCA.Intermediates...)...),
+		}},
+		TimestampAuthorities: []*prototrustroot.CertificateAuthority{{
+			CertChain: certChain(append([]*x509.Certificate{tsaCA.Leaf, tsaCA.Root}, tsaCA.Intermediates...)...),
+		}},
+	}
+	for logID, ctlog := range ctlogSigstore.CTLogs() {
+		keyID, err := hex.DecodeString(logID)
+		assert.NoError(t, err)
+		pubBytes, err := x509.MarshalPKIXPublicKey(ctlog.PublicKey)
+		assert.NoError(t, err)
+		pbTrustedRoot.Ctlogs = append(pbTrustedRoot.Ctlogs, &prototrustroot.TransparencyLogInstance{
+			BaseUrl:       "https://ctfe.example.com",
+			HashAlgorithm: protocommon.HashAlgorithm_SHA2_256,
+			PublicKey: &protocommon.PublicKey{
+				RawBytes:   pubBytes,
+				KeyDetails: protocommon.PublicKeyDetails_PKIX_ECDSA_P256_SHA_256,
+				ValidFor:   &protocommon.TimeRange{Start: timestamppb.New(time.Now().Add(-time.Hour))},
+			},
+			LogId: &protocommon.LogId{KeyId: keyID},
+		})
+	}
+
+	trustedRoot, err := root.NewTrustedRootFromProtobuf(pbTrustedRoot)
+	assert.NoError(t, err)
+	return trustedRoot
+}
+
+func TestCTOnlyTrustedRoot(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	trustedRoot := ctOnlyTrustedRoot(t, virtualSigstore, virtualSigstore)
+	assert.Empty(t, trustedRoot.RekorLogs())
+	assert.Len(t, trustedRoot.CTLogs(), 1)
+
+	artifact := "artifact"
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	policy := func() verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	}
+
+	// the entity's log entries are not checked, but its SCT is
+	verifier, err := verify.NewSignedEntityVerifier(trustedRoot, verify.WithoutTransparencyLog(), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	res, err := verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+	assert.False(t, res.TransparencyLogVerified)
+	assert.Empty(t, res.VerifiedTlogEntries)
+
+	// or without saying so explicitly
+	verifier, err = verify.NewSignedEntityVerifier(trustedRoot, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	assert.NoError(t, err)
+
+	// an SCT from a CT log that isn't trusted fails
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	verifier, err = verify.NewSignedEntityVerifier(ctOnlyTrustedRoot(t, virtualSigstore, otherSigstore), verify.WithoutTransparencyLog(), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy())
+	assert.ErrorIs(t, err, verify.ErrInvalidSCT)
+
+	// log entries can't be required of a trusted root without logs
+	_, err = verify.NewSignedEntityVerifier(trustedRoot, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.Error(t, err)
+}
+
+// TestConcurrentVerify shares one verifier and one set of policies between
+// many goroutines verifying valid and tampered entities. Run it with -race.
+func TestConcurrentVerify(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+
+	signed, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	attested, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	otherIdentity, err := virtualSigstore.Sign("bar@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	otherArtifact, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("other artifact"))
+	assert.NoError(t, err)
+	untrusted, err := otherSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	assert.NoError(t, err)
+	digestPolicy := verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithCertificateIdentity(identity))
+	statementPolicy := verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithCertificateIdentity(identity))
+
+	publicGoodVerifier, err := verify.NewSignedEntityVerifier(data.PublicGoodTrustedMaterialRoot(t), verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+	assert.NoError(t, err)
+	publicGoodBundle := data.SigstoreJS200ProvenanceBundle(t)
+
+	tests := []struct {
+		name     string
+		verify   func() (*verify.VerificationResult, error)
+		expectOK bool
+	}{
+		{
+			name:     "signed artifact",
+			verify:   func() (*verify.VerificationResult, error) { return verifier.Verify(signed, digestPolicy) },
+			expectOK: true,
+		},
+		{
+			name: "signed artifact read from a reader",
+			verify: func() (*verify.VerificationResult, error) {
+				// WithArtifact consumes its reader, so its policy is created per call
+				return verifier.Verify(signed, verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity)))
+			},
+			expectOK: true,
+		},
+		{
+			name:     "attestation",
+			verify:   func() (*verify.VerificationResult, error) { return verifier.Verify(attested, statementPolicy) },
+			expectOK: true,
+		},
+		{
+			name:   "other identity",
+			verify: func() (*verify.VerificationResult, error) { return verifier.Verify(otherIdentity, digestPolicy) },
+		},
+		{
+			name:   "other artifact",
+			verify: func() (*verify.VerificationResult, error) { return verifier.Verify(otherArtifact, digestPolicy) },
+		},
+		{
+			name:   "untrusted signer",
+			verify: func() (*verify.VerificationResult, error) { return verifier.Verify(untrusted, digestPolicy) },
+		},
+		{
+			name: "public good bundle",
+			verify: func() (*verify.VerificationResult, error) {
+				return publicGoodVerifier.Verify(publicGoodBundle, SkipArtifactAndIdentitiesPolicy)
+			},
+			expectOK: true,
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		for _, test := range tests {
+			wg.Add(1)
+			go func(name string, verifyEntity func() (*verify.VerificationResult, error), expectOK bool) {
+				defer wg.Done()
+				for j := 0; j < 4; j++ {
+					result, err := verifyEntity()
+					if expectOK {
+						assert.NoError(t, err, name)
+						assert.NotNil(t, result, name)
+					} else {
+						assert.Error(t, err, name)
+					}
+				}
+			}(test.name, test.verify, test.expectOK)
+		}
+	}
+	wg.Wait()
+}
+
+// loggedRecords decodes the records written by a slog JSON handler.
+func loggedRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	decoder := json.NewDecoder(buf)
+	for decoder.More() {
+		var record map[string]any
+		assert.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func loggedMessages(records []map[string]any) []string {
+	messages := make([]string, len(records))
+	for i, record := range records {
+		messages[i], _ = record[slog.MessageKey].(string)
+	}
+	return messages
+}
+
+func TestVerifierLogger(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithLogger(nil))
+	assert.Error(t, err)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithLogger(logger))
+	assert.NoError(t, err)
+
+	policy := func(san string) verify.PolicyBuilder {
+		identity, err := verify.NewShortCertificateIdentity("issuer", san, "", "")
+		assert.NoError(t, err)
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity))
+	}
+
+	// a successful verification logs each phase
+	_, err = verifier.Verify(entity, policy("foo@example.com"))
+	assert.NoError(t, err)
+	records := loggedRecords(t, &buf)
+	assert.Equal(t, []string{
+		"trusted material selected",
+		"transparency log entry verified",
+		"transparency log inclusion verified",
+		"observer timestamps verified",
+		"certificate chain verified",
+		"signed certificate timestamps verified",
+		"signature verified",
+		"certificate identity verified",
+		"verification succeeded",
+	}, loggedMessages(records))
+	for _, record := range records {
+		assert.Equal(t, "DEBUG", record[slog.LevelKey])
+	}
+	assert.Equal(t, float64(1000), records[1]["log_index"])
+	assert.Equal(t, "foo@example.com", records[7]["san"])
+	assert.Contains(t, records[8], "duration")
+
+	// a failing policy logs the phases that passed, then the failure
+	_, err = verifier.Verify(entity, policy("bar@example.com"))
+	assert.Error(t, err)
+	records = loggedRecords(t, &buf)
+	messages := loggedMessages(records)
+	assert.Contains(t, messages, "signature verified")
+	assert.NotContains(t, messages, "certificate identity verified")
+	assert.Equal(t, "verification failed", messages[len(messages)-1])
+	assert.Contains(t, records[len(records)-1]["error"], "failed to verify certificate identity")
+
+	// as does an entity from untrusted infrastructure
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrusted, err := otherSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(untrusted, policy("foo@example.com"))
+	assert.Error(t, err)
+	assert.Equal(t, []string{"trusted material selected", "verification failed"}, loggedMessages(loggedRecords(t, &buf)))
+}
+
+// recordingMetricsHook records the metrics reported to it.
+type recordingMetricsHook struct {
+	mu            sync.Mutex
+	verifications []string
+	phases        []string
+	errors        []string
+	cacheLookups  []string
+}
+
+func (h *recordingMetricsHook) ObserveVerification(outcome string, _ time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.verifications = append(h.verifications, outcome)
+}
+
+func (h *recordingMetricsHook) ObservePhase(name string, _ time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.phases = append(h.phases, name)
+}
+
+func (h *recordingMetricsHook) IncError(kind string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errors = append(h.errors, kind)
+}
+
+func (h *recordingMetricsHook) ObserveCacheLookup(cache string, hit bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if hit {
+		h.cacheLookups = append(h.cacheLookups, cache+" hit")
+	} else {
+		h.cacheLookups = append(h.cacheLookups, cache+" miss")
+	}
+}
+
+func (h *recordingMetricsHook) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.verifications, h.phases, h.errors, h.cacheLookups = nil, nil, nil, nil
+}
+
+func TestVerifierMetricsHook(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithMetricsHook(nil))
+	assert.Error(t, err)
+
+	metrics := &recordingMetricsHook{}
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1), verify.WithMetricsHook(metrics))
+	assert.NoError(t, err)
+
+	policy := func(san string) verify.PolicyBuilder {
+		identity, err := verify.NewShortCertificateIdentity("issuer", san, "", "")
+		assert.NoError(t, err)
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithCertificateIdentity(identity))
+	}
+
+	// a successful verification observes each phase and its outcome
+	_, err = verifier.Verify(entity, policy("foo@example.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		verify.PhaseTransparencyLog,
+		verify.PhaseObserverTimestamps,
+		verify.PhaseCertificateChain,
+		verify.PhaseSignedCertificateTimestamps,
+		verify.PhaseSignature,
+		verify.PhasePolicy,
+	}, metrics.phases)
+	assert.Equal(t, []string{verify.OutcomeSuccess}, metrics.verifications)
+	assert.Empty(t, metrics.errors)
+
+	// a failing policy observes every phase, including the one that failed
+	metrics.reset()
+	_, err = verifier.Verify(entity, policy("bar@example.com"))
+	assert.Error(t, err)
+	assert.Len(t, metrics.phases, 6)
+	assert.Equal(t, verify.PhasePolicy, metrics.phases[5])
+	assert.Equal(t, []string{verify.OutcomeFailure}, metrics.verifications)
+	assert.Equal(t, []string{verify.ErrorKindIdentityMismatch}, metrics.errors)
+
+	// an entity from untrusted infrastructure stops at the transparency log
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrusted, err := otherSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	metrics.reset()
+	_, err = verifier.Verify(untrusted, policy("foo@example.com"))
+	assert.Error(t, err)
+	assert.Equal(t, []string{verify.PhaseTransparencyLog}, metrics.phases)
+	assert.Equal(t, []string{verify.OutcomeFailure}, metrics.verifications)
+	assert.Equal(t, []string{verify.ErrorKindUnknownLog}, metrics.errors)
+
+	// without a hook, verification still succeeds
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy("foo@example.com"))
+	assert.NoError(t, err)
+}
+
+// reloadableTrustedMaterial stands in for a trusted root that is
+// periodically reloaded, identified by its fingerprint.
+type reloadableTrustedMaterial struct {
+	*ca.VirtualSigstore
+	fingerprint string
+}
+
+func (r *reloadableTrustedMaterial) Fingerprint() string {
+	return r.fingerprint
+}
+
+func TestVerifierTrustedMaterialCache(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	otherEntity, err := otherSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	digest := sha256.Sum256(artifact)
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithoutIdentitiesUnsafe())
+	hit := verify.CacheTimestampAuthorities + " hit"
+	miss := verify.CacheTimestampAuthorities + " miss"
+
+	trustedMaterial := &reloadableTrustedMaterial{virtualSigstore, "a"}
+	metrics := &recordingMetricsHook{}
+	verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithMetricsHook(metrics))
+	assert.NoError(t, err)
+
+	// the timestamp authorities are prepared when the verifier is created,
+	// and reused while the fingerprint is unchanged
+	for i := 0; i < 2; i++ {
+		_, err = verifier.Verify(entity, policy)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{hit, hit}, metrics.cacheLookups)
+
+	// a changed trusted root is prepared again, and nothing prepared from
+	// the previous one is used
+	metrics.reset()
+	trustedMaterial.VirtualSigstore, trustedMaterial.fingerprint = otherSigstore, "b"
+	_, err = verifier.Verify(otherEntity, policy)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(otherEntity, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{miss, hit}, metrics.cacheLookups)
+	_, err = verifier.Verify(entity, policy)
+	assert.Error(t, err)
+
+	// a change of fingerprint alone is enough to prepare them again
+	metrics.reset()
+	trustedMaterial.fingerprint = "c"
+	_, err = verifier.Verify(otherEntity, policy)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{miss}, metrics.cacheLookups)
+}
+
+// countingEntity counts how often the content of the entity it wraps is
+// fetched.
+type countingEntity struct {
+	*ca.TestEntity
+	verificationContent int
+	sigContent          int
+	tlogEntries         int
+}
+
+func (e *countingEntity) VerificationContent() (verify.VerificationContent, error) {
+	e.verificationContent++
+	return e.TestEntity.VerificationContent()
+}
+
+func (e *countingEntity) SignatureContent() (verify.SignatureContent, error) {
+	e.sigContent++
+	return e.TestEntity.SignatureContent()
+}
+
+func (e *countingEntity) TlogEntries() ([]*tlog.Entry, error) {
+	e.tlogEntries++
+	return e.TestEntity.TlogEntries()
+}
+
+func TestVerifyFetchesEntityContentOnce(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithCertificateNotBeforeGrace(verify.DefaultCertificateNotBeforeGrace))
+	assert.NoError(t, err)
+	identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+	assert.NoError(t, err)
+
+	// for a bundle, fetching its content parses its certificate, envelope
+	// and log entries
+	counting := &countingEntity{TestEntity: entity}
+	_, err = v.Verify(counting, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithCertificateIdentity(identity)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counting.verificationContent)
+	assert.Equal(t, 1, counting.sigContent)
+	assert.Equal(t, 1, counting.tlogEntries)
+}
+
+func BenchmarkVerify(b *testing.B) {
+	b.Run("virtual sigstore", func(b *testing.B) {
+		virtualSigstore, err := ca.NewVirtualSigstore()
+		assert.NoError(b, err)
+
+		artifact := []byte("artifact")
+		artifactDigest := sha256.Sum256(artifact)
+		const bundles = 16
+		entities := make([]*ca.TestEntity, bundles)
+		for i := range entities {
+			entities[i], err = virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+			assert.NoError(b, err)
+		}
+
+		v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+		assert.NoError(b, err)
+		identity, err := verify.NewShortCertificateIdentity("issuer", "foo@example.com", "", "")
+		assert.NoError(b, err)
+		policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithCertificateIdentity(identity))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := v.Verify(entities[i%bundles], policy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("public good", func(b *testing.B) {
+		trustedRoot := data.PublicGoodTrustedMaterialRoot(b)
+		var entity bundle.ProtobufBundle
+		assert.NoError(b, entity.UnmarshalJSON(data.SigstoreJS200ProvenanceBundleRaw))
+
+		v, err := verify.NewSignedEntityVerifier(trustedRoot, verify.WithTransparencyLog(1), verify.WithObserverTimestamps(1))
+		assert.NoError(b, err)
+		identity, err := verify.NewShortCertificateIdentity("https://token.actions.githubusercontent.com", "https://github.com/sigstore/sigstore-js/.github/workflows/release.yml@refs/heads/main", "", "")
+		assert.NoError(b, err)
+		digest, err := hex.DecodeString("46d4e2f74c4877316640000a6fdf8a8b59f1e0847667973e9859f774dd31b8f1e0937813b777fb66a2ac67d50540fe34640966eee9fc2ccca387082b4c85cd3c")
+		assert.NoError(b, err)
+		policy := verify.NewPolicy(verify.WithArtifactDigest("sha512", digest), verify.WithCertificateIdentity(identity))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := v.Verify(&entity, policy); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+	"github.com/sigstore/sigstore-go/pkg/fulcio/certificate"
+)
+
+// StatementPredicate decides whether a verified in-toto statement satisfies
+// a policy the other options can't express. signer is the summary of the
+// signing certificate, or nil if the entity was signed with a key. It
+// returns an error wrapping ErrStatementPolicyFailed if the statement
+// doesn't satisfy the policy.
+type StatementPredicate func(statement *in_toto.Statement, signer *certificate.Summary) error
+
+// WithStatementPredicate allows the caller of Verify to check the
+// SignedEntity's in-toto statement with a predicate of their own, such as
+// one from the celpolicy package. Providing this function multiple times
+// requires each of the predicates to be satisfied. If this policy is
+// enabled, but the SignedEntity does not contain an in-toto statement,
+// verification will fail.
+func WithStatementPredicate(predicate StatementPredicate) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if predicate == nil {
+			return errors.New("statement predicate must not be nil")
+		}
+
+		p.statementPredicates = append(p.statementPredicates, predicate)
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	in_toto "github.com/in-toto/attestation/go/v1"
+)
+
+// NewSubjectGlobPolicy allows the caller of Verify to enforce that one of
+// the subjects of the SignedEntity's in-toto statement has a name matching
+// pattern, e.g. "pkg:docker/myorg/*" for every image of an organization.
+//
+// The pattern has the syntax of path.Match, and the whole name must match:
+//   - '*' matches any sequence of characters other than '/'
+//   - '?' matches any single character other than '/'
+//   - '[...]' matches a character class, negated with '[^...]'
+//   - '\' escapes the character that follows it
+//
+// A pattern without any of these matches the subject name exactly.
+// Providing this function multiple times requires each of the patterns to
+// match a subject. If this policy is enabled, but the SignedEntity does not
+// contain an in-toto statement, verification will fail.
+func NewSubjectGlobPolicy(pattern string) PolicyOption {
+	return func(p *PolicyConfig) error {
+		if pattern == "" {
+			return errors.New("subject name pattern must not be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid subject name pattern %q: %w", pattern, err)
+		}
+
+		p.subjectNamePatterns = append(p.subjectNamePatterns, pattern)
+		return nil
+	}
+}
+
+// verifySubjectName checks that one of the statement's subjects has a name
+// matching pattern, returning that name.
+func verifySubjectName(statement *in_toto.Statement, pattern string) (string, error) {
+	if statement == nil {
+		return "", errors.New("no in-toto statement to check the subject names of")
+	}
+	for _, subject := range statement.Subject {
+		// the pattern was checked when the policy was built
+		if matched, _ := path.Match(pattern, subject.Name); matched {
+			return subject.Name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrSubjectNameMismatch, pattern)
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectGlobPolicy(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	artifactDigest := sha256.Sum256(artifact)
+	statement := []byte(fmt.Sprintf(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"pkg:docker/myorg/app","digest":{"sha256":"%s"}}],"predicate":{}}`,
+		hex.EncodeToString(artifactDigest[:])))
+	attestation, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	messageSignature, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		entity      verify.SignedEntity
+		pattern     string
+		wantErr     bool
+		wantErrIs   error
+		wantOptsErr bool
+	}{
+		{
+			name:    "exact name",
+			entity:  attestation,
+			pattern: "pkg:docker/myorg/app",
+		},
+		{
+			name:    "star",
+			entity:  attestation,
+			pattern: "pkg:docker/myorg/*",
+		},
+		{
+			name:    "question mark and class",
+			entity:  attestation,
+			pattern: "pkg:docker/[lm]yorg/ap?",
+		},
+		{
+			name:      "other organization",
+			entity:    attestation,
+			pattern:   "pkg:docker/otherorg/*",
+			wantErr:   true,
+			wantErrIs: verify.ErrSubjectNameMismatch,
+		},
+		{
+			name:      "star doesn't match a slash",
+			entity:    attestation,
+			pattern:   "pkg:docker/*",
+			wantErr:   true,
+			wantErrIs: verify.ErrSubjectNameMismatch,
+		},
+		{
+			name:      "whole name must match",
+			entity:    attestation,
+			pattern:   "pkg:docker/myorg/ap",
+			wantErr:   true,
+			wantErrIs: verify.ErrSubjectNameMismatch,
+		},
+		{
+			name:    "no statement",
+			entity:  messageSignature,
+			pattern: "*",
+			wantErr: true,
+		},
+		{
+			name:        "empty pattern",
+			entity:      attestation,
+			wantOptsErr: true,
+		},
+		{
+			name:        "malformed pattern",
+			entity:      attestation,
+			pattern:     "pkg:docker/myorg/[",
+			wantOptsErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(), verify.NewSubjectGlobPolicy(tt.pattern))
+			_, err := policy.BuildConfig()
+			if tt.wantOptsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			_, err = verifier.Verify(tt.entity, policy)
+			if tt.wantErr {
+				assert.Error(t, err)
+				if tt.wantErrIs != nil {
+					assert.ErrorIs(t, err, tt.wantErrIs)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// every pattern must match
+	_, err = verifier.Verify(attestation, verify.NewPolicy(verify.WithArtifactDigest("sha256", artifactDigest[:]), verify.WithoutIdentitiesUnsafe(),
+		verify.NewSubjectGlobPolicy("pkg:docker/myorg/*"), verify.NewSubjectGlobPolicy("pkg:docker/otherorg/*")))
+	assert.ErrorIs(t, err, verify.ErrSubjectNameMismatch)
+}
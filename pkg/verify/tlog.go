@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"crypto"
+	"crypto/rsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -34,6 +35,25 @@ import (
 	"github.com/sigstore/sigstore-go/pkg/tlog"
 )
 
+// TlogEntryInfo identifies a transparency log entry that was verified, for
+// recording which entries backed a verification.
+type TlogEntryInfo struct {
+	// LogID is the hex-encoded ID of the log's key
+	LogID          string    `json:"logID"` //nolint:tagliatelle
+	LogIndex       int64     `json:"logIndex"`
+	IntegratedTime time.Time `json:"integratedTime"`
+	UUID           string    `json:"uuid"`
+}
+
+func newTlogEntryInfo(entry *tlog.Entry) TlogEntryInfo {
+	return TlogEntryInfo{
+		LogID:          hex.EncodeToString([]byte(entry.LogKeyID())),
+		LogIndex:       entry.LogIndex(),
+		IntegratedTime: entry.IntegratedTime(),
+		UUID:           entry.UUID(),
+	}
+}
+
 // VerifyArtifactTransparencyLog verifies that the given entity has been logged
 // in the transparency log and that the log entry is valid.
 //
@@ -42,49 +62,73 @@ import (
 //
 // If online is true, the log entry is verified against the Rekor server.
 func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.TrustedMaterial, logThreshold int, trustIntegratedTime, online bool) ([]time.Time, error) { //nolint:revive
+	verifiedTimestamps, _, err := verifyArtifactTransparencyLog(entity, trustedMaterial, logThreshold, trustIntegratedTime, online, false, 0, SystemClock{})
+	return verifiedTimestamps, err
+}
+
+// verifyArtifactTransparencyLog is VerifyArtifactTransparencyLog, also
+// returning the entries that were verified. If checkpointMaxAge is
+// positive, the checkpoints of inclusion proofs must be within it of the
+// clock's current time. If trustCheckpointTime is set, the times of those
+// checkpoints are returned as timestamps for entries without a promise.
+func verifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.TrustedMaterial, logThreshold int, trustIntegratedTime, online, trustCheckpointTime bool, checkpointMaxAge time.Duration, clock Clock) ([]time.Time, []TlogEntryInfo, error) {
 	entries, err := entity.TlogEntries()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// disallow duplicate entries, as a malicious actor could use duplicates to bypass the threshold
 	for i := 0; i < len(entries); i++ {
 		for j := i + 1; j < len(entries); j++ {
 			if entries[i].LogKeyID() == entries[j].LogKeyID() && entries[i].LogIndex() == entries[j].LogIndex() {
-				return nil, errors.New("duplicate tlog entries found")
+				return nil, nil, errors.New("duplicate tlog entries found")
 			}
 		}
 	}
 
 	sigContent, err := entity.SignatureContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	entitySignature := sigContent.Signature()
 
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	verifiedTimestamps := []time.Time{}
+	verifiedEntries := []TlogEntryInfo{}
 	logEntriesVerified := 0
+	// reasons entries were skipped, reported if the threshold isn't met
+	var skipped []error
 
 	for _, entry := range entries {
 		err := tlog.ValidateEntry(entry)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		if !online {
 			if !entry.HasInclusionPromise() && !entry.HasInclusionProof() {
-				return nil, fmt.Errorf("entry must contain an inclusion proof and/or promise")
+				return nil, nil, fmt.Errorf("entry must contain an inclusion proof and/or promise")
 			}
 			if entry.HasInclusionPromise() {
-				err = tlog.VerifySET(entry, trustedMaterial.RekorLogs())
+				if keyVersions, ok := trustedMaterial.(root.TlogKeyVersions); ok {
+					// a log may have more than one key version valid at the
+					// integrated time while its key is rotated
+					var versions []*root.TransparencyLog
+					versions, err = keyVersions.TlogVerifiersAt([]byte(entry.LogKeyID()), entry.IntegratedTime())
+					if err == nil {
+						err = tlog.VerifySETWithKeyVersions(entry, versions)
+					}
+				} else {
+					err = tlog.VerifySET(entry, trustedMaterial.RekorLogs())
+				}
 				if err != nil {
 					// skip entries the trust root cannot verify
+					skipped = append(skipped, fmt.Errorf("log entry %d: %w", entry.LogIndex(), err))
 					continue
 				}
 				if trustIntegratedTime {
@@ -92,41 +136,54 @@ func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.Tru
 				}
 			}
 			if entity.HasInclusionProof() {
-				keyID := entry.LogKeyID()
-				hex64Key := hex.EncodeToString([]byte(keyID))
-				tlogVerifier, ok := trustedMaterial.RekorLogs()[hex64Key]
-				if !ok {
+				tlogVerifier, err := rekorLogAt(trustedMaterial, entry)
+				if err != nil {
 					// skip entries the trust root cannot verify
+					skipped = append(skipped, err)
 					continue
 				}
 
-				verifier, err := getVerifier(tlogVerifier.PublicKey, tlogVerifier.SignatureHashFunc)
+				verifier, err := getVerifier(tlogVerifier)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 
-				err = tlog.VerifyInclusion(entry, *verifier)
+				err = tlog.VerifyInclusionWithHash(entry, *verifier, tlogVerifier.HashFunc)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
+				}
+				if checkpointMaxAge > 0 {
+					if err := verifyCheckpointFreshness(entry, checkpointMaxAge, clock.Now()); err != nil {
+						return nil, nil, err
+					}
 				}
 				// DO NOT use timestamp with only an inclusion proof, because it is not signed metadata
+				if trustCheckpointTime && !entry.HasInclusionPromise() {
+					// but the checkpoint's time is, by the log
+					checkpointTime, ok, err := entry.CheckpointTime()
+					if err != nil {
+						return nil, nil, err
+					}
+					if ok {
+						verifiedTimestamps = append(verifiedTimestamps, checkpointTime)
+					}
+				}
 			}
 		} else {
-			keyID := entry.LogKeyID()
-			hex64Key := hex.EncodeToString([]byte(keyID))
-			tlogVerifier, ok := trustedMaterial.RekorLogs()[hex64Key]
-			if !ok {
+			tlogVerifier, err := rekorLogAt(trustedMaterial, entry)
+			if err != nil {
 				// skip entries the trust root cannot verify
+				skipped = append(skipped, err)
 				continue
 			}
 
 			client, err := getRekorClient(tlogVerifier.BaseURL)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
-			verifier, err := getVerifier(tlogVerifier.PublicKey, tlogVerifier.SignatureHashFunc)
+			verifier, err := getVerifier(tlogVerifier)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			logIndex := entry.LogIndex()
@@ -139,13 +196,13 @@ func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.Tru
 
 			resp, err := client.Entries.SearchLogQuery(searchParams)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			if len(resp.Payload) == 0 {
-				return nil, fmt.Errorf("unable to locate log entry %d", logIndex)
+				return nil, nil, fmt.Errorf("unable to locate log entry %d", logIndex)
 			} else if len(resp.Payload) > 1 {
-				return nil, errors.New("too many log entries returned")
+				return nil, nil, errors.New("too many log entries returned")
 			}
 
 			logEntry := resp.Payload[0]
@@ -154,7 +211,7 @@ func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.Tru
 				v := v
 				err = rekorVerify.VerifyLogEntry(context.TODO(), &v, *verifier)
 				if err != nil {
-					return nil, err
+					return nil, nil, err
 				}
 			}
 			if trustIntegratedTime {
@@ -163,34 +220,131 @@ func VerifyArtifactTransparencyLog(entity SignedEntity, trustedMaterial root.Tru
 		}
 		// Ensure entry signature matches signature from bundle
 		if !bytes.Equal(entry.Signature(), entitySignature) {
-			return nil, errors.New("transparency log signature does not match")
+			return nil, nil, errors.New("transparency log signature does not match")
 		}
 
 		// Ensure entry certificate matches bundle certificate
 		if !verificationContent.CompareKey(entry.PublicKey(), trustedMaterial) {
-			return nil, errors.New("transparency log certificate does not match")
+			return nil, nil, errors.New("transparency log certificate does not match")
+		}
+
+		// Ensure entry payload hash matches the bundle's envelope payload
+		if envelope := sigContent.EnvelopeContent(); envelope != nil {
+			if err := verifyLoggedPayloadHash(entry, envelope); err != nil {
+				return nil, nil, err
+			}
 		}
 
 		// TODO: if you have access to artifact, check that it matches body subject
 
 		// Check tlog entry time against bundle certificates
 		if !verificationContent.ValidAtTime(entry.IntegratedTime(), trustedMaterial) {
-			return nil, errors.New("integrated time outside certificate validity")
+			return nil, nil, errors.New("integrated time outside certificate validity")
 		}
 
 		// successful log entry verification
 		logEntriesVerified++
+		verifiedEntries = append(verifiedEntries, newTlogEntryInfo(entry))
 	}
 
 	if logEntriesVerified < logThreshold {
-		return nil, fmt.Errorf("not enough verified log entries from transparency log: %d < %d", logEntriesVerified, logThreshold)
+		thresholdErr := fmt.Errorf("%w: not enough verified log entries from transparency log: %d < %d", ErrThresholdNotMet, logEntriesVerified, logThreshold)
+		return nil, nil, errors.Join(append([]error{thresholdErr}, skipped...)...)
+	}
+
+	return verifiedTimestamps, verifiedEntries, nil
+}
+
+// additionalTlogs is trusted material holding only the Rekor logs given
+// with WithAdditionalTlogVerifier.
+type additionalTlogs struct {
+	root.BaseTrustedMaterial
+	rekorLogs map[string]*root.TransparencyLog
+}
+
+func newAdditionalTlogs(tlogs []*root.TransparencyLog) *additionalTlogs {
+	rekorLogs := make(map[string]*root.TransparencyLog, len(tlogs))
+	for _, transparencyLog := range tlogs {
+		rekorLogs[hex.EncodeToString(transparencyLog.ID)] = transparencyLog
+	}
+	return &additionalTlogs{rekorLogs: rekorLogs}
+}
+
+func (a *additionalTlogs) RekorLogs() map[string]*root.TransparencyLog {
+	return a.rekorLogs
+}
+
+// verifyCheckpointFreshness checks that the time in the checkpoint of the
+// entry's inclusion proof, if it has one, is within maxAge of now.
+func verifyCheckpointFreshness(entry *tlog.Entry, maxAge time.Duration, now time.Time) error {
+	checkpointTime, ok, err := entry.CheckpointTime()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if age := now.Sub(checkpointTime); age > maxAge || age < -maxAge {
+		return fmt.Errorf("%w: checkpoint of log entry %d is from %s, more than %s from %s", ErrStaleCheckpoint,
+			entry.LogIndex(), checkpointTime.UTC().Format(time.RFC3339), maxAge, now.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// rekorLogAt selects the version of the entry's log key that was valid at
+// its integrated time. Trusted material listing key versions is asked for
+// them, so rotated keys are found as they are for inclusion promises;
+// otherwise the RekorLogs() entry is used if it is valid at that time.
+func rekorLogAt(trustedMaterial root.TrustedMaterial, entry *tlog.Entry) (*root.TransparencyLog, error) {
+	logID := []byte(entry.LogKeyID())
+	if keyVersions, ok := trustedMaterial.(root.TlogKeyVersions); ok {
+		versions, err := keyVersions.TlogVerifiersAt(logID, entry.IntegratedTime())
+		if err != nil {
+			return nil, fmt.Errorf("log entry %d: %w", entry.LogIndex(), err)
+		}
+		return versions[0], nil
+	}
+
+	hex64Key := hex.EncodeToString(logID)
+	tlogVerifier, ok := trustedMaterial.RekorLogs()[hex64Key]
+	if !ok {
+		return nil, fmt.Errorf("log entry %d: %w: rekor log %s", entry.LogIndex(), root.ErrUnknownLog, hex64Key)
 	}
+	if err := verifyTlogValidity(entry, tlogVerifier); err != nil {
+		return nil, err
+	}
+	return tlogVerifier, nil
+}
 
-	return verifiedTimestamps, nil
+// verifyTlogValidity checks that the log's key was valid at the entry's
+// integrated time, so that a key rotated out of the log isn't trusted for
+// entries integrated after it. VerifySET checks this for entries with an
+// inclusion promise.
+func verifyTlogValidity(entry *tlog.Entry, transparencyLog *root.TransparencyLog) error {
+	if !transparencyLog.ValidAtTime(entry.IntegratedTime()) {
+		return fmt.Errorf("log entry %d: %w: rekor log %s key not valid at integrated time %s", entry.LogIndex(), root.ErrExpiredTrustMaterial,
+			hex.EncodeToString([]byte(entry.LogKeyID())), entry.IntegratedTime().UTC().Format(time.RFC3339))
+	}
+	return nil
 }
 
-func getVerifier(publicKey crypto.PublicKey, hashFunc crypto.Hash) (*signature.Verifier, error) {
-	verifier, err := signature.LoadVerifier(publicKey, hashFunc)
+// getVerifier returns a verifier of signatures by the log's key, such as
+// those on its checkpoints.
+func getVerifier(transparencyLog *root.TransparencyLog) (*signature.Verifier, error) {
+	// an unset signature hash function is the log's default of SHA-256,
+	// and is ignored for Ed25519 keys
+	hashFunc := transparencyLog.SignatureHashFunc
+	if hashFunc == 0 {
+		hashFunc = crypto.SHA256
+	}
+
+	var verifier signature.Verifier
+	var err error
+	if rsaKey, ok := transparencyLog.PublicKey.(*rsa.PublicKey); ok && transparencyLog.RSAPSS {
+		verifier, err = signature.LoadRSAPSSVerifier(rsaKey, hashFunc, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	} else {
+		verifier, err = signature.LoadVerifier(transparencyLog.PublicKey, hashFunc)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -206,3 +360,34 @@ func getRekorClient(baseURL string) (*rekorGeneratedClient.Rekor, error) {
 
 	return client, nil
 }
+
+// verifyLoggedPayloadHash checks that the payload hash recorded by a dsse or
+// intoto entry is the digest of the envelope's payload. Together with the
+// signature comparison, which covers the payload type through DSSE's
+// pre-authentication encoding, this binds the entry to the exact
+// attestation being verified. Entries that record no payload hash, such as
+// hashedrekord entries, are left alone.
+func verifyLoggedPayloadHash(entry *tlog.Entry, envelope EnvelopeContent) error {
+	algorithm, loggedDigest, err := entry.PayloadHash()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTlogPayloadMismatch, err)
+	}
+	if algorithm == "" {
+		return nil
+	}
+
+	hash, ok := artifactDigestHashes[algorithm]
+	if !ok {
+		return fmt.Errorf("%w: unsupported payload hash algorithm %q", ErrTlogPayloadMismatch, algorithm)
+	}
+	payload, err := envelope.RawEnvelope().DecodeB64Payload()
+	if err != nil {
+		return fmt.Errorf("unable to decode envelope payload: %w", err)
+	}
+	hasher := hash.New()
+	hasher.Write(payload)
+	if !bytes.Equal(hasher.Sum(nil), loggedDigest) {
+		return fmt.Errorf("%w: %s digest of envelope payload differs from the logged payload hash", ErrTlogPayloadMismatch, algorithm)
+	}
+	return nil
+}
@@ -15,15 +15,26 @@
 package verify_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
 	"github.com/sigstore/sigstore-go/pkg/tlog"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TODO(issue#53): Add unit tests for online log verification and inclusion proofs
@@ -63,6 +74,118 @@ func TestTlogVerifier(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEd25519TlogVerifier(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstoreWithOptions(ca.WithEd25519RekorKey())
+	assert.NoError(t, err)
+	for _, rekorLog := range virtualSigstore.RekorLogs() {
+		assert.IsType(t, ed25519.PublicKey{}, rekorLog.PublicKey)
+		assert.Zero(t, rekorLog.SignatureHashFunc)
+	}
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	res, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+	assert.Len(t, res.VerifiedTimestamps, 1)
+
+	// an ECDSA log's SETs don't verify with the Ed25519 log's key
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	entity, err = otherSigstore.Attest("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+	_, err = verify.VerifyArtifactTransparencyLog(entity, virtualSigstore, 1, true, false)
+	assert.Error(t, err)
+}
+
+func TestRSATlogVerifier(t *testing.T) {
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+
+	for _, tt := range []struct {
+		name string
+		pss  bool
+	}{
+		{name: "PKCS #1 v1.5", pss: false},
+		{name: "PSS", pss: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			virtualSigstore, err := ca.NewVirtualSigstoreWithOptions(ca.WithRSARekorKey(3072, tt.pss))
+			assert.NoError(t, err)
+			entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", statement)
+			assert.NoError(t, err)
+
+			verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+			assert.NoError(t, err)
+			res, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+			assert.NoError(t, err)
+			assert.Len(t, res.VerifiedTimestamps, 1)
+
+			// the SET only verifies with the signature scheme the log uses
+			rekorLogs := virtualSigstore.RekorLogs()
+			for _, rekorLog := range rekorLogs {
+				rekorLog.RSAPSS = !tt.pss
+			}
+			entries, err := entity.TlogEntries()
+			assert.NoError(t, err)
+			assert.Error(t, tlog.VerifySET(entries[0], rekorLogs))
+		})
+	}
+}
+
+// rekorLogsEndingAt is trusted material whose Rekor log keys stop being
+// valid at end.
+type rekorLogsEndingAt struct {
+	root.TrustedMaterial
+	end time.Time
+}
+
+func (r *rekorLogsEndingAt) RekorLogs() map[string]*root.TransparencyLog {
+	rekorLogs := r.TrustedMaterial.RekorLogs()
+	for _, rekorLog := range rekorLogs {
+		rekorLog.ValidityPeriodEnd = r.end
+	}
+	return rekorLogs
+}
+
+func TestTlogValidityPeriod(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	promised, err := virtualSigstore.Attest("foo@fighters.com", "issuer", statement)
+	assert.NoError(t, err)
+	proved, err := virtualSigstore.ProveInclusion(promised)
+	assert.NoError(t, err)
+
+	for _, tt := range []struct {
+		name   string
+		entity verify.SignedEntity
+	}{
+		{name: "inclusion promise", entity: promised},
+		{name: "inclusion proof", entity: proved},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verify.VerifyArtifactTransparencyLog(tt.entity, virtualSigstore, 1, false, false)
+			assert.NoError(t, err)
+
+			// the entry was integrated after the log's key was rotated out
+			expired := &rekorLogsEndingAt{TrustedMaterial: virtualSigstore, end: time.Now().Add(-time.Minute)}
+			_, err = verify.VerifyArtifactTransparencyLog(tt.entity, expired, 1, false, false)
+			assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+			assert.ErrorIs(t, err, root.ErrExpiredTrustMaterial)
+
+			// trusted material listing key versions selects them the same way
+			_, err = verify.VerifyArtifactTransparencyLog(tt.entity, root.TrustedMaterialCollection{virtualSigstore}, 1, false, false)
+			assert.NoError(t, err)
+			_, err = verify.VerifyArtifactTransparencyLog(tt.entity, root.TrustedMaterialCollection{expired}, 1, false, false)
+			assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+			assert.ErrorIs(t, err, root.ErrExpiredTrustMaterial)
+		})
+	}
+}
+
 type oneTrustedOneUntrustedLogEntry struct {
 	*ca.TestEntity
 	UntrustedTestEntity *ca.TestEntity
@@ -193,3 +316,262 @@ func TestDuplicateTlogEntries(t *testing.T) {
 	_, err = verify.VerifyArtifactTransparencyLog(&dupTlogEntity{entity}, virtualSigstore, 1, true, false)
 	assert.Error(t, err) // duplicate tlog entries should fail to verify
 }
+
+// swappedPayloadEntity presents its envelope with a different payload, while
+// keeping the signatures and the log entries made for the original.
+type swappedPayloadEntity struct {
+	*ca.TestEntity
+	payload []byte
+}
+
+func (e *swappedPayloadEntity) SignatureContent() (verify.SignatureContent, error) {
+	sigContent, err := e.TestEntity.SignatureContent()
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := *sigContent.EnvelopeContent().RawEnvelope()
+	envelope.Payload = base64.StdEncoding.EncodeToString(e.payload)
+	return &bundle.Envelope{Envelope: &envelope}, nil
+}
+
+func TestTlogPayloadHash(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foofighters@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	entries, err := entity.TlogEntries()
+	assert.NoError(t, err)
+	algorithm, digest, err := entries[0].PayloadHash()
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256", algorithm)
+	statementDigest := sha256.Sum256(statement)
+	assert.Equal(t, statementDigest[:], digest)
+
+	_, err = verify.VerifyArtifactTransparencyLog(&swappedPayloadEntity{entity, statement}, virtualSigstore, 1, true, false)
+	assert.NoError(t, err)
+
+	otherStatement := []byte(strings.Replace(string(statement), "customFoo", "customBar", 1))
+	_, err = verify.VerifyArtifactTransparencyLog(&swappedPayloadEntity{entity, otherStatement}, virtualSigstore, 1, true, false)
+	assert.ErrorIs(t, err, verify.ErrTlogPayloadMismatch)
+}
+
+func TestTlogEntryPublicKey(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+
+	// The logged public key is the signing certificate's key
+	entity, err := virtualSigstore.SignWithLoggedPublicKey("foo@example.com", "issuer", artifact, nil)
+	require.NoError(t, err)
+
+	_, err = verify.VerifyArtifactTransparencyLog(entity, virtualSigstore, 1, true, false)
+	assert.NoError(t, err)
+
+	// The logged public key belongs to a different signer
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	entity, err = virtualSigstore.SignWithLoggedPublicKey("foo@example.com", "issuer", artifact, otherKey)
+	require.NoError(t, err)
+
+	_, err = verify.VerifyArtifactTransparencyLog(entity, virtualSigstore, 1, true, false)
+	assert.ErrorContains(t, err, "transparency log certificate does not match")
+}
+
+// multiLogEntity is an entity logged to more than one log.
+type multiLogEntity struct {
+	*ca.TestEntity
+	otherEntries []*tlog.Entry
+}
+
+func (e *multiLogEntity) TlogEntries() ([]*tlog.Entry, error) {
+	entries, err := e.TestEntity.TlogEntries()
+	if err != nil {
+		return nil, err
+	}
+	return append(entries, e.otherEntries...), nil
+}
+
+func TestVerifiedTlogEntries(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	otherLog, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	// log the same signature to a second log
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leaf, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+	integratedTime := time.Now().Add(6 * time.Minute).Truncate(time.Second)
+	otherEntry, err := otherLog.GenerateTlogEntry(&leaf, sigContent.EnvelopeContent().RawEnvelope(), sigContent.Signature(), integratedTime.Unix())
+	assert.NoError(t, err)
+
+	entries, err := entity.TlogEntries()
+	assert.NoError(t, err)
+	entries = append(entries, otherEntry)
+
+	trustedMaterial := root.TrustedMaterialCollection{virtualSigstore, otherLog}
+	verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(2), verify.WithIntegratedTimestamps(2))
+	assert.NoError(t, err)
+
+	result, err := verifier.Verify(&multiLogEntity{entity, []*tlog.Entry{otherEntry}}, verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+
+	assert.Len(t, result.VerifiedTlogEntries, 2)
+	for i, entry := range entries {
+		leafHash := sha256.Sum256(append([]byte{0x00}, entry.CanonicalizedBody()...))
+		assert.Equal(t, verify.TlogEntryInfo{
+			LogID:          hex.EncodeToString([]byte(entry.LogKeyID())),
+			LogIndex:       entry.LogIndex(),
+			IntegratedTime: entry.IntegratedTime(),
+			UUID:           hex.EncodeToString(leafHash[:]),
+		}, result.VerifiedTlogEntries[i])
+	}
+	assert.NotEqual(t, result.VerifiedTlogEntries[0].LogID, result.VerifiedTlogEntries[1].LogID)
+	assert.True(t, integratedTime.Equal(result.VerifiedTlogEntries[1].IntegratedTime))
+}
+
+// withoutRekorLogs is a virtual Sigstore whose trusted material leaves out
+// its Rekor log.
+type withoutRekorLogs struct {
+	*ca.VirtualSigstore
+}
+
+func (m *withoutRekorLogs) RekorLogs() map[string]*root.TransparencyLog {
+	return map[string]*root.TransparencyLog{}
+}
+
+// outOfBandRekorLog returns the virtual Sigstore's Rekor log as it would be
+// configured out-of-band, keyed by its raw log ID.
+func outOfBandRekorLog(t *testing.T, virtualSigstore *ca.VirtualSigstore) *root.TransparencyLog {
+	t.Helper()
+	for keyID, rekorLog := range virtualSigstore.RekorLogs() {
+		logID, err := hex.DecodeString(keyID)
+		assert.NoError(t, err)
+		outOfBand := *rekorLog
+		outOfBand.ID = logID
+		return &outOfBand
+	}
+	t.Fatal("virtual Sigstore has no Rekor log")
+	return nil
+}
+
+func TestAdditionalTlogVerifier(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := virtualSigstore.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithoutArtifactUnsafe(), verify.WithoutIdentitiesUnsafe())
+	trustedMaterial := &withoutRekorLogs{virtualSigstore}
+
+	// the trusted material has no Rekor log to verify the entry with
+	_, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.Error(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithAdditionalTlogVerifier(outOfBandRekorLog(t, virtualSigstore)), verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	result, err := verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+	assert.Len(t, result.VerifiedTlogEntries, 1)
+
+	// another log's key doesn't verify the entry
+	otherSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	verifier, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithAdditionalTlogVerifier(outOfBandRekorLog(t, otherSigstore)), verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy)
+	assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+
+	// the additional log augments the trusted material's
+	verifier, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithAdditionalTlogVerifier(outOfBandRekorLog(t, otherSigstore)), verify.WithTransparencyLog(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = verifier.Verify(entity, policy)
+	assert.NoError(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithAdditionalTlogVerifier(&root.TransparencyLog{}), verify.WithTransparencyLog(1))
+	assert.Error(t, err)
+}
+
+// signatureEntryBody is the body of a log entry of a custom type, recording
+// a signature over an artifact and the certificate that verifies it.
+type signatureEntryBody struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Signature   []byte `json:"signature"`
+		Certificate []byte `json:"certificate"`
+	} `json:"spec"`
+}
+
+func TestCustomEntryTypeVerify(t *testing.T) {
+	tlog.RegisterRekorEntryType("signature", "0.0.1", func(entry *tlog.Entry) ([]byte, []byte, error) {
+		return entry.CanonicalizedBody(), tlog.LeafHash(entry.CanonicalizedBody()), nil
+	}, tlog.WithEntryVerificationMaterial(func(entry *tlog.Entry) ([]byte, any, error) {
+		var body signatureEntryBody
+		if err := json.Unmarshal(entry.CanonicalizedBody(), &body); err != nil {
+			return nil, nil, err
+		}
+		cert, err := x509.ParseCertificate(body.Spec.Certificate)
+		if err != nil {
+			return nil, nil, err
+		}
+		return body.Spec.Signature, cert, nil
+	}))
+
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	artifact := []byte("artifact")
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	require.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	require.NoError(t, err)
+	certChain, err := entity.CertificateChain()
+	require.NoError(t, err)
+	otherEntity, err := virtualSigstore.Sign("foo@example.com", "issuer", artifact)
+	require.NoError(t, err)
+	otherSigContent, err := otherEntity.SignatureContent()
+	require.NoError(t, err)
+
+	// logged returns the entity logged as an entry of the custom type
+	logged := func(sig []byte) *ca.TestEntity {
+		body := signatureEntryBody{APIVersion: "0.0.1", Kind: "signature"}
+		body.Spec.Signature = sig
+		body.Spec.Certificate = certChain[0].Raw
+		bodyJSON, err := json.Marshal(body)
+		require.NoError(t, err)
+		customEntity, err := virtualSigstore.LogEntryBody(entity, bodyJSON)
+		require.NoError(t, err)
+		return customEntity
+	}
+	promised := logged(sigContent.Signature())
+	proved, err := virtualSigstore.ProveInclusion(promised)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256(artifact)
+	policy := verify.NewPolicy(verify.WithArtifactDigest("sha256", digest[:]), verify.WithoutIdentitiesUnsafe())
+	verifier, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	require.NoError(t, err)
+
+	_, err = verifier.Verify(promised, policy)
+	assert.NoError(t, err)
+	// the inclusion proof is verified against the reconstructed leaf hash
+	_, err = verifier.Verify(proved, policy)
+	assert.NoError(t, err)
+
+	// the entry logs another signature over the artifact
+	_, err = verifier.Verify(logged(otherSigContent.Signature()), policy)
+	assert.ErrorContains(t, err, "transparency log signature does not match")
+}
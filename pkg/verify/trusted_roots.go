@@ -0,0 +1,163 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+)
+
+// TrustedRootResult identifies which of the roots given with
+// WithTrustedRoots an entity verified against.
+type TrustedRootResult struct {
+	// Index is the position of the root in WithTrustedRoots
+	Index int `json:"index"`
+	// Fingerprint is the root's fingerprint, if it has one, such as a
+	// root.TrustedRoot does
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// WithTrustedRoots configures the SignedEntityVerifier to verify entities
+// against each of roots in turn, and to succeed with the first one they
+// verify against, such as while migrating from an old trusted root to a new
+// one. The result's TrustedRoot reports which root it was. If the entity
+// verifies against none of them, the error holds the reason for each root.
+// Pass nil as the trusted material to NewSignedEntityVerifier.
+//
+// Unlike a root.TrustedMaterialCollection, which is the union of its
+// members, each root is verified against on its own: a certificate chained
+// to one root is never accepted with a log entry only another root trusts.
+//
+// An artifact given to WithArtifact is read again for each root once it has
+// been read, so it must be an io.Seeker for verification against the later
+// roots to be attempted; WithArtifactDigest has no such restriction.
+// VerifyTransparencyLogInclusion and VerifyObserverTimestamps use the first
+// root.
+func WithTrustedRoots(roots ...root.TrustedMaterial) VerifierOption {
+	return func(c *VerifierConfig) error {
+		if len(roots) == 0 {
+			return errors.New("at least one trusted root is required")
+		}
+		for i, tm := range roots {
+			if tm == nil {
+				return fmt.Errorf("trusted root %d must not be nil", i)
+			}
+		}
+		if c.trustedRoots != nil {
+			return errors.New("only one invocation of WithTrustedRoots is allowed")
+		}
+		c.trustedRoots = roots
+		return nil
+	}
+}
+
+// newTrustedRootsVerifier returns a SignedEntityVerifier that verifies
+// against each of the configuration's trusted roots in turn.
+func newTrustedRootsVerifier(c VerifierConfig) (*SignedEntityVerifier, error) {
+	rootConfig := c
+	rootConfig.trustedRoots = nil
+
+	rootVerifiers := make([]*SignedEntityVerifier, 0, len(c.trustedRoots))
+	for i, tm := range c.trustedRoots {
+		rootVerifier, err := newSignedEntityVerifier(tm, rootConfig)
+		if err != nil {
+			return nil, fmt.Errorf("trusted root %d: %w", i, err)
+		}
+		rootVerifiers = append(rootVerifiers, rootVerifier)
+	}
+
+	return &SignedEntityVerifier{
+		trustedMaterial:      rootVerifiers[0].trustedMaterial,
+		config:               c,
+		timestampAuthorities: rootVerifiers[0].timestampAuthorities,
+		rootVerifiers:        rootVerifiers,
+	}, nil
+}
+
+// verifyWithTrustedRoots verifies the entity against each of the verifier's
+// trusted roots in turn, until one succeeds.
+func (v *SignedEntityVerifier) verifyWithTrustedRoots(entity SignedEntity, policy *PolicyConfig) (*VerificationResult, error) {
+	var artifact *rereadableArtifact
+	if policy.artifact != nil {
+		artifact = newRereadableArtifact(policy.artifact)
+		policy.artifact = artifact
+	}
+
+	errs := make([]error, 0, len(v.rootVerifiers))
+	for i, rootVerifier := range v.rootVerifiers {
+		if artifact != nil {
+			if err := artifact.rewind(); err != nil {
+				errs = append(errs, fmt.Errorf("trusted roots from %d on not tried: %w", i, err))
+				break
+			}
+		}
+
+		result, err := rootVerifier.verifyWithPolicy(entity, policy)
+		if err == nil {
+			result.TrustedRoot = &TrustedRootResult{
+				Index:       i,
+				Fingerprint: trustedMaterialFingerprint(rootVerifier.trustedMaterial),
+			}
+			return result, nil
+		}
+		errs = append(errs, fmt.Errorf("trusted root %d: %w", i, err))
+	}
+
+	return nil, fmt.Errorf("failed to verify against any trusted root: %w", errors.Join(errs...))
+}
+
+// rereadableArtifact is an artifact reader that can be read from the start
+// again, so that it can be verified against more than one trusted root.
+type rereadableArtifact struct {
+	io.Reader
+	// start is the offset of the artifact in the reader, if it is an
+	// io.Seeker
+	start  int64
+	seeker io.Seeker
+	read   bool
+}
+
+func newRereadableArtifact(r io.Reader) *rereadableArtifact {
+	a := &rereadableArtifact{Reader: r}
+	if seeker, ok := r.(io.Seeker); ok {
+		if start, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			a.seeker, a.start = seeker, start
+		}
+	}
+	return a
+}
+
+func (a *rereadableArtifact) Read(p []byte) (int, error) {
+	a.read = true
+	return a.Reader.Read(p)
+}
+
+// rewind readies the artifact to be read from the start.
+func (a *rereadableArtifact) rewind() error {
+	if !a.read {
+		return nil
+	}
+	if a.seeker == nil {
+		return errors.New("the artifact has been read and is not an io.Seeker")
+	}
+	if _, err := a.seeker.Seek(a.start, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind the artifact: %w", err)
+	}
+	a.read = false
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/sigstore/sigstore-go/pkg/verify"
+	"github.com/stretchr/testify/assert"
+)
+
+// crossRootEntity is signed with a certificate from one virtual Sigstore,
+// and logged and timestamped by another.
+type crossRootEntity struct {
+	*ca.TestEntity
+	tlogEntries []*tlog.Entry
+	timestamps  [][]byte
+}
+
+func (e *crossRootEntity) TlogEntries() ([]*tlog.Entry, error) {
+	return e.tlogEntries, nil
+}
+
+func (e *crossRootEntity) Timestamps() ([][]byte, error) {
+	return e.timestamps, nil
+}
+
+func TestWithTrustedRoots(t *testing.T) {
+	oldRoot, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	newRoot, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	untrustedRoot, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := []byte("artifact")
+	oldEntity, err := oldRoot.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	newEntity, err := newRoot.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+	untrustedEntity, err := untrustedRoot.Sign("foo@example.com", "issuer", artifact)
+	assert.NoError(t, err)
+
+	verifier, err := verify.NewSignedEntityVerifier(nil, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithTrustedRoots(oldRoot, newRoot))
+	assert.NoError(t, err)
+	policy := func() verify.PolicyBuilder {
+		return verify.NewPolicy(verify.WithArtifact(bytes.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+	}
+
+	// the first root the entity verifies against is reported
+	result, err := verifier.Verify(oldEntity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, &verify.TrustedRootResult{Index: 0}, result.TrustedRoot)
+
+	result, err = verifier.Verify(newEntity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, &verify.TrustedRootResult{Index: 1}, result.TrustedRoot)
+
+	// the reason for each root is reported
+	_, err = verifier.Verify(untrustedEntity, policy())
+	assert.ErrorIs(t, err, root.ErrUnknownLog)
+	assert.ErrorContains(t, err, "trusted root 0: ")
+	assert.ErrorContains(t, err, "trusted root 1: ")
+
+	// the root's fingerprint is reported if it has one
+	fingerprinted, err := verify.NewSignedEntityVerifier(nil, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1),
+		verify.WithTrustedRoots(&reloadableTrustedMaterial{oldRoot, "old"}, &reloadableTrustedMaterial{newRoot, "new"}))
+	assert.NoError(t, err)
+	result, err = fingerprinted.Verify(newEntity, policy())
+	assert.NoError(t, err)
+	assert.Equal(t, &verify.TrustedRootResult{Index: 1, Fingerprint: "new"}, result.TrustedRoot)
+
+	// without WithTrustedRoots nothing is reported
+	singleRoot, err := verify.NewSignedEntityVerifier(newRoot, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	result, err = singleRoot.Verify(newEntity, policy())
+	assert.NoError(t, err)
+	assert.Nil(t, result.TrustedRoot)
+}
+
+func TestWithTrustedRootsDoesNotMixRoots(t *testing.T) {
+	oldRoot, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	newRoot, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	statement := []byte(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"customFoo","subject":[{"name":"subject","digest":{"sha256":"deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"}}],"predicate":{}}`)
+	entity, err := oldRoot.Attest("foo@example.com", "issuer", statement)
+	assert.NoError(t, err)
+
+	// log and timestamp the old root's certificate and signature with the
+	// new root's log and timestamp authority
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+	entry, err := newRoot.GenerateTlogEntry(&leafCert, sigContent.EnvelopeContent().RawEnvelope(), sigContent.Signature(), time.Now().Add(5*time.Minute).Unix())
+	assert.NoError(t, err)
+	timestamp, err := newRoot.TimestampResponse(sigContent.Signature())
+	assert.NoError(t, err)
+	mixed := &crossRootEntity{TestEntity: entity, tlogEntries: []*tlog.Entry{entry}, timestamps: [][]byte{timestamp}}
+
+	options := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1)}
+
+	// the union of the roots accepts the certificate of one with the log
+	// entry and timestamp of the other
+	union, err := verify.NewSignedEntityVerifier(root.TrustedMaterialCollection{oldRoot, newRoot}, options...)
+	assert.NoError(t, err)
+	_, err = union.Verify(mixed, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+
+	// each root on its own does not
+	verifier, err := verify.NewSignedEntityVerifier(nil, append(options, verify.WithTrustedRoots(oldRoot, newRoot))...)
+	assert.NoError(t, err)
+	_, err = verifier.Verify(mixed, SkipArtifactAndIdentitiesPolicy)
+	assert.Error(t, err)
+	assert.ErrorContains(t, err, "trusted root 0: failed to verify log inclusion")
+	assert.ErrorContains(t, err, "trusted root 1: ")
+
+	// while the unmixed entity verifies against the old root
+	result, err := verifier.Verify(entity, SkipArtifactAndIdentitiesPolicy)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.TrustedRoot.Index)
+}
+
+func TestWithTrustedRootsOptions(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	options := []verify.VerifierOption{verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1)}
+
+	// the roots replace the trusted material
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, append(options, verify.WithTrustedRoots(virtualSigstore))...)
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(nil, options...)
+	assert.Error(t, err)
+
+	_, err = verify.NewSignedEntityVerifier(nil, append(options, verify.WithTrustedRoots())...)
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(nil, append(options, verify.WithTrustedRoots(virtualSigstore, nil))...)
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(nil, append(options, verify.WithTrustedRoots(virtualSigstore), verify.WithTrustedRoots(virtualSigstore))...)
+	assert.Error(t, err)
+
+	// every root must suit the options
+	_, err = verify.NewSignedEntityVerifier(nil, append(options, verify.WithTrustedRoots(virtualSigstore, &root.BaseTrustedMaterial{}))...)
+	assert.ErrorContains(t, err, "trusted root 1: ")
+}
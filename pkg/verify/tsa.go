@@ -16,58 +16,353 @@ package verify
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
-	tsaverification "github.com/sigstore/timestamp-authority/pkg/verification"
+	"github.com/digitorus/pkcs7"
+	"github.com/digitorus/timestamp"
 
 	"github.com/sigstore/sigstore-go/pkg/root"
 )
 
+var (
+	ErrTSALeafIsCA              = errors.New("TSA leaf certificate must not be a CA")
+	ErrTSALeafMissingEKU        = errors.New("TSA leaf certificate is missing the extended key usage extension")
+	ErrTSALeafEKUNotCritical    = errors.New("TSA leaf certificate extended key usage extension must be critical")
+	ErrTSALeafInvalidEKU        = errors.New("TSA leaf certificate extended key usage must only be timeStamping")
+	ErrTSAChainInvalidAtGenTime = errors.New("TSA certificate chain is not valid at the timestamp's genTime")
+	ErrTSASignatureInvalid      = errors.New("timestamp was not signed by the TSA leaf certificate")
+	ErrTSAUnsupportedHash       = errors.New("timestamp message imprint uses an unsupported hash algorithm")
+	ErrTSAImprintMismatch       = errors.New("timestamp message imprint does not match the signature")
+	ErrTSAOutsideValidityPeriod = errors.New("timestamp genTime is outside the TSA's validity period in the trusted root")
+)
+
+var oidExtensionExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+
+// TimestampInfo holds the details of a verified RFC 3161 timestamp token, as
+// recorded in its TSTInfo.
+type TimestampInfo struct {
+	GenTime time.Time `json:"genTime"`
+	// Accuracy is the maximum deviation of GenTime from the actual time the
+	// timestamp was generated. Zero means the TSA did not declare it.
+	Accuracy     time.Duration `json:"accuracy"`
+	PolicyOID    string        `json:"policyOID"` //nolint:tagliatelle
+	SerialNumber string        `json:"serialNumber"`
+	TSASubject   string        `json:"tsaSubject"`
+	// TimestampedContent is the content the timestamp's message imprint
+	// was found to cover
+	TimestampedContent TimestampedContent `json:"timestampedContent"`
+}
+
+// TimestampedContent identifies the bytes an RFC 3161 timestamp's message
+// imprint is computed over. Producers differ in what they timestamp.
+type TimestampedContent string
+
+const (
+	// TimestampedSignature is the raw signature bytes: the message
+	// signature, or the first signature of a DSSE envelope. This is the
+	// documented convention, and the one cosign follows.
+	TimestampedSignature TimestampedContent = "signature"
+	// TimestampedEnvelope is the JSON encoding of the whole DSSE envelope,
+	// as the entity was read with it: the EnvelopeJSON of an envelope that
+	// implements EnvelopeJSONProvider, as it is or compacted. Re-encoding the
+	// envelope wouldn't reproduce the bytes its producer timestamped, so an
+	// envelope that doesn't keep its JSON has no timestamped envelope.
+	TimestampedEnvelope TimestampedContent = "envelope"
+	// TimestampedDigest is the message digest of a message signature. Such a
+	// timestamp only shows that the artifact existed at that time, not that
+	// it was signed then, as anyone can timestamp an artifact's digest. It is
+	// verified and reported, but doesn't count towards timestamp thresholds,
+	// isn't used as the signing time, and isn't a time the signing
+	// certificate is checked at.
+	TimestampedDigest TimestampedContent = "digest"
+)
+
+// timestampedContent is a candidate for the content covered by a timestamp.
+type timestampedContent struct {
+	kind  TimestampedContent
+	bytes []byte
+}
+
+// timestampedContents returns the candidates for the content covered by the
+// entity's timestamps, the signature first followed by any accepted
+// alternates that apply to the entity.
+func timestampedContents(sigContent SignatureContent, alternates []TimestampedContent) ([]timestampedContent, error) {
+	contents := []timestampedContent{{kind: TimestampedSignature, bytes: sigContent.Signature()}}
+	for _, alternate := range alternates {
+		switch alternate {
+		case TimestampedSignature:
+		case TimestampedEnvelope:
+			if envelope := sigContent.EnvelopeContent(); envelope != nil {
+				contents = append(contents, envelopeContents(envelope)...)
+			}
+		case TimestampedDigest:
+			if msg := sigContent.MessageSignatureContent(); msg != nil {
+				contents = append(contents, timestampedContent{kind: TimestampedDigest, bytes: msg.Digest()})
+			}
+		default:
+			return nil, fmt.Errorf("unknown timestamped content: %s", alternate)
+		}
+	}
+	return contents, nil
+}
+
+// envelopeContents returns the candidates for a timestamp over the envelope:
+// the JSON it was read from, and that JSON compacted, since an envelope read
+// as part of an indented bundle carries the bundle's indentation.
+func envelopeContents(envelope EnvelopeContent) []timestampedContent {
+	provider, ok := envelope.(EnvelopeJSONProvider)
+	if !ok || len(provider.EnvelopeJSON()) == 0 {
+		return nil
+	}
+	envelopeJSON := provider.EnvelopeJSON()
+	contents := []timestampedContent{{kind: TimestampedEnvelope, bytes: envelopeJSON}}
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, envelopeJSON); err == nil && !bytes.Equal(compacted.Bytes(), envelopeJSON) {
+		contents = append(contents, timestampedContent{kind: TimestampedEnvelope, bytes: compacted.Bytes()})
+	}
+	return contents
+}
+
+// matchTimestampedContent returns the candidate whose hash, computed with the
+// timestamp's declared hash algorithm, equals the timestamp's message imprint.
+// A well-formed token from a trusted TSA is otherwise no evidence of when the
+// entity was signed, as it may have been issued for any other content.
+func matchTimestampedContent(ts *timestamp.Timestamp, contents []timestampedContent) (timestampedContent, error) {
+	hasher := ts.HashAlgorithm.New()
+	var sum []byte
+	for _, content := range contents {
+		hasher.Reset()
+		hasher.Write(content.bytes)
+		sum = hasher.Sum(sum[:0])
+		if bytes.Equal(sum, ts.HashedMessage) {
+			return content, nil
+		}
+	}
+	return timestampedContent{}, fmt.Errorf("%w: no accepted timestamped content hashes to %x", ErrTSAImprintMismatch, ts.HashedMessage)
+}
+
+// Earliest returns the earliest time the timestamp may have been generated,
+// taking its accuracy into account.
+func (i TimestampInfo) Earliest() time.Time {
+	return i.GenTime.Add(-i.Accuracy)
+}
+
+// Latest returns the latest time the timestamp may have been generated,
+// taking its accuracy into account.
+func (i TimestampInfo) Latest() time.Time {
+	return i.GenTime.Add(i.Accuracy)
+}
+
+// verifiedSignedTimestamp is an RFC 3161 timestamp that has been verified
+// against one of the trusted timestamp authorities.
+type verifiedSignedTimestamp struct {
+	Time time.Time
+	Info TimestampInfo
+	// authority identifies the timestamp authority that issued the timestamp
+	authority string
+}
+
+// timestampAuthority is a trusted timestamp authority along with the state
+// needed to verify its timestamps that does not depend on the timestamp
+// itself, so that it can be prepared once and reused across verifications.
+type timestampAuthority struct {
+	root.CertificateAuthority
+	// id distinguishes the authority when counting distinct authorities
+	id            string
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	// leafErr is the result of checking the authority's own leaf certificate,
+	// if the trusted material provides one
+	leafErr error
+}
+
+func newTimestampAuthorities(cas []root.CertificateAuthority) []*timestampAuthority {
+	authorities := make([]*timestampAuthority, 0, len(cas))
+	for _, ca := range cas {
+		tsa := &timestampAuthority{
+			CertificateAuthority: ca,
+			roots:                x509.NewCertPool(),
+			intermediates:        x509.NewCertPool(),
+		}
+		if ca.Root != nil {
+			tsa.id = string(ca.Root.Raw)
+			tsa.roots.AddCert(ca.Root)
+		}
+		for _, cert := range ca.Intermediates {
+			tsa.intermediates.AddCert(cert)
+		}
+		if ca.Leaf != nil {
+			tsa.leafErr = checkTSALeafCertificate(ca.Leaf)
+		}
+		authorities = append(authorities, tsa)
+	}
+	return authorities
+}
+
+// timestampAuthorityCache holds the prepared timestamp authorities for the
+// trusted material last seen. Trusted material that is refreshed, such as a
+// LiveTrustedRoot, is recognised as unchanged by its fingerprint when it has
+// one, and otherwise by returning the same authorities. When it has changed,
+// the authorities are prepared again.
+type timestampAuthorityCache struct {
+	mu          sync.Mutex
+	fingerprint string
+	cas         []root.CertificateAuthority
+	authorities []*timestampAuthority
+}
+
+func newTimestampAuthorityCache(trustedMaterial root.TrustedMaterial) *timestampAuthorityCache {
+	c := &timestampAuthorityCache{}
+	c.get(trustedMaterial)
+	return c
+}
+
+// get returns the prepared timestamp authorities of the trusted material,
+// and whether they were prepared by an earlier call.
+func (c *timestampAuthorityCache) get(trustedMaterial root.TrustedMaterial) ([]*timestampAuthority, bool) {
+	fingerprint := trustedMaterialFingerprint(trustedMaterial)
+	cas := trustedMaterial.TimestampingAuthorities()
+	if fingerprint != trustedMaterialFingerprint(trustedMaterial) {
+		// refreshed in between, so the fingerprint may not be that of cas
+		fingerprint = ""
+	}
+	if c == nil {
+		return newTimestampAuthorities(cas), false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.authorities != nil {
+		if fingerprint != "" && fingerprint == c.fingerprint {
+			return c.authorities, true
+		}
+		if fingerprint == "" && sameCertificateAuthorities(c.cas, cas) {
+			return c.authorities, true
+		}
+	}
+	c.fingerprint = fingerprint
+	c.cas = append([]root.CertificateAuthority(nil), cas...)
+	c.authorities = newTimestampAuthorities(cas)
+	return c.authorities, false
+}
+
+// trustedMaterialFingerprint returns the fingerprint of the trusted material,
+// or "" if it has none.
+func trustedMaterialFingerprint(trustedMaterial root.TrustedMaterial) string {
+	if fingerprinted, ok := trustedMaterial.(root.Fingerprinted); ok {
+		return fingerprinted.Fingerprint()
+	}
+	return ""
+}
+
+// sameCertificateAuthorities reports whether both lists hold the same
+// certificates, by identity, with the same validity periods.
+func sameCertificateAuthorities(a, b []root.CertificateAuthority) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Root != b[i].Root || a[i].Leaf != b[i].Leaf || len(a[i].Intermediates) != len(b[i].Intermediates) {
+			return false
+		}
+		for j := range a[i].Intermediates {
+			if a[i].Intermediates[j] != b[i].Intermediates[j] {
+				return false
+			}
+		}
+		if !a[i].ValidityPeriodStart.Equal(b[i].ValidityPeriodStart) || !a[i].ValidityPeriodEnd.Equal(b[i].ValidityPeriodEnd) {
+			return false
+		}
+	}
+	return true
+}
+
 // VerifyTimestampAuthority verifies that the given entity has been timestamped
 // by a trusted timestamp authority and that the timestamp is valid.
 func VerifyTimestampAuthority(entity SignedEntity, trustedMaterial root.TrustedMaterial) ([]time.Time, error) { //nolint:revive
-	signedTimestamps, err := entity.Timestamps()
+	authorities := newTimestampAuthorities(trustedMaterial.TimestampingAuthorities())
+	verifiedSignedTimestamps, _, err := verifyTimestampAuthority(entity, authorities, trustedMaterial, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	verifiedTimestamps := make([]time.Time, len(verifiedSignedTimestamps))
+	for i, vts := range verifiedSignedTimestamps {
+		verifiedTimestamps[i] = vts.Time
+	}
+
+	return verifiedTimestamps, nil
+}
+
+// verifyTimestampAuthority returns the entity's verified signed timestamps,
+// along with the reasons any other signed timestamps were not verified.
+// Timestamps must cover the entity's signature, or one of the alternate
+// timestamped contents.
+func verifyTimestampAuthority(entity SignedEntity, authorities []*timestampAuthority, trustedMaterial root.TrustedMaterial, alternates []TimestampedContent) ([]verifiedSignedTimestamp, []error, error) {
+	signedTimestamps, err := entity.Timestamps()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// disallow duplicate timestamps, as a malicious actor could use duplicates to bypass the threshold
 	for i := 0; i < len(signedTimestamps); i++ {
 		for j := i + 1; j < len(signedTimestamps); j++ {
 			if bytes.Equal(signedTimestamps[i], signedTimestamps[j]) {
-				return nil, errors.New("duplicate timestamps found")
+				return nil, nil, errors.New("duplicate timestamps found")
 			}
 		}
 	}
 
 	sigContent, err := entity.SignatureContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	signatureBytes := sigContent.Signature()
+	contents, err := timestampedContents(sigContent, alternates)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	verificationContent, err := entity.VerificationContent()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	verifiedTimestamps := []time.Time{}
+	verifiedTimestamps := []verifiedSignedTimestamp{}
+	var rejected []error
 	for _, timestamp := range signedTimestamps {
-		verifiedSignedTimestamp, err := verifySignedTimestamp(timestamp, signatureBytes, trustedMaterial, verificationContent)
+		verifiedTimestamp, err := verifySignedTimestamp(timestamp, contents, authorities, trustedMaterial, verificationContent)
 
 		// Timestamps from unknown source are okay, but don't count as verified
 		if err != nil {
+			rejected = append(rejected, err)
 			continue
 		}
 
-		verifiedTimestamps = append(verifiedTimestamps, verifiedSignedTimestamp)
+		verifiedTimestamps = append(verifiedTimestamps, verifiedTimestamp)
 	}
 
-	return verifiedTimestamps, nil
+	return verifiedTimestamps, rejected, nil
+}
+
+// countDistinctTimestampAuthorities returns the number of distinct timestamp
+// authorities that issued the given verified timestamps. Authorities are
+// distinguished by their root certificate, so several timestamps from the same
+// TSA only count once.
+func countDistinctTimestampAuthorities(verifiedTimestamps []verifiedSignedTimestamp) int {
+	authorities := make(map[string]struct{})
+	for _, vts := range verifiedTimestamps {
+		authorities[vts.authority] = struct{}{}
+	}
+	return len(authorities)
 }
 
 // VerifyTimestampAuthority verifies that the given entity has been timestamped
@@ -76,49 +371,186 @@ func VerifyTimestampAuthority(entity SignedEntity, trustedMaterial root.TrustedM
 // The threshold parameter is the number of unique timestamps that must be
 // verified.
 func VerifyTimestampAuthorityWithThreshold(entity SignedEntity, trustedMaterial root.TrustedMaterial, threshold int) ([]time.Time, error) { //nolint:revive
-	verifiedTimestamps, err := VerifyTimestampAuthority(entity, trustedMaterial)
+	authorities := newTimestampAuthorities(trustedMaterial.TimestampingAuthorities())
+	verifiedSignedTimestamps, rejected, err := verifyTimestampAuthority(entity, authorities, trustedMaterial, nil)
 	if err != nil {
 		return nil, err
 	}
-	if len(verifiedTimestamps) < threshold {
-		return nil, fmt.Errorf("threshold not met for verified signed timestamps: %d < %d", len(verifiedTimestamps), threshold)
+	if len(verifiedSignedTimestamps) < threshold {
+		return nil, errors.Join(append([]error{fmt.Errorf("%w for verified signed timestamps: %d < %d", ErrThresholdNotMet, len(verifiedSignedTimestamps), threshold)}, rejected...)...)
+	}
+
+	verifiedTimestamps := make([]time.Time, len(verifiedSignedTimestamps))
+	for i, vts := range verifiedSignedTimestamps {
+		verifiedTimestamps[i] = vts.Time
 	}
 	return verifiedTimestamps, nil
 }
 
-func verifySignedTimestamp(signedTimestamp []byte, dsseSignatureBytes []byte, trustedMaterial root.TrustedMaterial, verificationContent VerificationContent) (time.Time, error) {
-	certAuthorities := trustedMaterial.TimestampingAuthorities()
+func verifySignedTimestamp(signedTimestamp []byte, contents []timestampedContent, authorities []*timestampAuthority, trustedMaterial root.TrustedMaterial, verificationContent VerificationContent) (verifiedSignedTimestamp, error) {
+	ts, err := timestamp.ParseResponse(signedTimestamp)
+	if err != nil {
+		return verifiedSignedTimestamp{}, fmt.Errorf("unable to parse signed timestamp: %w", err)
+	}
+
+	// The message imprint is recomputed with the hash algorithm the token
+	// declares, which must not be a broken one such as MD5 or SHA-1
+	switch ts.HashAlgorithm {
+	case crypto.SHA256, crypto.SHA384, crypto.SHA512:
+	default:
+		return verifiedSignedTimestamp{}, fmt.Errorf("%w: %s", ErrTSAUnsupportedHash, ts.HashAlgorithm)
+	}
+
+	content, err := matchTimestampedContent(ts, contents)
+	if err != nil {
+		return verifiedSignedTimestamp{}, err
+	}
+
+	token, err := pkcs7.Parse(ts.RawToken)
+	if err != nil {
+		return verifiedSignedTimestamp{}, fmt.Errorf("unable to parse signed timestamp: %w", err)
+	}
+	if len(token.Signers) != 1 {
+		return verifiedSignedTimestamp{}, fmt.Errorf("signed timestamp must have exactly one signer, found %d", len(token.Signers))
+	}
+
+	// The certificates a timestamp embeds are an unordered set, which may
+	// hold the TSA's chain as well as its leaf, so the embedded leaf is the
+	// one the SignerInfo identifies by issuer and serial number
+	var embeddedLeafErr error
+	embeddedLeaf := token.GetOnlySigner()
+	if embeddedLeaf != nil {
+		embeddedLeafErr = checkTSALeafCertificate(embeddedLeaf)
+	}
+
+	var errs []error
 
 	// Iterate through TSA certificate authorities to find one that verifies
-	for _, ca := range certAuthorities {
-		trustedRootVerificationOptions := tsaverification.VerifyOpts{
-			Roots:          []*x509.Certificate{ca.Root},
-			Intermediates:  ca.Intermediates,
-			TSACertificate: ca.Leaf,
+	for _, ca := range authorities {
+		leaf := ca.Leaf
+		leafErr := ca.leafErr
+		if leaf == nil {
+			leaf = embeddedLeaf
+			leafErr = embeddedLeafErr
+		}
+		if leaf == nil {
+			errs = append(errs, errors.New("timestamp does not embed its signing certificate and the trusted root does not provide one"))
+			continue
+		}
+		if leafErr != nil {
+			errs = append(errs, leafErr)
+			continue
+		}
+		if err := verifyTSAChainAtTime(leaf, ca, ts.Time); err != nil {
+			errs = append(errs, err)
+			continue
 		}
 
 		// Ensure timestamp responses are from trusted sources
-		timestamp, err := tsaverification.VerifyTimestampResponse(signedTimestamp, bytes.NewReader(dsseSignatureBytes), trustedRootVerificationOptions)
-		if err != nil {
+		if err := verifyTimestampSignature(token, leaf); err != nil {
+			errs = append(errs, err)
 			continue
 		}
 
-		if !ca.ValidityPeriodStart.IsZero() && timestamp.Time.Before(ca.ValidityPeriodStart) {
+		// The chain may still verify for timestamps issued before the TSA was
+		// trusted, or after it stopped being trusted, so the trusted root's
+		// validity period is checked separately. Zero bounds are open-ended.
+		if !ca.ValidityPeriodStart.IsZero() && ts.Time.Before(ca.ValidityPeriodStart) {
+			errs = append(errs, fmt.Errorf("%w: genTime %s is before the start of the validity period %s", ErrTSAOutsideValidityPeriod, ts.Time.Format(time.RFC3339), ca.ValidityPeriodStart.Format(time.RFC3339)))
 			continue
 		}
-		if !ca.ValidityPeriodEnd.IsZero() && timestamp.Time.After(ca.ValidityPeriodEnd) {
+		if !ca.ValidityPeriodEnd.IsZero() && ts.Time.After(ca.ValidityPeriodEnd) {
+			errs = append(errs, fmt.Errorf("%w: genTime %s is after the end of the validity period %s", ErrTSAOutsideValidityPeriod, ts.Time.Format(time.RFC3339), ca.ValidityPeriodEnd.Format(time.RFC3339)))
 			continue
 		}
 
-		// Check tlog entry time against bundle certificates
+		info := TimestampInfo{
+			GenTime:            ts.Time,
+			Accuracy:           ts.Accuracy,
+			TimestampedContent: content.kind,
+		}
+		if ts.Policy != nil {
+			info.PolicyOID = ts.Policy.String()
+		}
+		if ts.SerialNumber != nil {
+			info.SerialNumber = ts.SerialNumber.String()
+		}
+		info.TSASubject = leaf.Subject.String()
+
+		// Check timestamp against bundle certificates, over the whole window
+		// the timestamp's accuracy allows for. A timestamp over the digest
+		// says nothing about when the certificate signed it.
 		// TODO: technically no longer needed since we check the cert validity period in the main Verify loop
-		if !verificationContent.ValidAtTime(timestamp.Time, trustedMaterial) {
+		if content.kind != TimestampedDigest && (!verificationContent.ValidAtTime(info.Earliest(), trustedMaterial) || !verificationContent.ValidAtTime(info.Latest(), trustedMaterial)) {
+			errs = append(errs, errors.New("timestamp outside certificate validity"))
 			continue
 		}
 
 		// All above verification successful, so return nil
-		return timestamp.Time, nil
+		return verifiedSignedTimestamp{Time: ts.Time, Info: info, authority: ca.id}, nil
+	}
+
+	return verifiedSignedTimestamp{}, fmt.Errorf("unable to verify signed timestamps: %w", errors.Join(errs...))
+}
+
+// verifyTimestampSignature checks that leaf is the certificate the timestamp
+// token's SignerInfo identifies, and that it signed the token. Any other
+// certificates the token embeds are ignored: leaf has been checked, and
+// chained to the timestamp authority, already.
+func verifyTimestampSignature(token *pkcs7.PKCS7, leaf *x509.Certificate) error {
+	signed := *token
+	signed.Certificates = []*x509.Certificate{leaf}
+	if err := signed.Verify(); err != nil {
+		return fmt.Errorf("%w: %w", ErrTSASignatureInvalid, err)
+	}
+	return nil
+}
+
+// checkTSALeafCertificate checks that the certificate that signed a timestamp
+// is a proper RFC 3161 TSA certificate.
+func checkTSALeafCertificate(leaf *x509.Certificate) error {
+	if leaf.IsCA {
+		return ErrTSALeafIsCA
+	}
+
+	// RFC 3161 2.3: the certificate MUST contain only one instance of the
+	// extended key usage field extension, with KeyPurposeID having value
+	// id-kp-timeStamping, and this extension MUST be critical.
+	var ekuFound bool
+	for _, ext := range leaf.Extensions {
+		if !ext.Id.Equal(oidExtensionExtendedKeyUsage) {
+			continue
+		}
+		ekuFound = true
+		if !ext.Critical {
+			return ErrTSALeafEKUNotCritical
+		}
+	}
+	if !ekuFound {
+		return ErrTSALeafMissingEKU
+	}
+	if len(leaf.ExtKeyUsage) != 1 || leaf.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping || len(leaf.UnknownExtKeyUsage) != 0 {
+		return ErrTSALeafInvalidEKU
+	}
+
+	return nil
+}
+
+// verifyTSAChainAtTime checks that the certificate that signed a timestamp
+// chains to the timestamp authority at the time the timestamp claims to have
+// been generated.
+func verifyTSAChainAtTime(leaf *x509.Certificate, tsa *timestampAuthority, genTime time.Time) error {
+	_, err := leaf.Verify(x509.VerifyOptions{
+		CurrentTime:   genTime,
+		Roots:         tsa.roots,
+		Intermediates: tsa.intermediates,
+		KeyUsages: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageTimeStamping,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTSAChainInvalidAtGenTime, err)
 	}
 
-	return time.Time{}, errors.New("unable to verify signed timestamps")
+	return nil
 }
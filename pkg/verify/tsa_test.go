@@ -15,13 +15,29 @@
 package verify_test
 
 import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
 	"testing"
 	"time"
 
+	protocommon "github.com/sigstore/protobuf-specs/gen/pb-go/common/v1"
+	prototrustroot "github.com/sigstore/protobuf-specs/gen/pb-go/trustroot/v1"
+	"github.com/sigstore/sigstore-go/pkg/bundle"
 	"github.com/sigstore/sigstore-go/pkg/root"
 	"github.com/sigstore/sigstore-go/pkg/testing/ca"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
 	"github.com/sigstore/sigstore-go/pkg/verify"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTimestampAuthorityVerifier(t *testing.T) {
@@ -168,6 +184,156 @@ func TestBadTSACertificateChain(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestTSACertificateChainOrder(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	tsa := virtualSigstore.TimestampingAuthorities()[0]
+	leaf, intermediate, rootCert := tsa.Leaf, tsa.Intermediates[0], tsa.Root
+
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name  string
+		chain []*x509.Certificate
+	}{
+		{
+			name:  "leaf first",
+			chain: []*x509.Certificate{leaf, intermediate, rootCert},
+		},
+		{
+			name:  "root first",
+			chain: []*x509.Certificate{rootCert, intermediate, leaf},
+		},
+		{
+			name:  "intermediate first",
+			chain: []*x509.Certificate{intermediate, rootCert, leaf},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			chain := &protocommon.X509CertificateChain{}
+			for _, cert := range test.chain {
+				chain.Certificates = append(chain.Certificates, &protocommon.X509Certificate{RawBytes: cert.Raw})
+			}
+			parsed, err := root.ParseCertificateAuthority(&prototrustroot.CertificateAuthority{CertChain: chain})
+			assert.NoError(t, err)
+
+			_, err = verify.VerifyTimestampAuthorityWithThreshold(entity, &customTSAChainTrustedMaterial{VirtualSigstore: virtualSigstore, tsaChain: []root.CertificateAuthority{*parsed}}, 1)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestTSAEmbeddedCertificates(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	require.NoError(t, err)
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	require.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	require.NoError(t, err)
+
+	rootCert, rootKey, err := ca.GenerateRootCa()
+	require.NoError(t, err)
+	intermediate, intermediateKey, err := ca.GenerateTSAIntermediate(rootCert, rootKey)
+	require.NoError(t, err)
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leaf, err := ca.GenerateTSALeafCert(time.Now().Add(-5*time.Minute), leafKey, intermediate, intermediateKey)
+	require.NoError(t, err)
+
+	// a certificate from the same TSA that isn't for timestamping
+	codeSigningOID := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	other, err := ca.GenerateTSALeafCert(time.Now().Add(-5*time.Minute), otherKey, intermediate, intermediateKey,
+		ca.WithTSALeafEKU(codeSigningOID), func(cert *x509.Certificate) { cert.SerialNumber = big.NewInt(2) })
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		name        string
+		signer      *x509.Certificate
+		signerKey   *ecdsa.PrivateKey
+		embedded    []*x509.Certificate
+		trustedLeaf bool
+		wantErr     error
+		wantErrMsg  string
+	}{
+		{
+			name:      "leaf only",
+			signer:    leaf,
+			signerKey: leafKey,
+			embedded:  []*x509.Certificate{leaf},
+		},
+		{
+			name:      "leaf after its chain",
+			signer:    leaf,
+			signerKey: leafKey,
+			embedded:  []*x509.Certificate{rootCert, intermediate, leaf},
+		},
+		{
+			name:       "leaf not embedded",
+			signer:     leaf,
+			signerKey:  leafKey,
+			embedded:   []*x509.Certificate{intermediate, rootCert},
+			wantErrMsg: "unable to parse signed timestamp",
+		},
+		{
+			name:      "signed by another embedded certificate",
+			signer:    other,
+			signerKey: otherKey,
+			embedded:  []*x509.Certificate{leaf, other},
+			wantErr:   verify.ErrTSALeafInvalidEKU,
+		},
+		{
+			name:       "signed by another key than the leaf's",
+			signer:     leaf,
+			signerKey:  otherKey,
+			embedded:   []*x509.Certificate{leaf},
+			wantErrMsg: "unable to parse signed timestamp",
+		},
+		{
+			name:        "trusted leaf",
+			signer:      leaf,
+			signerKey:   leafKey,
+			embedded:    []*x509.Certificate{intermediate, leaf},
+			trustedLeaf: true,
+		},
+		{
+			name:        "signed by another certificate than the trusted leaf",
+			signer:      other,
+			signerKey:   otherKey,
+			embedded:    []*x509.Certificate{leaf, other},
+			trustedLeaf: true,
+			wantErr:     verify.ErrTSASignatureInvalid,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := ca.GenerateTimestampResponse(sigContent.Signature(), test.signer, test.signerKey, test.embedded)
+			require.NoError(t, err)
+
+			tsa := root.CertificateAuthority{
+				Root:          rootCert,
+				Intermediates: []*x509.Certificate{intermediate},
+			}
+			if test.trustedLeaf {
+				tsa.Leaf = leaf
+			}
+			trustedMaterial := &customTSAChainTrustedMaterial{VirtualSigstore: virtualSigstore, tsaChain: []root.CertificateAuthority{tsa}}
+
+			_, err = verify.VerifyTimestampAuthorityWithThreshold(&multiTimestampEntity{entity, [][]byte{ts}}, trustedMaterial, 1)
+			switch {
+			case test.wantErr != nil:
+				assert.ErrorIs(t, err, test.wantErr)
+			case test.wantErrMsg != "":
+				assert.ErrorContains(t, err, test.wantErrMsg)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestBadTSACertificateChainOutsideValidityPeriod(t *testing.T) {
 	virtualSigstore, err := ca.NewVirtualSigstore()
 	assert.NoError(t, err)
@@ -223,3 +389,882 @@ func TestBadTSACertificateChainOutsideValidityPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestTSAValidityPeriod(t *testing.T) {
+	now := time.Now()
+
+	for _, test := range []struct {
+		name       string
+		start, end time.Time
+		errMessage string
+	}{
+		{
+			name: "open-ended on both sides",
+		},
+		{
+			name:  "open-ended end",
+			start: now.Add(-time.Hour),
+		},
+		{
+			name: "open-ended start",
+			end:  now.Add(time.Hour),
+		},
+		{
+			name:  "closed period containing genTime",
+			start: now.Add(-time.Hour),
+			end:   now.Add(time.Hour),
+		},
+		{
+			name:       "genTime predates the period",
+			start:      now.Add(time.Hour),
+			errMessage: "before the start of the validity period",
+		},
+		{
+			name:       "genTime postdates a closed period",
+			start:      now.Add(-2 * time.Hour),
+			end:        now.Add(-time.Hour),
+			errMessage: "after the end of the validity period",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			virtualSigstore, err := ca.NewVirtualSigstore()
+			assert.NoError(t, err)
+			virtualSigstore.SetTSAValidityPeriod(test.start, test.end)
+
+			entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+			assert.NoError(t, err)
+
+			_, err = verify.VerifyTimestampAuthorityWithThreshold(entity, virtualSigstore, 1)
+			if test.errMessage == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, verify.ErrTSAOutsideValidityPeriod)
+			assert.ErrorContains(t, err, test.errMessage)
+		})
+	}
+}
+
+type multiTimestampEntity struct {
+	*ca.TestEntity
+	timestamps [][]byte
+}
+
+func (e *multiTimestampEntity) Timestamps() ([][]byte, error) {
+	return e.timestamps, nil
+}
+
+func TestTimestampThresholdSemantics(t *testing.T) {
+	virtualSigstoreA, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	virtualSigstoreB, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	entity, err := virtualSigstoreA.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	// each call yields a distinct timestamp, as the TSA signature is randomized
+	timestampsFrom := func(vs *ca.VirtualSigstore, n int) [][]byte {
+		var timestamps [][]byte
+		for i := 0; i < n; i++ {
+			ts, err := vs.TimestampResponse(sigContent.Signature())
+			assert.NoError(t, err)
+			timestamps = append(timestamps, ts)
+		}
+		return timestamps
+	}
+
+	trustedMaterial := root.TrustedMaterialCollection{virtualSigstoreA, virtualSigstoreB}
+	logTimestamp := verify.TimestampVerificationResult{Type: "Tlog", Timestamp: time.Now()}
+
+	for _, test := range []struct {
+		name          string
+		fromA         int
+		fromB         int
+		logTimestamps int
+		opts          []verify.VerifierOption
+		wantCount     int
+		wantErr       bool
+	}{
+		{
+			name:      "signed: 2 from A, 1 from B meets threshold of 3",
+			fromA:     2,
+			fromB:     1,
+			opts:      []verify.VerifierOption{verify.WithSignedTimestamps(3)},
+			wantCount: 3,
+		},
+		{
+			name:    "signed distinct: 2 from A, 1 from B fails threshold of 3",
+			fromA:   2,
+			fromB:   1,
+			opts:    []verify.VerifierOption{verify.WithSignedTimestamps(3), verify.WithDistinctTimestampAuthorities()},
+			wantErr: true,
+		},
+		{
+			name:      "signed distinct: 2 from A, 1 from B meets threshold of 2",
+			fromA:     2,
+			fromB:     1,
+			opts:      []verify.VerifierOption{verify.WithSignedTimestamps(2), verify.WithDistinctTimestampAuthorities()},
+			wantCount: 3,
+		},
+		{
+			name:      "signed: 2 from A meets threshold of 2",
+			fromA:     2,
+			opts:      []verify.VerifierOption{verify.WithSignedTimestamps(2)},
+			wantCount: 2,
+		},
+		{
+			name:    "signed distinct: 2 from A fails threshold of 2",
+			fromA:   2,
+			opts:    []verify.VerifierOption{verify.WithSignedTimestamps(2), verify.WithDistinctTimestampAuthorities()},
+			wantErr: true,
+		},
+		{
+			name:      "signed distinct: 3 from A meets threshold of 1",
+			fromA:     3,
+			opts:      []verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithDistinctTimestampAuthorities()},
+			wantCount: 3,
+		},
+		{
+			name:          "observer: 2 from A and 1 log meets threshold of 3",
+			fromA:         2,
+			logTimestamps: 1,
+			opts:          []verify.VerifierOption{verify.WithObserverTimestamps(3)},
+			wantCount:     3,
+		},
+		{
+			name:          "observer distinct: 2 from A and 1 log fails threshold of 3",
+			fromA:         2,
+			logTimestamps: 1,
+			opts:          []verify.VerifierOption{verify.WithObserverTimestamps(3), verify.WithDistinctTimestampAuthorities()},
+			wantErr:       true,
+		},
+		{
+			name:          "observer distinct: 2 from A and 1 log meets threshold of 2",
+			fromA:         2,
+			logTimestamps: 1,
+			opts:          []verify.VerifierOption{verify.WithObserverTimestamps(2), verify.WithDistinctTimestampAuthorities()},
+			wantCount:     3,
+		},
+		{
+			name:          "observer distinct: 1 from A, 1 from B and 2 logs meets threshold of 4",
+			fromA:         1,
+			fromB:         1,
+			logTimestamps: 2,
+			opts:          []verify.VerifierOption{verify.WithObserverTimestamps(4), verify.WithDistinctTimestampAuthorities()},
+			wantCount:     4,
+		},
+		{
+			name:          "observer: only logs meets threshold of 2",
+			logTimestamps: 2,
+			opts:          []verify.VerifierOption{verify.WithObserverTimestamps(2)},
+			wantCount:     2,
+		},
+		{
+			name:    "observer: no timestamps fails threshold of 1",
+			opts:    []verify.VerifierOption{verify.WithObserverTimestamps(1)},
+			wantErr: true,
+		},
+		{
+			name:          "signed and integrated: 1 from B and 1 log meets both thresholds",
+			fromB:         1,
+			logTimestamps: 1,
+			opts:          []verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithIntegratedTimestamps(1)},
+			wantCount:     2,
+		},
+		{
+			name:    "signed and integrated: 1 from B and no log fails integrated threshold",
+			fromB:   1,
+			opts:    []verify.VerifierOption{verify.WithSignedTimestamps(1), verify.WithIntegratedTimestamps(1)},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			timestamps := append(timestampsFrom(virtualSigstoreA, test.fromA), timestampsFrom(virtualSigstoreB, test.fromB)...)
+			var logTimestamps []verify.TimestampVerificationResult
+			for i := 0; i < test.logTimestamps; i++ {
+				logTimestamps = append(logTimestamps, logTimestamp)
+			}
+
+			v, err := verify.NewSignedEntityVerifier(trustedMaterial, test.opts...)
+			assert.NoError(t, err)
+
+			verifiedTimestamps, err := v.VerifyObserverTimestamps(&multiTimestampEntity{entity, timestamps}, logTimestamps)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, verifiedTimestamps, test.wantCount)
+			}
+		})
+	}
+}
+
+func TestSigningTimeIsEarliestTimestamp(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	// integrated time is later than the signed timestamp
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1), verify.WithIntegratedTimestamps(1))
+	assert.NoError(t, err)
+
+	res, err := v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+	assert.Len(t, res.VerifiedTimestamps, 2)
+	for _, vts := range res.VerifiedTimestamps {
+		assert.False(t, vts.Timestamp.Before(res.SigningTime))
+	}
+	assert.Equal(t, "TimestampAuthority", res.VerifiedTimestamps[0].Type)
+	assert.Equal(t, res.VerifiedTimestamps[0].Timestamp, res.SigningTime)
+}
+
+func TestTSALeafCertificateProperties(t *testing.T) {
+	codeSigningOID := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}
+	timeStampingOID := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}
+
+	for _, test := range []struct {
+		name    string
+		opts    []ca.TSALeafOption
+		wantErr error
+	}{
+		{
+			name: "conforming leaf",
+		},
+		{
+			name:    "leaf is a CA",
+			opts:    []ca.TSALeafOption{ca.WithTSALeafIsCA()},
+			wantErr: verify.ErrTSALeafIsCA,
+		},
+		{
+			name:    "leaf without EKU",
+			opts:    []ca.TSALeafOption{ca.WithoutTSALeafEKU()},
+			wantErr: verify.ErrTSALeafMissingEKU,
+		},
+		{
+			name:    "leaf with non-critical EKU",
+			opts:    []ca.TSALeafOption{ca.WithNonCriticalTSALeafEKU()},
+			wantErr: verify.ErrTSALeafEKUNotCritical,
+		},
+		{
+			name:    "leaf with code signing EKU",
+			opts:    []ca.TSALeafOption{ca.WithTSALeafEKU(codeSigningOID)},
+			wantErr: verify.ErrTSALeafInvalidEKU,
+		},
+		{
+			name:    "leaf with additional EKU",
+			opts:    []ca.TSALeafOption{ca.WithTSALeafEKU(timeStampingOID, codeSigningOID)},
+			wantErr: verify.ErrTSALeafInvalidEKU,
+		},
+		{
+			name:    "leaf not yet valid at genTime",
+			opts:    []ca.TSALeafOption{ca.WithTSALeafValidity(time.Now().Add(time.Hour), time.Now().Add(2*time.Hour))},
+			wantErr: verify.ErrTSAChainInvalidAtGenTime,
+		},
+		{
+			name:    "leaf expired at genTime",
+			opts:    []ca.TSALeafOption{ca.WithTSALeafValidity(time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))},
+			wantErr: verify.ErrTSAChainInvalidAtGenTime,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			virtualSigstore, err := ca.NewVirtualSigstoreWithTSALeafOptions(test.opts...)
+			assert.NoError(t, err)
+
+			entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+			assert.NoError(t, err)
+
+			_, err = verify.VerifyTimestampAuthorityWithThreshold(entity, virtualSigstore, 1)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTimestampInfo(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	res, err := v.Verify(entity, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.NoError(t, err)
+	assert.Len(t, res.VerifiedTimestamps, 1)
+
+	info := res.VerifiedTimestamps[0].TimestampInfo
+	assert.NotNil(t, info)
+	assert.Equal(t, res.VerifiedTimestamps[0].Timestamp, info.GenTime)
+	assert.Equal(t, "1.3.6.1.4.1.57264.2", info.PolicyOID)
+	assert.Zero(t, info.Accuracy)
+}
+
+func TestTimestampAccuracyWidensValidityWindow(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	// the certificate's NotBefore has second granularity, so make sure genTime
+	// minus a second of accuracy falls after it
+	time.Sleep(2 * time.Second)
+
+	for _, test := range []struct {
+		name     string
+		accuracy time.Duration
+		wantErr  bool
+	}{
+		{
+			name:     "accuracy within certificate validity",
+			accuracy: time.Second,
+		},
+		{
+			name:     "accuracy reaching before certificate validity",
+			accuracy: time.Hour,
+			wantErr:  true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := virtualSigstore.TimestampResponseWithAccuracy(sigContent.Signature(), test.accuracy)
+			assert.NoError(t, err)
+
+			res, err := v.Verify(&multiTimestampEntity{entity, [][]byte{ts}}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.accuracy, res.VerifiedTimestamps[0].TimestampInfo.Accuracy)
+			}
+		})
+	}
+}
+
+func TestTimestampCrossCheckClockSkew(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	maxSkew := time.Minute
+	genTime := time.Now().Truncate(time.Second).Add(3 * time.Minute)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1),
+		verify.WithIntegratedTimestamps(1), verify.WithTimestampCrossCheck(maxSkew))
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name    string
+		offset  time.Duration
+		wantErr bool
+	}{
+		{
+			name: "identical times",
+		},
+		{
+			name:   "log at maximum skew after timestamp",
+			offset: maxSkew,
+		},
+		{
+			name:   "log at maximum skew before timestamp",
+			offset: -maxSkew,
+		},
+		{
+			name:    "log one second beyond maximum skew after timestamp",
+			offset:  maxSkew + time.Second,
+			wantErr: true,
+		},
+		{
+			name:    "log one second beyond maximum skew before timestamp",
+			offset:  -maxSkew - time.Second,
+			wantErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			entity, err := virtualSigstore.SignAtTime("foo@fighters.com", "issuer", []byte(artifact), genTime.Add(test.offset))
+			assert.NoError(t, err)
+			sigContent, err := entity.SignatureContent()
+			assert.NoError(t, err)
+			ts, err := virtualSigstore.TimestampResponseAtTime(sigContent.Signature(), genTime)
+			assert.NoError(t, err)
+
+			_, err = v.Verify(&multiTimestampEntity{entity, [][]byte{ts}}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+			if test.wantErr {
+				assert.ErrorContains(t, err, "differ by")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// the skew does not stretch the certificate's validity period: the log
+	// entry predates the certificate, but is within the skew of the timestamp
+	entity, err := virtualSigstore.SignAtTime("foo@fighters.com", "issuer", []byte(artifact), time.Now().Add(-20*time.Second))
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leaf, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+	ts, err := virtualSigstore.TimestampResponseAtTime(sigContent.Signature(), leaf.NotBefore.Add(20*time.Second))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(&multiTimestampEntity{entity, [][]byte{ts}}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.ErrorContains(t, err, "certificate")
+}
+
+func TestTimestampMessageImprintHash(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name    string
+		hash    crypto.Hash
+		wantErr error
+	}{
+		{
+			name: "SHA-256",
+			hash: crypto.SHA256,
+		},
+		{
+			name: "SHA-384",
+			hash: crypto.SHA384,
+		},
+		{
+			name: "SHA-512",
+			hash: crypto.SHA512,
+		},
+		{
+			name:    "SHA-1",
+			hash:    crypto.SHA1,
+			wantErr: verify.ErrTSAUnsupportedHash,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := virtualSigstore.TimestampResponseWithHash(sigContent.Signature(), test.hash)
+			require.NoError(t, err)
+
+			_, err = verify.VerifyTimestampAuthorityWithThreshold(&multiTimestampEntity{entity, [][]byte{ts}}, virtualSigstore, 1)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTimestampMessageImprintMismatch(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+	other, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("other statement"))
+	assert.NoError(t, err)
+	otherContent, err := other.SignatureContent()
+	assert.NoError(t, err)
+
+	// a well-formed token from the trusted TSA, but over another signature
+	ts, err := virtualSigstore.TimestampResponse(otherContent.Signature())
+	assert.NoError(t, err)
+	mismatched := &multiTimestampEntity{entity, [][]byte{ts}}
+
+	_, err = verify.VerifyTimestampAuthorityWithThreshold(mismatched, virtualSigstore, 1)
+	assert.ErrorIs(t, err, verify.ErrTSAImprintMismatch)
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+	_, err = v.Verify(mismatched, SkipArtifactAndIdentitiesPolicy)
+	assert.ErrorIs(t, err, verify.ErrTSAImprintMismatch)
+
+	// the token verifies for the signature it was issued over
+	_, err = verify.VerifyTimestampAuthorityWithThreshold(&multiTimestampEntity{other, [][]byte{ts}}, virtualSigstore, 1)
+	assert.NoError(t, err)
+}
+
+func TestTimestampedContentConventions(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	attested, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+	attestedContent, err := attested.SignatureContent()
+	assert.NoError(t, err)
+	attestedEnvelope := attestedContent.EnvelopeContent().RawEnvelope()
+	goEnvelopeJSON, err := json.Marshal(attestedEnvelope)
+	assert.NoError(t, err)
+
+	// the envelope as a producer other than encoding/json encodes it, with
+	// its fields reordered and spaced, and without an empty keyid
+	envelopeJSON := []byte(fmt.Sprintf(`{"payload": %q, "payloadType": %q, "signatures": [{"sig": %q}]}`,
+		attestedEnvelope.Payload, attestedEnvelope.PayloadType, attestedEnvelope.Signatures[0].Sig))
+	assert.NotEqual(t, goEnvelopeJSON, envelopeJSON)
+	readEnvelope := &bundle.Envelope{}
+	assert.NoError(t, json.Unmarshal(envelopeJSON, readEnvelope))
+	var compactedEnvelopeJSON bytes.Buffer
+	assert.NoError(t, json.Compact(&compactedEnvelopeJSON, envelopeJSON))
+
+	signed, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte("artifact"))
+	assert.NoError(t, err)
+	signedContent, err := signed.SignatureContent()
+	assert.NoError(t, err)
+
+	for _, test := range []struct {
+		name   string
+		entity *ca.TestEntity
+		// envelope replaces the entity's envelope, if set
+		envelope    *bundle.Envelope
+		timestamped []byte
+		alternates  []verify.TimestampedContent
+		want        verify.TimestampedContent
+		wantErr     bool
+	}{
+		{
+			name:        "signature",
+			entity:      attested,
+			timestamped: attestedContent.Signature(),
+			want:        verify.TimestampedSignature,
+		},
+		{
+			name:        "signature with alternates accepted",
+			entity:      signed,
+			timestamped: signedContent.Signature(),
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope, verify.TimestampedDigest},
+			want:        verify.TimestampedSignature,
+		},
+		{
+			name:        "envelope not accepted by default",
+			entity:      attested,
+			envelope:    readEnvelope,
+			timestamped: envelopeJSON,
+			wantErr:     true,
+		},
+		{
+			name:        "envelope",
+			entity:      attested,
+			envelope:    readEnvelope,
+			timestamped: envelopeJSON,
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope},
+			want:        verify.TimestampedEnvelope,
+		},
+		{
+			name:        "compacted envelope",
+			entity:      attested,
+			envelope:    readEnvelope,
+			timestamped: compactedEnvelopeJSON.Bytes(),
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope},
+			want:        verify.TimestampedEnvelope,
+		},
+		{
+			name:        "re-encoded envelope",
+			entity:      attested,
+			envelope:    readEnvelope,
+			timestamped: goEnvelopeJSON,
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope},
+			wantErr:     true,
+		},
+		{
+			name:        "envelope not read from JSON",
+			entity:      attested,
+			timestamped: goEnvelopeJSON,
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope},
+			wantErr:     true,
+		},
+		{
+			name:        "digest not accepted by default",
+			entity:      signed,
+			timestamped: signedContent.MessageSignatureContent().Digest(),
+			wantErr:     true,
+		},
+		{
+			// verified, but not counted; see TestTimestampedDigestIsNotSigningTime
+			name:        "digest",
+			entity:      signed,
+			timestamped: signedContent.MessageSignatureContent().Digest(),
+			alternates:  []verify.TimestampedContent{verify.TimestampedDigest},
+			wantErr:     true,
+		},
+		{
+			name:        "digest with only envelope accepted",
+			entity:      signed,
+			timestamped: signedContent.MessageSignatureContent().Digest(),
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope},
+			wantErr:     true,
+		},
+		{
+			name:        "unrelated content",
+			entity:      attested,
+			timestamped: []byte("something else"),
+			alternates:  []verify.TimestampedContent{verify.TimestampedEnvelope, verify.TimestampedDigest},
+			wantErr:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts, err := virtualSigstore.TimestampResponse(test.timestamped)
+			assert.NoError(t, err)
+
+			v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithAlternateTimestampedContent(test.alternates...))
+			assert.NoError(t, err)
+
+			timestamped := &multiTimestampEntity{test.entity, [][]byte{ts}}
+			var entity verify.SignedEntity = timestamped
+			if test.envelope != nil {
+				entity = &envelopeJSONEntity{timestamped, test.envelope}
+			}
+			verifiedTimestamps, err := v.VerifyObserverTimestamps(entity, nil)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, verifiedTimestamps, 1)
+			assert.Equal(t, test.want, verifiedTimestamps[0].TimestampInfo.TimestampedContent)
+		})
+	}
+
+	_, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1), verify.WithAlternateTimestampedContent("payload"))
+	assert.Error(t, err)
+}
+
+// envelopeJSONEntity is an entity whose envelope was read from JSON.
+type envelopeJSONEntity struct {
+	*multiTimestampEntity
+	envelope *bundle.Envelope
+}
+
+func (e *envelopeJSONEntity) SignatureContent() (verify.SignatureContent, error) {
+	return e.envelope, nil
+}
+
+func TestTimestampedDigestIsNotSigningTime(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	artifact := "Hi, I am an artifact!"
+	entity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	sigContent, err := entity.SignatureContent()
+	assert.NoError(t, err)
+	verificationContent, err := entity.VerificationContent()
+	assert.NoError(t, err)
+	leafCert, ok := verificationContent.HasCertificate()
+	assert.True(t, ok)
+
+	signatureTimestamp, err := virtualSigstore.TimestampResponse(sigContent.Signature())
+	assert.NoError(t, err)
+	// anyone can timestamp the artifact's digest, even before the signing
+	// certificate was issued
+	digestTimestamp, err := virtualSigstore.TimestampResponseAtTime(sigContent.MessageSignatureContent().Digest(), leafCert.NotBefore.Add(-time.Minute))
+	assert.NoError(t, err)
+	timestamped := &multiTimestampEntity{entity, [][]byte{signatureTimestamp, digestTimestamp}}
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(1),
+		verify.WithTimestampWithinCertValidity(), verify.WithAlternateTimestampedContent(verify.TimestampedDigest))
+	assert.NoError(t, err)
+	res, err := v.Verify(timestamped, policy)
+	assert.NoError(t, err)
+	assert.Len(t, res.VerifiedTimestamps, 2)
+	assert.Equal(t, verify.TimestampedSignature, res.VerifiedTimestamps[0].TimestampInfo.TimestampedContent)
+	assert.Equal(t, verify.TimestampedDigest, res.VerifiedTimestamps[1].TimestampInfo.TimestampedContent)
+	assert.Equal(t, res.VerifiedTimestamps[0].Timestamp, res.SigningTime)
+
+	// the digest timestamp doesn't count towards the threshold
+	v, err = verify.NewSignedEntityVerifier(virtualSigstore, verify.WithTransparencyLog(1), verify.WithSignedTimestamps(2),
+		verify.WithAlternateTimestampedContent(verify.TimestampedDigest))
+	assert.NoError(t, err)
+	_, err = v.Verify(timestamped, policy)
+	assert.ErrorIs(t, err, verify.ErrThresholdNotMet)
+}
+
+// tsaOnlyEntity has signed timestamps but no transparency log entries.
+type tsaOnlyEntity struct {
+	*ca.TestEntity
+	timestamps [][]byte
+}
+
+func (e *tsaOnlyEntity) Timestamps() ([][]byte, error) {
+	return e.timestamps, nil
+}
+
+func (e *tsaOnlyEntity) TlogEntries() ([]*tlog.Entry, error) {
+	return nil, nil
+}
+
+// tsaOnlyTrustedMaterial trusts no transparency logs.
+type tsaOnlyTrustedMaterial struct {
+	*ca.VirtualSigstore
+}
+
+func (m *tsaOnlyTrustedMaterial) RekorLogs() map[string]*root.TransparencyLog {
+	return map[string]*root.TransparencyLog{}
+}
+
+func TestVerifyWithoutTransparencyLog(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+	trustedMaterial := &tsaOnlyTrustedMaterial{virtualSigstore}
+
+	artifact := "Hi, I am an artifact!"
+	testEntity, err := virtualSigstore.Sign("foo@fighters.com", "issuer", []byte(artifact))
+	assert.NoError(t, err)
+	timestamps, err := testEntity.Timestamps()
+	assert.NoError(t, err)
+	policy := verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe())
+
+	v, err := verify.NewSignedEntityVerifier(trustedMaterial, verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog())
+	assert.NoError(t, err)
+
+	res, err := v.Verify(&tsaOnlyEntity{testEntity, timestamps}, policy)
+	assert.NoError(t, err)
+	assert.False(t, res.TransparencyLogVerified)
+	assert.Len(t, res.VerifiedTimestamps, 1)
+	assert.Equal(t, "TimestampAuthority", res.VerifiedTimestamps[0].Type)
+	assert.Equal(t, res.VerifiedTimestamps[0].Timestamp, res.SigningTime)
+
+	// without the timestamp, nothing establishes when the entity was signed
+	_, err = v.Verify(&tsaOnlyEntity{testEntity, nil}, verify.NewPolicy(verify.WithArtifact(strings.NewReader(artifact)), verify.WithoutIdentitiesUnsafe()))
+	assert.ErrorIs(t, err, verify.ErrNoTrustedTimeSource)
+	assert.ErrorContains(t, err, "no trusted time source")
+
+	// the preset needs a timestamp authority to trust
+	_, err = verify.NewSignedEntityVerifier(&customTSAChainTrustedMaterial{VirtualSigstore: virtualSigstore}, verify.WithSignedTimestamps(1), verify.WithoutTransparencyLog())
+	assert.Error(t, err)
+
+	// and signed timestamps to rely on instead of the log
+	_, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithObserverTimestamps(1), verify.WithoutTransparencyLog())
+	assert.Error(t, err)
+	_, err = verify.NewSignedEntityVerifier(trustedMaterial, verify.WithSignedTimestamps(1), verify.WithTransparencyLog(1), verify.WithoutTransparencyLog())
+	assert.Error(t, err)
+}
+
+// alternatingTSATrustedMaterial returns one of two equivalent copies of its
+// timestamp authorities on each call, so that a verifier never sees the same
+// authorities twice in a row.
+type alternatingTSATrustedMaterial struct {
+	*ca.VirtualSigstore
+	copies [2][]root.CertificateAuthority
+	calls  int
+}
+
+func (m *alternatingTSATrustedMaterial) TimestampingAuthorities() []root.CertificateAuthority {
+	m.calls++
+	return m.copies[m.calls%2]
+}
+
+func copyCertificateAuthority(t testing.TB, authority root.CertificateAuthority) root.CertificateAuthority {
+	parse := func(cert *x509.Certificate) *x509.Certificate {
+		parsed, err := x509.ParseCertificate(cert.Raw)
+		assert.NoError(t, err)
+		return parsed
+	}
+	authorityCopy := authority
+	authorityCopy.Root = parse(authority.Root)
+	authorityCopy.Intermediates = nil
+	for _, intermediate := range authority.Intermediates {
+		authorityCopy.Intermediates = append(authorityCopy.Intermediates, parse(intermediate))
+	}
+	if authority.Leaf != nil {
+		authorityCopy.Leaf = parse(authority.Leaf)
+	}
+	return authorityCopy
+}
+
+func TestTimestampAuthoritiesPreparedOnce(t *testing.T) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(t, err)
+
+	entity, err := virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+	assert.NoError(t, err)
+
+	prepared, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	alternating := &alternatingTSATrustedMaterial{VirtualSigstore: virtualSigstore}
+	for i := range alternating.copies {
+		alternating.copies[i] = []root.CertificateAuthority{copyCertificateAuthority(t, virtualSigstore.TimestampingAuthorities()[0])}
+	}
+	unprepared, err := verify.NewSignedEntityVerifier(alternating, verify.WithSignedTimestamps(1))
+	assert.NoError(t, err)
+
+	_, err = prepared.VerifyObserverTimestamps(entity, nil)
+	assert.NoError(t, err)
+	_, err = unprepared.VerifyObserverTimestamps(entity, nil)
+	assert.NoError(t, err)
+
+	// Both verifiers do the same per-call work of parsing and verifying the
+	// timestamp token, but only the second has to prepare the timestamp
+	// authorities again on every call
+	preparedAllocs := testing.AllocsPerRun(20, func() {
+		_, _ = prepared.VerifyObserverTimestamps(entity, nil)
+	})
+	unpreparedAllocs := testing.AllocsPerRun(20, func() {
+		_, _ = unprepared.VerifyObserverTimestamps(entity, nil)
+	})
+	assert.Less(t, preparedAllocs, unpreparedAllocs)
+}
+
+// FuzzVerifyTimestampAuthority checks that verifying an untrusted RFC 3161
+// timestamp never panics.
+func FuzzVerifyTimestampAuthority(f *testing.F) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(f, err)
+	entity, err := virtualSigstore.Sign("foo@example.com", "issuer", []byte("artifact"))
+	assert.NoError(f, err)
+	timestamps, err := entity.Timestamps()
+	assert.NoError(f, err)
+	for _, timestamp := range timestamps {
+		f.Add(timestamp)
+	}
+
+	f.Fuzz(func(_ *testing.T, timestamp []byte) {
+		_, _ = verify.VerifyTimestampAuthority(&multiTimestampEntity{entity, [][]byte{timestamp}}, virtualSigstore)
+	})
+}
+
+func BenchmarkVerifyObserverTimestamps(b *testing.B) {
+	virtualSigstore, err := ca.NewVirtualSigstore()
+	assert.NoError(b, err)
+
+	const bundles = 16
+	entities := make([]*ca.TestEntity, bundles)
+	for i := range entities {
+		entities[i], err = virtualSigstore.Attest("foo@fighters.com", "issuer", []byte("statement"))
+		assert.NoError(b, err)
+	}
+
+	v, err := verify.NewSignedEntityVerifier(virtualSigstore, verify.WithSignedTimestamps(1))
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := v.VerifyObserverTimestamps(entities[i%bundles], nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
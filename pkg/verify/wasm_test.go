@@ -0,0 +1,120 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !wasm
+
+package verify_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// corePackages are the packages verification needs, which must build for
+// WebAssembly without reaching for the filesystem or the network.
+var corePackages = []string{
+	"github.com/sigstore/sigstore-go/pkg/bundle",
+	"github.com/sigstore/sigstore-go/pkg/root",
+	"github.com/sigstore/sigstore-go/pkg/tlog",
+	"github.com/sigstore/sigstore-go/pkg/verify",
+	"github.com/sigstore/sigstore-go/pkg/version",
+}
+
+// wasmForbiddenImports are left to the loaders and fetchers outside the
+// WebAssembly builds of the core packages.
+var wasmForbiddenImports = []string{
+	"net",
+	"net/http",
+	"os",
+	"github.com/sigstore/sigstore-go/pkg/tuf",
+}
+
+func TestWasmBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the core packages for WebAssembly")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	for _, target := range []struct {
+		goos string
+		// runtime is the command the exec wrapper runs tests with.
+		runtime string
+	}{
+		{goos: "wasip1", runtime: wasiRuntime()},
+		{goos: "js", runtime: "node"},
+	} {
+		t.Run(target.goos, func(t *testing.T) {
+			goCmd := func(args ...string) string {
+				cmd := exec.Command(goBin, args...)
+				cmd.Dir = filepath.Join("..", "..")
+				cmd.Env = append(os.Environ(), "GOOS="+target.goos, "GOARCH=wasm")
+				out, err := cmd.CombinedOutput()
+				require.NoError(t, err, string(out))
+				return string(out)
+			}
+
+			goCmd(append([]string{"build"}, corePackages...)...)
+
+			imports := goCmd(append([]string{"list", "-f", `{{.ImportPath}}:{{range .Imports}} {{.}}{{end}}`}, corePackages...)...)
+			for _, line := range strings.Split(strings.TrimSpace(imports), "\n") {
+				pkg, pkgImports, _ := strings.Cut(line, ":")
+				for _, imported := range strings.Fields(pkgImports) {
+					assert.NotContains(t, wasmForbiddenImports, imported, "%s imports %s", pkg, imported)
+				}
+			}
+
+			if _, err := exec.LookPath(target.runtime); err != nil {
+				t.Logf("%s not found; not running the tests under WebAssembly", target.runtime)
+				return
+			}
+			execWrapper := wasmExecWrapper(t, goBin, target.goos)
+			goCmd(append([]string{"test", "-short", "-exec", execWrapper}, corePackages...)...)
+		})
+	}
+}
+
+// wasiRuntime returns the WASI runtime Go's exec wrapper uses.
+func wasiRuntime() string {
+	if runtime := os.Getenv("GOWASIRUNTIME"); runtime != "" {
+		return runtime
+	}
+	return "wasmtime"
+}
+
+// wasmExecWrapper returns the path of the script Go ships to run WebAssembly
+// binaries for goos.
+func wasmExecWrapper(t *testing.T, goBin, goos string) string {
+	out, err := exec.Command(goBin, "env", "GOROOT").Output()
+	require.NoError(t, err)
+	goroot := strings.TrimSpace(string(out))
+	name := "go_" + goos + "_wasm_exec"
+	// the scripts moved from misc/wasm to lib/wasm in Go 1.24
+	for _, dir := range []string{"lib", "misc"} {
+		path := filepath.Join(goroot, dir, "wasm", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	t.Fatalf("%s not found in %s", name, goroot)
+	return ""
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version reports the version of sigstore-go in a binary and what it
+// can verify, so that tools embedding the library can report what they
+// verify with and detect capabilities at runtime.
+package version
+
+import (
+	"runtime/debug"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+)
+
+const modulePath = "github.com/sigstore/sigstore-go"
+
+// Devel is the version reported when the binary's build information doesn't
+// record one, as when it was built from a source checkout.
+const Devel = "devel"
+
+// Features of the library that some builds leave out.
+const (
+	// FeatureSCT is the verification of certificates' signed certificate
+	// timestamps.
+	FeatureSCT = "sct"
+	// FeatureCTInclusionProof is the offline verification of certificates'
+	// inclusion in certificate transparency logs.
+	FeatureCTInclusionProof = "ct-inclusion-proof"
+	// FeatureOnlineCTInclusionProof is fetching the proofs of certificates'
+	// inclusion from certificate transparency logs.
+	FeatureOnlineCTInclusionProof = "online-ct-inclusion-proof"
+	// FeatureTUF is fetching trusted roots with TUF.
+	FeatureTUF = "tuf"
+)
+
+// Capabilities describes the version of sigstore-go in the binary and what it
+// can verify.
+type Capabilities struct {
+	// Version is the sigstore-go module version, or Devel.
+	Version string `json:"version"`
+	// BundleMediaTypes are the media types of the bundle versions it
+	// verifies.
+	BundleMediaTypes []string `json:"bundleMediaTypes"`
+	// TlogEntryTypes are the kinds and versions of Rekor entries it
+	// verifies, as "kind/version".
+	TlogEntryTypes []string `json:"tlogEntryTypes"`
+	// TrustedRootMediaTypes are the media types of the trusted roots it
+	// parses.
+	TrustedRootMediaTypes []string `json:"trustedRootMediaTypes"`
+	// Features are the optional features available in this build.
+	Features []string `json:"features"`
+}
+
+// Version returns the sigstore-go module version from the binary's build
+// information, or Devel if it doesn't record one.
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Devel
+	}
+	return moduleVersion(info)
+}
+
+func moduleVersion(info *debug.BuildInfo) string {
+	module := &info.Main
+	if module.Path != modulePath {
+		module = nil
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				module = dep
+				break
+			}
+		}
+	}
+	if module == nil {
+		return Devel
+	}
+	if module.Replace != nil {
+		module = module.Replace
+	}
+	if module.Version == "" || module.Version == "(devel)" {
+		return Devel
+	}
+	return module.Version
+}
+
+// GetCapabilities returns the capabilities of the library in this binary.
+// Entry types registered with tlog.RegisterRekorEntryType are included, so
+// call it after registering them.
+func GetCapabilities() Capabilities {
+	return Capabilities{
+		Version:               Version(),
+		BundleMediaTypes:      bundle.SupportedMediaTypes(),
+		TlogEntryTypes:        tlog.RegisteredEntryTypes(),
+		TrustedRootMediaTypes: root.SupportedMediaTypes(),
+		Features:              features(),
+	}
+}
+
+// SupportsBundleMediaType reports whether bundles of the media type can be
+// verified.
+func (c Capabilities) SupportsBundleMediaType(mediaType string) bool {
+	return contains(c.BundleMediaTypes, mediaType)
+}
+
+// SupportsTlogEntryType reports whether Rekor entries of the kind and
+// version can be verified.
+func (c Capabilities) SupportsTlogEntryType(kind, version string) bool {
+	return contains(c.TlogEntryTypes, kind+"/"+version)
+}
+
+// SupportsTrustedRootMediaType reports whether trusted roots of the media
+// type can be parsed.
+func (c Capabilities) SupportsTrustedRootMediaType(mediaType string) bool {
+	return contains(c.TrustedRootMediaTypes, mediaType)
+}
+
+// HasFeature reports whether the feature is available in this build.
+func (c Capabilities) HasFeature(feature string) bool {
+	return contains(c.Features, feature)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Sigstore Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import (
+	"os"
+	"runtime/debug"
+	"testing"
+
+	"github.com/sigstore/sigstore-go/pkg/bundle"
+	"github.com/sigstore/sigstore-go/pkg/root"
+	"github.com/sigstore/sigstore-go/pkg/tlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	capabilities := GetCapabilities()
+
+	// the lists come from the packages that do the parsing
+	assert.Equal(t, bundle.SupportedMediaTypes(), capabilities.BundleMediaTypes)
+	assert.Equal(t, tlog.RegisteredEntryTypes(), capabilities.TlogEntryTypes)
+	assert.Equal(t, root.SupportedMediaTypes(), capabilities.TrustedRootMediaTypes)
+
+	assert.True(t, capabilities.SupportsBundleMediaType("application/vnd.dev.sigstore.bundle.v0.3+json"))
+	assert.False(t, capabilities.SupportsBundleMediaType("application/vnd.dev.sigstore.bundle.v0.4+json"))
+	assert.True(t, capabilities.SupportsTlogEntryType("hashedrekord", "0.0.1"))
+	assert.False(t, capabilities.SupportsTlogEntryType("hashedrekord", "0.0.2"))
+	assert.True(t, capabilities.HasFeature(FeatureSCT))
+
+	trustedRootJSON, err := os.ReadFile("../../examples/trusted-root-public-good.json")
+	assert.NoError(t, err)
+	pb, err := root.NewTrustedRootProtobuf(trustedRootJSON)
+	assert.NoError(t, err)
+	assert.True(t, capabilities.SupportsTrustedRootMediaType(pb.GetMediaType()))
+
+	// entry types registered later are picked up
+	tlog.RegisterRekorEntryType("capabilities-test", "0.0.1", func(*tlog.Entry) ([]byte, []byte, error) {
+		return nil, nil, nil
+	})
+	assert.False(t, capabilities.SupportsTlogEntryType("capabilities-test", "0.0.1"))
+	assert.True(t, GetCapabilities().SupportsTlogEntryType("capabilities-test", "0.0.1"))
+}
+
+func TestModuleVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		info *debug.BuildInfo
+		want string
+	}{
+		{
+			name: "main module",
+			info: &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v0.5.0"}},
+			want: "v0.5.0",
+		},
+		{
+			name: "main module built from source",
+			info: &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "(devel)"}},
+			want: Devel,
+		},
+		{
+			name: "dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool", Version: "v1.0.0"},
+				Deps: []*debug.Module{
+					{Path: "github.com/sigstore/sigstore", Version: "v1.8.0"},
+					{Path: modulePath, Version: "v0.4.0"},
+				},
+			},
+			want: "v0.4.0",
+		},
+		{
+			name: "replaced dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool", Version: "v1.0.0"},
+				Deps: []*debug.Module{
+					{Path: modulePath, Version: "v0.4.0", Replace: &debug.Module{Path: "example.com/fork", Version: "v0.4.1"}},
+				},
+			},
+			want: "v0.4.1",
+		},
+		{
+			name: "replaced with a local checkout",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tool", Version: "v1.0.0"},
+				Deps: []*debug.Module{
+					{Path: modulePath, Version: "v0.4.0", Replace: &debug.Module{Path: "../sigstore-go"}},
+				},
+			},
+			want: Devel,
+		},
+		{
+			name: "not in the build",
+			info: &debug.BuildInfo{Main: debug.Module{Path: "example.com/tool", Version: "v1.0.0"}},
+			want: Devel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, moduleVersion(tt.info))
+		})
+	}
+}